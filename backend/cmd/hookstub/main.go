@@ -0,0 +1,56 @@
+// Command hookstub is a local receiver for testing webhook and pager
+// channels. It logs every request and, when HOOK_SECRET is set, answers 401
+// to a request whose X-Praana-Signature header doesn't match. Set
+// HOOK_FAIL=1 to answer 500 and exercise retries.
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"log"
+	"net/http"
+	"os"
+)
+
+func main() {
+	addr := os.Getenv("HOOK_ADDR")
+	if addr == "" {
+		addr = ":9090"
+	}
+	log.Printf("hookstub listening on %s", addr)
+	log.Fatal(http.ListenAndServe(addr, newHandler(os.Getenv("HOOK_SECRET"), os.Getenv("HOOK_FAIL") == "1")))
+}
+
+func newHandler(secret string, fail bool) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		log.Printf("%s %s event=%q delivery=%q auth=%q", r.Method, r.URL.Path,
+			r.Header.Get("X-Praana-Event"), r.Header.Get("X-Praana-Delivery"), r.Header.Get("Authorization"))
+		log.Printf("%s", body)
+		if secret != "" && r.Header.Get("X-Praana-Signature") != "" {
+			valid := validSignature(secret, r.Header.Get("X-Praana-Timestamp"), body, r.Header.Get("X-Praana-Signature"))
+			log.Printf("signature valid: %v", valid)
+			if !valid {
+				http.Error(w, "bad signature", http.StatusUnauthorized)
+				return
+			}
+		}
+		if fail {
+			http.Error(w, "stub failure", http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
+
+// validSignature checks signature against the HMAC-SHA256 of
+// "<timestamp>.<body>" under secret.
+func validSignature(secret, timestamp string, body []byte, signature string) bool {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(body)
+	want := "sha256=" + hex.EncodeToString(mac.Sum(nil))
+	return hmac.Equal([]byte(want), []byte(signature))
+}
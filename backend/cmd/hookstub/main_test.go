@@ -0,0 +1,57 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func signedRequest(secret, timestamp, body string) *http.Request {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "." + body))
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+	req.Header.Set("X-Praana-Timestamp", timestamp)
+	req.Header.Set("X-Praana-Signature", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	return req
+}
+
+func TestHandler(t *testing.T) {
+	tests := []struct {
+		name   string
+		fail   bool
+		req    *http.Request
+		status int
+	}{
+		{name: "valid signature", req: signedRequest("s3cret", "1700000000", `{"a":1}`), status: http.StatusNoContent},
+		{name: "wrong secret", req: signedRequest("other", "1700000000", `{"a":1}`), status: http.StatusUnauthorized},
+		{name: "unsigned", req: httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{}`)), status: http.StatusNoContent},
+		{name: "failing", fail: true, req: signedRequest("s3cret", "1700000000", `{}`), status: http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			newHandler("s3cret", tt.fail).ServeHTTP(w, tt.req)
+			if w.Code != tt.status {
+				t.Fatalf("status = %d, want %d", w.Code, tt.status)
+			}
+		})
+	}
+}
+
+func TestValidSignatureRejectsTamperedBody(t *testing.T) {
+	req := signedRequest("s3cret", "1700000000", `{"a":1}`)
+	sig := req.Header.Get("X-Praana-Signature")
+	if !validSignature("s3cret", "1700000000", []byte(`{"a":1}`), sig) {
+		t.Fatal("valid signature rejected")
+	}
+	if validSignature("s3cret", "1700000000", []byte(`{"a":2}`), sig) {
+		t.Fatal("signature accepted for a different body")
+	}
+	if validSignature("s3cret", "1700000001", []byte(`{"a":1}`), sig) {
+		t.Fatal("signature accepted for a different timestamp")
+	}
+}
@@ -1,8 +1,15 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"net/http"
 	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/rs/zerolog"
@@ -13,9 +20,13 @@ import (
 	_ "praana/docs"
 	"praana/internal/config"
 	"praana/internal/handlers"
+	"praana/internal/mailer"
 	"praana/internal/middleware"
+	"praana/internal/models"
+	"praana/internal/oidc"
 	"praana/internal/repository"
 	"praana/internal/services"
+	"praana/internal/utils"
 )
 
 // @title Praana API
@@ -39,6 +50,15 @@ func main() {
 	zerolog.SetGlobalLevel(level)
 	log.Logger = log.Output(zerolog.ConsoleWriter{Out: os.Stderr})
 
+	utils.AllowPrivateNetworks = cfg.AllowPrivateNetworks
+	if cfg.AllowPrivateNetworks {
+		log.Warn().Msg("ALLOW_PRIVATE_NETWORKS is set: outbound requests may reach internal addresses")
+	}
+
+	// Background workers stop, and the server shuts down, on SIGINT/SIGTERM.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
 	// Connect Redis
 	repo, err := repository.NewRedisRepo(cfg.RedisAddr, cfg.RedisPass, cfg.RedisDB)
 	if err != nil {
@@ -50,23 +70,80 @@ func main() {
 	go wsHub.Run()
 
 	// Init services
-	authService := services.NewAuthService(repo, cfg.JWTSecret, cfg.JWTExpiry)
-	orgService := services.NewOrgService(repo)
-	patientService := services.NewPatientService(repo)
+	var mail mailer.Mailer
+	switch cfg.MailDriver {
+	case "smtp":
+		mail = &mailer.SMTPMailer{Addr: cfg.SMTPAddr, User: cfg.SMTPUser, Password: cfg.SMTPPassword, From: cfg.SMTPFrom}
+	case "file":
+		log.Warn().Str("dir", cfg.MailDropDir).Msg("MAIL_DRIVER=file: emails are written to disk, not sent")
+		mail = &mailer.FileMailer{Dir: cfg.MailDropDir, From: cfg.SMTPFrom}
+	case "":
+		log.Warn().Msg("MAIL_DRIVER is not set: emails will fail to send")
+		mail = mailer.DisabledMailer{}
+	default:
+		log.Fatal().Str("driver", cfg.MailDriver).Msg("Unknown MAIL_DRIVER")
+	}
+	mailService := services.NewMailService(mail, cfg.JWTSecret, cfg.AppURL)
+	auditService := services.NewAuditService(repo)
+	authService := services.NewAuthService(repo, mailService, auditService, wsHub, cfg.JWTSecret, cfg.JWTExpiry, cfg.SessionIdleTimeout, cfg.SessionMaxAge)
+	orgService := services.NewOrgService(repo, mailService, auditService, wsHub)
+	ssoService := services.NewSSOService(repo, authService, orgService, auditService, &oidc.Client{}, cfg.AppURL)
+	wardService := services.NewWardService(repo)
+	deliveryService := services.NewDeliveryService(repo)
+	webhookService := services.NewWebhookService(repo, deliveryService)
+	episodeService := services.NewEpisodeService(repo, wardService, webhookService)
+	patientService := services.NewPatientService(repo, episodeService)
+	mergeService := services.NewMergeService(repo, episodeService, auditService)
+	noteService := services.NewNoteService(repo, patientService)
+	timelineService := services.NewTimelineService(repo, patientService)
+	handoverService := services.NewHandoverService(repo, patientService)
 	statsService := services.NewStatsService(repo)
-	alertService := services.NewAlertService(repo, wsHub)
-	vitalsService := services.NewVitalsService(repo, alertService, statsService)
+	careTeamService := services.NewCareTeamService(repo)
+	notificationService := services.NewNotificationService(repo, deliveryService,
+		services.WebhookNotifier{},
+		services.EmailNotifier{Mail: mailService},
+		services.PagerNotifier{},
+	)
+	go deliveryService.Run(ctx, 5*time.Second)
+	alertService := services.NewAlertService(repo, wsHub, careTeamService, notificationService, webhookService)
+	alertService.BackfillIndexes(context.Background())
+	vitalsService := services.NewVitalsService(repo, alertService, statsService, webhookService)
+	observationService := services.NewObservationService(repo, patientService, alertService)
+	muteService := services.NewMuteService(repo, patientService, auditService)
+	go observationService.Run(ctx, time.Minute)
 
 	// Init handlers
 	authHandler := handlers.NewAuthHandler(authService, orgService)
 	orgHandler := handlers.NewOrgHandler(orgService)
-	patientHandler := handlers.NewPatientHandler(patientService, orgService, vitalsService)
+	ssoHandler := handlers.NewSSOHandler(ssoService)
+	patientHandler := handlers.NewPatientHandler(patientService, orgService, vitalsService, mergeService, muteService)
+	episodeHandler := handlers.NewEpisodeHandler(episodeService, orgService)
+	wardHandler := handlers.NewWardHandler(wardService)
+	auditHandler := handlers.NewAuditHandler(auditService)
+	careTeamHandler := handlers.NewCareTeamHandler(careTeamService)
+	noteHandler := handlers.NewNoteHandler(noteService, timelineService)
+	handoverHandler := handlers.NewHandoverHandler(handoverService, careTeamService)
+	observationHandler := handlers.NewObservationHandler(observationService, careTeamService)
+	muteHandler := handlers.NewMuteHandler(muteService)
+	notificationHandler := handlers.NewNotificationHandler(notificationService, deliveryService)
+	webhookHandler := handlers.NewWebhookHandler(webhookService)
 	vitalsHandler := handlers.NewVitalsHandler(vitalsService)
-	alertHandler := handlers.NewAlertHandler(alertService)
-	dashboardHandler := handlers.NewDashboardHandler(statsService)
+	alertHandler := handlers.NewAlertHandler(alertService, careTeamService)
+	dashboardHandler := handlers.NewDashboardHandler(statsService, careTeamService)
 	wsHandler := handlers.NewWSHandler(wsHub, authService)
 	// Setup Gin
 	r := gin.Default()
+	// ClientIP only honours X-Forwarded-For from these addresses; with none
+	// configured it is the address of the direct peer.
+	var trustedProxies []string
+	for _, p := range strings.Split(cfg.TrustedProxies, ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			trustedProxies = append(trustedProxies, p)
+		}
+	}
+	if err := r.SetTrustedProxies(trustedProxies); err != nil {
+		log.Fatal().Err(err).Msg("Invalid TRUSTED_PROXIES")
+	}
 	r.Use(middleware.CORSMiddleware(cfg.CORSOrigins))
 	r.Use(middleware.RateLimitMiddleware(120))
 
@@ -88,7 +165,13 @@ func main() {
 		{
 			auth.POST("/signup", authHandler.Signup)
 			auth.POST("/login", authHandler.Login)
+			auth.POST("/login/2fa", authHandler.LoginMFA)
+			auth.POST("/sso/start", ssoHandler.Start)
+			auth.POST("/sso/callback", ssoHandler.Callback)
+			auth.POST("/refresh", authHandler.Refresh)
 			auth.POST("/accept-invite", authHandler.AcceptInvite)
+			auth.POST("/forgot-password", authHandler.ForgotPassword)
+			auth.POST("/reset-password", authHandler.ResetPassword)
 		}
 	}
 
@@ -97,6 +180,15 @@ func main() {
 	protected.Use(middleware.AuthMiddleware(authService))
 	{
 		protected.POST("/auth/logout", authHandler.Logout)
+		protected.POST("/auth/change-password", authHandler.ChangePassword)
+		protected.GET("/auth/sessions", authHandler.Sessions)
+		protected.DELETE("/auth/sessions", authHandler.RevokeOtherSessions)
+		protected.DELETE("/auth/sessions/:id", authHandler.RevokeSession)
+		protected.GET("/auth/2fa", authHandler.TwoFactorStatus)
+		protected.POST("/auth/2fa/setup", authHandler.SetupTOTP)
+		protected.POST("/auth/2fa/enable", authHandler.EnableTOTP)
+		protected.POST("/auth/2fa/recovery-codes", authHandler.RegenerateRecoveryCodes)
+		protected.POST("/auth/2fa/disable", authHandler.DisableTOTP)
 
 		// Org
 		org := protected.Group("/org")
@@ -105,7 +197,18 @@ func main() {
 			org.PUT("", middleware.AdminOnly(), orgHandler.UpdateOrg)
 			org.GET("/members", orgHandler.GetMembers)
 			org.DELETE("/members/:id", middleware.AdminOnly(), orgHandler.RemoveMember)
+			org.GET("/members/:id/sessions", middleware.AdminOnly(), orgHandler.MemberSessions)
+			org.DELETE("/members/:id/sessions", middleware.AdminOnly(), orgHandler.RevokeMemberSessions)
+			org.DELETE("/members/:id/sessions/:sessionId", middleware.AdminOnly(), orgHandler.RevokeMemberSessions)
+			org.DELETE("/members/:id/2fa", middleware.AdminOnly(), orgHandler.ResetMemberTOTP)
+			org.POST("/members/:id/unlock", middleware.AdminOnly(), orgHandler.UnlockMember)
+			org.GET("/sso", middleware.AdminOnly(), ssoHandler.GetConfig)
+			org.PUT("/sso", middleware.AdminOnly(), ssoHandler.SaveConfig)
+			org.DELETE("/sso", middleware.AdminOnly(), ssoHandler.DeleteConfig)
 			org.POST("/invite", middleware.AdminOnly(), orgHandler.Invite)
+			org.GET("/invites", middleware.AdminOnly(), orgHandler.ListInvites)
+			org.POST("/invites/:id/resend", middleware.AdminOnly(), orgHandler.ResendInvite)
+			org.DELETE("/invites/:id", middleware.AdminOnly(), orgHandler.RevokeInvite)
 		}
 
 		// Patients
@@ -113,22 +216,80 @@ func main() {
 		{
 			patients.POST("", patientHandler.Create)
 			patients.GET("", patientHandler.List)
+			patients.GET("/by-identifier", patientHandler.GetByIdentifier)
 			patients.GET("/:id", patientHandler.Get)
 			patients.PUT("/:id", patientHandler.Update)
 			patients.DELETE("/:id", patientHandler.Delete)
+			patients.DELETE("/:id/purge", middleware.AdminOnly(), patientHandler.Purge)
+			patients.POST("/:id/merge", middleware.AdminOnly(), patientHandler.Merge)
+			patients.POST("/:id/admit", episodeHandler.Admit)
+			patients.POST("/:id/transfer", episodeHandler.Transfer)
+			patients.POST("/:id/discharge", episodeHandler.Discharge)
+			patients.GET("/:id/episodes", episodeHandler.List)
+			patients.POST("/:id/notes", noteHandler.Create)
+			patients.GET("/:id/notes", noteHandler.List)
+			patients.GET("/:id/timeline", noteHandler.Timeline)
+			patients.GET("/:id/handovers", handoverHandler.ListForPatient)
+			patients.GET("/:id/observation-schedule", observationHandler.Get)
+			patients.PUT("/:id/observation-schedule", middleware.RoleRequired(models.RoleAdmin, models.RoleDoctor), observationHandler.Set)
+			patients.POST("/:id/mutes", middleware.RoleRequired(models.RoleAdmin, models.RoleDoctor), muteHandler.Create)
+			patients.GET("/:id/mutes", muteHandler.List)
+			patients.DELETE("/:id/mutes/:muteId", middleware.RoleRequired(models.RoleAdmin, models.RoleDoctor), muteHandler.Delete)
 			patients.POST("/:id/vitals", vitalsHandler.Record)
 			patients.GET("/:id/vitals", vitalsHandler.GetHistory)
 		}
 
+		// Wards & beds
+		wards := protected.Group("/wards")
+		{
+			wards.GET("", wardHandler.List)
+			wards.POST("", middleware.AdminOnly(), wardHandler.Create)
+			wards.GET("/occupancy", wardHandler.OccupancySummary)
+			wards.GET("/:id", wardHandler.Get)
+			wards.PUT("/:id", middleware.AdminOnly(), wardHandler.Update)
+			wards.DELETE("/:id", middleware.AdminOnly(), wardHandler.Delete)
+			wards.GET("/:id/occupancy", wardHandler.Occupancy)
+			wards.GET("/:id/beds", wardHandler.ListBeds)
+			wards.POST("/:id/beds", middleware.AdminOnly(), wardHandler.AddBed)
+			wards.DELETE("/:id/beds/:bedId", middleware.AdminOnly(), wardHandler.DeleteBed)
+		}
+
 		// Vitals bulk
 		protected.POST("/vitals/bulk", vitalsHandler.BulkRecord)
 
+		protected.POST("/notes/:id/amend", noteHandler.Amend)
+
+		protected.GET("/observations/due", observationHandler.Due)
+
+		// Handovers
+		handovers := protected.Group("/handovers")
+		{
+			handovers.POST("", handoverHandler.Generate)
+			handovers.GET("", handoverHandler.List)
+			handovers.GET("/:id", handoverHandler.Get)
+			handovers.PUT("/:id", handoverHandler.Update)
+			handovers.POST("/:id/sign", handoverHandler.Sign)
+		}
+
+		// Care team
+		assignments := protected.Group("/assignments")
+		{
+			assignments.GET("", careTeamHandler.List)
+			assignments.POST("", middleware.RoleRequired(models.RoleAdmin, models.RoleDoctor), careTeamHandler.Assign)
+			assignments.DELETE("/:id", middleware.RoleRequired(models.RoleAdmin, models.RoleDoctor), careTeamHandler.Remove)
+		}
+		protected.GET("/me/patients", careTeamHandler.MyPatients)
+
 		// Alerts
 		alerts := protected.Group("/alerts")
 		{
 			alerts.GET("", alertHandler.GetActive)
 			alerts.POST("/:id/acknowledge", alertHandler.Acknowledge)
 			alerts.GET("/history", alertHandler.GetHistory)
+			alerts.GET("/:id", alertHandler.Get)
+			alerts.POST("/:id/assign", alertHandler.Assign)
+			alerts.POST("/:id/comments", alertHandler.Comment)
+			alerts.POST("/:id/resolve", alertHandler.Resolve)
 		}
 
 		// Thresholds
@@ -139,6 +300,32 @@ func main() {
 			thresholds.PUT("/patient/:id", alertHandler.SetPatientThresholds)
 		}
 
+		// Notification channels and delivery log
+		channels := protected.Group("/notification-channels", middleware.AdminOnly())
+		{
+			channels.GET("", notificationHandler.List)
+			channels.POST("", notificationHandler.Create)
+			channels.PUT("/:id", notificationHandler.Update)
+			channels.DELETE("/:id", notificationHandler.Delete)
+			channels.POST("/:id/test", notificationHandler.Test)
+		}
+		protected.GET("/deliveries", middleware.AdminOnly(), notificationHandler.Deliveries)
+		protected.POST("/deliveries/:id/replay", middleware.AdminOnly(), notificationHandler.Replay)
+
+		// Event webhooks
+		webhooks := protected.Group("/webhooks", middleware.AdminOnly())
+		{
+			webhooks.GET("", webhookHandler.List)
+			webhooks.POST("", webhookHandler.Create)
+			webhooks.GET("/:id", webhookHandler.Get)
+			webhooks.PUT("/:id", webhookHandler.Update)
+			webhooks.DELETE("/:id", webhookHandler.Delete)
+			webhooks.GET("/:id/deliveries", webhookHandler.Deliveries)
+		}
+
+		// Audit
+		protected.GET("/audit", middleware.AdminOnly(), auditHandler.List)
+
 		// Dashboard
 		dashboard := protected.Group("/dashboard")
 		{
@@ -152,8 +339,19 @@ func main() {
 	}
 
 	addr := fmt.Sprintf(":%s", cfg.ServerPort)
+	srv := &http.Server{Addr: addr, Handler: r}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if err := srv.Shutdown(shutdownCtx); err != nil {
+			log.Error().Err(err).Msg("Server shutdown failed")
+		}
+	}()
+
 	log.Info().Str("addr", addr).Msg("Starting Praana server")
-	if err := r.Run(addr); err != nil {
+	if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
 		log.Fatal().Err(err).Msg("Server failed")
 	}
+	log.Info().Msg("Server stopped")
 }
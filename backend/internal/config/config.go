@@ -14,7 +14,23 @@ type Config struct {
 	JWTSecret   string        `mapstructure:"JWT_SECRET"`
 	JWTExpiry   time.Duration `mapstructure:"JWT_EXPIRY"`
 	CORSOrigins string        `mapstructure:"CORS_ORIGINS"`
-	LogLevel    string        `mapstructure:"LOG_LEVEL"`
+
+	TrustedProxies string `mapstructure:"TRUSTED_PROXIES"`
+
+	SessionIdleTimeout time.Duration `mapstructure:"SESSION_IDLE_TIMEOUT"`
+	SessionMaxAge      time.Duration `mapstructure:"SESSION_MAX_AGE"`
+
+	LogLevel string `mapstructure:"LOG_LEVEL"`
+
+	AppURL       string `mapstructure:"APP_URL"`
+	MailDriver   string `mapstructure:"MAIL_DRIVER"`
+	MailDropDir  string `mapstructure:"MAIL_DROP_DIR"`
+	SMTPAddr     string `mapstructure:"SMTP_ADDR"`
+	SMTPUser     string `mapstructure:"SMTP_USER"`
+	SMTPPassword string `mapstructure:"SMTP_PASSWORD"`
+	SMTPFrom     string `mapstructure:"SMTP_FROM"`
+
+	AllowPrivateNetworks bool `mapstructure:"ALLOW_PRIVATE_NETWORKS"`
 }
 
 func Load() (*Config, error) {
@@ -26,9 +42,20 @@ func Load() (*Config, error) {
 	viper.SetDefault("REDIS_PASSWORD", "")
 	viper.SetDefault("REDIS_DB", 0)
 	viper.SetDefault("JWT_SECRET", "dev-secret-change-me")
-	viper.SetDefault("JWT_EXPIRY", "24h")
+	viper.SetDefault("JWT_EXPIRY", "15m")
+	viper.SetDefault("SESSION_IDLE_TIMEOUT", "12h")
+	viper.SetDefault("SESSION_MAX_AGE", "168h")
 	viper.SetDefault("CORS_ORIGINS", "http://localhost:4200")
+	viper.SetDefault("TRUSTED_PROXIES", "")
 	viper.SetDefault("LOG_LEVEL", "debug")
+	viper.SetDefault("APP_URL", "http://localhost:4200")
+	viper.SetDefault("MAIL_DRIVER", "")
+	viper.SetDefault("MAIL_DROP_DIR", "maildrop")
+	viper.SetDefault("SMTP_ADDR", "")
+	viper.SetDefault("SMTP_USER", "")
+	viper.SetDefault("SMTP_PASSWORD", "")
+	viper.SetDefault("SMTP_FROM", "praana@localhost")
+	viper.SetDefault("ALLOW_PRIVATE_NETWORKS", false)
 
 	_ = viper.ReadInConfig() // OK if .env doesn't exist
 
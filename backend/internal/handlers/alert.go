@@ -1,6 +1,9 @@
 package handlers
 
 import (
+	"errors"
+	"strconv"
+
 	"github.com/gin-gonic/gin"
 	"praana/internal/models"
 	"praana/internal/services"
@@ -8,22 +11,36 @@ import (
 )
 
 type AlertHandler struct {
-	alertService *services.AlertService
+	alertService    *services.AlertService
+	careTeamService *services.CareTeamService
 }
 
-func NewAlertHandler(as *services.AlertService) *AlertHandler {
-	return &AlertHandler{alertService: as}
+func NewAlertHandler(as *services.AlertService, cs *services.CareTeamService) *AlertHandler {
+	return &AlertHandler{alertService: as, careTeamService: cs}
 }
 
 // GetActiveAlerts godoc
 // @Summary Get active alerts
 // @Tags alerts
 // @Security BearerAuth
+// @Param patient_id query string false "Only this patient's alerts"
+// @Param assigned query string false "Set to 'me' for alerts on your assigned patients"
 // @Success 200 {object} utils.APIResponse{data=[]models.Alert}
 // @Router /api/alerts [get]
 func (h *AlertHandler) GetActive(c *gin.Context) {
 	orgID := c.GetString("org_id")
-	alerts, err := h.alertService.GetActive(c.Request.Context(), orgID)
+	patientIDs, ok := assignedPatientFilter(c, h.careTeamService)
+	if !ok {
+		return
+	}
+	if patientID := c.Query("patient_id"); patientID != "" {
+		if patientIDs != nil && !patientIDs[patientID] {
+			utils.OK(c, []models.Alert{})
+			return
+		}
+		patientIDs = map[string]bool{patientID: true}
+	}
+	alerts, err := h.alertService.GetActive(c.Request.Context(), orgID, patientIDs)
 	if err != nil {
 		utils.InternalError(c, err.Error())
 		return
@@ -31,39 +48,239 @@ func (h *AlertHandler) GetActive(c *gin.Context) {
 	utils.OK(c, alerts)
 }
 
+// alertError maps an alert action error to its status: 404 for a missing
+// alert, 400 for a bad assignee or parent comment, 409 when the alert has
+// moved on or kept changing underneath us, and 500 for anything else.
+func alertError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrAlertNotFound):
+		utils.NotFound(c, err.Error())
+	case errors.Is(err, services.ErrAssigneeNotFound), errors.Is(err, services.ErrParentNotFound):
+		utils.BadRequest(c, err.Error())
+	case errors.Is(err, services.ErrAlertNotOpen), errors.Is(err, utils.ErrConcurrentUpdate):
+		utils.Conflict(c, err.Error())
+	default:
+		utils.InternalError(c, err.Error())
+	}
+}
+
 // AcknowledgeAlert godoc
-// @Summary Acknowledge an alert
+// @Summary Acknowledge an alert with a reason
 // @Tags alerts
 // @Security BearerAuth
 // @Param id path string true "Alert ID"
+// @Accept json
+// @Produce json
+// @Param body body models.AcknowledgeAlertRequest true "Reason and action taken"
 // @Success 200 {object} utils.APIResponse
+// @Failure 404 {object} utils.APIResponse
+// @Failure 409 {object} utils.APIResponse
 // @Router /api/alerts/{id}/acknowledge [post]
 func (h *AlertHandler) Acknowledge(c *gin.Context) {
 	orgID := c.GetString("org_id")
 	alertID := c.Param("id")
 	userID := c.GetString("user_id")
 
-	if err := h.alertService.Acknowledge(c.Request.Context(), orgID, alertID, userID); err != nil {
-		utils.NotFound(c, err.Error())
+	var req models.AcknowledgeAlertRequest
+	if err := utils.BindAndValidate(c, &req); err != nil {
+		utils.BadRequest(c, err.Error())
+		return
+	}
+
+	err := h.alertService.Acknowledge(c.Request.Context(), orgID, alertID, userID, &req)
+	if err != nil {
+		alertError(c, err)
 		return
 	}
 	utils.OK(c, gin.H{"message": "alert acknowledged"})
 }
 
+// GetAlert godoc
+// @Summary Get an alert with its comments
+// @Tags alerts
+// @Security BearerAuth
+// @Param id path string true "Alert ID"
+// @Success 200 {object} utils.APIResponse{data=models.AlertDetail}
+// @Failure 404 {object} utils.APIResponse
+// @Router /api/alerts/{id} [get]
+func (h *AlertHandler) Get(c *gin.Context) {
+	orgID := c.GetString("org_id")
+	detail, err := h.alertService.Get(c.Request.Context(), orgID, c.Param("id"))
+	if err != nil {
+		alertError(c, err)
+		return
+	}
+	utils.OK(c, detail)
+}
+
+// AssignAlert godoc
+// @Summary Assign an alert to a member
+// @Tags alerts
+// @Security BearerAuth
+// @Param id path string true "Alert ID"
+// @Accept json
+// @Produce json
+// @Param body body models.AssignAlertRequest true "Assignee"
+// @Success 200 {object} utils.APIResponse{data=models.Alert}
+// @Failure 400 {object} utils.APIResponse
+// @Failure 404 {object} utils.APIResponse
+// @Failure 409 {object} utils.APIResponse
+// @Router /api/alerts/{id}/assign [post]
+func (h *AlertHandler) Assign(c *gin.Context) {
+	orgID := c.GetString("org_id")
+	userID := c.GetString("user_id")
+
+	var req models.AssignAlertRequest
+	if err := utils.BindAndValidate(c, &req); err != nil {
+		utils.BadRequest(c, err.Error())
+		return
+	}
+
+	alert, err := h.alertService.Assign(c.Request.Context(), orgID, c.Param("id"), userID, &req)
+	if err != nil {
+		alertError(c, err)
+		return
+	}
+	utils.OK(c, alert)
+}
+
+// CommentOnAlert godoc
+// @Summary Comment on an alert
+// @Tags alerts
+// @Security BearerAuth
+// @Param id path string true "Alert ID"
+// @Accept json
+// @Produce json
+// @Param body body models.AlertCommentRequest true "Comment"
+// @Success 201 {object} utils.APIResponse{data=models.AlertComment}
+// @Failure 400 {object} utils.APIResponse
+// @Failure 404 {object} utils.APIResponse
+// @Failure 409 {object} utils.APIResponse
+// @Router /api/alerts/{id}/comments [post]
+func (h *AlertHandler) Comment(c *gin.Context) {
+	orgID := c.GetString("org_id")
+	userID := c.GetString("user_id")
+
+	var req models.AlertCommentRequest
+	if err := utils.BindAndValidate(c, &req); err != nil {
+		utils.BadRequest(c, err.Error())
+		return
+	}
+
+	comment, err := h.alertService.Comment(c.Request.Context(), orgID, c.Param("id"), userID, &req)
+	if err != nil {
+		alertError(c, err)
+		return
+	}
+	utils.Created(c, comment)
+}
+
+// ResolveAlert godoc
+// @Summary Resolve an open or acknowledged alert
+// @Tags alerts
+// @Security BearerAuth
+// @Param id path string true "Alert ID"
+// @Accept json
+// @Produce json
+// @Param body body models.ResolveAlertRequest false "Resolution"
+// @Success 200 {object} utils.APIResponse{data=models.Alert}
+// @Failure 404 {object} utils.APIResponse
+// @Failure 409 {object} utils.APIResponse
+// @Router /api/alerts/{id}/resolve [post]
+func (h *AlertHandler) Resolve(c *gin.Context) {
+	orgID := c.GetString("org_id")
+	userID := c.GetString("user_id")
+
+	var req models.ResolveAlertRequest
+	if c.Request.ContentLength > 0 {
+		if err := utils.BindAndValidate(c, &req); err != nil {
+			utils.BadRequest(c, err.Error())
+			return
+		}
+	}
+
+	alert, err := h.alertService.Resolve(c.Request.Context(), orgID, c.Param("id"), userID, &req)
+	if err != nil {
+		alertError(c, err)
+		return
+	}
+	utils.OK(c, alert)
+}
+
 // GetAlertHistory godoc
-// @Summary Get alert history
+// @Summary Search alert history, newest first
 // @Tags alerts
 // @Security BearerAuth
-// @Success 200 {object} utils.APIResponse{data=[]models.Alert}
+// @Param patient_id query string false "Patient ID"
+// @Param severity query string false "warning or critical"
+// @Param vital_type query string false "e.g. heart_rate, spo2"
+// @Param state query string false "open, acknowledged or resolved"
+// @Param assigned_to query string false "Assignee user ID, or 'me'"
+// @Param from query int false "Created at or after (unix seconds)"
+// @Param to query int false "Created at or before (unix seconds)"
+// @Param cursor query string false "next_cursor from the previous page"
+// @Param limit query int false "Alerts per page (default 50, max 200)"
+// @Param assigned query string false "Set to 'me' for alerts on your assigned patients"
+// @Success 200 {object} utils.APIResponse{data=models.AlertPage}
 // @Router /api/alerts/history [get]
 func (h *AlertHandler) GetHistory(c *gin.Context) {
 	orgID := c.GetString("org_id")
-	alerts, err := h.alertService.GetHistory(c.Request.Context(), orgID)
+	patientIDs, ok := assignedPatientFilter(c, h.careTeamService)
+	if !ok {
+		return
+	}
+
+	q := models.AlertQuery{
+		PatientID:  c.Query("patient_id"),
+		Severity:   models.AlertSeverity(c.Query("severity")),
+		VitalType:  c.Query("vital_type"),
+		State:      models.AlertState(c.Query("state")),
+		AssignedTo: c.Query("assigned_to"),
+		Cursor:     c.Query("cursor"),
+	}
+	if q.AssignedTo == "me" {
+		q.AssignedTo = c.GetString("user_id")
+	}
+	switch q.Severity {
+	case "", models.SeverityWarning, models.SeverityCritical:
+	default:
+		utils.BadRequest(c, "severity must be warning or critical")
+		return
+	}
+	if q.State != "" && !validAlertState(q.State) {
+		utils.BadRequest(c, "unknown alert state")
+		return
+	}
+	var err error
+	for param, dst := range map[string]*int64{"from": &q.From, "to": &q.To} {
+		if raw := c.Query(param); raw != "" {
+			if *dst, err = strconv.ParseInt(raw, 10, 64); err != nil {
+				utils.BadRequest(c, param+" must be a unix timestamp")
+				return
+			}
+		}
+	}
+	q.Limit, _ = strconv.Atoi(c.Query("limit"))
+
+	page, err := h.alertService.GetHistory(c.Request.Context(), orgID, &q, patientIDs)
+	if errors.Is(err, services.ErrInvalidAlertQuery) {
+		utils.BadRequest(c, err.Error())
+		return
+	}
 	if err != nil {
 		utils.InternalError(c, err.Error())
 		return
 	}
-	utils.OK(c, alerts)
+	utils.OK(c, page)
+}
+
+func validAlertState(state models.AlertState) bool {
+	for _, s := range models.AlertStates {
+		if s == state {
+			return true
+		}
+	}
+	return false
 }
 
 // GetThresholds godoc
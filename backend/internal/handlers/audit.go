@@ -0,0 +1,35 @@
+package handlers
+
+import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"praana/internal/services"
+	"praana/internal/utils"
+)
+
+type AuditHandler struct {
+	auditService *services.AuditService
+}
+
+func NewAuditHandler(as *services.AuditService) *AuditHandler {
+	return &AuditHandler{auditService: as}
+}
+
+// ListAudit godoc
+// @Summary Recent audit log entries
+// @Tags audit
+// @Security BearerAuth
+// @Param limit query int false "Max entries (default 100, max 500)"
+// @Success 200 {object} utils.APIResponse{data=[]models.AuditEntry}
+// @Router /api/audit [get]
+func (h *AuditHandler) List(c *gin.Context) {
+	orgID := c.GetString("org_id")
+	limit, _ := strconv.ParseInt(c.Query("limit"), 10, 64)
+	entries, err := h.auditService.List(c.Request.Context(), orgID, limit)
+	if err != nil {
+		utils.InternalError(c, err.Error())
+		return
+	}
+	utils.OK(c, entries)
+}
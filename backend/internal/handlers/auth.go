@@ -1,7 +1,12 @@
 package handlers
 
 import (
+	"errors"
+	"math"
+	"strconv"
+
 	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
 	"praana/internal/models"
 	"praana/internal/services"
 	"praana/internal/utils"
@@ -16,6 +21,29 @@ func NewAuthHandler(authService *services.AuthService, orgService *services.OrgS
 	return &AuthHandler{authService: authService, orgService: orgService}
 }
 
+// clientInfo describes the requesting device for the session list.
+func clientInfo(c *gin.Context) models.ClientInfo {
+	return models.ClientInfo{UserAgent: c.Request.UserAgent(), IP: c.ClientIP()}
+}
+
+// loginError reports a failed login step: 401 for wrong credentials or an
+// expired challenge, 429 and Retry-After while the account or IP is
+// throttled, and 500 without details when the check itself failed.
+func loginError(c *gin.Context, err error) {
+	var throttled *services.LoginThrottledError
+	switch {
+	case errors.As(err, &throttled):
+		c.Header("Retry-After", strconv.Itoa(int(math.Ceil(throttled.RetryAfter.Seconds()))))
+		utils.TooManyRequests(c, err.Error())
+	case errors.Is(err, services.ErrInvalidCredentials), errors.Is(err, services.ErrInvalidMFACode),
+		errors.Is(err, services.ErrMFAChallengeExpired):
+		utils.Unauthorized(c, err.Error())
+	default:
+		log.Error().Err(err).Msg("Sign-in failed")
+		utils.InternalError(c, "sign-in failed; try again later")
+	}
+}
+
 // Signup godoc
 // @Summary Create new account and organization
 // @Tags auth
@@ -32,7 +60,7 @@ func (h *AuthHandler) Signup(c *gin.Context) {
 		return
 	}
 
-	resp, err := h.authService.Signup(c.Request.Context(), &req)
+	resp, err := h.authService.Signup(c.Request.Context(), &req, clientInfo(c))
 	if err != nil {
 		utils.BadRequest(c, err.Error())
 		return
@@ -49,6 +77,8 @@ func (h *AuthHandler) Signup(c *gin.Context) {
 // @Param body body models.LoginRequest true "Login credentials"
 // @Success 200 {object} utils.APIResponse{data=models.LoginResponse}
 // @Failure 401 {object} utils.APIResponse
+// @Failure 429 {object} utils.APIResponse
+// @Failure 500 {object} utils.APIResponse
 // @Router /api/auth/login [post]
 func (h *AuthHandler) Login(c *gin.Context) {
 	var req models.LoginRequest
@@ -57,9 +87,44 @@ func (h *AuthHandler) Login(c *gin.Context) {
 		return
 	}
 
-	resp, err := h.authService.Login(c.Request.Context(), &req)
+	resp, err := h.authService.Login(c.Request.Context(), &req, clientInfo(c))
 	if err != nil {
-		utils.Unauthorized(c, err.Error())
+		loginError(c, err)
+		return
+	}
+
+	utils.OK(c, resp)
+}
+
+// Refresh godoc
+// @Summary Exchange a refresh token for new access and refresh tokens
+// @Description Each refresh token works once. Replaying a used one signs the session out; 409 means another client just refreshed it.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param body body models.RefreshRequest true "Refresh token"
+// @Success 200 {object} utils.APIResponse{data=models.LoginResponse}
+// @Failure 401 {object} utils.APIResponse
+// @Failure 409 {object} utils.APIResponse
+// @Router /api/auth/refresh [post]
+func (h *AuthHandler) Refresh(c *gin.Context) {
+	var req models.RefreshRequest
+	if err := utils.BindAndValidate(c, &req); err != nil {
+		utils.BadRequest(c, err.Error())
+		return
+	}
+
+	resp, err := h.authService.Refresh(c.Request.Context(), &req, clientInfo(c))
+	if err != nil {
+		if errors.Is(err, services.ErrRefreshRaced) {
+			utils.Conflict(c, err.Error())
+			return
+		}
+		if errors.Is(err, services.ErrInvalidRefreshToken) {
+			utils.Unauthorized(c, err.Error())
+			return
+		}
+		utils.InternalError(c, err.Error())
 		return
 	}
 
@@ -73,8 +138,8 @@ func (h *AuthHandler) Login(c *gin.Context) {
 // @Success 200 {object} utils.APIResponse
 // @Router /api/auth/logout [post]
 func (h *AuthHandler) Logout(c *gin.Context) {
-	jwtID := c.GetString("jwt_id")
-	if err := h.authService.Logout(c.Request.Context(), jwtID); err != nil {
+	sessionID := c.GetString("session_id")
+	if err := h.authService.Logout(c.Request.Context(), c.GetString("user_id"), sessionID); err != nil {
 		utils.InternalError(c, err.Error())
 		return
 	}
@@ -105,3 +170,117 @@ func (h *AuthHandler) AcceptInvite(c *gin.Context) {
 
 	utils.Created(c, user)
 }
+
+// ChangePassword godoc
+// @Summary Change password and sign out other sessions
+// @Tags auth
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param body body models.ChangePasswordRequest true "Current and new password"
+// @Success 200 {object} utils.APIResponse
+// @Failure 400 {object} utils.APIResponse
+// @Router /api/auth/change-password [post]
+func (h *AuthHandler) ChangePassword(c *gin.Context) {
+	var req models.ChangePasswordRequest
+	if err := utils.BindAndValidate(c, &req); err != nil {
+		utils.BadRequest(c, err.Error())
+		return
+	}
+	userID := c.GetString("user_id")
+	if err := h.authService.ChangePassword(c.Request.Context(), userID, c.GetString("session_id"), &req); err != nil {
+		utils.BadRequest(c, err.Error())
+		return
+	}
+	utils.OK(c, gin.H{"message": "password changed"})
+}
+
+// ForgotPassword godoc
+// @Summary Email a password reset link
+// @Description Always succeeds, so it can't be used to discover registered emails.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param body body models.ForgotPasswordRequest true "Account email"
+// @Success 200 {object} utils.APIResponse
+// @Router /api/auth/forgot-password [post]
+func (h *AuthHandler) ForgotPassword(c *gin.Context) {
+	var req models.ForgotPasswordRequest
+	if err := utils.BindAndValidate(c, &req); err != nil {
+		utils.BadRequest(c, err.Error())
+		return
+	}
+	h.authService.ForgotPassword(c.Request.Context(), &req)
+	utils.OK(c, gin.H{"message": "if that email is registered, a reset link has been sent"})
+}
+
+// ResetPassword godoc
+// @Summary Set a new password from a reset link
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param body body models.ResetPasswordRequest true "Reset code and new password"
+// @Success 200 {object} utils.APIResponse
+// @Failure 400 {object} utils.APIResponse
+// @Router /api/auth/reset-password [post]
+func (h *AuthHandler) ResetPassword(c *gin.Context) {
+	var req models.ResetPasswordRequest
+	if err := utils.BindAndValidate(c, &req); err != nil {
+		utils.BadRequest(c, err.Error())
+		return
+	}
+	if err := h.authService.ResetPassword(c.Request.Context(), &req); err != nil {
+		utils.BadRequest(c, err.Error())
+		return
+	}
+	utils.OK(c, gin.H{"message": "password reset"})
+}
+
+// Sessions godoc
+// @Summary List your active sessions
+// @Tags auth
+// @Security BearerAuth
+// @Success 200 {object} utils.APIResponse{data=[]models.Session}
+// @Router /api/auth/sessions [get]
+func (h *AuthHandler) Sessions(c *gin.Context) {
+	sessions, err := h.authService.Sessions(c.Request.Context(), c.GetString("user_id"), c.GetString("session_id"))
+	if err != nil {
+		utils.InternalError(c, err.Error())
+		return
+	}
+	utils.OK(c, sessions)
+}
+
+// RevokeSession godoc
+// @Summary Sign out one of your sessions
+// @Tags auth
+// @Security BearerAuth
+// @Param id path string true "Session ID"
+// @Success 200 {object} utils.APIResponse
+// @Router /api/auth/sessions/{id} [delete]
+func (h *AuthHandler) RevokeSession(c *gin.Context) {
+	if err := h.authService.RevokeSession(c.Request.Context(), c.GetString("user_id"), c.Param("id")); err != nil {
+		if errors.Is(err, services.ErrSessionNotFound) {
+			utils.NotFound(c, err.Error())
+			return
+		}
+		utils.InternalError(c, err.Error())
+		return
+	}
+	utils.OK(c, gin.H{"message": "session revoked"})
+}
+
+// RevokeOtherSessions godoc
+// @Summary Sign out all your other sessions
+// @Tags auth
+// @Security BearerAuth
+// @Success 200 {object} utils.APIResponse
+// @Router /api/auth/sessions [delete]
+func (h *AuthHandler) RevokeOtherSessions(c *gin.Context) {
+	revoked, err := h.authService.RevokeOtherSessions(c.Request.Context(), c.GetString("user_id"), c.GetString("session_id"))
+	if err != nil {
+		utils.InternalError(c, err.Error())
+		return
+	}
+	utils.OK(c, gin.H{"revoked": revoked})
+}
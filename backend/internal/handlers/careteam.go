@@ -0,0 +1,108 @@
+package handlers
+
+import (
+	"github.com/gin-gonic/gin"
+	"praana/internal/models"
+	"praana/internal/services"
+	"praana/internal/utils"
+)
+
+type CareTeamHandler struct {
+	careTeamService *services.CareTeamService
+}
+
+func NewCareTeamHandler(cs *services.CareTeamService) *CareTeamHandler {
+	return &CareTeamHandler{careTeamService: cs}
+}
+
+// assignedPatientFilter resolves `?assigned=me` to the caller's current
+// patients. It returns nil when no filter was requested and writes an error
+// response (ok=false) if the lookup fails.
+func assignedPatientFilter(c *gin.Context, cs *services.CareTeamService) (map[string]bool, bool) {
+	if c.Query("assigned") != "me" {
+		return nil, true
+	}
+	ids, err := cs.AssignedPatientIDs(c.Request.Context(), c.GetString("org_id"), c.GetString("user_id"))
+	if err != nil {
+		utils.InternalError(c, err.Error())
+		return nil, false
+	}
+	return ids, true
+}
+
+// CreateAssignment godoc
+// @Summary Assign a member to a patient or ward for a shift
+// @Tags care-team
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param body body models.CreateAssignmentRequest true "Assignment"
+// @Success 201 {object} utils.APIResponse{data=models.CareAssignment}
+// @Router /api/assignments [post]
+func (h *CareTeamHandler) Assign(c *gin.Context) {
+	orgID := c.GetString("org_id")
+	userID := c.GetString("user_id")
+
+	var req models.CreateAssignmentRequest
+	if err := utils.BindAndValidate(c, &req); err != nil {
+		utils.BadRequest(c, err.Error())
+		return
+	}
+
+	a, err := h.careTeamService.Assign(c.Request.Context(), orgID, userID, &req)
+	if err != nil {
+		utils.BadRequest(c, err.Error())
+		return
+	}
+	utils.Created(c, a)
+}
+
+// ListAssignments godoc
+// @Summary Current and upcoming care assignments
+// @Tags care-team
+// @Security BearerAuth
+// @Param user_id query string false "Only this member's assignments"
+// @Success 200 {object} utils.APIResponse{data=[]models.CareAssignment}
+// @Router /api/assignments [get]
+func (h *CareTeamHandler) List(c *gin.Context) {
+	orgID := c.GetString("org_id")
+	assignments, err := h.careTeamService.List(c.Request.Context(), orgID, c.Query("user_id"))
+	if err != nil {
+		utils.InternalError(c, err.Error())
+		return
+	}
+	utils.OK(c, assignments)
+}
+
+// DeleteAssignment godoc
+// @Summary Remove a care assignment
+// @Tags care-team
+// @Security BearerAuth
+// @Param id path string true "Assignment ID"
+// @Success 200 {object} utils.APIResponse
+// @Router /api/assignments/{id} [delete]
+func (h *CareTeamHandler) Remove(c *gin.Context) {
+	orgID := c.GetString("org_id")
+	if err := h.careTeamService.Remove(c.Request.Context(), orgID, c.Param("id")); err != nil {
+		utils.NotFound(c, err.Error())
+		return
+	}
+	utils.OK(c, gin.H{"message": "assignment removed"})
+}
+
+// MyPatients godoc
+// @Summary Patients the current user is assigned to this shift
+// @Tags care-team
+// @Security BearerAuth
+// @Success 200 {object} utils.APIResponse{data=[]models.Patient}
+// @Router /api/me/patients [get]
+func (h *CareTeamHandler) MyPatients(c *gin.Context) {
+	orgID := c.GetString("org_id")
+	userID := c.GetString("user_id")
+	patients, err := h.careTeamService.MyPatients(c.Request.Context(), orgID, userID)
+	if err != nil {
+		utils.InternalError(c, err.Error())
+		return
+	}
+	utils.OK(c, patients)
+}
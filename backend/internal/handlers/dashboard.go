@@ -1,28 +1,38 @@
 package handlers
 
 import (
+	"errors"
+	"strconv"
+	"time"
+
 	"github.com/gin-gonic/gin"
 	"praana/internal/services"
 	"praana/internal/utils"
 )
 
 type DashboardHandler struct {
-	statsService *services.StatsService
+	statsService    *services.StatsService
+	careTeamService *services.CareTeamService
 }
 
-func NewDashboardHandler(ss *services.StatsService) *DashboardHandler {
-	return &DashboardHandler{statsService: ss}
+func NewDashboardHandler(ss *services.StatsService, cs *services.CareTeamService) *DashboardHandler {
+	return &DashboardHandler{statsService: ss, careTeamService: cs}
 }
 
 // Overview godoc
 // @Summary Dashboard overview - all patients with latest vitals
 // @Tags dashboard
 // @Security BearerAuth
+// @Param assigned query string false "Set to 'me' to show only your assigned patients"
 // @Success 200 {object} utils.APIResponse{data=models.DashboardOverview}
 // @Router /api/dashboard/overview [get]
 func (h *DashboardHandler) Overview(c *gin.Context) {
 	orgID := c.GetString("org_id")
-	overview, err := h.statsService.GetDashboardOverview(c.Request.Context(), orgID)
+	patientIDs, ok := assignedPatientFilter(c, h.careTeamService)
+	if !ok {
+		return
+	}
+	overview, err := h.statsService.GetDashboardOverview(c.Request.Context(), orgID, patientIDs)
 	if err != nil {
 		utils.InternalError(c, err.Error())
 		return
@@ -52,11 +62,27 @@ func (h *DashboardHandler) PatientTrends(c *gin.Context) {
 // @Summary Current shift statistics
 // @Tags dashboard
 // @Security BearerAuth
+// @Param shift_start query int false "Any time within a past shift (unix seconds); default the current shift"
 // @Success 200 {object} utils.APIResponse{data=models.ShiftSummary}
 // @Router /api/dashboard/shift-summary [get]
 func (h *DashboardHandler) ShiftSummary(c *gin.Context) {
 	orgID := c.GetString("org_id")
-	summary, err := h.statsService.GetShiftSummary(c.Request.Context(), orgID)
+
+	var at time.Time
+	if raw := c.Query("shift_start"); raw != "" {
+		unix, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			utils.BadRequest(c, "shift_start must be a unix timestamp")
+			return
+		}
+		at = time.Unix(unix, 0)
+	}
+
+	summary, err := h.statsService.GetShiftSummary(c.Request.Context(), orgID, at)
+	if errors.Is(err, services.ErrShiftNotStarted) {
+		utils.BadRequest(c, err.Error())
+		return
+	}
 	if err != nil {
 		utils.InternalError(c, err.Error())
 		return
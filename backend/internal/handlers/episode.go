@@ -0,0 +1,138 @@
+package handlers
+
+import (
+	"errors"
+
+	"github.com/gin-gonic/gin"
+	"praana/internal/models"
+	"praana/internal/services"
+	"praana/internal/utils"
+)
+
+type EpisodeHandler struct {
+	episodeService *services.EpisodeService
+	orgService     *services.OrgService
+}
+
+func NewEpisodeHandler(es *services.EpisodeService, os *services.OrgService) *EpisodeHandler {
+	return &EpisodeHandler{episodeService: es, orgService: os}
+}
+
+// AdmitPatient godoc
+// @Summary Readmit a discharged patient, opening a new episode
+// @Tags episodes
+// @Security BearerAuth
+// @Param id path string true "Patient ID"
+// @Accept json
+// @Produce json
+// @Param body body models.AdmitRequest true "Admission details"
+// @Success 201 {object} utils.APIResponse{data=models.Episode}
+// @Router /api/patients/{id}/admit [post]
+func (h *EpisodeHandler) Admit(c *gin.Context) {
+	orgID := c.GetString("org_id")
+	patientID := c.Param("id")
+	userID := c.GetString("user_id")
+
+	if err := h.orgService.CheckPlanLimit(c.Request.Context(), orgID, "patients"); err != nil {
+		utils.Forbidden(c, err.Error())
+		return
+	}
+
+	var req models.AdmitRequest
+	if err := utils.BindAndValidate(c, &req); err != nil {
+		utils.BadRequest(c, err.Error())
+		return
+	}
+
+	ep, err := h.episodeService.Admit(c.Request.Context(), orgID, patientID, userID, &req)
+	if errors.Is(err, services.ErrBedOccupied) {
+		utils.Conflict(c, err.Error())
+		return
+	}
+	if err != nil {
+		utils.BadRequest(c, err.Error())
+		return
+	}
+	utils.Created(c, ep)
+}
+
+// TransferPatient godoc
+// @Summary Move an admitted patient to another ward or bed
+// @Tags episodes
+// @Security BearerAuth
+// @Param id path string true "Patient ID"
+// @Accept json
+// @Produce json
+// @Param body body models.TransferRequest true "Transfer details"
+// @Success 200 {object} utils.APIResponse{data=models.Episode}
+// @Router /api/patients/{id}/transfer [post]
+func (h *EpisodeHandler) Transfer(c *gin.Context) {
+	orgID := c.GetString("org_id")
+	patientID := c.Param("id")
+	userID := c.GetString("user_id")
+
+	var req models.TransferRequest
+	if err := utils.BindAndValidate(c, &req); err != nil {
+		utils.BadRequest(c, err.Error())
+		return
+	}
+
+	ep, err := h.episodeService.Transfer(c.Request.Context(), orgID, patientID, userID, &req)
+	if errors.Is(err, services.ErrBedOccupied) {
+		utils.Conflict(c, err.Error())
+		return
+	}
+	if err != nil {
+		utils.BadRequest(c, err.Error())
+		return
+	}
+	utils.OK(c, ep)
+}
+
+// DischargePatient godoc
+// @Summary Discharge a patient, closing the active episode
+// @Tags episodes
+// @Security BearerAuth
+// @Param id path string true "Patient ID"
+// @Accept json
+// @Produce json
+// @Param body body models.DischargeRequest true "Discharge details"
+// @Success 200 {object} utils.APIResponse{data=models.Episode}
+// @Router /api/patients/{id}/discharge [post]
+func (h *EpisodeHandler) Discharge(c *gin.Context) {
+	orgID := c.GetString("org_id")
+	patientID := c.Param("id")
+	userID := c.GetString("user_id")
+
+	var req models.DischargeRequest
+	if err := utils.BindAndValidate(c, &req); err != nil {
+		utils.BadRequest(c, err.Error())
+		return
+	}
+
+	ep, err := h.episodeService.Discharge(c.Request.Context(), orgID, patientID, userID, &req)
+	if err != nil {
+		utils.BadRequest(c, err.Error())
+		return
+	}
+	utils.OK(c, ep)
+}
+
+// ListEpisodes godoc
+// @Summary List a patient's admission episodes, most recent first
+// @Tags episodes
+// @Security BearerAuth
+// @Param id path string true "Patient ID"
+// @Success 200 {object} utils.APIResponse{data=[]models.Episode}
+// @Router /api/patients/{id}/episodes [get]
+func (h *EpisodeHandler) List(c *gin.Context) {
+	orgID := c.GetString("org_id")
+	patientID := c.Param("id")
+
+	episodes, err := h.episodeService.List(c.Request.Context(), orgID, patientID)
+	if err != nil {
+		utils.NotFound(c, err.Error())
+		return
+	}
+	utils.OK(c, episodes)
+}
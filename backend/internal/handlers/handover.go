@@ -0,0 +1,162 @@
+package handlers
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"praana/internal/models"
+	"praana/internal/services"
+	"praana/internal/utils"
+)
+
+type HandoverHandler struct {
+	handoverService *services.HandoverService
+	careTeamService *services.CareTeamService
+}
+
+func NewHandoverHandler(hs *services.HandoverService, cs *services.CareTeamService) *HandoverHandler {
+	return &HandoverHandler{handoverService: hs, careTeamService: cs}
+}
+
+// GenerateHandovers godoc
+// @Summary Draft SBAR handovers for the current shift
+// @Tags handovers
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param body body models.GenerateHandoverRequest false "Patients to include (default: all admitted)"
+// @Success 201 {object} utils.APIResponse{data=[]models.HandoverReport}
+// @Router /api/handovers [post]
+func (h *HandoverHandler) Generate(c *gin.Context) {
+	orgID := c.GetString("org_id")
+	userID := c.GetString("user_id")
+
+	var req models.GenerateHandoverRequest
+	if c.Request.ContentLength > 0 {
+		if err := utils.BindAndValidate(c, &req); err != nil {
+			utils.BadRequest(c, err.Error())
+			return
+		}
+	}
+
+	reports, err := h.handoverService.Generate(c.Request.Context(), orgID, userID, &req)
+	if err != nil {
+		utils.BadRequest(c, err.Error())
+		return
+	}
+	utils.Created(c, reports)
+}
+
+// ListHandovers godoc
+// @Summary Handovers for a shift (default: the most recent shift with handovers)
+// @Tags handovers
+// @Security BearerAuth
+// @Param shift_start query int false "Shift start (unix seconds)"
+// @Param assigned query string false "Set to 'me' for your assigned patients"
+// @Success 200 {object} utils.APIResponse{data=[]models.HandoverReport}
+// @Router /api/handovers [get]
+func (h *HandoverHandler) List(c *gin.Context) {
+	orgID := c.GetString("org_id")
+	shiftStart, _ := strconv.ParseInt(c.Query("shift_start"), 10, 64)
+	patientIDs, ok := assignedPatientFilter(c, h.careTeamService)
+	if !ok {
+		return
+	}
+
+	reports, err := h.handoverService.List(c.Request.Context(), orgID, shiftStart, patientIDs)
+	if err != nil {
+		utils.InternalError(c, err.Error())
+		return
+	}
+	utils.OK(c, reports)
+}
+
+// GetHandover godoc
+// @Summary Get a handover report
+// @Tags handovers
+// @Security BearerAuth
+// @Param id path string true "Handover ID"
+// @Success 200 {object} utils.APIResponse{data=models.HandoverReport}
+// @Router /api/handovers/{id} [get]
+func (h *HandoverHandler) Get(c *gin.Context) {
+	orgID := c.GetString("org_id")
+	report, err := h.handoverService.Get(c.Request.Context(), orgID, c.Param("id"))
+	if err != nil {
+		utils.NotFound(c, err.Error())
+		return
+	}
+	utils.OK(c, report)
+}
+
+// UpdateHandover godoc
+// @Summary Edit a draft handover
+// @Tags handovers
+// @Security BearerAuth
+// @Param id path string true "Handover ID"
+// @Accept json
+// @Produce json
+// @Param body body models.UpdateHandoverRequest true "SBAR sections to replace"
+// @Success 200 {object} utils.APIResponse{data=models.HandoverReport}
+// @Router /api/handovers/{id} [put]
+func (h *HandoverHandler) Update(c *gin.Context) {
+	orgID := c.GetString("org_id")
+	userID := c.GetString("user_id")
+
+	var req models.UpdateHandoverRequest
+	if err := utils.BindAndValidate(c, &req); err != nil {
+		utils.BadRequest(c, err.Error())
+		return
+	}
+
+	report, err := h.handoverService.Update(c.Request.Context(), orgID, c.Param("id"), userID, &req)
+	if errors.Is(err, services.ErrHandoverSigned) {
+		utils.Conflict(c, err.Error())
+		return
+	}
+	if err != nil {
+		utils.NotFound(c, err.Error())
+		return
+	}
+	utils.OK(c, report)
+}
+
+// SignHandover godoc
+// @Summary Sign off a handover
+// @Tags handovers
+// @Security BearerAuth
+// @Param id path string true "Handover ID"
+// @Success 200 {object} utils.APIResponse{data=models.HandoverReport}
+// @Router /api/handovers/{id}/sign [post]
+func (h *HandoverHandler) Sign(c *gin.Context) {
+	orgID := c.GetString("org_id")
+	userID := c.GetString("user_id")
+
+	report, err := h.handoverService.Sign(c.Request.Context(), orgID, c.Param("id"), userID)
+	if errors.Is(err, services.ErrHandoverSigned) {
+		utils.Conflict(c, err.Error())
+		return
+	}
+	if err != nil {
+		utils.NotFound(c, err.Error())
+		return
+	}
+	utils.OK(c, report)
+}
+
+// PatientHandovers godoc
+// @Summary A patient's handover history
+// @Tags handovers
+// @Security BearerAuth
+// @Param id path string true "Patient ID"
+// @Success 200 {object} utils.APIResponse{data=[]models.HandoverReport}
+// @Router /api/patients/{id}/handovers [get]
+func (h *HandoverHandler) ListForPatient(c *gin.Context) {
+	orgID := c.GetString("org_id")
+	reports, err := h.handoverService.ListForPatient(c.Request.Context(), orgID, c.Param("id"))
+	if err != nil {
+		utils.NotFound(c, err.Error())
+		return
+	}
+	utils.OK(c, reports)
+}
@@ -0,0 +1,144 @@
+package handlers
+
+import (
+	"errors"
+
+	"github.com/gin-gonic/gin"
+	"praana/internal/models"
+	"praana/internal/services"
+	"praana/internal/utils"
+)
+
+// LoginMFA godoc
+// @Summary Finish a login with a TOTP or recovery code
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param body body models.LoginMFARequest true "Challenge token from /auth/login and a code"
+// @Success 200 {object} utils.APIResponse{data=models.LoginResponse}
+// @Failure 401 {object} utils.APIResponse
+// @Failure 429 {object} utils.APIResponse
+// @Failure 500 {object} utils.APIResponse
+// @Router /api/auth/login/2fa [post]
+func (h *AuthHandler) LoginMFA(c *gin.Context) {
+	var req models.LoginMFARequest
+	if err := utils.BindAndValidate(c, &req); err != nil {
+		utils.BadRequest(c, err.Error())
+		return
+	}
+
+	resp, err := h.authService.LoginMFA(c.Request.Context(), &req, clientInfo(c))
+	if err != nil {
+		loginError(c, err)
+		return
+	}
+
+	utils.OK(c, resp)
+}
+
+// TwoFactorStatus godoc
+// @Summary Get your two-factor authentication status
+// @Tags auth
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} utils.APIResponse{data=models.TwoFactorStatus}
+// @Router /api/auth/2fa [get]
+func (h *AuthHandler) TwoFactorStatus(c *gin.Context) {
+	status, err := h.authService.TwoFactorStatus(c.Request.Context(), c.GetString("user_id"))
+	if err != nil {
+		utils.NotFound(c, err.Error())
+		return
+	}
+	utils.OK(c, status)
+}
+
+// SetupTOTP godoc
+// @Summary Start authenticator app enrolment
+// @Tags auth
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} utils.APIResponse{data=models.TOTPSetup}
+// @Router /api/auth/2fa/setup [post]
+func (h *AuthHandler) SetupTOTP(c *gin.Context) {
+	setup, err := h.authService.SetupTOTP(c.Request.Context(), c.GetString("user_id"))
+	if err != nil {
+		utils.BadRequest(c, err.Error())
+		return
+	}
+	utils.OK(c, setup)
+}
+
+// EnableTOTP godoc
+// @Summary Confirm enrolment with a code and get recovery codes
+// @Tags auth
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param body body models.TOTPCodeRequest true "Code from the authenticator app"
+// @Success 200 {object} utils.APIResponse{data=models.RecoveryCodes}
+// @Failure 400 {object} utils.APIResponse
+// @Router /api/auth/2fa/enable [post]
+func (h *AuthHandler) EnableTOTP(c *gin.Context) {
+	var req models.TOTPCodeRequest
+	if err := utils.BindAndValidate(c, &req); err != nil {
+		utils.BadRequest(c, err.Error())
+		return
+	}
+	codes, err := h.authService.EnableTOTP(c.Request.Context(), c.GetString("user_id"), &req)
+	if err != nil {
+		utils.BadRequest(c, err.Error())
+		return
+	}
+	utils.OK(c, codes)
+}
+
+// RegenerateRecoveryCodes godoc
+// @Summary Replace your recovery codes
+// @Tags auth
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param body body models.TOTPCodeRequest true "Code from the authenticator app"
+// @Success 200 {object} utils.APIResponse{data=models.RecoveryCodes}
+// @Failure 400 {object} utils.APIResponse
+// @Router /api/auth/2fa/recovery-codes [post]
+func (h *AuthHandler) RegenerateRecoveryCodes(c *gin.Context) {
+	var req models.TOTPCodeRequest
+	if err := utils.BindAndValidate(c, &req); err != nil {
+		utils.BadRequest(c, err.Error())
+		return
+	}
+	codes, err := h.authService.RegenerateRecoveryCodes(c.Request.Context(), c.GetString("user_id"), &req)
+	if err != nil {
+		utils.BadRequest(c, err.Error())
+		return
+	}
+	utils.OK(c, codes)
+}
+
+// DisableTOTP godoc
+// @Summary Turn off two-factor authentication
+// @Tags auth
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param body body models.DisableTOTPRequest true "Password and a TOTP or recovery code"
+// @Success 200 {object} utils.APIResponse
+// @Failure 403 {object} utils.APIResponse
+// @Router /api/auth/2fa/disable [post]
+func (h *AuthHandler) DisableTOTP(c *gin.Context) {
+	var req models.DisableTOTPRequest
+	if err := utils.BindAndValidate(c, &req); err != nil {
+		utils.BadRequest(c, err.Error())
+		return
+	}
+	if err := h.authService.DisableTOTP(c.Request.Context(), c.GetString("user_id"), &req); err != nil {
+		if errors.Is(err, services.ErrMFARequiredByOrg) {
+			utils.Forbidden(c, err.Error())
+			return
+		}
+		utils.BadRequest(c, err.Error())
+		return
+	}
+	utils.OK(c, gin.H{"message": "two-factor authentication disabled"})
+}
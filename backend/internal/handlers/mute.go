@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"errors"
+
+	"github.com/gin-gonic/gin"
+	"praana/internal/models"
+	"praana/internal/services"
+	"praana/internal/utils"
+)
+
+type MuteHandler struct {
+	muteService *services.MuteService
+}
+
+func NewMuteHandler(ms *services.MuteService) *MuteHandler {
+	return &MuteHandler{muteService: ms}
+}
+
+// CreateMute godoc
+// @Summary Mute alerts for one of a patient's vitals for a while
+// @Tags alerts
+// @Security BearerAuth
+// @Param id path string true "Patient ID"
+// @Accept json
+// @Produce json
+// @Param body body models.CreateMuteRequest true "Vital, duration and reason"
+// @Success 201 {object} utils.APIResponse{data=models.AlertMute}
+// @Router /api/patients/{id}/mutes [post]
+func (h *MuteHandler) Create(c *gin.Context) {
+	orgID := c.GetString("org_id")
+	userID := c.GetString("user_id")
+
+	var req models.CreateMuteRequest
+	if err := utils.BindAndValidate(c, &req); err != nil {
+		utils.BadRequest(c, err.Error())
+		return
+	}
+
+	mute, err := h.muteService.Create(c.Request.Context(), orgID, c.Param("id"), userID, &req)
+	if err != nil {
+		utils.NotFound(c, err.Error())
+		return
+	}
+	utils.Created(c, mute)
+}
+
+// ListMutes godoc
+// @Summary A patient's active alert mutes
+// @Tags alerts
+// @Security BearerAuth
+// @Param id path string true "Patient ID"
+// @Success 200 {object} utils.APIResponse{data=[]models.AlertMute}
+// @Router /api/patients/{id}/mutes [get]
+func (h *MuteHandler) List(c *gin.Context) {
+	orgID := c.GetString("org_id")
+	mutes, err := h.muteService.Active(c.Request.Context(), orgID, c.Param("id"))
+	if err != nil {
+		utils.NotFound(c, err.Error())
+		return
+	}
+	utils.OK(c, mutes)
+}
+
+// DeleteMute godoc
+// @Summary Lift an alert mute before it expires
+// @Tags alerts
+// @Security BearerAuth
+// @Param id path string true "Patient ID"
+// @Param muteId path string true "Mute ID"
+// @Success 200 {object} utils.APIResponse
+// @Router /api/patients/{id}/mutes/{muteId} [delete]
+func (h *MuteHandler) Delete(c *gin.Context) {
+	orgID := c.GetString("org_id")
+	userID := c.GetString("user_id")
+	err := h.muteService.Delete(c.Request.Context(), orgID, c.Param("id"), c.Param("muteId"), userID)
+	if errors.Is(err, services.ErrPatientNotFound) || errors.Is(err, services.ErrMuteNotFound) {
+		utils.NotFound(c, err.Error())
+		return
+	}
+	if err != nil {
+		utils.InternalError(c, err.Error())
+		return
+	}
+	utils.OK(c, gin.H{"message": "mute lifted"})
+}
@@ -0,0 +1,132 @@
+package handlers
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"praana/internal/models"
+	"praana/internal/services"
+	"praana/internal/utils"
+)
+
+type NoteHandler struct {
+	noteService     *services.NoteService
+	timelineService *services.TimelineService
+}
+
+func NewNoteHandler(ns *services.NoteService, ts *services.TimelineService) *NoteHandler {
+	return &NoteHandler{noteService: ns, timelineService: ts}
+}
+
+// CreateNote godoc
+// @Summary Write a clinical note for a patient
+// @Tags notes
+// @Security BearerAuth
+// @Param id path string true "Patient ID"
+// @Accept json
+// @Produce json
+// @Param body body models.CreateNoteRequest true "Note"
+// @Success 201 {object} utils.APIResponse{data=models.ClinicalNote}
+// @Router /api/patients/{id}/notes [post]
+func (h *NoteHandler) Create(c *gin.Context) {
+	orgID := c.GetString("org_id")
+	patientID := c.Param("id")
+	userID := c.GetString("user_id")
+
+	var req models.CreateNoteRequest
+	if err := utils.BindAndValidate(c, &req); err != nil {
+		utils.BadRequest(c, err.Error())
+		return
+	}
+
+	note, err := h.noteService.Create(c.Request.Context(), orgID, patientID, userID, &req)
+	if err != nil {
+		utils.NotFound(c, err.Error())
+		return
+	}
+	utils.Created(c, note)
+}
+
+// ListNotes godoc
+// @Summary List a patient's clinical notes
+// @Tags notes
+// @Security BearerAuth
+// @Param id path string true "Patient ID"
+// @Param category query string false "nursing, medical or handover"
+// @Success 200 {object} utils.APIResponse{data=[]models.ClinicalNote}
+// @Router /api/patients/{id}/notes [get]
+func (h *NoteHandler) List(c *gin.Context) {
+	orgID := c.GetString("org_id")
+	patientID := c.Param("id")
+
+	category := models.NoteCategory(c.Query("category"))
+	switch category {
+	case "", models.NoteNursing, models.NoteMedical, models.NoteHandover:
+	default:
+		utils.BadRequest(c, "category must be nursing, medical or handover")
+		return
+	}
+
+	notes, err := h.noteService.List(c.Request.Context(), orgID, patientID, category)
+	if err != nil {
+		utils.NotFound(c, err.Error())
+		return
+	}
+	utils.OK(c, notes)
+}
+
+// AmendNote godoc
+// @Summary Amend a clinical note, keeping the previous text
+// @Tags notes
+// @Security BearerAuth
+// @Param id path string true "Note ID"
+// @Accept json
+// @Produce json
+// @Param body body models.AmendNoteRequest true "Amendment"
+// @Success 200 {object} utils.APIResponse{data=models.ClinicalNote}
+// @Router /api/notes/{id}/amend [post]
+func (h *NoteHandler) Amend(c *gin.Context) {
+	orgID := c.GetString("org_id")
+	userID := c.GetString("user_id")
+	role := models.Role(c.GetString("role"))
+
+	var req models.AmendNoteRequest
+	if err := utils.BindAndValidate(c, &req); err != nil {
+		utils.BadRequest(c, err.Error())
+		return
+	}
+
+	note, err := h.noteService.Amend(c.Request.Context(), orgID, c.Param("id"), userID, role, &req)
+	if errors.Is(err, services.ErrNotNoteAuthor) {
+		utils.Forbidden(c, err.Error())
+		return
+	}
+	if err != nil {
+		utils.NotFound(c, err.Error())
+		return
+	}
+	utils.OK(c, note)
+}
+
+// Timeline godoc
+// @Summary Patient timeline: notes, vitals, alerts, acknowledgements, transfers and status changes
+// @Tags notes
+// @Security BearerAuth
+// @Param id path string true "Patient ID"
+// @Param cursor query string false "next_cursor from the previous page"
+// @Param limit query int false "Entries per page (default 50, max 200)"
+// @Success 200 {object} utils.APIResponse{data=models.TimelinePage}
+// @Router /api/patients/{id}/timeline [get]
+func (h *NoteHandler) Timeline(c *gin.Context) {
+	orgID := c.GetString("org_id")
+	patientID := c.Param("id")
+	limit, _ := strconv.Atoi(c.Query("limit"))
+
+	page, err := h.timelineService.Get(c.Request.Context(), orgID, patientID, c.Query("cursor"), limit)
+	if err != nil {
+		utils.BadRequest(c, err.Error())
+		return
+	}
+	utils.OK(c, page)
+}
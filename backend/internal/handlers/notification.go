@@ -0,0 +1,197 @@
+package handlers
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"praana/internal/models"
+	"praana/internal/services"
+	"praana/internal/utils"
+)
+
+type NotificationHandler struct {
+	notificationService *services.NotificationService
+	deliveryService     *services.DeliveryService
+}
+
+func NewNotificationHandler(ns *services.NotificationService, ds *services.DeliveryService) *NotificationHandler {
+	return &NotificationHandler{notificationService: ns, deliveryService: ds}
+}
+
+// ListChannels godoc
+// @Summary List notification channels (Admin)
+// @Tags notifications
+// @Security BearerAuth
+// @Success 200 {object} utils.APIResponse{data=[]models.NotificationChannel}
+// @Router /api/notification-channels [get]
+func (h *NotificationHandler) List(c *gin.Context) {
+	orgID := c.GetString("org_id")
+	channels, err := h.notificationService.List(c.Request.Context(), orgID)
+	if err != nil {
+		utils.InternalError(c, err.Error())
+		return
+	}
+	utils.OK(c, channels)
+}
+
+// CreateChannel godoc
+// @Summary Add a webhook, email or pager channel for alerts (Admin)
+// @Tags notifications
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param body body models.ChannelRequest true "Channel"
+// @Success 201 {object} utils.APIResponse{data=models.NotificationChannel}
+// @Router /api/notification-channels [post]
+func (h *NotificationHandler) Create(c *gin.Context) {
+	orgID := c.GetString("org_id")
+
+	var req models.ChannelRequest
+	if err := utils.BindAndValidate(c, &req); err != nil {
+		utils.BadRequest(c, err.Error())
+		return
+	}
+
+	ch, err := h.notificationService.Create(c.Request.Context(), orgID, &req)
+	if errors.Is(err, services.ErrInvalidChannel) {
+		utils.BadRequest(c, err.Error())
+		return
+	}
+	if err != nil {
+		utils.InternalError(c, err.Error())
+		return
+	}
+	utils.Created(c, ch)
+}
+
+// UpdateChannel godoc
+// @Summary Replace a notification channel's settings (Admin)
+// @Tags notifications
+// @Security BearerAuth
+// @Param id path string true "Channel ID"
+// @Accept json
+// @Produce json
+// @Param body body models.ChannelRequest true "Channel"
+// @Success 200 {object} utils.APIResponse{data=models.NotificationChannel}
+// @Router /api/notification-channels/{id} [put]
+func (h *NotificationHandler) Update(c *gin.Context) {
+	orgID := c.GetString("org_id")
+
+	var req models.ChannelRequest
+	if err := utils.BindAndValidate(c, &req); err != nil {
+		utils.BadRequest(c, err.Error())
+		return
+	}
+
+	ch, err := h.notificationService.Update(c.Request.Context(), orgID, c.Param("id"), &req)
+	if errors.Is(err, services.ErrChannelNotFound) {
+		utils.NotFound(c, err.Error())
+		return
+	}
+	if errors.Is(err, services.ErrInvalidChannel) {
+		utils.BadRequest(c, err.Error())
+		return
+	}
+	if err != nil {
+		utils.InternalError(c, err.Error())
+		return
+	}
+	utils.OK(c, ch)
+}
+
+// DeleteChannel godoc
+// @Summary Remove a notification channel (Admin)
+// @Tags notifications
+// @Security BearerAuth
+// @Param id path string true "Channel ID"
+// @Success 200 {object} utils.APIResponse
+// @Router /api/notification-channels/{id} [delete]
+func (h *NotificationHandler) Delete(c *gin.Context) {
+	orgID := c.GetString("org_id")
+	err := h.notificationService.Delete(c.Request.Context(), orgID, c.Param("id"))
+	if errors.Is(err, services.ErrChannelNotFound) {
+		utils.NotFound(c, err.Error())
+		return
+	}
+	if err != nil {
+		utils.InternalError(c, err.Error())
+		return
+	}
+	utils.OK(c, gin.H{"message": "channel removed"})
+}
+
+// TestChannel godoc
+// @Summary Queue a test notification to a channel (Admin)
+// @Tags notifications
+// @Security BearerAuth
+// @Param id path string true "Channel ID"
+// @Success 201 {object} utils.APIResponse{data=models.Delivery}
+// @Router /api/notification-channels/{id}/test [post]
+func (h *NotificationHandler) Test(c *gin.Context) {
+	orgID := c.GetString("org_id")
+	d, err := h.notificationService.Test(c.Request.Context(), orgID, c.Param("id"))
+	if errors.Is(err, services.ErrChannelNotFound) {
+		utils.NotFound(c, err.Error())
+		return
+	}
+	if err != nil {
+		utils.InternalError(c, err.Error())
+		return
+	}
+	utils.Created(c, d)
+}
+
+// ListDeliveries godoc
+// @Summary Outbound delivery log, newest first (Admin)
+// @Tags notifications
+// @Security BearerAuth
+// @Param kind query string false "notification or webhook"
+// @Param target_id query string false "Channel or webhook ID"
+// @Param status query string false "pending, retrying, delivered or dead"
+// @Param cursor query string false "next_cursor from the previous page"
+// @Param limit query int false "Page size (default 50, max 200)"
+// @Success 200 {object} utils.APIResponse{data=models.DeliveryPage}
+// @Router /api/deliveries [get]
+func (h *NotificationHandler) Deliveries(c *gin.Context) {
+	orgID := c.GetString("org_id")
+	limit, _ := strconv.Atoi(c.Query("limit"))
+	q := &models.DeliveryQuery{
+		Kind:     c.Query("kind"),
+		TargetID: c.Query("target_id"),
+		Status:   models.DeliveryStatus(c.Query("status")),
+		Cursor:   c.Query("cursor"),
+		Limit:    limit,
+	}
+	page, err := h.deliveryService.Log(c.Request.Context(), orgID, q)
+	if err != nil {
+		utils.BadRequest(c, err.Error())
+		return
+	}
+	utils.OK(c, page)
+}
+
+// ReplayDelivery godoc
+// @Summary Send a delivered or dead-lettered delivery again (Admin)
+// @Tags notifications
+// @Security BearerAuth
+// @Param id path string true "Delivery ID"
+// @Success 200 {object} utils.APIResponse{data=models.Delivery}
+// @Router /api/deliveries/{id}/replay [post]
+func (h *NotificationHandler) Replay(c *gin.Context) {
+	orgID := c.GetString("org_id")
+	d, err := h.deliveryService.Replay(c.Request.Context(), orgID, c.Param("id"))
+	if errors.Is(err, services.ErrDeliveryQueued) {
+		utils.Conflict(c, err.Error())
+		return
+	}
+	if errors.Is(err, services.ErrDeliveryNotFound) {
+		utils.NotFound(c, err.Error())
+		return
+	}
+	if err != nil {
+		utils.InternalError(c, err.Error())
+		return
+	}
+	utils.OK(c, d)
+}
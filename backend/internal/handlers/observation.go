@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"errors"
+
+	"github.com/gin-gonic/gin"
+	"praana/internal/models"
+	"praana/internal/services"
+	"praana/internal/utils"
+)
+
+type ObservationHandler struct {
+	observationService *services.ObservationService
+	careTeamService    *services.CareTeamService
+}
+
+func NewObservationHandler(obs *services.ObservationService, cs *services.CareTeamService) *ObservationHandler {
+	return &ObservationHandler{observationService: obs, careTeamService: cs}
+}
+
+// ObservationsDue godoc
+// @Summary When each admitted patient's next observations are due, soonest first
+// @Tags observations
+// @Security BearerAuth
+// @Param overdue query bool false "Only overdue patients"
+// @Param assigned query string false "Set to 'me' for your assigned patients"
+// @Success 200 {object} utils.APIResponse{data=[]models.ObservationDue}
+// @Router /api/observations/due [get]
+func (h *ObservationHandler) Due(c *gin.Context) {
+	orgID := c.GetString("org_id")
+	patientIDs, ok := assignedPatientFilter(c, h.careTeamService)
+	if !ok {
+		return
+	}
+	due, err := h.observationService.Due(c.Request.Context(), orgID, patientIDs, c.Query("overdue") == "true")
+	if err != nil {
+		utils.InternalError(c, err.Error())
+		return
+	}
+	utils.OK(c, due)
+}
+
+// GetObservationSchedule godoc
+// @Summary A patient's observation interval and next due time
+// @Tags observations
+// @Security BearerAuth
+// @Param id path string true "Patient ID"
+// @Success 200 {object} utils.APIResponse{data=models.ObservationDue}
+// @Router /api/patients/{id}/observation-schedule [get]
+func (h *ObservationHandler) Get(c *gin.Context) {
+	orgID := c.GetString("org_id")
+	due, err := h.observationService.ForPatient(c.Request.Context(), orgID, c.Param("id"))
+	if errors.Is(err, services.ErrPatientNotFound) {
+		utils.NotFound(c, err.Error())
+		return
+	}
+	if err != nil {
+		utils.InternalError(c, err.Error())
+		return
+	}
+	utils.OK(c, due)
+}
+
+// SetObservationSchedule godoc
+// @Summary Override a patient's observation interval (0 to follow status/NEWS2)
+// @Tags observations
+// @Security BearerAuth
+// @Param id path string true "Patient ID"
+// @Accept json
+// @Produce json
+// @Param body body models.SetObservationScheduleRequest true "Interval"
+// @Success 200 {object} utils.APIResponse{data=models.ObservationDue}
+// @Router /api/patients/{id}/observation-schedule [put]
+func (h *ObservationHandler) Set(c *gin.Context) {
+	orgID := c.GetString("org_id")
+
+	var req models.SetObservationScheduleRequest
+	if err := utils.BindAndValidate(c, &req); err != nil {
+		utils.BadRequest(c, err.Error())
+		return
+	}
+
+	due, err := h.observationService.SetSchedule(c.Request.Context(), orgID, c.Param("id"), &req)
+	if errors.Is(err, services.ErrPatientNotFound) {
+		utils.NotFound(c, err.Error())
+		return
+	}
+	if err != nil {
+		utils.InternalError(c, err.Error())
+		return
+	}
+	utils.OK(c, due)
+}
@@ -1,6 +1,8 @@
 package handlers
 
 import (
+	"errors"
+
 	"github.com/gin-gonic/gin"
 	"praana/internal/models"
 	"praana/internal/services"
@@ -88,8 +90,76 @@ func (h *OrgHandler) RemoveMember(c *gin.Context) {
 	utils.OK(c, gin.H{"message": "member removed"})
 }
 
+// MemberSessions godoc
+// @Summary List a member's active sessions
+// @Tags org
+// @Security BearerAuth
+// @Param id path string true "Member ID"
+// @Success 200 {object} utils.APIResponse{data=[]models.Session}
+// @Router /api/org/members/{id}/sessions [get]
+func (h *OrgHandler) MemberSessions(c *gin.Context) {
+	orgID := c.GetString("org_id")
+	sessions, err := h.orgService.MemberSessions(c.Request.Context(), orgID, c.Param("id"))
+	if err != nil {
+		utils.NotFound(c, err.Error())
+		return
+	}
+	utils.OK(c, sessions)
+}
+
+// RevokeMemberSessions godoc
+// @Summary Force-logout a member from one session, or from all of them
+// @Tags org
+// @Security BearerAuth
+// @Param id path string true "Member ID"
+// @Param sessionId path string false "Session ID; omit to revoke all"
+// @Success 200 {object} utils.APIResponse
+// @Router /api/org/members/{id}/sessions [delete]
+// @Router /api/org/members/{id}/sessions/{sessionId} [delete]
+func (h *OrgHandler) RevokeMemberSessions(c *gin.Context) {
+	orgID := c.GetString("org_id")
+	revoked, err := h.orgService.RevokeMemberSessions(c.Request.Context(), orgID, c.Param("id"), c.Param("sessionId"))
+	if err != nil {
+		utils.NotFound(c, err.Error())
+		return
+	}
+	utils.OK(c, gin.H{"revoked": revoked})
+}
+
+// ResetMemberTOTP godoc
+// @Summary Turn off two-factor authentication for a member and sign them out
+// @Tags org
+// @Security BearerAuth
+// @Param id path string true "Member ID"
+// @Success 200 {object} utils.APIResponse
+// @Router /api/org/members/{id}/2fa [delete]
+func (h *OrgHandler) ResetMemberTOTP(c *gin.Context) {
+	orgID := c.GetString("org_id")
+	if err := h.orgService.ResetMemberTOTP(c.Request.Context(), orgID, c.Param("id")); err != nil {
+		utils.NotFound(c, err.Error())
+		return
+	}
+	utils.OK(c, gin.H{"message": "two-factor authentication reset"})
+}
+
+// UnlockMember godoc
+// @Summary Lift a member's login lockout after repeated failed sign-ins
+// @Tags org
+// @Security BearerAuth
+// @Param id path string true "Member ID"
+// @Success 200 {object} utils.APIResponse
+// @Router /api/org/members/{id}/unlock [post]
+func (h *OrgHandler) UnlockMember(c *gin.Context) {
+	orgID := c.GetString("org_id")
+	if err := h.orgService.UnlockMember(c.Request.Context(), orgID, c.GetString("user_id"), c.Param("id")); err != nil {
+		utils.NotFound(c, err.Error())
+		return
+	}
+	utils.OK(c, gin.H{"message": "member unlocked"})
+}
+
 // Invite godoc
-// @Summary Generate team invite
+// @Summary Email a team invite with a signed link, or re-send a pending one to the same email
 // @Tags org
 // @Security BearerAuth
 // @Accept json
@@ -99,15 +169,74 @@ func (h *OrgHandler) RemoveMember(c *gin.Context) {
 // @Router /api/org/invite [post]
 func (h *OrgHandler) Invite(c *gin.Context) {
 	orgID := c.GetString("org_id")
+	userID := c.GetString("user_id")
 	var req models.InviteRequest
 	if err := utils.BindAndValidate(c, &req); err != nil {
 		utils.BadRequest(c, err.Error())
 		return
 	}
-	invite, err := h.orgService.CreateInvite(c.Request.Context(), orgID, &req)
+	invite, err := h.orgService.CreateInvite(c.Request.Context(), orgID, userID, &req)
 	if err != nil {
 		utils.BadRequest(c, err.Error())
 		return
 	}
 	utils.Created(c, invite)
 }
+
+// ListInvites godoc
+// @Summary List pending invites, soonest to expire first
+// @Tags org
+// @Security BearerAuth
+// @Success 200 {object} utils.APIResponse{data=[]models.Invite}
+// @Router /api/org/invites [get]
+func (h *OrgHandler) ListInvites(c *gin.Context) {
+	orgID := c.GetString("org_id")
+	invites, err := h.orgService.ListInvites(c.Request.Context(), orgID)
+	if err != nil {
+		utils.InternalError(c, err.Error())
+		return
+	}
+	utils.OK(c, invites)
+}
+
+// ResendInvite godoc
+// @Summary Email a fresh link for a pending invite and restart its expiry
+// @Tags org
+// @Security BearerAuth
+// @Param id path string true "Invite ID"
+// @Success 200 {object} utils.APIResponse{data=models.Invite}
+// @Router /api/org/invites/{id}/resend [post]
+func (h *OrgHandler) ResendInvite(c *gin.Context) {
+	orgID := c.GetString("org_id")
+	userID := c.GetString("user_id")
+	invite, err := h.orgService.ResendInvite(c.Request.Context(), orgID, c.Param("id"), userID)
+	if err != nil {
+		if errors.Is(err, services.ErrInviteNotFound) {
+			utils.NotFound(c, err.Error())
+			return
+		}
+		utils.BadRequest(c, err.Error())
+		return
+	}
+	utils.OK(c, invite)
+}
+
+// RevokeInvite godoc
+// @Summary Cancel a pending invite
+// @Tags org
+// @Security BearerAuth
+// @Param id path string true "Invite ID"
+// @Success 200 {object} utils.APIResponse
+// @Router /api/org/invites/{id} [delete]
+func (h *OrgHandler) RevokeInvite(c *gin.Context) {
+	orgID := c.GetString("org_id")
+	if err := h.orgService.RevokeInvite(c.Request.Context(), orgID, c.Param("id")); err != nil {
+		if errors.Is(err, services.ErrInviteNotFound) {
+			utils.NotFound(c, err.Error())
+			return
+		}
+		utils.InternalError(c, err.Error())
+		return
+	}
+	utils.OK(c, gin.H{"message": "invite revoked"})
+}
@@ -1,6 +1,8 @@
 package handlers
 
 import (
+	"errors"
+
 	"github.com/gin-gonic/gin"
 	"praana/internal/models"
 	"praana/internal/services"
@@ -11,10 +13,12 @@ type PatientHandler struct {
 	patientService *services.PatientService
 	orgService     *services.OrgService
 	vitalsService  *services.VitalsService
+	mergeService   *services.MergeService
+	muteService    *services.MuteService
 }
 
-func NewPatientHandler(ps *services.PatientService, os *services.OrgService, vs *services.VitalsService) *PatientHandler {
-	return &PatientHandler{patientService: ps, orgService: os, vitalsService: vs}
+func NewPatientHandler(ps *services.PatientService, os *services.OrgService, vs *services.VitalsService, ms *services.MergeService, mutes *services.MuteService) *PatientHandler {
+	return &PatientHandler{patientService: ps, orgService: os, vitalsService: vs, mergeService: ms, muteService: mutes}
 }
 
 // CreatePatient godoc
@@ -40,7 +44,16 @@ func (h *PatientHandler) Create(c *gin.Context) {
 		return
 	}
 
-	patient, err := h.patientService.Create(c.Request.Context(), orgID, &req)
+	userID := c.GetString("user_id")
+	patient, err := h.patientService.Create(c.Request.Context(), orgID, userID, &req)
+	if errors.Is(err, services.ErrIdentifierInUse) || errors.Is(err, services.ErrBedOccupied) {
+		utils.Conflict(c, err.Error())
+		return
+	}
+	if errors.Is(err, services.ErrInvalidIdentifier) {
+		utils.BadRequest(c, err.Error())
+		return
+	}
 	if err != nil {
 		utils.InternalError(c, err.Error())
 		return
@@ -52,11 +65,13 @@ func (h *PatientHandler) Create(c *gin.Context) {
 // @Summary List all patients
 // @Tags patients
 // @Security BearerAuth
+// @Param archived query bool false "List discharged (archived) patients instead"
 // @Success 200 {object} utils.APIResponse{data=[]models.Patient}
 // @Router /api/patients [get]
 func (h *PatientHandler) List(c *gin.Context) {
 	orgID := c.GetString("org_id")
-	patients, err := h.patientService.List(c.Request.Context(), orgID)
+	archived := c.Query("archived") == "true"
+	patients, err := h.patientService.List(c.Request.Context(), orgID, archived)
 	if err != nil {
 		utils.InternalError(c, err.Error())
 		return
@@ -82,12 +97,39 @@ func (h *PatientHandler) Get(c *gin.Context) {
 	}
 
 	latestVitals, _ := h.vitalsService.GetLatest(c.Request.Context(), orgID, patientID)
+	mutes, _ := h.muteService.Active(c.Request.Context(), orgID, patient.ID)
 	utils.OK(c, gin.H{
 		"patient":       patient,
 		"latest_vitals": latestVitals,
+		"active_mutes":  mutes,
 	})
 }
 
+// GetPatientByIdentifier godoc
+// @Summary Look up a patient by external identifier (e.g. MRN)
+// @Tags patients
+// @Security BearerAuth
+// @Param system query string true "Identifier system URI"
+// @Param value query string true "Identifier value"
+// @Success 200 {object} utils.APIResponse{data=models.Patient}
+// @Router /api/patients/by-identifier [get]
+func (h *PatientHandler) GetByIdentifier(c *gin.Context) {
+	orgID := c.GetString("org_id")
+	system := c.Query("system")
+	value := c.Query("value")
+	if system == "" || value == "" {
+		utils.BadRequest(c, "system and value are required")
+		return
+	}
+
+	patient, err := h.patientService.FindByIdentifier(c.Request.Context(), orgID, system, value)
+	if err != nil {
+		utils.NotFound(c, err.Error())
+		return
+	}
+	utils.OK(c, patient)
+}
+
 // UpdatePatient godoc
 // @Summary Update patient details
 // @Tags patients
@@ -101,6 +143,7 @@ func (h *PatientHandler) Get(c *gin.Context) {
 func (h *PatientHandler) Update(c *gin.Context) {
 	orgID := c.GetString("org_id")
 	patientID := c.Param("id")
+	userID := c.GetString("user_id")
 
 	var req models.UpdatePatientRequest
 	if err := utils.BindAndValidate(c, &req); err != nil {
@@ -108,28 +151,96 @@ func (h *PatientHandler) Update(c *gin.Context) {
 		return
 	}
 
-	patient, err := h.patientService.Update(c.Request.Context(), orgID, patientID, &req)
-	if err != nil {
+	patient, err := h.patientService.Update(c.Request.Context(), orgID, patientID, userID, &req)
+	if errors.Is(err, services.ErrIdentifierInUse) || errors.Is(err, services.ErrBedOccupied) {
+		utils.Conflict(c, err.Error())
+		return
+	}
+	if errors.Is(err, services.ErrPatientNotFound) {
 		utils.NotFound(c, err.Error())
 		return
 	}
+	if errors.Is(err, services.ErrInvalidIdentifier) || errors.Is(err, services.ErrPatientHasBed) || errors.Is(err, services.ErrPatientDischarged) {
+		utils.BadRequest(c, err.Error())
+		return
+	}
+	if err != nil {
+		utils.InternalError(c, err.Error())
+		return
+	}
 	utils.OK(c, patient)
 }
 
 // DeletePatient godoc
-// @Summary Discharge/remove patient
+// @Summary Discharge patient and archive their record
 // @Tags patients
 // @Security BearerAuth
 // @Param id path string true "Patient ID"
-// @Success 200 {object} utils.APIResponse
+// @Param reason query string false "Discharge reason"
+// @Success 200 {object} utils.APIResponse{data=models.Episode}
 // @Router /api/patients/{id} [delete]
 func (h *PatientHandler) Delete(c *gin.Context) {
 	orgID := c.GetString("org_id")
 	patientID := c.Param("id")
+	userID := c.GetString("user_id")
 
-	if err := h.patientService.Delete(c.Request.Context(), orgID, patientID); err != nil {
-		utils.NotFound(c, err.Error())
+	ep, err := h.patientService.Discharge(c.Request.Context(), orgID, patientID, userID, c.Query("reason"))
+	if err != nil {
+		utils.BadRequest(c, err.Error())
+		return
+	}
+	utils.OK(c, ep)
+}
+
+// PurgePatient godoc
+// @Summary Permanently delete a patient and all related data
+// @Tags patients
+// @Security BearerAuth
+// @Param id path string true "Patient ID"
+// @Success 200 {object} utils.APIResponse
+// @Failure 404 {object} utils.APIResponse
+// @Router /api/patients/{id}/purge [delete]
+func (h *PatientHandler) Purge(c *gin.Context) {
+	orgID := c.GetString("org_id")
+	patientID := c.Param("id")
+
+	if err := h.patientService.Purge(c.Request.Context(), orgID, patientID); err != nil {
+		if errors.Is(err, services.ErrPatientNotFound) {
+			utils.NotFound(c, err.Error())
+			return
+		}
+		utils.InternalError(c, err.Error())
+		return
+	}
+	utils.OK(c, gin.H{"message": "patient purged"})
+}
+
+// MergePatient godoc
+// @Summary Merge a duplicate registration into this patient
+// @Description Safe to retry: an interrupted merge resumes from the last completed step.
+// @Tags patients
+// @Security BearerAuth
+// @Param id path string true "Target patient ID"
+// @Accept json
+// @Produce json
+// @Param body body models.MergePatientRequest true "Duplicate patient to merge in"
+// @Success 200 {object} utils.APIResponse{data=models.PatientMerge}
+// @Router /api/patients/{id}/merge [post]
+func (h *PatientHandler) Merge(c *gin.Context) {
+	orgID := c.GetString("org_id")
+	targetID := c.Param("id")
+	userID := c.GetString("user_id")
+
+	var req models.MergePatientRequest
+	if err := utils.BindAndValidate(c, &req); err != nil {
+		utils.BadRequest(c, err.Error())
+		return
+	}
+
+	merge, err := h.mergeService.Merge(c.Request.Context(), orgID, targetID, req.SourceID, userID)
+	if err != nil {
+		utils.BadRequest(c, err.Error())
 		return
 	}
-	utils.OK(c, gin.H{"message": "patient removed"})
+	utils.OK(c, merge)
 }
@@ -0,0 +1,135 @@
+package handlers
+
+import (
+	"errors"
+
+	"github.com/gin-gonic/gin"
+	"praana/internal/models"
+	"praana/internal/services"
+	"praana/internal/utils"
+)
+
+type SSOHandler struct {
+	ssoService *services.SSOService
+}
+
+func NewSSOHandler(ssoService *services.SSOService) *SSOHandler {
+	return &SSOHandler{ssoService: ssoService}
+}
+
+// Start godoc
+// @Summary Start single sign-on for an email's organization
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param body body models.SSOStartRequest true "Work email"
+// @Success 200 {object} utils.APIResponse{data=models.SSOStartResponse}
+// @Failure 400 {object} utils.APIResponse
+// @Router /api/auth/sso/start [post]
+func (h *SSOHandler) Start(c *gin.Context) {
+	var req models.SSOStartRequest
+	if err := utils.BindAndValidate(c, &req); err != nil {
+		utils.BadRequest(c, err.Error())
+		return
+	}
+	resp, err := h.ssoService.Start(c.Request.Context(), &req)
+	if err != nil {
+		utils.BadRequest(c, err.Error())
+		return
+	}
+	utils.OK(c, resp)
+}
+
+// Callback godoc
+// @Summary Finish single sign-on with the code and state from the identity provider
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param body body models.SSOCallbackRequest true "Code and state"
+// @Success 200 {object} utils.APIResponse{data=models.LoginResponse}
+// @Failure 401 {object} utils.APIResponse
+// @Router /api/auth/sso/callback [post]
+func (h *SSOHandler) Callback(c *gin.Context) {
+	var req models.SSOCallbackRequest
+	if err := utils.BindAndValidate(c, &req); err != nil {
+		utils.BadRequest(c, err.Error())
+		return
+	}
+	resp, err := h.ssoService.Callback(c.Request.Context(), &req, clientInfo(c))
+	if err != nil {
+		utils.Unauthorized(c, err.Error())
+		return
+	}
+	utils.OK(c, resp)
+}
+
+// GetConfig godoc
+// @Summary Get the org's single sign-on configuration
+// @Tags org
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} utils.APIResponse{data=models.SSOConfig}
+// @Router /api/org/sso [get]
+func (h *SSOHandler) GetConfig(c *gin.Context) {
+	cfg, err := h.ssoService.GetConfig(c.Request.Context(), c.GetString("org_id"))
+	if err != nil {
+		if errors.Is(err, services.ErrSSONotConfigured) {
+			utils.NotFound(c, err.Error())
+			return
+		}
+		utils.InternalError(c, err.Error())
+		return
+	}
+	utils.OK(c, cfg)
+}
+
+// SaveConfig godoc
+// @Summary Set the org's OpenID Connect provider
+// @Tags org
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param body body models.SSOConfigRequest true "Provider settings"
+// @Success 200 {object} utils.APIResponse{data=models.SSOConfig}
+// @Failure 400 {object} utils.APIResponse
+// @Failure 409 {object} utils.APIResponse
+// @Router /api/org/sso [put]
+func (h *SSOHandler) SaveConfig(c *gin.Context) {
+	var req models.SSOConfigRequest
+	if err := utils.BindAndValidate(c, &req); err != nil {
+		utils.BadRequest(c, err.Error())
+		return
+	}
+	cfg, err := h.ssoService.SaveConfig(c.Request.Context(), c.GetString("org_id"), &req)
+	if err != nil {
+		if errors.Is(err, services.ErrSSODomainTaken) {
+			utils.Conflict(c, err.Error())
+			return
+		}
+		if errors.Is(err, services.ErrInvalidSSOConfig) || errors.Is(err, services.ErrSSODomainUnverified) {
+			utils.BadRequest(c, err.Error())
+			return
+		}
+		utils.InternalError(c, err.Error())
+		return
+	}
+	utils.OK(c, cfg)
+}
+
+// DeleteConfig godoc
+// @Summary Remove the org's single sign-on configuration
+// @Tags org
+// @Security BearerAuth
+// @Success 200 {object} utils.APIResponse
+// @Router /api/org/sso [delete]
+func (h *SSOHandler) DeleteConfig(c *gin.Context) {
+	if err := h.ssoService.DeleteConfig(c.Request.Context(), c.GetString("org_id")); err != nil {
+		if errors.Is(err, services.ErrSSONotConfigured) {
+			utils.NotFound(c, err.Error())
+			return
+		}
+		utils.InternalError(c, err.Error())
+		return
+	}
+	utils.OK(c, gin.H{"message": "single sign-on removed"})
+}
@@ -0,0 +1,213 @@
+package handlers
+
+import (
+	"errors"
+
+	"github.com/gin-gonic/gin"
+	"praana/internal/models"
+	"praana/internal/services"
+	"praana/internal/utils"
+)
+
+type WardHandler struct {
+	wardService *services.WardService
+}
+
+func NewWardHandler(ws *services.WardService) *WardHandler {
+	return &WardHandler{wardService: ws}
+}
+
+// CreateWard godoc
+// @Summary Create a ward
+// @Tags wards
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param body body models.CreateWardRequest true "Ward details"
+// @Success 201 {object} utils.APIResponse{data=models.Ward}
+// @Router /api/wards [post]
+func (h *WardHandler) Create(c *gin.Context) {
+	orgID := c.GetString("org_id")
+	var req models.CreateWardRequest
+	if err := utils.BindAndValidate(c, &req); err != nil {
+		utils.BadRequest(c, err.Error())
+		return
+	}
+	ward, err := h.wardService.Create(c.Request.Context(), orgID, &req)
+	if err != nil {
+		utils.InternalError(c, err.Error())
+		return
+	}
+	utils.Created(c, ward)
+}
+
+// ListWards godoc
+// @Summary List wards
+// @Tags wards
+// @Security BearerAuth
+// @Success 200 {object} utils.APIResponse{data=[]models.Ward}
+// @Router /api/wards [get]
+func (h *WardHandler) List(c *gin.Context) {
+	orgID := c.GetString("org_id")
+	wards, err := h.wardService.List(c.Request.Context(), orgID)
+	if err != nil {
+		utils.InternalError(c, err.Error())
+		return
+	}
+	utils.OK(c, wards)
+}
+
+// GetWard godoc
+// @Summary Get a ward
+// @Tags wards
+// @Security BearerAuth
+// @Param id path string true "Ward ID"
+// @Success 200 {object} utils.APIResponse{data=models.Ward}
+// @Router /api/wards/{id} [get]
+func (h *WardHandler) Get(c *gin.Context) {
+	orgID := c.GetString("org_id")
+	ward, err := h.wardService.Get(c.Request.Context(), orgID, c.Param("id"))
+	if err != nil {
+		utils.NotFound(c, err.Error())
+		return
+	}
+	utils.OK(c, ward)
+}
+
+// UpdateWard godoc
+// @Summary Rename a ward or change its capacity
+// @Tags wards
+// @Security BearerAuth
+// @Param id path string true "Ward ID"
+// @Accept json
+// @Produce json
+// @Param body body models.UpdateWardRequest true "Ward update"
+// @Success 200 {object} utils.APIResponse{data=models.Ward}
+// @Router /api/wards/{id} [put]
+func (h *WardHandler) Update(c *gin.Context) {
+	orgID := c.GetString("org_id")
+	var req models.UpdateWardRequest
+	if err := utils.BindAndValidate(c, &req); err != nil {
+		utils.BadRequest(c, err.Error())
+		return
+	}
+	ward, err := h.wardService.Update(c.Request.Context(), orgID, c.Param("id"), &req)
+	if err != nil {
+		utils.BadRequest(c, err.Error())
+		return
+	}
+	utils.OK(c, ward)
+}
+
+// DeleteWard godoc
+// @Summary Delete an empty ward and its beds
+// @Tags wards
+// @Security BearerAuth
+// @Param id path string true "Ward ID"
+// @Success 200 {object} utils.APIResponse
+// @Router /api/wards/{id} [delete]
+func (h *WardHandler) Delete(c *gin.Context) {
+	orgID := c.GetString("org_id")
+	if err := h.wardService.Delete(c.Request.Context(), orgID, c.Param("id")); err != nil {
+		utils.BadRequest(c, err.Error())
+		return
+	}
+	utils.OK(c, gin.H{"message": "ward deleted"})
+}
+
+// AddBed godoc
+// @Summary Add a bed to a ward
+// @Tags wards
+// @Security BearerAuth
+// @Param id path string true "Ward ID"
+// @Accept json
+// @Produce json
+// @Param body body models.CreateBedRequest true "Bed details"
+// @Success 201 {object} utils.APIResponse{data=models.Bed}
+// @Router /api/wards/{id}/beds [post]
+func (h *WardHandler) AddBed(c *gin.Context) {
+	orgID := c.GetString("org_id")
+	var req models.CreateBedRequest
+	if err := utils.BindAndValidate(c, &req); err != nil {
+		utils.BadRequest(c, err.Error())
+		return
+	}
+	bed, err := h.wardService.AddBed(c.Request.Context(), orgID, c.Param("id"), &req)
+	if err != nil {
+		utils.BadRequest(c, err.Error())
+		return
+	}
+	utils.Created(c, bed)
+}
+
+// ListBeds godoc
+// @Summary List a ward's beds with occupants
+// @Tags wards
+// @Security BearerAuth
+// @Param id path string true "Ward ID"
+// @Success 200 {object} utils.APIResponse{data=[]models.Bed}
+// @Router /api/wards/{id}/beds [get]
+func (h *WardHandler) ListBeds(c *gin.Context) {
+	orgID := c.GetString("org_id")
+	beds, err := h.wardService.ListBeds(c.Request.Context(), orgID, c.Param("id"))
+	if err != nil {
+		utils.NotFound(c, err.Error())
+		return
+	}
+	utils.OK(c, beds)
+}
+
+// DeleteBed godoc
+// @Summary Remove an unoccupied bed
+// @Tags wards
+// @Security BearerAuth
+// @Param id path string true "Ward ID"
+// @Param bedId path string true "Bed ID"
+// @Success 200 {object} utils.APIResponse
+// @Router /api/wards/{id}/beds/{bedId} [delete]
+func (h *WardHandler) DeleteBed(c *gin.Context) {
+	orgID := c.GetString("org_id")
+	err := h.wardService.DeleteBed(c.Request.Context(), orgID, c.Param("id"), c.Param("bedId"))
+	if errors.Is(err, services.ErrBedOccupied) {
+		utils.Conflict(c, err.Error())
+		return
+	}
+	if err != nil {
+		utils.NotFound(c, err.Error())
+		return
+	}
+	utils.OK(c, gin.H{"message": "bed removed"})
+}
+
+// WardOccupancy godoc
+// @Summary Bed board for a ward
+// @Tags wards
+// @Security BearerAuth
+// @Param id path string true "Ward ID"
+// @Success 200 {object} utils.APIResponse{data=models.WardOccupancy}
+// @Router /api/wards/{id}/occupancy [get]
+func (h *WardHandler) Occupancy(c *gin.Context) {
+	orgID := c.GetString("org_id")
+	occ, err := h.wardService.Occupancy(c.Request.Context(), orgID, c.Param("id"))
+	if err != nil {
+		utils.NotFound(c, err.Error())
+		return
+	}
+	utils.OK(c, occ)
+}
+
+// OccupancySummary godoc
+// @Summary Occupancy counts for all wards
+// @Tags wards
+// @Security BearerAuth
+// @Success 200 {object} utils.APIResponse{data=[]models.WardOccupancy}
+// @Router /api/wards/occupancy [get]
+func (h *WardHandler) OccupancySummary(c *gin.Context) {
+	orgID := c.GetString("org_id")
+	summary, err := h.wardService.OccupancySummary(c.Request.Context(), orgID)
+	if err != nil {
+		utils.InternalError(c, err.Error())
+		return
+	}
+	utils.OK(c, summary)
+}
@@ -0,0 +1,146 @@
+package handlers
+
+import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"praana/internal/models"
+	"praana/internal/services"
+	"praana/internal/utils"
+)
+
+type WebhookHandler struct {
+	webhookService *services.WebhookService
+}
+
+func NewWebhookHandler(ws *services.WebhookService) *WebhookHandler {
+	return &WebhookHandler{webhookService: ws}
+}
+
+// ListWebhooks godoc
+// @Summary List event webhook endpoints (Admin)
+// @Tags webhooks
+// @Security BearerAuth
+// @Success 200 {object} utils.APIResponse{data=[]models.WebhookEndpoint}
+// @Router /api/webhooks [get]
+func (h *WebhookHandler) List(c *gin.Context) {
+	orgID := c.GetString("org_id")
+	endpoints, err := h.webhookService.List(c.Request.Context(), orgID)
+	if err != nil {
+		utils.InternalError(c, err.Error())
+		return
+	}
+	utils.OK(c, endpoints)
+}
+
+// CreateWebhook godoc
+// @Summary Register an endpoint for domain events (Admin)
+// @Tags webhooks
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param body body models.WebhookEndpointRequest true "Endpoint"
+// @Success 201 {object} utils.APIResponse{data=models.WebhookEndpoint}
+// @Router /api/webhooks [post]
+func (h *WebhookHandler) Create(c *gin.Context) {
+	orgID := c.GetString("org_id")
+
+	var req models.WebhookEndpointRequest
+	if err := utils.BindAndValidate(c, &req); err != nil {
+		utils.BadRequest(c, err.Error())
+		return
+	}
+
+	ep, err := h.webhookService.Create(c.Request.Context(), orgID, &req)
+	if err != nil {
+		utils.InternalError(c, err.Error())
+		return
+	}
+	utils.Created(c, ep)
+}
+
+// GetWebhook godoc
+// @Summary Get a webhook endpoint (Admin)
+// @Tags webhooks
+// @Security BearerAuth
+// @Param id path string true "Webhook ID"
+// @Success 200 {object} utils.APIResponse{data=models.WebhookEndpoint}
+// @Router /api/webhooks/{id} [get]
+func (h *WebhookHandler) Get(c *gin.Context) {
+	orgID := c.GetString("org_id")
+	ep, err := h.webhookService.Get(c.Request.Context(), orgID, c.Param("id"))
+	if err != nil {
+		utils.NotFound(c, err.Error())
+		return
+	}
+	utils.OK(c, ep)
+}
+
+// UpdateWebhook godoc
+// @Summary Replace a webhook endpoint's URL, events or secret (Admin)
+// @Tags webhooks
+// @Security BearerAuth
+// @Param id path string true "Webhook ID"
+// @Accept json
+// @Produce json
+// @Param body body models.WebhookEndpointRequest true "Endpoint"
+// @Success 200 {object} utils.APIResponse{data=models.WebhookEndpoint}
+// @Router /api/webhooks/{id} [put]
+func (h *WebhookHandler) Update(c *gin.Context) {
+	orgID := c.GetString("org_id")
+
+	var req models.WebhookEndpointRequest
+	if err := utils.BindAndValidate(c, &req); err != nil {
+		utils.BadRequest(c, err.Error())
+		return
+	}
+
+	ep, err := h.webhookService.Update(c.Request.Context(), orgID, c.Param("id"), &req)
+	if err != nil {
+		utils.NotFound(c, err.Error())
+		return
+	}
+	utils.OK(c, ep)
+}
+
+// DeleteWebhook godoc
+// @Summary Remove a webhook endpoint (Admin)
+// @Tags webhooks
+// @Security BearerAuth
+// @Param id path string true "Webhook ID"
+// @Success 200 {object} utils.APIResponse
+// @Router /api/webhooks/{id} [delete]
+func (h *WebhookHandler) Delete(c *gin.Context) {
+	orgID := c.GetString("org_id")
+	if err := h.webhookService.Delete(c.Request.Context(), orgID, c.Param("id")); err != nil {
+		utils.NotFound(c, err.Error())
+		return
+	}
+	utils.OK(c, gin.H{"message": "webhook removed"})
+}
+
+// WebhookDeliveries godoc
+// @Summary A webhook endpoint's deliveries, newest first (Admin)
+// @Tags webhooks
+// @Security BearerAuth
+// @Param id path string true "Webhook ID"
+// @Param status query string false "pending, retrying, delivered or dead"
+// @Param cursor query string false "next_cursor from the previous page"
+// @Param limit query int false "Page size (default 50, max 200)"
+// @Success 200 {object} utils.APIResponse{data=models.DeliveryPage}
+// @Router /api/webhooks/{id}/deliveries [get]
+func (h *WebhookHandler) Deliveries(c *gin.Context) {
+	orgID := c.GetString("org_id")
+	limit, _ := strconv.Atoi(c.Query("limit"))
+	q := &models.DeliveryQuery{
+		Status: models.DeliveryStatus(c.Query("status")),
+		Cursor: c.Query("cursor"),
+		Limit:  limit,
+	}
+	page, err := h.webhookService.Deliveries(c.Request.Context(), orgID, c.Param("id"), q)
+	if err != nil {
+		utils.NotFound(c, err.Error())
+		return
+	}
+	utils.OK(c, page)
+}
@@ -43,6 +43,10 @@ func (h *WSHandler) Handle(c *gin.Context) {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
 		return
 	}
+	if claims.MFASetup {
+		c.JSON(http.StatusForbidden, gin.H{"error": "two-factor authentication setup required"})
+		return
+	}
 
 	conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
 	if err != nil {
@@ -51,9 +55,11 @@ func (h *WSHandler) Handle(c *gin.Context) {
 	}
 
 	client := &services.WSClient{
-		Conn:  conn,
-		OrgID: claims.OrgID,
-		Send:  make(chan []byte, 256),
+		Conn:      conn,
+		OrgID:     claims.OrgID,
+		UserID:    claims.UserID,
+		SessionID: claims.SessionID,
+		Send:      make(chan []byte, 256),
 	}
 
 	h.hub.Register(client)
@@ -0,0 +1,106 @@
+package mailer
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"mime"
+	"net/smtp"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Message is an email with a plain-text body and an optional HTML alternative.
+type Message struct {
+	To      []string
+	Subject string
+	Text    string
+	HTML    string
+}
+
+// Mailer sends email.
+type Mailer interface {
+	Send(ctx context.Context, msg *Message) error
+}
+
+// SMTPMailer sends through an SMTP server, with PLAIN auth when User is set.
+type SMTPMailer struct {
+	Addr     string
+	User     string
+	Password string
+	From     string
+}
+
+func (m *SMTPMailer) Send(ctx context.Context, msg *Message) error {
+	var auth smtp.Auth
+	if m.User != "" {
+		host, _, _ := strings.Cut(m.Addr, ":")
+		auth = smtp.PlainAuth("", m.User, m.Password, host)
+	}
+	return smtp.SendMail(m.Addr, auth, m.From, msg.To, build(m.From, msg))
+}
+
+// ErrNotConfigured is returned by DisabledMailer.
+var ErrNotConfigured = errors.New("no mail driver configured")
+
+// DisabledMailer is used when no MAIL_DRIVER is set. Every send fails, so
+// nothing is reported as sent when it wasn't.
+type DisabledMailer struct{}
+
+func (DisabledMailer) Send(ctx context.Context, msg *Message) error {
+	return ErrNotConfigured
+}
+
+// FileMailer writes each message as an .eml file in Dir instead of sending
+// it, for development and tests.
+type FileMailer struct {
+	Dir  string
+	From string
+}
+
+func (m *FileMailer) Send(ctx context.Context, msg *Message) error {
+	if err := os.MkdirAll(m.Dir, 0o750); err != nil {
+		return err
+	}
+	name := fmt.Sprintf("%s-%s.eml", time.Now().UTC().Format("20060102T150405"), randomHex(4))
+	return os.WriteFile(filepath.Join(m.Dir, name), build(m.From, msg), 0o640)
+}
+
+// build renders msg as RFC 5322 text, multipart/alternative when it has HTML.
+func build(from string, msg *Message) []byte {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "From: %s\r\n", from)
+	fmt.Fprintf(&b, "To: %s\r\n", strings.Join(msg.To, ", "))
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", msg.Subject))
+	fmt.Fprintf(&b, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+
+	text := crlf(msg.Text)
+	if msg.HTML == "" {
+		b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n\r\n")
+		b.WriteString(text)
+		return b.Bytes()
+	}
+
+	boundary := "praana-" + randomHex(12)
+	fmt.Fprintf(&b, "Content-Type: multipart/alternative; boundary=%q\r\n\r\n", boundary)
+	fmt.Fprintf(&b, "--%s\r\nContent-Type: text/plain; charset=UTF-8\r\n\r\n%s\r\n", boundary, text)
+	fmt.Fprintf(&b, "--%s\r\nContent-Type: text/html; charset=UTF-8\r\n\r\n%s\r\n", boundary, crlf(msg.HTML))
+	fmt.Fprintf(&b, "--%s--\r\n", boundary)
+	return b.Bytes()
+}
+
+func crlf(s string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(s, "\r\n", "\n"), "\n", "\r\n")
+}
+
+func randomHex(n int) string {
+	b := make([]byte, n)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
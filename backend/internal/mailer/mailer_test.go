@@ -0,0 +1,143 @@
+package mailer
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"strings"
+	"testing"
+)
+
+// smtpMessage is one message accepted by an smtpStub.
+type smtpMessage struct {
+	from string
+	to   []string
+	data string
+}
+
+// smtpStub is a minimal SMTP server that accepts every message and hands it
+// to the test on a channel.
+type smtpStub struct {
+	addr     string
+	messages chan smtpMessage
+}
+
+func newSMTPStub(t *testing.T) *smtpStub {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	s := &smtpStub{addr: ln.Addr().String(), messages: make(chan smtpMessage, 4)}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn)
+		}
+	}()
+	return s
+}
+
+func (s *smtpStub) serve(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	reply := func(line string) { conn.Write([]byte(line + "\r\n")) }
+	reply("220 stub ESMTP")
+	var msg smtpMessage
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		cmd := strings.TrimRight(line, "\r\n")
+		switch verb := strings.ToUpper(strings.SplitN(cmd, " ", 2)[0]); verb {
+		case "EHLO", "HELO":
+			reply("250 stub")
+		case "MAIL":
+			msg = smtpMessage{from: addrArg(cmd)}
+			reply("250 ok")
+		case "RCPT":
+			msg.to = append(msg.to, addrArg(cmd))
+			reply("250 ok")
+		case "DATA":
+			reply("354 go ahead")
+			var data strings.Builder
+			for {
+				l, err := r.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if l == ".\r\n" {
+					break
+				}
+				data.WriteString(l)
+			}
+			msg.data = data.String()
+			s.messages <- msg
+			reply("250 queued")
+		case "QUIT":
+			reply("221 bye")
+			return
+		default:
+			reply("250 ok")
+		}
+	}
+}
+
+// addrArg returns the address in "MAIL FROM:<a>" or "RCPT TO:<a>".
+func addrArg(cmd string) string {
+	_, rest, _ := strings.Cut(cmd, "<")
+	addr, _, _ := strings.Cut(rest, ">")
+	return addr
+}
+
+func TestSMTPMailerSend(t *testing.T) {
+	stub := newSMTPStub(t)
+	m := &SMTPMailer{Addr: stub.addr, From: "praana@hospital.org"}
+	msg := &Message{
+		To:      []string{"a@hospital.org", "b@hospital.org"},
+		Subject: "Alert digest",
+		Text:    "Bed 4: SpO2 88%\n",
+		HTML:    "<p>Bed 4: SpO2 88%</p>",
+	}
+
+	if err := m.Send(context.Background(), msg); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	got := <-stub.messages
+	if got.from != "praana@hospital.org" {
+		t.Fatalf("MAIL FROM = %q", got.from)
+	}
+	if strings.Join(got.to, ",") != "a@hospital.org,b@hospital.org" {
+		t.Fatalf("RCPT TO = %v", got.to)
+	}
+	for _, want := range []string{
+		"To: a@hospital.org, b@hospital.org\r\n",
+		"Subject: Alert digest\r\n",
+		"Content-Type: multipart/alternative",
+		"Bed 4: SpO2 88%\r\n",
+		"<p>Bed 4: SpO2 88%</p>",
+	} {
+		if !strings.Contains(got.data, want) {
+			t.Errorf("message is missing %q:\n%s", want, got.data)
+		}
+	}
+}
+
+func TestSMTPMailerSendUnreachable(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := ln.Addr().String()
+	ln.Close()
+
+	m := &SMTPMailer{Addr: addr, From: "praana@hospital.org"}
+	if err := m.Send(context.Background(), &Message{To: []string{"a@hospital.org"}, Subject: "x", Text: "x"}); err == nil {
+		t.Fatal("Send to a closed port succeeded")
+	}
+}
@@ -0,0 +1,71 @@
+package mailer
+
+import (
+	"bytes"
+	"embed"
+	htmltemplate "html/template"
+	"strings"
+	texttemplate "text/template"
+)
+
+// Each email has <name>.txt, which also defines the "subject" template, and
+// <name>.html.
+//
+//go:embed templates
+var templateFS embed.FS
+
+var (
+	textTemplates = texttemplate.Must(texttemplate.ParseFS(templateFS, "templates/*.txt"))
+	htmlTemplates = htmltemplate.Must(htmltemplate.ParseFS(templateFS, "templates/*.html"))
+)
+
+// InviteData fills the "invite" email.
+type InviteData struct {
+	OrgName   string
+	InvitedBy string
+	Role      string
+	Link      string
+	ExpiresAt string
+}
+
+// PasswordResetData fills the "password_reset" email.
+type PasswordResetData struct {
+	Name      string
+	Link      string
+	ExpiresIn string
+}
+
+// DigestAlert is one line of an alert digest.
+type DigestAlert struct {
+	Severity string `json:"severity"`
+	Patient  string `json:"patient"`
+	Location string `json:"location,omitempty"`
+	Message  string `json:"message"`
+	At       string `json:"at"`
+}
+
+// AlertDigestData fills the "alert_digest" email.
+type AlertDigestData struct {
+	OrgName string
+	Alerts  []DigestAlert
+}
+
+// Render builds the named email for the recipients.
+func Render(name string, to []string, data interface{}) (*Message, error) {
+	var subject, text, html bytes.Buffer
+	if err := textTemplates.ExecuteTemplate(&subject, name+".subject", data); err != nil {
+		return nil, err
+	}
+	if err := textTemplates.ExecuteTemplate(&text, name+".txt", data); err != nil {
+		return nil, err
+	}
+	if err := htmlTemplates.ExecuteTemplate(&html, name+".html", data); err != nil {
+		return nil, err
+	}
+	return &Message{
+		To:      to,
+		Subject: strings.TrimSpace(subject.String()),
+		Text:    strings.TrimSpace(text.String()) + "\n",
+		HTML:    html.String(),
+	}, nil
+}
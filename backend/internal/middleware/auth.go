@@ -31,12 +31,20 @@ func AuthMiddleware(authService *services.AuthService) gin.HandlerFunc {
 			return
 		}
 
+		// Until the user turns on the 2FA their org requires, only the auth
+		// endpoints (including 2FA setup) are reachable.
+		if claims.MFASetup && !strings.HasPrefix(c.FullPath(), "/api/auth/") {
+			utils.Forbidden(c, "two-factor authentication setup required")
+			c.Abort()
+			return
+		}
+
 		c.Set("user_id", claims.UserID)
 		c.Set("email", claims.Email)
 		c.Set("name", claims.Name)
 		c.Set("org_id", claims.OrgID)
 		c.Set("role", string(claims.Role))
-		c.Set("jwt_id", claims.ID)
+		c.Set("session_id", claims.SessionID)
 
 		c.Next()
 	}
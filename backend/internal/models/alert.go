@@ -7,22 +7,112 @@ const (
 	SeverityCritical AlertSeverity = "critical"
 )
 
+type AlertState string
+
+const (
+	AlertOpen         AlertState = "open"
+	AlertAcknowledged AlertState = "acknowledged"
+	AlertResolved     AlertState = "resolved"
+)
+
+var AlertStates = []AlertState{AlertOpen, AlertAcknowledged, AlertResolved}
+
 type Alert struct {
 	ID             string        `json:"id"`
 	OrgID          string        `json:"org_id"`
 	PatientID      string        `json:"patient_id"`
 	PatientName    string        `json:"patient_name"`
+	EpisodeID      string        `json:"episode_id,omitempty"`
 	VitalType      string        `json:"vital_type"`
 	Value          float64       `json:"value"`
 	Threshold      float64       `json:"threshold"`
 	Severity       AlertSeverity `json:"severity"`
 	Message        string        `json:"message"`
+	State          AlertState    `json:"state"`
 	Acknowledged   bool          `json:"acknowledged"`
 	AcknowledgedBy string        `json:"acknowledged_by,omitempty"`
 	AcknowledgedAt int64         `json:"acknowledged_at,omitempty"`
+	AckReason      AckReason     `json:"ack_reason,omitempty"`
+	AckAction      string        `json:"ack_action,omitempty"`
+	AssignedTo     string        `json:"assigned_to,omitempty"`
+	AssignedBy     string        `json:"assigned_by,omitempty"`
+	AssignedAt     int64         `json:"assigned_at,omitempty"`
+	CommentCount   int           `json:"comment_count,omitempty"`
+	Suppressed     bool          `json:"suppressed,omitempty"`
+	MuteID         string        `json:"mute_id,omitempty"`
+	ResolvedBy     string        `json:"resolved_by,omitempty"`
+	ResolvedAt     int64         `json:"resolved_at,omitempty"`
+	Resolution     string        `json:"resolution,omitempty"`
 	CreatedAt      int64         `json:"created_at"`
 }
 
+// AckReason records why an alert was acknowledged.
+type AckReason string
+
+const (
+	AckReassessed     AckReason = "reassessed"
+	AckDoctorInformed AckReason = "doctor_informed"
+	AckFalseReading   AckReason = "false_reading"
+	AckTreatmentGiven AckReason = "treatment_given"
+	AckEscalated      AckReason = "escalated"
+	AckOther          AckReason = "other"
+)
+
+// AcknowledgeAlertRequest requires a reason code; Action describes what was
+// done and is required when the reason is "other".
+type AcknowledgeAlertRequest struct {
+	Reason AckReason `json:"reason" validate:"required,oneof=reassessed doctor_informed false_reading treatment_given escalated other"`
+	Action string    `json:"action" validate:"required_if=Reason other,max=500"`
+}
+
+type AssignAlertRequest struct {
+	UserID string `json:"user_id" validate:"required"`
+}
+
+// AlertComment is a comment on an alert; ParentID threads replies.
+type AlertComment struct {
+	ID         string `json:"id"`
+	AlertID    string `json:"alert_id"`
+	ParentID   string `json:"parent_id,omitempty"`
+	AuthorID   string `json:"author_id"`
+	AuthorName string `json:"author_name"`
+	Body       string `json:"body"`
+	CreatedAt  int64  `json:"created_at"`
+}
+
+type AlertCommentRequest struct {
+	Body     string `json:"body" validate:"required,max=2000"`
+	ParentID string `json:"parent_id"`
+}
+
+type AlertDetail struct {
+	Alert    Alert          `json:"alert"`
+	Comments []AlertComment `json:"comments"`
+}
+
+type ResolveAlertRequest struct {
+	Resolution string `json:"resolution" validate:"max=500"`
+}
+
+// AlertQuery filters alert history. Zero values match everything; From and
+// To bound created_at (unix seconds, inclusive).
+type AlertQuery struct {
+	PatientID  string
+	Severity   AlertSeverity
+	VitalType  string
+	State      AlertState
+	AssignedTo string
+	From       int64
+	To         int64
+	Cursor     string
+	Limit      int
+}
+
+type AlertPage struct {
+	Alerts     []Alert `json:"alerts"`
+	NextCursor string  `json:"next_cursor,omitempty"`
+}
+
 type Threshold struct {
 	HeartRateHigh       float64 `json:"heart_rate_high"`
 	HeartRateLow        float64 `json:"heart_rate_low"`
@@ -64,3 +154,23 @@ type SetThresholdRequest struct {
 	RespiratoryRateHigh *float64 `json:"respiratory_rate_high"`
 	RespiratoryRateLow  *float64 `json:"respiratory_rate_low"`
 }
+
+// AlertMute silences one vital type for a patient until ExpiresAt. Breaches
+// during a mute are still recorded, as suppressed alerts.
+type AlertMute struct {
+	ID            string `json:"id"`
+	OrgID         string `json:"org_id"`
+	PatientID     string `json:"patient_id"`
+	VitalType     string `json:"vital_type"`
+	Reason        string `json:"reason"`
+	CreatedBy     string `json:"created_by"`
+	CreatedByName string `json:"created_by_name"`
+	CreatedAt     int64  `json:"created_at"`
+	ExpiresAt     int64  `json:"expires_at"`
+}
+
+type CreateMuteRequest struct {
+	VitalType       string `json:"vital_type" validate:"required,oneof=heart_rate systolic_bp diastolic_bp temperature spo2 respiratory_rate observations"`
+	DurationMinutes int    `json:"duration_minutes" validate:"required,min=5,max=1440"`
+	Reason          string `json:"reason" validate:"required,max=500"`
+}
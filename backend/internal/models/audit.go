@@ -0,0 +1,13 @@
+package models
+
+// AuditEntry records an administrative or security-relevant action.
+type AuditEntry struct {
+	ID         string            `json:"id"`
+	OrgID      string            `json:"org_id"`
+	ActorID    string            `json:"actor_id,omitempty"`
+	Action     string            `json:"action"`
+	TargetType string            `json:"target_type"`
+	TargetID   string            `json:"target_id"`
+	Details    map[string]string `json:"details,omitempty"`
+	CreatedAt  int64             `json:"created_at"`
+}
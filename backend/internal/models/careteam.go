@@ -0,0 +1,25 @@
+package models
+
+// CareAssignment makes a user responsible for a patient, or for every patient
+// in a ward, for the duration of a shift.
+type CareAssignment struct {
+	ID         string `json:"id"`
+	OrgID      string `json:"org_id"`
+	UserID     string `json:"user_id"`
+	UserName   string `json:"user_name"`
+	Role       Role   `json:"role"`
+	PatientID  string `json:"patient_id,omitempty"`
+	WardID     string `json:"ward_id,omitempty"`
+	ShiftStart int64  `json:"shift_start"`
+	ShiftEnd   int64  `json:"shift_end"`
+	CreatedBy  string `json:"created_by"`
+	CreatedAt  int64  `json:"created_at"`
+}
+
+type CreateAssignmentRequest struct {
+	UserID     string `json:"user_id" validate:"required"`
+	PatientID  string `json:"patient_id" validate:"required_without=WardID"`
+	WardID     string `json:"ward_id" validate:"required_without=PatientID"`
+	ShiftStart int64  `json:"shift_start" validate:"required"`
+	ShiftEnd   int64  `json:"shift_end" validate:"required,gtfield=ShiftStart"`
+}
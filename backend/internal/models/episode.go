@@ -0,0 +1,64 @@
+package models
+
+type EpisodeStatus string
+
+const (
+	EpisodeActive EpisodeStatus = "active"
+	EpisodeClosed EpisodeStatus = "closed"
+)
+
+// Episode is a single admission of a patient, from admit to discharge.
+type Episode struct {
+	ID              string            `json:"id"`
+	OrgID           string            `json:"org_id"`
+	PatientID       string            `json:"patient_id"`
+	Status          EpisodeStatus     `json:"status"`
+	Ward            string            `json:"ward"`
+	BedNumber       string            `json:"bed_number"`
+	WardID          string            `json:"ward_id,omitempty"`
+	BedID           string            `json:"bed_id,omitempty"`
+	Diagnosis       string            `json:"diagnosis"`
+	AdmitReason     string            `json:"admit_reason,omitempty"`
+	AdmittedBy      string            `json:"admitted_by,omitempty"`
+	AdmittedAt      int64             `json:"admitted_at"`
+	DischargeReason string            `json:"discharge_reason,omitempty"`
+	DischargedBy    string            `json:"discharged_by,omitempty"`
+	DischargedAt    int64             `json:"discharged_at,omitempty"`
+	Movements       []EpisodeMovement `json:"movements,omitempty"`
+	CreatedAt       int64             `json:"created_at"`
+	UpdatedAt       int64             `json:"updated_at"`
+}
+
+// EpisodeMovement records a ward/bed transfer within an episode.
+type EpisodeMovement struct {
+	FromWard      string `json:"from_ward"`
+	FromBedNumber string `json:"from_bed_number"`
+	FromBedID     string `json:"from_bed_id,omitempty"`
+	ToWard        string `json:"to_ward"`
+	ToBedNumber   string `json:"to_bed_number"`
+	ToBedID       string `json:"to_bed_id,omitempty"`
+	Reason        string `json:"reason"`
+	MovedBy       string `json:"moved_by"`
+	MovedAt       int64  `json:"moved_at"`
+}
+
+type AdmitRequest struct {
+	Ward      string `json:"ward"`
+	BedNumber string `json:"bed_number"`
+	BedID     string `json:"bed_id"`
+	Diagnosis string `json:"diagnosis"`
+	Reason    string `json:"reason" validate:"required,max=500"`
+}
+
+// TransferRequest moves a patient either to a managed bed (BedID) or to a
+// free-text ward/bed.
+type TransferRequest struct {
+	Ward      string `json:"ward" validate:"required_without=BedID"`
+	BedNumber string `json:"bed_number"`
+	BedID     string `json:"bed_id"`
+	Reason    string `json:"reason" validate:"required,max=500"`
+}
+
+type DischargeRequest struct {
+	Reason string `json:"reason" validate:"required,max=500"`
+}
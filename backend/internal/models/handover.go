@@ -0,0 +1,60 @@
+package models
+
+type HandoverStatus string
+
+const (
+	HandoverDraft  HandoverStatus = "draft"
+	HandoverSigned HandoverStatus = "signed"
+)
+
+// HandoverReport is an SBAR (Situation, Background, Assessment,
+// Recommendation) handover for one patient at the end of a shift. It is
+// generated as a draft from the shift's data, edited by staff and then signed
+// off, after which it is read-only.
+type HandoverReport struct {
+	ID             string         `json:"id"`
+	OrgID          string         `json:"org_id"`
+	PatientID      string         `json:"patient_id"`
+	PatientName    string         `json:"patient_name"`
+	EpisodeID      string         `json:"episode_id,omitempty"`
+	Ward           string         `json:"ward"`
+	BedNumber      string         `json:"bed_number"`
+	ShiftStart     int64          `json:"shift_start"`
+	ShiftEnd       int64          `json:"shift_end"`
+	Situation      string         `json:"situation"`
+	Background     string         `json:"background"`
+	Assessment     string         `json:"assessment"`
+	Recommendation string         `json:"recommendation"`
+	Stats          HandoverStats  `json:"stats"`
+	Status         HandoverStatus `json:"status"`
+	GeneratedBy    string         `json:"generated_by"`
+	GeneratedAt    int64          `json:"generated_at"`
+	EditedBy       string         `json:"edited_by,omitempty"`
+	EditedAt       int64          `json:"edited_at,omitempty"`
+	SignedBy       string         `json:"signed_by,omitempty"`
+	SignedAt       int64          `json:"signed_at,omitempty"`
+}
+
+// HandoverStats are the shift counts the draft was generated from.
+type HandoverStats struct {
+	VitalsRecorded  int `json:"vitals_recorded"`
+	AlertsTriggered int `json:"alerts_triggered"`
+	AlertsAcked     int `json:"alerts_acknowledged"`
+	NotesWritten    int `json:"notes_written"`
+	StatusChanges   int `json:"status_changes"`
+}
+
+// GenerateHandoverRequest drafts handovers for the current shift. With no
+// patient IDs, every admitted patient gets one.
+type GenerateHandoverRequest struct {
+	PatientIDs []string `json:"patient_ids"`
+}
+
+// UpdateHandoverRequest edits a draft. Omitted sections are kept; an empty
+// string clears the section.
+type UpdateHandoverRequest struct {
+	Situation      *string `json:"situation" validate:"omitempty,max=5000"`
+	Background     *string `json:"background" validate:"omitempty,max=5000"`
+	Assessment     *string `json:"assessment" validate:"omitempty,max=5000"`
+	Recommendation *string `json:"recommendation" validate:"omitempty,max=5000"`
+}
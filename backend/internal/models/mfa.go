@@ -0,0 +1,38 @@
+package models
+
+// LoginMFARequest completes a two-step login. Code is a current TOTP code or
+// an unused recovery code.
+type LoginMFARequest struct {
+	MFAToken string `json:"mfa_token" validate:"required"`
+	Code     string `json:"code" validate:"required,max=20"`
+}
+
+type TwoFactorStatus struct {
+	Enabled                bool `json:"enabled"`
+	Required               bool `json:"required"`
+	RecoveryCodesRemaining int  `json:"recovery_codes_remaining"`
+}
+
+// TOTPSetup is a pending enrolment: URI is the otpauth:// URI to show as a QR
+// code, Secret the same key for manual entry. It must be confirmed with a
+// code before ExpiresAt.
+type TOTPSetup struct {
+	Secret    string `json:"secret"`
+	URI       string `json:"uri"`
+	ExpiresAt int64  `json:"expires_at"`
+}
+
+type TOTPCodeRequest struct {
+	Code string `json:"code" validate:"required,max=20"`
+}
+
+type DisableTOTPRequest struct {
+	Password string `json:"password" validate:"required"`
+	Code     string `json:"code" validate:"required,max=20"`
+}
+
+// RecoveryCodes are shown once, when 2FA is enabled or the codes are
+// regenerated; only their hashes are kept.
+type RecoveryCodes struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
@@ -0,0 +1,83 @@
+package models
+
+type NoteCategory string
+
+const (
+	NoteNursing  NoteCategory = "nursing"
+	NoteMedical  NoteCategory = "medical"
+	NoteHandover NoteCategory = "handover"
+)
+
+// ClinicalNote is a progress note written against a patient. Notes are never
+// edited in place; each amendment keeps the text it replaced.
+type ClinicalNote struct {
+	ID         string          `json:"id"`
+	OrgID      string          `json:"org_id"`
+	PatientID  string          `json:"patient_id"`
+	EpisodeID  string          `json:"episode_id,omitempty"`
+	Category   NoteCategory    `json:"category"`
+	Body       string          `json:"body"`
+	AuthorID   string          `json:"author_id"`
+	AuthorName string          `json:"author_name"`
+	AuthorRole Role            `json:"author_role"`
+	Amendments []NoteAmendment `json:"amendments,omitempty"`
+	CreatedAt  int64           `json:"created_at"`
+	UpdatedAt  int64           `json:"updated_at"`
+}
+
+// NoteAmendment records the body a note had before it was amended.
+type NoteAmendment struct {
+	PreviousBody string `json:"previous_body"`
+	Reason       string `json:"reason"`
+	AmendedBy    string `json:"amended_by"`
+	AmendedAt    int64  `json:"amended_at"`
+}
+
+type CreateNoteRequest struct {
+	Category NoteCategory `json:"category" validate:"required,oneof=nursing medical handover"`
+	Body     string       `json:"body" validate:"required,max=10000"`
+}
+
+type AmendNoteRequest struct {
+	Body   string `json:"body" validate:"required,max=10000"`
+	Reason string `json:"reason" validate:"required,max=500"`
+}
+
+// StatusChange records a patient's clinical status moving from one value to another.
+type StatusChange struct {
+	ID        string        `json:"id"`
+	From      PatientStatus `json:"from"`
+	To        PatientStatus `json:"to"`
+	ChangedBy string        `json:"changed_by"`
+	ChangedAt int64         `json:"changed_at"`
+}
+
+type TimelineEntryType string
+
+const (
+	TimelineNote         TimelineEntryType = "note"
+	TimelineVitals       TimelineEntryType = "vitals"
+	TimelineAlert        TimelineEntryType = "alert"
+	TimelineAcknowledged TimelineEntryType = "acknowledgement"
+	TimelineResolved     TimelineEntryType = "alert_resolved"
+	TimelineAssigned     TimelineEntryType = "alert_assigned"
+	TimelineComment      TimelineEntryType = "alert_comment"
+	TimelineAdmission    TimelineEntryType = "admission"
+	TimelineTransfer     TimelineEntryType = "transfer"
+	TimelineDischarge    TimelineEntryType = "discharge"
+	TimelineStatus       TimelineEntryType = "status_change"
+)
+
+// TimelineEntry is one event in a patient's timeline. Data holds the
+// underlying record (note, vitals, alert, episode movement or status change).
+type TimelineEntry struct {
+	ID   string            `json:"id"`
+	Type TimelineEntryType `json:"type"`
+	At   int64             `json:"at"`
+	Data interface{}       `json:"data"`
+}
+
+type TimelinePage struct {
+	Entries    []TimelineEntry `json:"entries"`
+	NextCursor string          `json:"next_cursor,omitempty"`
+}
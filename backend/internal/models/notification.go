@@ -0,0 +1,92 @@
+package models
+
+import "encoding/json"
+
+type ChannelType string
+
+const (
+	ChannelWebhook ChannelType = "webhook"
+	ChannelEmail   ChannelType = "email"
+	ChannelPager   ChannelType = "pager"
+)
+
+// NotificationChannel is an org's outbound destination for alerts. URL and
+// Secret are used by webhooks, URL and AuthToken by the pager gateway, and
+// Recipients by email (addresses) and the pager gateway (numbers).
+// Severities and WardIDs route alerts to the channel; empty means all.
+// Secret and AuthToken are stored but never returned; HasSecret reports
+// whether either is set.
+type NotificationChannel struct {
+	ID         string          `json:"id"`
+	OrgID      string          `json:"org_id"`
+	Name       string          `json:"name"`
+	Type       ChannelType     `json:"type"`
+	Enabled    bool            `json:"enabled"`
+	URL        string          `json:"url,omitempty"`
+	Secret     string          `json:"-"`
+	AuthToken  string          `json:"-"`
+	HasSecret  bool            `json:"has_secret"`
+	Recipients []string        `json:"recipients,omitempty"`
+	Severities []AlertSeverity `json:"severities,omitempty"`
+	WardIDs    []string        `json:"ward_ids,omitempty"`
+	CreatedAt  int64           `json:"created_at"`
+	UpdatedAt  int64           `json:"updated_at"`
+}
+
+// ChannelRequest sets a channel's settings. An empty Secret or AuthToken
+// keeps the channel's current one when its type is unchanged.
+type ChannelRequest struct {
+	Name       string          `json:"name" validate:"required,max=100"`
+	Type       ChannelType     `json:"type" validate:"required,oneof=webhook email pager"`
+	Enabled    *bool           `json:"enabled"`
+	URL        string          `json:"url" validate:"required_unless=Type email,omitempty,url"`
+	Secret     string          `json:"secret" validate:"omitempty,min=16,max=128"`
+	AuthToken  string          `json:"auth_token" validate:"max=512"`
+	Recipients []string        `json:"recipients" validate:"required_unless=Type webhook,max=20"`
+	Severities []AlertSeverity `json:"severities" validate:"omitempty,dive,oneof=warning critical"`
+	WardIDs    []string        `json:"ward_ids"`
+}
+
+type DeliveryStatus string
+
+const (
+	DeliveryPending   DeliveryStatus = "pending"
+	DeliveryDelivered DeliveryStatus = "delivered"
+	DeliveryRetrying  DeliveryStatus = "retrying"
+	DeliveryDead      DeliveryStatus = "dead"
+)
+
+// Delivery is one queued outbound message. Kind selects the sender
+// ("notification" for alert channels, "webhook" for event endpoints);
+// TargetID is the channel or endpoint.
+// Subject and Body are the human-readable form used by email and pagers.
+type Delivery struct {
+	ID            string          `json:"id"`
+	OrgID         string          `json:"org_id"`
+	Kind          string          `json:"kind"`
+	TargetID      string          `json:"target_id"`
+	Event         string          `json:"event"`
+	Subject       string          `json:"subject,omitempty"`
+	Body          string          `json:"body,omitempty"`
+	Payload       json.RawMessage `json:"payload"`
+	Status        DeliveryStatus  `json:"status"`
+	Attempts      int             `json:"attempts"`
+	LastError     string          `json:"last_error,omitempty"`
+	NextAttemptAt int64           `json:"next_attempt_at,omitempty"`
+	CreatedAt     int64           `json:"created_at"`
+	DeliveredAt   int64           `json:"delivered_at,omitempty"`
+}
+
+// DeliveryQuery filters the delivery log. Zero values match everything.
+type DeliveryQuery struct {
+	Kind     string
+	TargetID string
+	Status   DeliveryStatus
+	Cursor   string
+	Limit    int
+}
+
+type DeliveryPage struct {
+	Deliveries []Delivery `json:"deliveries"`
+	NextCursor string     `json:"next_cursor,omitempty"`
+}
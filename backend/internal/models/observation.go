@@ -0,0 +1,38 @@
+package models
+
+type ObservationSource string
+
+const (
+	ObservationFromStatus ObservationSource = "status"
+	ObservationFromNEWS2  ObservationSource = "news2"
+	ObservationManual     ObservationSource = "manual"
+)
+
+// DefaultObservationMinutes is the observation interval for each patient
+// status when no interval has been set for the patient.
+var DefaultObservationMinutes = map[PatientStatus]int{
+	StatusCritical: 60,
+	StatusActive:   240,
+	StatusStable:   240,
+}
+
+// ObservationDue describes when a patient's next set of vitals is due.
+type ObservationDue struct {
+	PatientID       string            `json:"patient_id"`
+	PatientName     string            `json:"patient_name"`
+	Ward            string            `json:"ward"`
+	BedNumber       string            `json:"bed_number"`
+	IntervalMinutes int               `json:"interval_minutes"`
+	Source          ObservationSource `json:"source"`
+	NEWS2           int               `json:"news2_score"`
+	LastObservedAt  int64             `json:"last_observed_at,omitempty"`
+	DueAt           int64             `json:"due_at"`
+	Overdue         bool              `json:"overdue"`
+	OverdueMinutes  int               `json:"overdue_minutes,omitempty"`
+}
+
+// SetObservationScheduleRequest overrides a patient's observation interval.
+// Zero clears the override so the interval follows status and NEWS2 again.
+type SetObservationScheduleRequest struct {
+	IntervalMinutes int `json:"interval_minutes" validate:"omitempty,min=15,max=1440"`
+}
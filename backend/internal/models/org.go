@@ -19,14 +19,49 @@ var PlanConfig = map[Plan]PlanLimits{
 	PlanEnterprise: {MaxPatients: -1, MaxMembers: -1}, // unlimited
 }
 
+// AlertAudience controls who receives real-time alert broadcasts.
+type AlertAudience string
+
+const (
+	AlertAudienceOrg           AlertAudience = "org"            // everyone in the org
+	AlertAudienceAssignedFirst AlertAudience = "assigned_first" // care team first, then everyone else
+	AlertAudienceAssignedOnly  AlertAudience = "assigned_only"  // care team only, org-wide if nobody is assigned
+)
+
+// DefaultShiftStarts is the shift pattern for orgs that haven't configured
+// one: three 8-hour shifts.
+var DefaultShiftStarts = []string{"07:00", "15:00", "23:00"}
+
+// DefaultInviteExpiryHours is how long invite links last for orgs that
+// haven't set Org.InviteExpiryHours.
+const DefaultInviteExpiryHours = 72
+
+// Org.Timezone is an IANA zone name used for daily stats and shift
+// boundaries; empty means the server's local time. Org.ShiftStarts are the
+// local "HH:MM" times each shift begins, each shift running until the next.
+// Org.IdleTimeoutMinutes ends sessions that go that long without refreshing;
+// zero uses the server's SESSION_IDLE_TIMEOUT. Org.Require2FA makes members
+// set up TOTP before they can use anything but the auth routes.
 type Org struct {
-	ID        string `json:"id"`
-	Name      string `json:"name" validate:"required,min=2,max=100"`
-	Plan      Plan   `json:"plan"`
-	CreatedAt int64  `json:"created_at"`
-	UpdatedAt int64  `json:"updated_at"`
+	ID                 string        `json:"id"`
+	Name               string        `json:"name" validate:"required,min=2,max=100"`
+	Plan               Plan          `json:"plan"`
+	AlertAudience      AlertAudience `json:"alert_audience,omitempty"`
+	Timezone           string        `json:"timezone,omitempty"`
+	ShiftStarts        []string      `json:"shift_starts,omitempty"`
+	InviteExpiryHours  int           `json:"invite_expiry_hours,omitempty"`
+	IdleTimeoutMinutes int           `json:"idle_timeout_minutes,omitempty"`
+	Require2FA         bool          `json:"require_2fa,omitempty"`
+	CreatedAt          int64         `json:"created_at"`
+	UpdatedAt          int64         `json:"updated_at"`
 }
 
 type OrgUpdateRequest struct {
-	Name string `json:"name" validate:"required,min=2,max=100"`
+	Name               string        `json:"name" validate:"required,min=2,max=100"`
+	AlertAudience      AlertAudience `json:"alert_audience" validate:"omitempty,oneof=org assigned_first assigned_only"`
+	Timezone           string        `json:"timezone" validate:"omitempty,timezone"`
+	ShiftStarts        []string      `json:"shift_starts" validate:"omitempty,max=12,dive,datetime=15:04"`
+	InviteExpiryHours  int           `json:"invite_expiry_hours" validate:"omitempty,min=1,max=720"`
+	IdleTimeoutMinutes int           `json:"idle_timeout_minutes" validate:"omitempty,min=15,max=10080"`
+	Require2FA         *bool         `json:"require_2fa"`
 }
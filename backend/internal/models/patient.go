@@ -9,29 +9,53 @@ const (
 	StatusStable     PatientStatus = "stable"
 )
 
+type IdentifierType string
+
+const (
+	IdentifierMRN        IdentifierType = "mrn"
+	IdentifierNationalID IdentifierType = "national_id"
+	IdentifierInsurance  IdentifierType = "insurance"
+)
+
+// PatientIdentifier is an external identifier such as a hospital MRN. Value is
+// unique per org within its System (e.g. "urn:oid:2.16.840.1.113883.2.4.6.3").
+type PatientIdentifier struct {
+	Type   IdentifierType `json:"type" validate:"required,oneof=mrn national_id insurance"`
+	System string         `json:"system" validate:"required,uri,max=255"`
+	Value  string         `json:"value" validate:"required,max=64"`
+}
+
 type Patient struct {
-	ID          string        `json:"id"`
-	OrgID       string        `json:"org_id"`
-	Name        string        `json:"name" validate:"required,min=2,max=100"`
-	Age         int           `json:"age" validate:"required,min=0,max=150"`
-	Gender      string        `json:"gender" validate:"required,oneof=male female other"`
-	BedNumber   string        `json:"bed_number"`
-	Ward        string        `json:"ward"`
-	Diagnosis   string        `json:"diagnosis"`
-	Status      PatientStatus `json:"status"`
-	AdmittedAt  int64         `json:"admitted_at"`
-	DischargedAt int64        `json:"discharged_at,omitempty"`
-	CreatedAt   int64         `json:"created_at"`
-	UpdatedAt   int64         `json:"updated_at"`
+	ID                  string              `json:"id"`
+	OrgID               string              `json:"org_id"`
+	Name                string              `json:"name" validate:"required,min=2,max=100"`
+	Age                 int                 `json:"age" validate:"required,min=0,max=150"`
+	Gender              string              `json:"gender" validate:"required,oneof=male female other"`
+	BedNumber           string              `json:"bed_number"`
+	Ward                string              `json:"ward"`
+	WardID              string              `json:"ward_id,omitempty"`
+	BedID               string              `json:"bed_id,omitempty"`
+	Diagnosis           string              `json:"diagnosis"`
+	Identifiers         []PatientIdentifier `json:"identifiers,omitempty"`
+	Status              PatientStatus       `json:"status"`
+	ActiveEpisodeID     string              `json:"active_episode_id,omitempty"`
+	ObservationInterval int                 `json:"observation_interval_minutes,omitempty"`
+	AdmittedAt          int64               `json:"admitted_at"`
+	DischargedAt        int64               `json:"discharged_at,omitempty"`
+	CreatedAt           int64               `json:"created_at"`
+	UpdatedAt           int64               `json:"updated_at"`
 }
 
 type CreatePatientRequest struct {
-	Name      string `json:"name" validate:"required,min=2,max=100"`
-	Age       int    `json:"age" validate:"required,min=0,max=150"`
-	Gender    string `json:"gender" validate:"required,oneof=male female other"`
-	BedNumber string `json:"bed_number"`
-	Ward      string `json:"ward"`
-	Diagnosis string `json:"diagnosis"`
+	Name        string              `json:"name" validate:"required,min=2,max=100"`
+	Age         int                 `json:"age" validate:"required,min=0,max=150"`
+	Gender      string              `json:"gender" validate:"required,oneof=male female other"`
+	BedNumber   string              `json:"bed_number"`
+	Ward        string              `json:"ward"`
+	BedID       string              `json:"bed_id"`
+	Diagnosis   string              `json:"diagnosis"`
+	Identifiers []PatientIdentifier `json:"identifiers" validate:"omitempty,dive"`
+	AdmitReason string              `json:"admit_reason" validate:"max=500"`
 }
 
 type UpdatePatientRequest struct {
@@ -40,5 +64,31 @@ type UpdatePatientRequest struct {
 	BedNumber string `json:"bed_number"`
 	Ward      string `json:"ward"`
 	Diagnosis string `json:"diagnosis"`
-	Status    string `json:"status" validate:"omitempty,oneof=active discharged critical stable"`
+	Status    string `json:"status" validate:"omitempty,oneof=active critical stable"`
+	// Identifiers replaces the patient's identifiers when present.
+	Identifiers []PatientIdentifier `json:"identifiers" validate:"omitempty,dive"`
+}
+
+type MergeStatus string
+
+const (
+	MergeInProgress MergeStatus = "in_progress"
+	MergeCompleted  MergeStatus = "completed"
+)
+
+// PatientMerge tracks merging a duplicate (source) registration into a target
+// patient. CompletedSteps lets an interrupted merge resume where it stopped.
+type PatientMerge struct {
+	OrgID          string      `json:"org_id"`
+	SourceID       string      `json:"source_id"`
+	TargetID       string      `json:"target_id"`
+	Status         MergeStatus `json:"status"`
+	CompletedSteps []string    `json:"completed_steps"`
+	MergedBy       string      `json:"merged_by"`
+	StartedAt      int64       `json:"started_at"`
+	CompletedAt    int64       `json:"completed_at,omitempty"`
+}
+
+type MergePatientRequest struct {
+	SourceID string `json:"source_id" validate:"required"`
 }
@@ -0,0 +1,71 @@
+package models
+
+// SSOConfig is an org's OpenID Connect provider. RoleClaim names the ID
+// token claim (a string or list, e.g. "groups") looked up in RoleMapping to
+// pick a new user's role, falling back to DefaultRole; with neither matching
+// the sign-in is refused. Only emails in AllowedDomains may sign in, and
+// AutoProvision creates Praana accounts for those who have none.
+// ClientSecret is stored but never returned. DomainVerification is the DNS
+// TXT record a domain must publish before it can be added.
+type SSOConfig struct {
+	OrgID              string          `json:"org_id"`
+	Enabled            bool            `json:"enabled"`
+	Issuer             string          `json:"issuer"`
+	ClientID           string          `json:"client_id"`
+	ClientSecret       string          `json:"-"`
+	HasClientSecret    bool            `json:"has_client_secret"`
+	Scopes             []string        `json:"scopes"`
+	RoleClaim          string          `json:"role_claim,omitempty"`
+	RoleMapping        map[string]Role `json:"role_mapping,omitempty"`
+	DefaultRole        Role            `json:"default_role,omitempty"`
+	AllowedDomains     []string        `json:"allowed_domains"`
+	AutoProvision      bool            `json:"auto_provision"`
+	UpdatedAt          int64           `json:"updated_at"`
+	DomainVerification string          `json:"domain_verification,omitempty"`
+}
+
+// SSOConfigRequest sets the org's SSO provider. An empty ClientSecret keeps
+// the stored one.
+type SSOConfigRequest struct {
+	Enabled        bool            `json:"enabled"`
+	Issuer         string          `json:"issuer" validate:"required,url"`
+	ClientID       string          `json:"client_id" validate:"required,max=256"`
+	ClientSecret   string          `json:"client_secret" validate:"max=512"`
+	Scopes         []string        `json:"scopes" validate:"max=10,dive,min=1,max=64"`
+	RoleClaim      string          `json:"role_claim" validate:"max=64"`
+	RoleMapping    map[string]Role `json:"role_mapping" validate:"max=50,dive,oneof=admin doctor nurse"`
+	DefaultRole    Role            `json:"default_role" validate:"omitempty,oneof=admin doctor nurse"`
+	AllowedDomains []string        `json:"allowed_domains" validate:"required,min=1,max=20,dive,fqdn"`
+	AutoProvision  bool            `json:"auto_provision"`
+}
+
+// SSOState is what the server remembers between sending a user to the
+// provider and the callback. Verifier is the PKCE code verifier;
+// BrowserKeyHash ties the sign-in to the browser that started it.
+type SSOState struct {
+	OrgID          string `json:"org_id"`
+	Nonce          string `json:"nonce"`
+	Verifier       string `json:"verifier"`
+	BrowserKeyHash string `json:"browser_key_hash"`
+}
+
+// SSOStartRequest begins an SSO sign-in; the email's domain picks the org.
+type SSOStartRequest struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+// SSOStartResponse is where to send the browser. The browser keeps
+// BrowserKey (in session storage) and sends it back with the callback, so a
+// callback link started by someone else is refused.
+type SSOStartResponse struct {
+	RedirectURL string `json:"redirect_url"`
+	BrowserKey  string `json:"browser_key"`
+}
+
+// SSOCallbackRequest carries the code and state the provider sent back to
+// APP_URL/auth/sso/callback, and the browser key from SSOStartResponse.
+type SSOCallbackRequest struct {
+	Code       string `json:"code" validate:"required,max=2048"`
+	State      string `json:"state" validate:"required,max=128"`
+	BrowserKey string `json:"browser_key" validate:"required,max=128"`
+}
@@ -1,26 +1,31 @@
 package models
 
 type DashboardOverview struct {
-	TotalPatients    int              `json:"total_patients"`
-	CriticalCount    int              `json:"critical_count"`
-	StableCount      int              `json:"stable_count"`
-	ActiveAlerts     int              `json:"active_alerts"`
-	Patients         []PatientSummary `json:"patients"`
+	TotalPatients       int              `json:"total_patients"`
+	CriticalCount       int              `json:"critical_count"`
+	StableCount         int              `json:"stable_count"`
+	ActiveAlerts        int              `json:"active_alerts"`
+	ObservationsOverdue int              `json:"observations_overdue"`
+	Patients            []PatientSummary `json:"patients"`
 }
 
 type PatientSummary struct {
-	Patient      Patient  `json:"patient"`
-	LatestVitals *Vitals  `json:"latest_vitals,omitempty"`
-	AlertCount   int      `json:"alert_count"`
+	Patient      Patient         `json:"patient"`
+	LatestVitals *Vitals         `json:"latest_vitals,omitempty"`
+	AlertCount   int             `json:"alert_count"`
+	Observation  *ObservationDue `json:"observation,omitempty"`
 }
 
 type ShiftSummary struct {
-	ShiftStart      int64 `json:"shift_start"`
-	ShiftEnd        int64 `json:"shift_end"`
-	VitalsRecorded  int   `json:"vitals_recorded"`
-	AlertsTriggered int   `json:"alerts_triggered"`
-	AlertsAcked     int   `json:"alerts_acknowledged"`
-	PatientsChecked int   `json:"patients_checked"`
+	ShiftStart       int64    `json:"shift_start"`
+	ShiftEnd         int64    `json:"shift_end"`
+	VitalsRecorded   int      `json:"vitals_recorded"`
+	AlertsTriggered  int      `json:"alerts_triggered"`
+	AlertsAcked      int      `json:"alerts_acknowledged"`
+	PatientsChecked  int      `json:"patients_checked"`
+	PatientsOverdue  int      `json:"patients_overdue"`
+	OverduePatients  []string `json:"overdue_patient_ids,omitempty"`
+	MedianAckSeconds int64    `json:"median_ack_seconds"`
 }
 
 type OrgStats struct {
@@ -8,19 +8,29 @@ const (
 	RoleNurse  Role = "nurse"
 )
 
+// User.TOTPSecret, TOTPLastStep and RecoveryCodes (hashed) are stored with
+// the user but, like Password, never serialized in API responses.
+// LockedUntil is only set when listing members, for accounts whose login is
+// locked after repeated failures.
 type User struct {
-	ID        string `json:"id"`
-	Email     string `json:"email" validate:"required,email"`
-	Password  string `json:"-"`
-	Name      string `json:"name" validate:"required,min=2,max=100"`
-	Role      Role   `json:"role"`
-	OrgID     string `json:"org_id"`
-	CreatedAt int64  `json:"created_at"`
+	ID                string   `json:"id"`
+	Email             string   `json:"email" validate:"required,email"`
+	Password          string   `json:"-"`
+	Name              string   `json:"name" validate:"required,min=2,max=100"`
+	Role              Role     `json:"role"`
+	OrgID             string   `json:"org_id"`
+	CreatedAt         int64    `json:"created_at"`
+	PasswordChangedAt int64    `json:"password_changed_at,omitempty"`
+	TOTPEnabled       bool     `json:"totp_enabled"`
+	TOTPSecret        string   `json:"-"`
+	TOTPLastStep      int64    `json:"-"`
+	RecoveryCodes     []string `json:"-"`
+	LockedUntil       int64    `json:"locked_until,omitempty"`
 }
 
 type SignupRequest struct {
 	Email    string `json:"email" validate:"required,email"`
-	Password string `json:"password" validate:"required,min=8"`
+	Password string `json:"password" validate:"required,min=8,max=72"`
 	Name     string `json:"name" validate:"required,min=2,max=100"`
 	OrgName  string `json:"org_name" validate:"required,min=2,max=100"`
 }
@@ -30,9 +40,45 @@ type LoginRequest struct {
 	Password string `json:"password" validate:"required"`
 }
 
+// LoginResponse.Token is a short-lived access token that expires at
+// ExpiresAt; RefreshToken gets a new pair from /api/auth/refresh. When
+// MFARequired is set there are no tokens yet: MFAToken must be sent with a
+// code to /api/auth/login/2fa. MFASetupRequired means the org requires 2FA
+// and the token only reaches /api/auth routes until it is set up.
 type LoginResponse struct {
-	Token string `json:"token"`
-	User  User   `json:"user"`
+	Token            string `json:"token"`
+	RefreshToken     string `json:"refresh_token,omitempty"`
+	ExpiresAt        int64  `json:"expires_at,omitempty"`
+	User             *User  `json:"user,omitempty"`
+	MFARequired      bool   `json:"mfa_required,omitempty"`
+	MFAToken         string `json:"mfa_token,omitempty"`
+	MFASetupRequired bool   `json:"mfa_setup_required,omitempty"`
+}
+
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token" validate:"required"`
+}
+
+// Session is one sign-in, shared by the access tokens issued from it and
+// renewed through its refresh token. UserAgent, IP and LastSeenAt are from
+// the latest sign-in or refresh. ExpiresAt is the absolute limit; idle
+// sessions end sooner. Current marks the caller's own session in listings.
+type Session struct {
+	ID         string `json:"id"`
+	UserID     string `json:"user_id"`
+	OrgID      string `json:"org_id"`
+	UserAgent  string `json:"user_agent,omitempty"`
+	IP         string `json:"ip,omitempty"`
+	CreatedAt  int64  `json:"created_at"`
+	LastSeenAt int64  `json:"last_seen_at"`
+	ExpiresAt  int64  `json:"expires_at"`
+	Current    bool   `json:"current,omitempty"`
+}
+
+// ClientInfo identifies the device making a sign-in or refresh request.
+type ClientInfo struct {
+	UserAgent string
+	IP        string
 }
 
 type InviteRequest struct {
@@ -40,17 +86,40 @@ type InviteRequest struct {
 	Role  Role   `json:"role" validate:"required,oneof=doctor nurse"`
 }
 
+// AcceptInviteRequest.Code is the signed code from the invite link.
 type AcceptInviteRequest struct {
 	Code     string `json:"code" validate:"required"`
 	Email    string `json:"email" validate:"required,email"`
-	Password string `json:"password" validate:"required,min=8"`
+	Password string `json:"password" validate:"required,min=8,max=72"`
 	Name     string `json:"name" validate:"required,min=2,max=100"`
 }
 
+// Invite is a pending invitation. Code is only ever emailed, inside a signed
+// link, so it is never serialized in API responses.
 type Invite struct {
-	Code      string `json:"code"`
-	Email     string `json:"email"`
-	Role      Role   `json:"role"`
-	OrgID     string `json:"org_id"`
-	CreatedAt int64  `json:"created_at"`
+	ID         string `json:"id"`
+	Code       string `json:"-"`
+	Email      string `json:"email"`
+	Role       Role   `json:"role"`
+	OrgID      string `json:"org_id"`
+	InvitedBy  string `json:"invited_by,omitempty"`
+	SendCount  int    `json:"send_count"`
+	LastSentAt int64  `json:"last_sent_at"`
+	CreatedAt  int64  `json:"created_at"`
+	ExpiresAt  int64  `json:"expires_at"`
+}
+
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password" validate:"required"`
+	NewPassword     string `json:"new_password" validate:"required,min=8,max=72"`
+}
+
+type ForgotPasswordRequest struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+// ResetPasswordRequest.Code is the signed code from the reset link.
+type ResetPasswordRequest struct {
+	Code     string `json:"code" validate:"required"`
+	Password string `json:"password" validate:"required,min=8,max=72"`
 }
@@ -0,0 +1,46 @@
+package models
+
+type Ward struct {
+	ID        string `json:"id"`
+	OrgID     string `json:"org_id"`
+	Name      string `json:"name"`
+	Capacity  int    `json:"capacity"`
+	CreatedAt int64  `json:"created_at"`
+	UpdatedAt int64  `json:"updated_at"`
+}
+
+// Bed belongs to a ward. PatientID and OccupiedAt are filled from the bed's
+// occupancy record when the bed is read.
+type Bed struct {
+	ID         string `json:"id"`
+	OrgID      string `json:"org_id"`
+	WardID     string `json:"ward_id"`
+	Label      string `json:"label"`
+	PatientID  string `json:"patient_id,omitempty"`
+	OccupiedAt int64  `json:"occupied_at,omitempty"`
+	CreatedAt  int64  `json:"created_at"`
+}
+
+type CreateWardRequest struct {
+	Name     string `json:"name" validate:"required,min=1,max=100"`
+	Capacity int    `json:"capacity" validate:"required,min=1,max=1000"`
+}
+
+type UpdateWardRequest struct {
+	Name     string `json:"name" validate:"omitempty,min=1,max=100"`
+	Capacity int    `json:"capacity" validate:"omitempty,min=1,max=1000"`
+}
+
+type CreateBedRequest struct {
+	Label string `json:"label" validate:"required,min=1,max=20"`
+}
+
+type WardOccupancy struct {
+	WardID    string `json:"ward_id"`
+	WardName  string `json:"ward_name"`
+	Capacity  int    `json:"capacity"`
+	TotalBeds int    `json:"total_beds"`
+	Occupied  int    `json:"occupied"`
+	Available int    `json:"available"`
+	Beds      []Bed  `json:"beds,omitempty"`
+}
@@ -0,0 +1,49 @@
+package models
+
+// Domain event types delivered to webhook endpoints.
+const (
+	EventPatientAdmitted    = "patient.admitted"
+	EventPatientTransferred = "patient.transferred"
+	EventPatientDischarged  = "patient.discharged"
+	EventVitalsRecorded     = "vitals.recorded"
+	EventAlertRaised        = "alert.raised"
+	EventAlertAcknowledged  = "alert.acknowledged"
+	EventAlertResolved      = "alert.resolved"
+)
+
+var WebhookEvents = []string{
+	EventPatientAdmitted, EventPatientTransferred, EventPatientDischarged,
+	EventVitalsRecorded,
+	EventAlertRaised, EventAlertAcknowledged, EventAlertResolved,
+}
+
+// WebhookEndpoint receives the org's domain events it subscribes to. "*"
+// subscribes to every event.
+type WebhookEndpoint struct {
+	ID          string   `json:"id"`
+	OrgID       string   `json:"org_id"`
+	URL         string   `json:"url"`
+	Description string   `json:"description,omitempty"`
+	Events      []string `json:"events"`
+	Secret      string   `json:"secret"`
+	Enabled     bool     `json:"enabled"`
+	CreatedAt   int64    `json:"created_at"`
+	UpdatedAt   int64    `json:"updated_at"`
+}
+
+type WebhookEndpointRequest struct {
+	URL         string   `json:"url" validate:"required,url"`
+	Description string   `json:"description" validate:"max=200"`
+	Events      []string `json:"events" validate:"required,min=1,dive,oneof=* patient.admitted patient.transferred patient.discharged vitals.recorded alert.raised alert.acknowledged alert.resolved"`
+	Secret      string   `json:"secret" validate:"omitempty,min=16,max=128"`
+	Enabled     *bool    `json:"enabled"`
+}
+
+// WebhookEvent is the JSON body POSTed to endpoints.
+type WebhookEvent struct {
+	ID         string      `json:"id"`
+	Type       string      `json:"type"`
+	OrgID      string      `json:"org_id"`
+	OccurredAt int64       `json:"occurred_at"`
+	Data       interface{} `json:"data"`
+}
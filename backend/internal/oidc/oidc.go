@@ -0,0 +1,284 @@
+package oidc
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"praana/internal/utils"
+)
+
+// Client talks to OpenID Connect providers. HTTP defaults to a client with a
+// 10 second timeout that only connects to public addresses; a local stub
+// provider works when utils.AllowPrivateNetworks is set.
+type Client struct {
+	HTTP *http.Client
+}
+
+var defaultHTTP = utils.NewPublicHTTPClient(10 * time.Second)
+
+var (
+	ErrEmailNotVerified = errors.New("your identity provider did not share a verified email address")
+	ErrDomainNotAllowed = errors.New("email domain can't sign in to this organization")
+)
+
+// Provider is an issuer's discovery document.
+type Provider struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+
+	client *Client
+}
+
+// AuthRequest is one authorization code request. Verifier is the PKCE code
+// verifier; only its S256 challenge is sent to the provider.
+type AuthRequest struct {
+	ClientID    string
+	RedirectURI string
+	Scopes      []string
+	State       string
+	Nonce       string
+	Verifier    string
+}
+
+func (c *Client) http() *http.Client {
+	if c.HTTP != nil {
+		return c.HTTP
+	}
+	return defaultHTTP
+}
+
+// Discover fetches the issuer's discovery document and checks that it is
+// really for that issuer. The issuer and the endpoints the server calls must
+// be on public addresses.
+func (c *Client) Discover(ctx context.Context, issuer string) (*Provider, error) {
+	issuer = strings.TrimSuffix(issuer, "/")
+	if err := utils.CheckPublicURL(ctx, issuer); err != nil {
+		return nil, fmt.Errorf("discovery: %w", err)
+	}
+	var p Provider
+	if err := c.getJSON(ctx, issuer+"/.well-known/openid-configuration", &p); err != nil {
+		return nil, fmt.Errorf("discovery: %w", err)
+	}
+	if strings.TrimSuffix(p.Issuer, "/") != issuer {
+		return nil, fmt.Errorf("discovery: issuer is %q, expected %q", p.Issuer, issuer)
+	}
+	if p.AuthorizationEndpoint == "" || p.TokenEndpoint == "" || p.JWKSURI == "" {
+		return nil, fmt.Errorf("discovery: document is missing endpoints")
+	}
+	for _, endpoint := range []string{p.TokenEndpoint, p.JWKSURI} {
+		if err := utils.CheckPublicURL(ctx, endpoint); err != nil {
+			return nil, fmt.Errorf("discovery: %w", err)
+		}
+	}
+	p.client = c
+	return &p, nil
+}
+
+// AuthURL is where to send the user's browser to sign in.
+func (p *Provider) AuthURL(req AuthRequest) string {
+	v := url.Values{}
+	v.Set("response_type", "code")
+	v.Set("client_id", req.ClientID)
+	v.Set("redirect_uri", req.RedirectURI)
+	v.Set("scope", strings.Join(req.Scopes, " "))
+	v.Set("state", req.State)
+	v.Set("nonce", req.Nonce)
+	sum := sha256.Sum256([]byte(req.Verifier))
+	v.Set("code_challenge", base64.RawURLEncoding.EncodeToString(sum[:]))
+	v.Set("code_challenge_method", "S256")
+	sep := "?"
+	if strings.Contains(p.AuthorizationEndpoint, "?") {
+		sep = "&"
+	}
+	return p.AuthorizationEndpoint + sep + v.Encode()
+}
+
+// Exchange redeems an authorization code and returns the raw ID token.
+func (p *Provider) Exchange(ctx context.Context, clientID, clientSecret, code, redirectURI, verifier string) (string, error) {
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", redirectURI)
+	form.Set("code_verifier", verifier)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	req.SetBasicAuth(url.QueryEscape(clientID), url.QueryEscape(clientSecret))
+
+	resp, err := p.client.http().Do(req)
+	if err != nil {
+		return "", fmt.Errorf("token exchange: %w", err)
+	}
+	defer resp.Body.Close()
+	var body struct {
+		IDToken          string `json:"id_token"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&body); err != nil {
+		return "", fmt.Errorf("token exchange: %s", resp.Status)
+	}
+	if body.Error != "" {
+		return "", fmt.Errorf("token exchange: %s %s", body.Error, body.ErrorDescription)
+	}
+	if resp.StatusCode != http.StatusOK || body.IDToken == "" {
+		return "", fmt.Errorf("token exchange: %s, no id_token", resp.Status)
+	}
+	return body.IDToken, nil
+}
+
+// Verify checks an ID token's signature against the provider's keys, its
+// issuer, audience, expiry and nonce, and returns its claims.
+func (p *Provider) Verify(ctx context.Context, rawIDToken, clientID, nonce string) (jwt.MapClaims, error) {
+	keys, err := p.keys(ctx)
+	if err != nil {
+		return nil, err
+	}
+	claims := jwt.MapClaims{}
+	_, err = jwt.ParseWithClaims(rawIDToken, claims, func(t *jwt.Token) (interface{}, error) {
+		kid, _ := t.Header["kid"].(string)
+		if key, ok := keys[kid]; ok {
+			return key, nil
+		}
+		if kid == "" && len(keys) == 1 {
+			for _, key := range keys {
+				return key, nil
+			}
+		}
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	},
+		jwt.WithValidMethods([]string{"RS256", "RS384", "RS512", "ES256", "ES384", "ES512"}),
+		jwt.WithIssuer(p.Issuer),
+		jwt.WithAudience(clientID),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(time.Minute),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("id token: %w", err)
+	}
+	if got, _ := claims["nonce"].(string); got != nonce {
+		return nil, fmt.Errorf("id token: nonce mismatch")
+	}
+	// With several audiences, the token must have been issued to us.
+	if aud, _ := claims.GetAudience(); len(aud) > 1 {
+		if azp, _ := claims["azp"].(string); azp != clientID {
+			return nil, fmt.Errorf("id token: azp is %q, expected %q", azp, clientID)
+		}
+	}
+	return claims, nil
+}
+
+// AllowedEmail returns the ID token's email, lowercased, if its domain is one
+// of domains. The provider must mark it verified with email_verified.
+func AllowedEmail(claims jwt.MapClaims, domains []string) (string, error) {
+	raw, _ := claims["email"].(string)
+	verified := false
+	switch v := claims["email_verified"].(type) {
+	case bool:
+		verified = v
+	case string:
+		verified = v == "true"
+	}
+	if raw == "" || !verified {
+		return "", ErrEmailNotVerified
+	}
+	email := strings.ToLower(strings.TrimSpace(raw))
+	_, domain, _ := strings.Cut(email, "@")
+	if !slices.Contains(domains, domain) {
+		return "", fmt.Errorf("%w: %s", ErrDomainNotAllowed, domain)
+	}
+	return email, nil
+}
+
+// keys fetches the provider's signing keys, by key ID.
+func (p *Provider) keys(ctx context.Context) (map[string]interface{}, error) {
+	var set struct {
+		Keys []struct {
+			Kid string `json:"kid"`
+			Kty string `json:"kty"`
+			Use string `json:"use"`
+			N   string `json:"n"`
+			E   string `json:"e"`
+			Crv string `json:"crv"`
+			X   string `json:"x"`
+			Y   string `json:"y"`
+		} `json:"keys"`
+	}
+	if err := p.client.getJSON(ctx, p.JWKSURI, &set); err != nil {
+		return nil, fmt.Errorf("jwks: %w", err)
+	}
+	keys := make(map[string]interface{})
+	for _, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		switch k.Kty {
+		case "RSA":
+			n, errN := base64.RawURLEncoding.DecodeString(k.N)
+			e, errE := base64.RawURLEncoding.DecodeString(k.E)
+			if errN != nil || errE != nil {
+				continue
+			}
+			keys[k.Kid] = &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+		case "EC":
+			var curve elliptic.Curve
+			switch k.Crv {
+			case "P-256":
+				curve = elliptic.P256()
+			case "P-384":
+				curve = elliptic.P384()
+			case "P-521":
+				curve = elliptic.P521()
+			default:
+				continue
+			}
+			x, errX := base64.RawURLEncoding.DecodeString(k.X)
+			y, errY := base64.RawURLEncoding.DecodeString(k.Y)
+			if errX != nil || errY != nil {
+				continue
+			}
+			keys[k.Kid] = &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+		}
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("jwks: no usable signing keys")
+	}
+	return keys, nil
+}
+
+func (c *Client) getJSON(ctx context.Context, endpoint string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := c.http().Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(v)
+}
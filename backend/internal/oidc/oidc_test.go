@@ -0,0 +1,244 @@
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"praana/internal/utils"
+)
+
+const (
+	testClientID = "praana"
+	testNonce    = "nonce-1"
+	testCode     = "code-1"
+	testVerifier = "verifier-1"
+)
+
+// testIdP is a minimal OpenID provider: discovery, JWKS and a token endpoint
+// that hands out idToken for testCode.
+type testIdP struct {
+	server  *httptest.Server
+	key     *rsa.PrivateKey
+	idToken string
+}
+
+func newTestIdP(t *testing.T) *testIdP {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	idp := &testIdP{key: key}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string]string{
+			"issuer":                 idp.server.URL,
+			"authorization_endpoint": idp.server.URL + "/authorize",
+			"token_endpoint":         idp.server.URL + "/token",
+			"jwks_uri":               idp.server.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string]interface{}{"keys": []map[string]string{{
+			"kid": "k1",
+			"kty": "RSA",
+			"use": "sig",
+			"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}}})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		if r.FormValue("code") != testCode || r.FormValue("code_verifier") != testVerifier {
+			w.WriteHeader(http.StatusBadRequest)
+			writeJSON(w, map[string]string{"error": "invalid_grant"})
+			return
+		}
+		if user, _, _ := r.BasicAuth(); user != testClientID {
+			w.WriteHeader(http.StatusUnauthorized)
+			writeJSON(w, map[string]string{"error": "invalid_client"})
+			return
+		}
+		writeJSON(w, map[string]string{"id_token": idp.idToken, "token_type": "Bearer"})
+	})
+	idp.server = httptest.NewServer(mux)
+	t.Cleanup(idp.server.Close)
+	return idp
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// claims returns valid ID token claims for the test client.
+func (idp *testIdP) claims() jwt.MapClaims {
+	return jwt.MapClaims{
+		"iss":            idp.server.URL,
+		"sub":            "user-1",
+		"aud":            testClientID,
+		"exp":            time.Now().Add(time.Hour).Unix(),
+		"iat":            time.Now().Unix(),
+		"nonce":          testNonce,
+		"email":          "Nurse@Hospital.org",
+		"email_verified": true,
+	}
+}
+
+func sign(t *testing.T, key *rsa.PrivateKey, claims jwt.MapClaims) string {
+	t.Helper()
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = "k1"
+	raw, err := token.SignedString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return raw
+}
+
+// allowLoopback lets the client reach the httptest server for one test.
+func allowLoopback(t *testing.T) {
+	t.Helper()
+	utils.AllowPrivateNetworks = true
+	t.Cleanup(func() { utils.AllowPrivateNetworks = false })
+}
+
+func discover(t *testing.T, idp *testIdP) *Provider {
+	t.Helper()
+	p, err := (&Client{}).Discover(context.Background(), idp.server.URL)
+	if err != nil {
+		t.Fatalf("Discover: %v", err)
+	}
+	return p
+}
+
+func TestDiscoverRejectsPrivateAddresses(t *testing.T) {
+	idp := newTestIdP(t)
+	_, err := (&Client{}).Discover(context.Background(), idp.server.URL)
+	if !errors.Is(err, utils.ErrPrivateAddress) {
+		t.Fatalf("Discover(loopback) error = %v, want ErrPrivateAddress", err)
+	}
+}
+
+func TestExchangeAndVerify(t *testing.T) {
+	allowLoopback(t)
+	idp := newTestIdP(t)
+	p := discover(t, idp)
+	idp.idToken = sign(t, idp.key, idp.claims())
+
+	raw, err := p.Exchange(context.Background(), testClientID, "secret", testCode, "https://app/cb", testVerifier)
+	if err != nil {
+		t.Fatalf("Exchange: %v", err)
+	}
+	claims, err := p.Verify(context.Background(), raw, testClientID, testNonce)
+	if err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+	if claims["sub"] != "user-1" {
+		t.Fatalf("sub = %v, want user-1", claims["sub"])
+	}
+
+	if _, err := p.Exchange(context.Background(), testClientID, "secret", testCode, "https://app/cb", "wrong"); err == nil {
+		t.Fatal("Exchange with the wrong PKCE verifier succeeded")
+	}
+}
+
+func TestVerifyRejects(t *testing.T) {
+	allowLoopback(t)
+	idp := newTestIdP(t)
+	p := discover(t, idp)
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		key    *rsa.PrivateKey
+		change func(jwt.MapClaims)
+	}{
+		{name: "bad signature", key: otherKey},
+		{name: "wrong issuer", change: func(c jwt.MapClaims) { c["iss"] = "https://evil.example" }},
+		{name: "wrong audience", change: func(c jwt.MapClaims) { c["aud"] = "someone-else" }},
+		{name: "expired", change: func(c jwt.MapClaims) { c["exp"] = time.Now().Add(-time.Hour).Unix() }},
+		{name: "missing expiry", change: func(c jwt.MapClaims) { delete(c, "exp") }},
+		{name: "wrong nonce", change: func(c jwt.MapClaims) { c["nonce"] = "replayed" }},
+		{name: "missing nonce", change: func(c jwt.MapClaims) { delete(c, "nonce") }},
+		{name: "other azp", change: func(c jwt.MapClaims) {
+			c["aud"] = []string{testClientID, "someone-else"}
+			c["azp"] = "someone-else"
+		}},
+		{name: "missing azp", change: func(c jwt.MapClaims) { c["aud"] = []string{testClientID, "someone-else"} }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims := idp.claims()
+			if tt.change != nil {
+				tt.change(claims)
+			}
+			key := idp.key
+			if tt.key != nil {
+				key = tt.key
+			}
+			if _, err := p.Verify(context.Background(), sign(t, key, claims), testClientID, testNonce); err == nil {
+				t.Fatal("Verify accepted the token")
+			}
+		})
+	}
+
+	claims := idp.claims()
+	claims["aud"] = []string{testClientID, "someone-else"}
+	claims["azp"] = testClientID
+	if _, err := p.Verify(context.Background(), sign(t, idp.key, claims), testClientID, testNonce); err != nil {
+		t.Fatalf("Verify with several audiences and our azp: %v", err)
+	}
+}
+
+func TestAllowedEmail(t *testing.T) {
+	domains := []string{"hospital.org"}
+	base := func() jwt.MapClaims {
+		return jwt.MapClaims{"email": "Nurse@Hospital.org", "email_verified": true}
+	}
+
+	email, err := AllowedEmail(base(), domains)
+	if err != nil || email != "nurse@hospital.org" {
+		t.Fatalf("AllowedEmail = %q, %v; want nurse@hospital.org", email, err)
+	}
+
+	claims := base()
+	claims["email_verified"] = "true"
+	if _, err := AllowedEmail(claims, domains); err != nil {
+		t.Fatalf("AllowedEmail with email_verified as a string: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		change func(jwt.MapClaims)
+		want   error
+	}{
+		{name: "disallowed domain", change: func(c jwt.MapClaims) { c["email"] = "nurse@elsewhere.org" }, want: ErrDomainNotAllowed},
+		{name: "subdomain", change: func(c jwt.MapClaims) { c["email"] = "nurse@evil.hospital.org" }, want: ErrDomainNotAllowed},
+		{name: "unverified", change: func(c jwt.MapClaims) { c["email_verified"] = false }, want: ErrEmailNotVerified},
+		{name: "unverified as string", change: func(c jwt.MapClaims) { c["email_verified"] = "false" }, want: ErrEmailNotVerified},
+		{name: "no email_verified", change: func(c jwt.MapClaims) { delete(c, "email_verified") }, want: ErrEmailNotVerified},
+		{name: "no email", change: func(c jwt.MapClaims) { delete(c, "email") }, want: ErrEmailNotVerified},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims := base()
+			tt.change(claims)
+			if _, err := AllowedEmail(claims, domains); !errors.Is(err, tt.want) {
+				t.Fatalf("AllowedEmail error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,40 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/redis/go-redis/v9"
+	"praana/internal/models"
+)
+
+// ============ AUDIT ============
+
+func (r *RedisRepo) AppendAudit(ctx context.Context, entry *models.AuditEntry) error {
+	data, _ := json.Marshal(entry)
+	return r.client.XAdd(ctx, &redis.XAddArgs{
+		Stream: fmt.Sprintf("audit:%s", entry.OrgID),
+		Values: map[string]interface{}{"data": string(data)},
+	}).Err()
+}
+
+// GetAuditLog returns the most recent audit entries, newest first.
+func (r *RedisRepo) GetAuditLog(ctx context.Context, orgID string, limit int64) ([]models.AuditEntry, error) {
+	msgs, err := r.client.XRevRangeN(ctx, fmt.Sprintf("audit:%s", orgID), "+", "-", limit).Result()
+	if err != nil {
+		return nil, err
+	}
+	var entries []models.AuditEntry
+	for _, msg := range msgs {
+		dataStr, ok := msg.Values["data"].(string)
+		if !ok {
+			continue
+		}
+		var e models.AuditEntry
+		if err := json.Unmarshal([]byte(dataStr), &e); err == nil {
+			entries = append(entries, e)
+		}
+	}
+	return entries, nil
+}
@@ -0,0 +1,97 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/redis/go-redis/v9"
+	"praana/internal/models"
+)
+
+// ============ CARE TEAM ============
+
+// Assignments are indexed in sorted sets scored by shift end, so current and
+// upcoming assignments are a ZRANGEBYSCORE from now.
+func assignmentIndexKeys(a *models.CareAssignment) []string {
+	keys := []string{
+		fmt.Sprintf("assignments:%s", a.OrgID),
+		fmt.Sprintf("user_assignments:%s:%s", a.OrgID, a.UserID),
+	}
+	if a.PatientID != "" {
+		keys = append(keys, fmt.Sprintf("patient_assignments:%s:%s", a.OrgID, a.PatientID))
+	}
+	if a.WardID != "" {
+		keys = append(keys, fmt.Sprintf("ward_assignments:%s:%s", a.OrgID, a.WardID))
+	}
+	return keys
+}
+
+func (r *RedisRepo) CreateAssignment(ctx context.Context, a *models.CareAssignment) error {
+	data, _ := json.Marshal(a)
+	pipe := r.client.Pipeline()
+	pipe.Set(ctx, fmt.Sprintf("assignment:%s:%s", a.OrgID, a.ID), data, 0)
+	for _, key := range assignmentIndexKeys(a) {
+		pipe.ZAdd(ctx, key, redis.Z{Score: float64(a.ShiftEnd), Member: a.ID})
+	}
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+func (r *RedisRepo) GetAssignment(ctx context.Context, orgID, id string) (*models.CareAssignment, error) {
+	data, err := r.client.Get(ctx, fmt.Sprintf("assignment:%s:%s", orgID, id)).Bytes()
+	if err == redis.Nil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var a models.CareAssignment
+	return &a, json.Unmarshal(data, &a)
+}
+
+func (r *RedisRepo) DeleteAssignment(ctx context.Context, a *models.CareAssignment) error {
+	pipe := r.client.Pipeline()
+	pipe.Del(ctx, fmt.Sprintf("assignment:%s:%s", a.OrgID, a.ID))
+	for _, key := range assignmentIndexKeys(a) {
+		pipe.ZRem(ctx, key, a.ID)
+	}
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+func (r *RedisRepo) getAssignmentsEndingAfter(ctx context.Context, orgID, indexKey string, after int64) ([]models.CareAssignment, error) {
+	ids, err := r.client.ZRangeByScore(ctx, indexKey, &redis.ZRangeBy{
+		Min: "(" + strconv.FormatInt(after, 10),
+		Max: "+inf",
+	}).Result()
+	if err != nil {
+		return nil, err
+	}
+	var out []models.CareAssignment
+	for _, id := range ids {
+		a, err := r.GetAssignment(ctx, orgID, id)
+		if err != nil || a == nil {
+			continue
+		}
+		out = append(out, *a)
+	}
+	return out, nil
+}
+
+func (r *RedisRepo) GetOrgAssignments(ctx context.Context, orgID string, endingAfter int64) ([]models.CareAssignment, error) {
+	return r.getAssignmentsEndingAfter(ctx, orgID, fmt.Sprintf("assignments:%s", orgID), endingAfter)
+}
+
+func (r *RedisRepo) GetUserAssignments(ctx context.Context, orgID, userID string, endingAfter int64) ([]models.CareAssignment, error) {
+	return r.getAssignmentsEndingAfter(ctx, orgID, fmt.Sprintf("user_assignments:%s:%s", orgID, userID), endingAfter)
+}
+
+func (r *RedisRepo) GetPatientAssignments(ctx context.Context, orgID, patientID string, endingAfter int64) ([]models.CareAssignment, error) {
+	return r.getAssignmentsEndingAfter(ctx, orgID, fmt.Sprintf("patient_assignments:%s:%s", orgID, patientID), endingAfter)
+}
+
+func (r *RedisRepo) GetWardAssignments(ctx context.Context, orgID, wardID string, endingAfter int64) ([]models.CareAssignment, error) {
+	return r.getAssignmentsEndingAfter(ctx, orgID, fmt.Sprintf("ward_assignments:%s:%s", orgID, wardID), endingAfter)
+}
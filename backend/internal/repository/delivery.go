@@ -0,0 +1,402 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"praana/internal/models"
+	"praana/internal/utils"
+)
+
+// ============ NOTIFICATION CHANNELS ============
+
+// channelRecord is the stored form of a notification channel;
+// models.NotificationChannel keeps its credentials out of JSON.
+type channelRecord struct {
+	models.NotificationChannel
+	StoredSecret    string `json:"secret,omitempty"`
+	StoredAuthToken string `json:"auth_token,omitempty"`
+}
+
+func (r *RedisRepo) SaveChannel(ctx context.Context, ch *models.NotificationChannel) error {
+	data, _ := json.Marshal(channelRecord{NotificationChannel: *ch, StoredSecret: ch.Secret, StoredAuthToken: ch.AuthToken})
+	pipe := r.client.Pipeline()
+	pipe.Set(ctx, fmt.Sprintf("channel:%s:%s", ch.OrgID, ch.ID), data, 0)
+	pipe.SAdd(ctx, fmt.Sprintf("channels:%s", ch.OrgID), ch.ID)
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+func (r *RedisRepo) GetChannel(ctx context.Context, orgID, channelID string) (*models.NotificationChannel, error) {
+	data, err := r.client.Get(ctx, fmt.Sprintf("channel:%s:%s", orgID, channelID)).Bytes()
+	if err == redis.Nil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var rec channelRecord
+	if err := json.Unmarshal(data, &rec); err != nil {
+		return nil, err
+	}
+	ch := rec.NotificationChannel
+	ch.Secret = rec.StoredSecret
+	ch.AuthToken = rec.StoredAuthToken
+	ch.HasSecret = ch.Secret != "" || ch.AuthToken != ""
+	return &ch, nil
+}
+
+func (r *RedisRepo) GetChannels(ctx context.Context, orgID string) ([]models.NotificationChannel, error) {
+	ids, err := r.client.SMembers(ctx, fmt.Sprintf("channels:%s", orgID)).Result()
+	if err != nil {
+		return nil, err
+	}
+	var channels []models.NotificationChannel
+	for _, id := range ids {
+		ch, err := r.GetChannel(ctx, orgID, id)
+		if err != nil || ch == nil {
+			continue
+		}
+		channels = append(channels, *ch)
+	}
+	return channels, nil
+}
+
+func (r *RedisRepo) DeleteChannel(ctx context.Context, orgID, channelID string) error {
+	pipe := r.client.Pipeline()
+	pipe.Del(ctx, fmt.Sprintf("channel:%s:%s", orgID, channelID))
+	pipe.Del(ctx, digestKeys(orgID, channelID)...)
+	pipe.SRem(ctx, fmt.Sprintf("channels:%s", orgID), channelID)
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// ============ ALERT DIGESTS ============
+
+// An email channel's alerts are collected in digest:<org>:<channel> until its
+// digest is sent. digest_pending:<org>:<channel> is set while a digest
+// delivery is queued, so only the first alert of a batch queues one.
+
+func digestKeys(orgID, channelID string) []string {
+	return []string{
+		fmt.Sprintf("digest:%s:%s", orgID, channelID),
+		fmt.Sprintf("digest_pending:%s:%s", orgID, channelID),
+	}
+}
+
+// digestPendingTTL frees a channel whose digest delivery was dead-lettered,
+// so a later alert queues a new one that includes the backlog.
+const digestPendingTTL = time.Hour
+
+// addToDigestScript appends ARGV[1] and returns 1 if no digest delivery was
+// pending, meaning the caller must queue one.
+var addToDigestScript = redis.NewScript(`
+redis.call('RPUSH', KEYS[1], ARGV[1])
+if redis.call('SET', KEYS[2], '1', 'NX', 'EX', ARGV[2]) then
+	return 1
+end
+return 0
+`)
+
+// AddToDigest adds an entry to the channel's next digest. It reports whether
+// the caller must queue the digest delivery.
+func (r *RedisRepo) AddToDigest(ctx context.Context, orgID, channelID, entry string) (bool, error) {
+	n, err := addToDigestScript.Run(ctx, r.client, digestKeys(orgID, channelID), entry, int(digestPendingTTL.Seconds())).Int()
+	return n == 1, err
+}
+
+// GetDigest returns the entries collected for the channel's next digest.
+func (r *RedisRepo) GetDigest(ctx context.Context, orgID, channelID string) ([]string, error) {
+	return r.client.LRange(ctx, digestKeys(orgID, channelID)[0], 0, -1).Result()
+}
+
+// trimDigestScript drops the first ARGV[1] entries once they have been sent.
+// It returns 1 if entries added since remain, and otherwise clears the
+// pending flag so the next alert queues a new digest.
+var trimDigestScript = redis.NewScript(`
+redis.call('LTRIM', KEYS[1], ARGV[1], -1)
+if redis.call('LLEN', KEYS[1]) > 0 then
+	return 1
+end
+redis.call('DEL', KEYS[2])
+return 0
+`)
+
+// TrimDigest removes the first sent entries from the channel's digest. It
+// reports whether more are waiting, in which case the caller must queue
+// another digest delivery.
+func (r *RedisRepo) TrimDigest(ctx context.Context, orgID, channelID string, sent int) (bool, error) {
+	n, err := trimDigestScript.Run(ctx, r.client, digestKeys(orgID, channelID), sent).Int()
+	return n == 1, err
+}
+
+// ============ DELIVERY QUEUE ============
+
+// deliveryRetention bounds how long a delivery and its log entry are kept.
+const deliveryRetention = 14 * 24 * time.Hour
+
+const deliveryQueueKey = "delivery_queue"
+
+func deliveryKey(orgID, id string) string {
+	return fmt.Sprintf("delivery:%s:%s", orgID, id)
+}
+
+// EnqueueDelivery stores a delivery, logs it and schedules it at NextAttemptAt.
+func (r *RedisRepo) EnqueueDelivery(ctx context.Context, d *models.Delivery) error {
+	data, _ := json.Marshal(d)
+	logged := redis.Z{Score: float64(d.CreatedAt), Member: d.ID}
+	cutoff := strconv.FormatInt(time.Now().Add(-deliveryRetention).Unix(), 10)
+	_, err := r.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Set(ctx, deliveryKey(d.OrgID, d.ID), data, deliveryRetention)
+		for _, key := range deliveryLogKeys(d) {
+			pipe.ZAdd(ctx, key, logged)
+			pipe.ZRemRangeByScore(ctx, key, "-inf", "("+cutoff)
+		}
+		pipe.ZAdd(ctx, deliveryQueueKey, redis.Z{Score: float64(d.NextAttemptAt), Member: d.OrgID + ":" + d.ID})
+		return nil
+	})
+	return err
+}
+
+func deliveryLogKeys(d *models.Delivery) []string {
+	return []string{
+		fmt.Sprintf("deliveries:%s", d.OrgID),
+		fmt.Sprintf("target_deliveries:%s:%s", d.OrgID, d.TargetID),
+	}
+}
+
+// UpdateDelivery saves a delivery after an attempt. It reschedules the
+// delivery when it is still due for a retry and otherwise takes it off the
+// queue, in the same MULTI, so a claimed delivery leaves the queue only once
+// its outcome is saved.
+func (r *RedisRepo) UpdateDelivery(ctx context.Context, d *models.Delivery) error {
+	data, _ := json.Marshal(d)
+	_, err := r.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Set(ctx, deliveryKey(d.OrgID, d.ID), data, deliveryRetention)
+		queueDelivery(ctx, pipe, d)
+		return nil
+	})
+	return err
+}
+
+func queueDelivery(ctx context.Context, pipe redis.Pipeliner, d *models.Delivery) {
+	member := d.OrgID + ":" + d.ID
+	if d.Status == models.DeliveryPending || d.Status == models.DeliveryRetrying {
+		pipe.ZAdd(ctx, deliveryQueueKey, redis.Z{Score: float64(d.NextAttemptAt), Member: member})
+	} else {
+		pipe.ZRem(ctx, deliveryQueueKey, member)
+	}
+}
+
+// ChangeDelivery applies change to the current delivery and saves it, queued
+// or dequeued for its new status, under WATCH so it can't interleave with
+// another change or a worker saving an attempt. It returns nil if the
+// delivery doesn't exist.
+func (r *RedisRepo) ChangeDelivery(ctx context.Context, orgID, id string, change func(*models.Delivery) error) (*models.Delivery, error) {
+	key := deliveryKey(orgID, id)
+	var delivery *models.Delivery
+	txf := func(tx *redis.Tx) error {
+		data, err := tx.Get(ctx, key).Bytes()
+		if err == redis.Nil {
+			delivery = nil
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		var d models.Delivery
+		if err := json.Unmarshal(data, &d); err != nil {
+			return err
+		}
+		if err := change(&d); err != nil {
+			return err
+		}
+		updated, _ := json.Marshal(&d)
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.Set(ctx, key, updated, deliveryRetention)
+			queueDelivery(ctx, pipe, &d)
+			return nil
+		})
+		delivery = &d
+		return err
+	}
+	for i := 0; i < 3; i++ {
+		err := r.client.Watch(ctx, txf, key)
+		if err != redis.TxFailedErr {
+			return delivery, err
+		}
+	}
+	return nil, fmt.Errorf("delivery was %w", utils.ErrConcurrentUpdate)
+}
+
+func (r *RedisRepo) GetDelivery(ctx context.Context, orgID, id string) (*models.Delivery, error) {
+	data, err := r.client.Get(ctx, deliveryKey(orgID, id)).Bytes()
+	if err == redis.Nil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var d models.Delivery
+	return &d, json.Unmarshal(data, &d)
+}
+
+// claimDeliveriesScript leases up to ARGV[2] queue entries due by ARGV[1] by
+// re-scoring them to ARGV[3], so each is handed to one worker at a time and
+// comes due again if that worker never saves an outcome.
+var claimDeliveriesScript = redis.NewScript(`
+local due = redis.call('ZRANGEBYSCORE', KEYS[1], '-inf', ARGV[1], 'LIMIT', 0, ARGV[2])
+for _, member in ipairs(due) do
+	redis.call('ZADD', KEYS[1], ARGV[3], member)
+end
+return due
+`)
+
+// ClaimDueDeliveries leases up to count due deliveries until leaseUntil and
+// returns them. A worker that claims a delivery must save it with
+// UpdateDelivery, which requeues it for a retry or takes it off the queue;
+// if it doesn't, the delivery is claimed again once the lease runs out.
+func (r *RedisRepo) ClaimDueDeliveries(ctx context.Context, now, leaseUntil int64, count int) ([]models.Delivery, error) {
+	members, err := claimDeliveriesScript.Run(ctx, r.client, []string{deliveryQueueKey}, now, count, leaseUntil).StringSlice()
+	if err != nil {
+		return nil, err
+	}
+	var deliveries []models.Delivery
+	for _, m := range members {
+		orgID, id, ok := strings.Cut(m, ":")
+		if !ok {
+			r.client.ZRem(ctx, deliveryQueueKey, m)
+			continue
+		}
+		d, err := r.GetDelivery(ctx, orgID, id)
+		if err != nil {
+			continue
+		}
+		if d == nil {
+			// The delivery outlived its retention; don't keep leasing it.
+			r.client.ZRem(ctx, deliveryQueueKey, m)
+			continue
+		}
+		deliveries = append(deliveries, *d)
+	}
+	return deliveries, nil
+}
+
+// QueryDeliveries returns the delivery log, newest first, with cursor
+// "<created_at>:<id>".
+func (r *RedisRepo) QueryDeliveries(ctx context.Context, orgID string, q *models.DeliveryQuery) (*models.DeliveryPage, error) {
+	index := fmt.Sprintf("deliveries:%s", orgID)
+	if q.TargetID != "" {
+		index = fmt.Sprintf("target_deliveries:%s:%s", orgID, q.TargetID)
+	}
+
+	max := "+inf"
+	var cursorAt int64
+	var cursorID string
+	if q.Cursor != "" {
+		parts := strings.SplitN(q.Cursor, ":", 2)
+		at, err := strconv.ParseInt(parts[0], 10, 64)
+		if len(parts) != 2 || err != nil {
+			return nil, fmt.Errorf("invalid cursor")
+		}
+		cursorAt, cursorID = at, parts[1]
+		max = strconv.FormatInt(cursorAt, 10)
+	}
+
+	matches := func(d *models.Delivery) bool {
+		if q.Cursor != "" && (d.CreatedAt > cursorAt || (d.CreatedAt == cursorAt && d.ID >= cursorID)) {
+			return false
+		}
+		if q.Kind != "" && d.Kind != q.Kind {
+			return false
+		}
+		return q.Status == "" || d.Status == q.Status
+	}
+
+	batch := int64(q.Limit) * 2
+	if batch < 50 {
+		batch = 50
+	}
+	page := &models.DeliveryPage{Deliveries: []models.Delivery{}}
+	var offset int64
+	for len(page.Deliveries) <= q.Limit {
+		ids, err := r.client.ZRevRangeByScore(ctx, index, &redis.ZRangeBy{
+			Min: "-inf", Max: max, Offset: offset, Count: batch,
+		}).Result()
+		if err != nil {
+			return nil, err
+		}
+		for _, id := range ids {
+			d, err := r.GetDelivery(ctx, orgID, id)
+			if err != nil || d == nil {
+				continue
+			}
+			if matches(d) {
+				page.Deliveries = append(page.Deliveries, *d)
+			}
+		}
+		if int64(len(ids)) < batch {
+			break
+		}
+		offset += batch
+	}
+
+	if len(page.Deliveries) > q.Limit {
+		page.Deliveries = page.Deliveries[:q.Limit]
+		last := page.Deliveries[q.Limit-1]
+		page.NextCursor = fmt.Sprintf("%d:%s", last.CreatedAt, last.ID)
+	}
+	return page, nil
+}
+
+// ============ WEBHOOK ENDPOINTS ============
+
+func (r *RedisRepo) SaveWebhookEndpoint(ctx context.Context, ep *models.WebhookEndpoint) error {
+	data, _ := json.Marshal(ep)
+	pipe := r.client.Pipeline()
+	pipe.Set(ctx, fmt.Sprintf("webhook:%s:%s", ep.OrgID, ep.ID), data, 0)
+	pipe.SAdd(ctx, fmt.Sprintf("webhooks:%s", ep.OrgID), ep.ID)
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+func (r *RedisRepo) GetWebhookEndpoint(ctx context.Context, orgID, id string) (*models.WebhookEndpoint, error) {
+	data, err := r.client.Get(ctx, fmt.Sprintf("webhook:%s:%s", orgID, id)).Bytes()
+	if err == redis.Nil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var ep models.WebhookEndpoint
+	return &ep, json.Unmarshal(data, &ep)
+}
+
+func (r *RedisRepo) GetWebhookEndpoints(ctx context.Context, orgID string) ([]models.WebhookEndpoint, error) {
+	ids, err := r.client.SMembers(ctx, fmt.Sprintf("webhooks:%s", orgID)).Result()
+	if err != nil {
+		return nil, err
+	}
+	var endpoints []models.WebhookEndpoint
+	for _, id := range ids {
+		ep, err := r.GetWebhookEndpoint(ctx, orgID, id)
+		if err != nil || ep == nil {
+			continue
+		}
+		endpoints = append(endpoints, *ep)
+	}
+	return endpoints, nil
+}
+
+func (r *RedisRepo) DeleteWebhookEndpoint(ctx context.Context, orgID, id string) error {
+	pipe := r.client.Pipeline()
+	pipe.Del(ctx, fmt.Sprintf("webhook:%s:%s", orgID, id))
+	pipe.SRem(ctx, fmt.Sprintf("webhooks:%s", orgID), id)
+	_, err := pipe.Exec(ctx)
+	return err
+}
@@ -0,0 +1,68 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/redis/go-redis/v9"
+	"praana/internal/models"
+)
+
+// ============ EPISODES ============
+
+func (r *RedisRepo) CreateEpisode(ctx context.Context, ep *models.Episode) error {
+	data, _ := json.Marshal(ep)
+	pipe := r.client.Pipeline()
+	pipe.Set(ctx, fmt.Sprintf("episode:%s:%s", ep.OrgID, ep.ID), data, 0)
+	pipe.ZAdd(ctx, fmt.Sprintf("patient_episodes:%s:%s", ep.OrgID, ep.PatientID), redis.Z{
+		Score:  float64(ep.AdmittedAt),
+		Member: ep.ID,
+	})
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+func (r *RedisRepo) GetEpisode(ctx context.Context, orgID, episodeID string) (*models.Episode, error) {
+	data, err := r.client.Get(ctx, fmt.Sprintf("episode:%s:%s", orgID, episodeID)).Bytes()
+	if err == redis.Nil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var ep models.Episode
+	return &ep, json.Unmarshal(data, &ep)
+}
+
+func (r *RedisRepo) UpdateEpisode(ctx context.Context, ep *models.Episode) error {
+	data, _ := json.Marshal(ep)
+	return r.client.Set(ctx, fmt.Sprintf("episode:%s:%s", ep.OrgID, ep.ID), data, 0).Err()
+}
+
+// DeleteEpisode removes an episode that was opened but never took effect.
+func (r *RedisRepo) DeleteEpisode(ctx context.Context, ep *models.Episode) error {
+	_, err := r.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Del(ctx, fmt.Sprintf("episode:%s:%s", ep.OrgID, ep.ID))
+		pipe.ZRem(ctx, fmt.Sprintf("patient_episodes:%s:%s", ep.OrgID, ep.PatientID), ep.ID)
+		return nil
+	})
+	return err
+}
+
+// GetPatientEpisodes returns a patient's episodes, most recent admission first.
+func (r *RedisRepo) GetPatientEpisodes(ctx context.Context, orgID, patientID string) ([]models.Episode, error) {
+	ids, err := r.client.ZRevRange(ctx, fmt.Sprintf("patient_episodes:%s:%s", orgID, patientID), 0, -1).Result()
+	if err != nil {
+		return nil, err
+	}
+	var episodes []models.Episode
+	for _, id := range ids {
+		ep, err := r.GetEpisode(ctx, orgID, id)
+		if err != nil || ep == nil {
+			continue
+		}
+		episodes = append(episodes, *ep)
+	}
+	return episodes, nil
+}
@@ -0,0 +1,178 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"praana/internal/models"
+	"praana/internal/utils"
+)
+
+// ============ HANDOVERS ============
+
+func (r *RedisRepo) CreateHandover(ctx context.Context, h *models.HandoverReport) error {
+	data, _ := json.Marshal(h)
+	shift := strconv.FormatInt(h.ShiftStart, 10)
+	pipe := r.client.TxPipeline()
+	pipe.Set(ctx, fmt.Sprintf("handover:%s:%s", h.OrgID, h.ID), data, 0)
+	pipe.ZAdd(ctx, fmt.Sprintf("shift_handovers:%s:%s", h.OrgID, shift), redis.Z{
+		Score:  float64(h.GeneratedAt),
+		Member: h.ID,
+	})
+	pipe.ZAdd(ctx, fmt.Sprintf("handover_shifts:%s", h.OrgID), redis.Z{
+		Score:  float64(h.ShiftStart),
+		Member: shift,
+	})
+	pipe.ZAdd(ctx, fmt.Sprintf("patient_handovers:%s:%s", h.OrgID, h.PatientID), redis.Z{
+		Score:  float64(h.ShiftStart),
+		Member: h.ID,
+	})
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+func (r *RedisRepo) GetHandover(ctx context.Context, orgID, handoverID string) (*models.HandoverReport, error) {
+	data, err := r.client.Get(ctx, fmt.Sprintf("handover:%s:%s", orgID, handoverID)).Bytes()
+	if err == redis.Nil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var h models.HandoverReport
+	return &h, json.Unmarshal(data, &h)
+}
+
+// ChangeHandover applies change to the current handover and saves it. The
+// handover key is watched, so an edit can't overwrite a concurrent sign-off.
+// It returns nil if the handover doesn't exist.
+func (r *RedisRepo) ChangeHandover(ctx context.Context, orgID, handoverID string, change func(*models.HandoverReport) error) (*models.HandoverReport, error) {
+	key := fmt.Sprintf("handover:%s:%s", orgID, handoverID)
+	var handover *models.HandoverReport
+	txf := func(tx *redis.Tx) error {
+		data, err := tx.Get(ctx, key).Bytes()
+		if err == redis.Nil {
+			handover = nil
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		var h models.HandoverReport
+		if err := json.Unmarshal(data, &h); err != nil {
+			return err
+		}
+		if err := change(&h); err != nil {
+			return err
+		}
+		updated, _ := json.Marshal(&h)
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.Set(ctx, key, updated, 0)
+			return nil
+		})
+		handover = &h
+		return err
+	}
+	for i := 0; i < 3; i++ {
+		err := r.client.Watch(ctx, txf, key)
+		if err != redis.TxFailedErr {
+			return handover, err
+		}
+	}
+	return nil, fmt.Errorf("handover was %w", utils.ErrConcurrentUpdate)
+}
+
+// GetShiftHandovers returns the handovers written for the shift starting at shiftStart.
+func (r *RedisRepo) GetShiftHandovers(ctx context.Context, orgID string, shiftStart int64) ([]models.HandoverReport, error) {
+	ids, err := r.client.ZRange(ctx, fmt.Sprintf("shift_handovers:%s:%d", orgID, shiftStart), 0, -1).Result()
+	if err != nil {
+		return nil, err
+	}
+	return r.getHandovers(ctx, orgID, ids), nil
+}
+
+// GetLatestHandoverShift returns the start of the most recent shift with any
+// handovers, or 0 if there are none.
+func (r *RedisRepo) GetLatestHandoverShift(ctx context.Context, orgID string) (int64, error) {
+	shifts, err := r.client.ZRevRange(ctx, fmt.Sprintf("handover_shifts:%s", orgID), 0, 0).Result()
+	if err != nil || len(shifts) == 0 {
+		return 0, err
+	}
+	return strconv.ParseInt(shifts[0], 10, 64)
+}
+
+// GetPatientHandovers returns a patient's handovers, most recent shift first.
+func (r *RedisRepo) GetPatientHandovers(ctx context.Context, orgID, patientID string) ([]models.HandoverReport, error) {
+	ids, err := r.client.ZRevRange(ctx, fmt.Sprintf("patient_handovers:%s:%s", orgID, patientID), 0, -1).Result()
+	if err != nil {
+		return nil, err
+	}
+	return r.getHandovers(ctx, orgID, ids), nil
+}
+
+func (r *RedisRepo) getHandovers(ctx context.Context, orgID string, ids []string) []models.HandoverReport {
+	var reports []models.HandoverReport
+	for _, id := range ids {
+		h, err := r.GetHandover(ctx, orgID, id)
+		if err != nil || h == nil {
+			continue
+		}
+		reports = append(reports, *h)
+	}
+	return reports
+}
+
+// AcquireHandoverLock serialises draft generation for a shift so concurrent
+// requests don't create two drafts for the same patient. owner identifies
+// the holder for ReleaseHandoverLock.
+func (r *RedisRepo) AcquireHandoverLock(ctx context.Context, orgID string, shiftStart int64, owner string, ttl time.Duration) (bool, error) {
+	return r.client.SetNX(ctx, fmt.Sprintf("handover_lock:%s:%d", orgID, shiftStart), owner, ttl).Result()
+}
+
+// releaseLockScript deletes the lock at KEYS[1] only if ARGV[1] still holds it.
+var releaseLockScript = redis.NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	return redis.call('DEL', KEYS[1])
+end
+return 0
+`)
+
+// ReleaseHandoverLock releases the lock if owner holds it, so a generation
+// that outlived its lock can't release one taken over by another request.
+func (r *RedisRepo) ReleaseHandoverLock(ctx context.Context, orgID string, shiftStart int64, owner string) error {
+	return releaseLockScript.Run(ctx, r.client, []string{fmt.Sprintf("handover_lock:%s:%d", orgID, shiftStart)}, owner).Err()
+}
+
+// MoveHandovers reassigns the source patient's handovers to the target.
+func (r *RedisRepo) MoveHandovers(ctx context.Context, orgID, sourceID, targetID, targetName string) error {
+	sourceKey := fmt.Sprintf("patient_handovers:%s:%s", orgID, sourceID)
+	targetKey := fmt.Sprintf("patient_handovers:%s:%s", orgID, targetID)
+	ids, err := r.client.ZRangeWithScores(ctx, sourceKey, 0, -1).Result()
+	if err != nil {
+		return err
+	}
+	for _, z := range ids {
+		handoverID := z.Member.(string)
+		h, err := r.GetHandover(ctx, orgID, handoverID)
+		if err != nil {
+			return err
+		}
+		pipe := r.client.TxPipeline()
+		if h != nil {
+			h.PatientID = targetID
+			h.PatientName = targetName
+			data, _ := json.Marshal(h)
+			pipe.Set(ctx, fmt.Sprintf("handover:%s:%s", orgID, handoverID), data, 0)
+		}
+		pipe.ZAdd(ctx, targetKey, redis.Z{Score: z.Score, Member: handoverID})
+		pipe.ZRem(ctx, sourceKey, handoverID)
+		if _, err := pipe.Exec(ctx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
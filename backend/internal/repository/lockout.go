@@ -0,0 +1,58 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// ============ LOGIN LOCKOUT ============
+
+// Failed logins are counted per scope ("account", keyed by email, or "ip")
+// in login_failures:<scope>:<key>, which expires window after the first
+// failure. login_block:<scope>:<key> exists while further attempts are
+// refused.
+
+// RecordLoginFailure counts a failed login and returns the count so far in
+// the current window.
+func (r *RedisRepo) RecordLoginFailure(ctx context.Context, scope, key string, window time.Duration) (int64, error) {
+	failKey := fmt.Sprintf("login_failures:%s:%s", scope, key)
+	var incr *redis.IntCmd
+	_, err := r.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		incr = pipe.Incr(ctx, failKey)
+		pipe.ExpireNX(ctx, failKey, window)
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return incr.Val(), nil
+}
+
+// BlockLogin refuses logins for scope/key for d.
+func (r *RedisRepo) BlockLogin(ctx context.Context, scope, key string, d time.Duration) error {
+	return r.client.Set(ctx, fmt.Sprintf("login_block:%s:%s", scope, key), 1, d).Err()
+}
+
+// LoginBlocked returns how much longer logins for scope/key are refused,
+// or 0 if they are not.
+func (r *RedisRepo) LoginBlocked(ctx context.Context, scope, key string) (time.Duration, error) {
+	ttl, err := r.client.PTTL(ctx, fmt.Sprintf("login_block:%s:%s", scope, key)).Result()
+	if err != nil {
+		return 0, err
+	}
+	if ttl < 0 {
+		return 0, nil
+	}
+	return ttl, nil
+}
+
+// ClearLoginFailures resets the failure count and lifts any block.
+func (r *RedisRepo) ClearLoginFailures(ctx context.Context, scope, key string) error {
+	return r.client.Del(ctx,
+		fmt.Sprintf("login_failures:%s:%s", scope, key),
+		fmt.Sprintf("login_block:%s:%s", scope, key),
+	).Err()
+}
@@ -0,0 +1,329 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"praana/internal/models"
+	"praana/internal/utils"
+)
+
+// ============ PATIENT MERGE ============
+
+func (r *RedisRepo) GetPatientMerge(ctx context.Context, orgID, sourceID string) (*models.PatientMerge, error) {
+	data, err := r.client.Get(ctx, fmt.Sprintf("patient_merge:%s:%s", orgID, sourceID)).Bytes()
+	if err == redis.Nil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var m models.PatientMerge
+	return &m, json.Unmarshal(data, &m)
+}
+
+func (r *RedisRepo) SavePatientMerge(ctx context.Context, m *models.PatientMerge) error {
+	data, _ := json.Marshal(m)
+	return r.client.Set(ctx, fmt.Sprintf("patient_merge:%s:%s", m.OrgID, m.SourceID), data, 0).Err()
+}
+
+// AcquireMergeLock locks a patient against taking part in another merge,
+// as either source or target.
+func (r *RedisRepo) AcquireMergeLock(ctx context.Context, orgID, patientID string, ttl time.Duration) (bool, error) {
+	return r.client.SetNX(ctx, fmt.Sprintf("patient_merge_lock:%s:%s", orgID, patientID), 1, ttl).Result()
+}
+
+func (r *RedisRepo) ReleaseMergeLock(ctx context.Context, orgID, patientID string) error {
+	return r.client.Del(ctx, fmt.Sprintf("patient_merge_lock:%s:%s", orgID, patientID)).Err()
+}
+
+// GetPatientRedirect returns the patient a merged-away ID now points to.
+func (r *RedisRepo) GetPatientRedirect(ctx context.Context, orgID, patientID string) (string, error) {
+	id, err := r.client.Get(ctx, fmt.Sprintf("patient_redirect:%s:%s", orgID, patientID)).Result()
+	if err == redis.Nil {
+		return "", nil
+	}
+	return id, err
+}
+
+type streamEntry struct {
+	ms, seq int64
+	vitals  models.Vitals
+}
+
+func parseStreamID(id string) (int64, int64) {
+	parts := strings.SplitN(id, "-", 2)
+	ms, _ := strconv.ParseInt(parts[0], 10, 64)
+	var seq int64
+	if len(parts) == 2 {
+		seq, _ = strconv.ParseInt(parts[1], 10, 64)
+	}
+	return ms, seq
+}
+
+// MergeVitalsStreams folds the source patient's vitals stream into the
+// target's, keeping entry timestamps so range queries still work. Entries are
+// deduplicated by vitals ID, which makes the operation safe to repeat. The
+// rebuilt stream replaces the target's only if the target stream was not
+// written to meanwhile.
+func (r *RedisRepo) MergeVitalsStreams(ctx context.Context, orgID, sourceID, targetID string) error {
+	sourceKey := fmt.Sprintf("vitals:%s:%s", orgID, sourceID)
+	targetKey := fmt.Sprintf("vitals:%s:%s", orgID, targetID)
+	tmpKey := targetKey + ":merging"
+	sourceLatest := fmt.Sprintf("latest_vitals:%s:%s", orgID, sourceID)
+	targetLatest := fmt.Sprintf("latest_vitals:%s:%s", orgID, targetID)
+
+	txf := func(tx *redis.Tx) error {
+		var entries []streamEntry
+		seen := make(map[string]bool)
+		for _, key := range []string{targetKey, sourceKey} {
+			msgs, err := tx.XRange(ctx, key, "-", "+").Result()
+			if err != nil {
+				return err
+			}
+			if key == sourceKey && len(msgs) == 0 {
+				return tx.Del(ctx, sourceLatest).Err()
+			}
+			for _, msg := range msgs {
+				dataStr, ok := msg.Values["data"].(string)
+				if !ok {
+					continue
+				}
+				var v models.Vitals
+				if json.Unmarshal([]byte(dataStr), &v) != nil || seen[v.ID] {
+					continue
+				}
+				seen[v.ID] = true
+				v.PatientID = targetID
+				ms, seq := parseStreamID(msg.ID)
+				entries = append(entries, streamEntry{ms: ms, seq: seq, vitals: v})
+			}
+		}
+		sort.SliceStable(entries, func(i, j int) bool {
+			if entries[i].ms != entries[j].ms {
+				return entries[i].ms < entries[j].ms
+			}
+			return entries[i].seq < entries[j].seq
+		})
+
+		// Build the merged stream under a scratch key; stream IDs must be
+		// strictly increasing, so colliding IDs get their sequence bumped.
+		build := r.client.Pipeline()
+		build.Del(ctx, tmpKey)
+		var lastMs, lastSeq int64 = -1, 0
+		for _, e := range entries {
+			ms, seq := e.ms, e.seq
+			if ms < lastMs || (ms == lastMs && seq <= lastSeq) {
+				ms, seq = lastMs, lastSeq+1
+			}
+			data, _ := json.Marshal(e.vitals)
+			build.XAdd(ctx, &redis.XAddArgs{
+				Stream: tmpKey,
+				ID:     fmt.Sprintf("%d-%d", ms, seq),
+				Values: map[string]interface{}{"data": string(data)},
+			})
+			lastMs, lastSeq = ms, seq
+		}
+		if len(entries) == 0 {
+			_, err := tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+				pipe.Del(ctx, sourceKey, sourceLatest)
+				return nil
+			})
+			return err
+		}
+		if _, err := build.Exec(ctx); err != nil {
+			return err
+		}
+		latest, _ := json.Marshal(entries[len(entries)-1].vitals)
+
+		_, err := tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.Rename(ctx, tmpKey, targetKey)
+			pipe.Del(ctx, sourceKey, sourceLatest)
+			pipe.Set(ctx, targetLatest, latest, 0)
+			return nil
+		})
+		return err
+	}
+
+	for i := 0; i < 3; i++ {
+		err := r.client.Watch(ctx, txf, targetKey, sourceKey)
+		if err != redis.TxFailedErr {
+			return err
+		}
+	}
+	return fmt.Errorf("vitals merge conflicted with concurrent writes; retry")
+}
+
+// MoveAlerts re-keys every alert indexed under the source patient to the
+// target. Each alert moves through transitionAlert, so a concurrent
+// acknowledge or resolve is retried against rather than overwritten.
+func (r *RedisRepo) MoveAlerts(ctx context.Context, orgID, sourceID, targetID, targetName string) error {
+	sourceKey := fmt.Sprintf("patient_alerts:%s:%s", orgID, sourceID)
+	targetKey := fmt.Sprintf("patient_alerts:%s:%s", orgID, targetID)
+	ids, err := r.client.ZRangeWithScores(ctx, sourceKey, 0, -1).Result()
+	if err != nil {
+		return err
+	}
+	for _, z := range ids {
+		alertID := z.Member.(string)
+		_, err := r.transitionAlert(ctx, orgID, alertID, func(a *models.Alert) error {
+			a.PatientID = targetID
+			a.PatientName = targetName
+			return nil
+		}, func(pipe redis.Pipeliner, _ *models.Alert) {
+			pipe.ZAdd(ctx, targetKey, redis.Z{Score: z.Score, Member: alertID})
+			pipe.ZRem(ctx, sourceKey, alertID)
+			pipe.ZRem(ctx, patientOpenAlertsKey(orgID, sourceID), alertID)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// MoveEpisodes reassigns the source patient's episodes to the target.
+func (r *RedisRepo) MoveEpisodes(ctx context.Context, orgID, sourceID, targetID string) error {
+	sourceKey := fmt.Sprintf("patient_episodes:%s:%s", orgID, sourceID)
+	targetKey := fmt.Sprintf("patient_episodes:%s:%s", orgID, targetID)
+	ids, err := r.client.ZRangeWithScores(ctx, sourceKey, 0, -1).Result()
+	if err != nil {
+		return err
+	}
+	for _, z := range ids {
+		episodeID := z.Member.(string)
+		ep, err := r.GetEpisode(ctx, orgID, episodeID)
+		if err != nil {
+			return err
+		}
+		pipe := r.client.TxPipeline()
+		if ep != nil {
+			ep.PatientID = targetID
+			data, _ := json.Marshal(ep)
+			pipe.Set(ctx, fmt.Sprintf("episode:%s:%s", orgID, episodeID), data, 0)
+		}
+		pipe.ZAdd(ctx, targetKey, redis.Z{Score: z.Score, Member: episodeID})
+		pipe.ZRem(ctx, sourceKey, episodeID)
+		if _, err := pipe.Exec(ctx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// MoveAssignments hands the source patient's care team assignments to the
+// target.
+func (r *RedisRepo) MoveAssignments(ctx context.Context, orgID, sourceID, targetID string) error {
+	sourceKey := fmt.Sprintf("patient_assignments:%s:%s", orgID, sourceID)
+	targetKey := fmt.Sprintf("patient_assignments:%s:%s", orgID, targetID)
+	ids, err := r.client.ZRangeWithScores(ctx, sourceKey, 0, -1).Result()
+	if err != nil {
+		return err
+	}
+	for _, z := range ids {
+		assignmentID := z.Member.(string)
+		a, err := r.GetAssignment(ctx, orgID, assignmentID)
+		if err != nil {
+			return err
+		}
+		pipe := r.client.TxPipeline()
+		if a != nil {
+			a.PatientID = targetID
+			data, _ := json.Marshal(a)
+			pipe.Set(ctx, fmt.Sprintf("assignment:%s:%s", orgID, assignmentID), data, 0)
+		}
+		pipe.ZAdd(ctx, targetKey, redis.Z{Score: z.Score, Member: assignmentID})
+		pipe.ZRem(ctx, sourceKey, assignmentID)
+		if _, err := pipe.Exec(ctx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// transferIdentifiersScript points identifier entries owned by ARGV[1] (or
+// unowned) at ARGV[2].
+var transferIdentifiersScript = redis.NewScript(`
+for i = 1, #KEYS do
+	local owner = redis.call('HGET', KEYS[i], ARGV[i + 2])
+	if not owner or owner == ARGV[1] then
+		redis.call('HSET', KEYS[i], ARGV[i + 2], ARGV[2])
+	end
+end
+return 0
+`)
+
+func (r *RedisRepo) TransferPatientIdentifiers(ctx context.Context, orgID, fromID, toID string, ids []models.PatientIdentifier) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	keys := make([]string, len(ids))
+	args := []interface{}{fromID, toID}
+	for i, id := range ids {
+		keys[i] = fmt.Sprintf("patient_identifiers:%s:%s", orgID, id.System)
+		args = append(args, id.Value)
+	}
+	return transferIdentifiersScript.Run(ctx, r.client, keys, args...).Err()
+}
+
+// MovePatientThresholds keeps the target's own thresholds if it has any,
+// otherwise adopts the source's. The source's thresholds are removed.
+func (r *RedisRepo) MovePatientThresholds(ctx context.Context, orgID, sourceID, targetID string) error {
+	sourceKey := fmt.Sprintf("thresholds:%s:%s", orgID, sourceID)
+	targetKey := fmt.Sprintf("thresholds:%s:%s", orgID, targetID)
+	exists, err := r.client.Exists(ctx, sourceKey).Result()
+	if err != nil || exists == 0 {
+		return err
+	}
+	if err := r.client.RenameNX(ctx, sourceKey, targetKey).Err(); err != nil {
+		return err
+	}
+	return r.client.Del(ctx, sourceKey).Err()
+}
+
+// TombstonePatient removes the merged-away patient and leaves a redirect to
+// the target in its place. The target's patient_merged_from set lists every
+// ID merged into it, including earlier merges into the source, so a purge
+// can remove their redirects. It reports false, and changes nothing, if the
+// target has itself been merged away in the meantime.
+func (r *RedisRepo) TombstonePatient(ctx context.Context, orgID, sourceID, targetID string) (bool, error) {
+	sourceMerged := fmt.Sprintf("patient_merged_from:%s:%s", orgID, sourceID)
+	targetMerged := fmt.Sprintf("patient_merged_from:%s:%s", orgID, targetID)
+	targetRedirect := fmt.Sprintf("patient_redirect:%s:%s", orgID, targetID)
+	ok := false
+	txf := func(tx *redis.Tx) error {
+		redirect, err := tx.Get(ctx, targetRedirect).Result()
+		if err != nil && err != redis.Nil {
+			return err
+		}
+		if redirect != "" {
+			ok = false
+			return nil
+		}
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.Set(ctx, fmt.Sprintf("patient_redirect:%s:%s", orgID, sourceID), targetID, 0)
+			pipe.SAdd(ctx, targetMerged, sourceID)
+			pipe.SUnionStore(ctx, targetMerged, targetMerged, sourceMerged)
+			pipe.Del(ctx, sourceMerged)
+			pipe.Del(ctx, fmt.Sprintf("patient:%s:%s", orgID, sourceID))
+			pipe.SRem(ctx, fmt.Sprintf("patients:%s", orgID), sourceID)
+			pipe.SRem(ctx, fmt.Sprintf("patients_archived:%s", orgID), sourceID)
+			return nil
+		})
+		ok = err == nil
+		return err
+	}
+	for i := 0; i < 3; i++ {
+		err := r.client.Watch(ctx, txf, targetRedirect)
+		if err != redis.TxFailedErr {
+			return ok, err
+		}
+	}
+	return false, fmt.Errorf("target patient was %w", utils.ErrConcurrentUpdate)
+}
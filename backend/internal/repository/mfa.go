@@ -0,0 +1,73 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// ============ TWO-FACTOR AUTH ============
+
+// SaveTOTPEnrolment holds a TOTP secret until the user confirms it with a code.
+func (r *RedisRepo) SaveTOTPEnrolment(ctx context.Context, userID, secret string, ttl time.Duration) error {
+	return r.client.Set(ctx, fmt.Sprintf("totp_enrolment:%s", userID), secret, ttl).Err()
+}
+
+// GetTOTPEnrolment returns the user's unconfirmed TOTP secret, or "".
+func (r *RedisRepo) GetTOTPEnrolment(ctx context.Context, userID string) (string, error) {
+	secret, err := r.client.Get(ctx, fmt.Sprintf("totp_enrolment:%s", userID)).Result()
+	if err == redis.Nil {
+		return "", nil
+	}
+	return secret, err
+}
+
+func (r *RedisRepo) DeleteTOTPEnrolment(ctx context.Context, userID string) error {
+	return r.client.Del(ctx, fmt.Sprintf("totp_enrolment:%s", userID)).Err()
+}
+
+// CreateMFAChallenge records that the user passed the password step of a
+// login and may now send a second factor with token.
+func (r *RedisRepo) CreateMFAChallenge(ctx context.Context, token, userID string, ttl time.Duration) error {
+	key := fmt.Sprintf("mfa_challenge:%s", token)
+	_, err := r.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HSet(ctx, key, "user_id", userID, "attempts", 0)
+		pipe.Expire(ctx, key, ttl)
+		return nil
+	})
+	return err
+}
+
+// GetMFAChallenge returns the user a login challenge belongs to, or "".
+func (r *RedisRepo) GetMFAChallenge(ctx context.Context, token string) (string, error) {
+	userID, err := r.client.HGet(ctx, fmt.Sprintf("mfa_challenge:%s", token), "user_id").Result()
+	if err == redis.Nil {
+		return "", nil
+	}
+	return userID, err
+}
+
+// failMFAChallengeScript counts a failed attempt against KEYS[1] and
+// deletes it at ARGV[1] attempts. It does nothing if the challenge has
+// already expired, so HINCRBY can't recreate it without a TTL.
+var failMFAChallengeScript = redis.NewScript(`
+if redis.call('EXISTS', KEYS[1]) == 0 then
+	return 0
+end
+if redis.call('HINCRBY', KEYS[1], 'attempts', 1) >= tonumber(ARGV[1]) then
+	redis.call('DEL', KEYS[1])
+end
+return 1
+`)
+
+// FailMFAChallenge counts a wrong code against the challenge and deletes it
+// once maxAttempts is reached, so the login has to start again.
+func (r *RedisRepo) FailMFAChallenge(ctx context.Context, token string, maxAttempts int) error {
+	return failMFAChallengeScript.Run(ctx, r.client, []string{fmt.Sprintf("mfa_challenge:%s", token)}, maxAttempts).Err()
+}
+
+func (r *RedisRepo) DeleteMFAChallenge(ctx context.Context, token string) error {
+	return r.client.Del(ctx, fmt.Sprintf("mfa_challenge:%s", token)).Err()
+}
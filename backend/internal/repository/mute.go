@@ -0,0 +1,108 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"praana/internal/models"
+)
+
+// ============ ALERT MUTES ============
+
+func patientMutesKey(orgID, patientID string) string {
+	return fmt.Sprintf("patient_mutes:%s:%s", orgID, patientID)
+}
+
+// CreateMute stores a mute that Redis expires on its own at ExpiresAt. The
+// patient index is scored by expiry so lapsed entries can be trimmed.
+func (r *RedisRepo) CreateMute(ctx context.Context, mute *models.AlertMute) error {
+	data, _ := json.Marshal(mute)
+	key := fmt.Sprintf("alert_mute:%s:%s", mute.OrgID, mute.ID)
+	_, err := r.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Set(ctx, key, data, 0)
+		pipe.ExpireAt(ctx, key, time.Unix(mute.ExpiresAt, 0))
+		pipe.ZAdd(ctx, patientMutesKey(mute.OrgID, mute.PatientID), redis.Z{
+			Score:  float64(mute.ExpiresAt),
+			Member: mute.ID,
+		})
+		return nil
+	})
+	return err
+}
+
+// GetActiveMutes returns the patient's unexpired mutes, soonest to expire first.
+func (r *RedisRepo) GetActiveMutes(ctx context.Context, orgID, patientID string) ([]models.AlertMute, error) {
+	key := patientMutesKey(orgID, patientID)
+	now := strconv.FormatInt(time.Now().Unix(), 10)
+	r.client.ZRemRangeByScore(ctx, key, "-inf", "("+now)
+	ids, err := r.client.ZRangeByScore(ctx, key, &redis.ZRangeBy{Min: now, Max: "+inf"}).Result()
+	if err != nil || len(ids) == 0 {
+		return nil, err
+	}
+	keys := make([]string, len(ids))
+	for i, id := range ids {
+		keys[i] = fmt.Sprintf("alert_mute:%s:%s", orgID, id)
+	}
+	vals, err := r.client.MGet(ctx, keys...).Result()
+	if err != nil {
+		return nil, err
+	}
+	mutes := make([]models.AlertMute, 0, len(vals))
+	for _, v := range vals {
+		s, ok := v.(string)
+		if !ok {
+			continue
+		}
+		var m models.AlertMute
+		if json.Unmarshal([]byte(s), &m) == nil {
+			mutes = append(mutes, m)
+		}
+	}
+	return mutes, nil
+}
+
+// GetMute returns nil once the mute has been lifted or has expired.
+func (r *RedisRepo) GetMute(ctx context.Context, orgID, muteID string) (*models.AlertMute, error) {
+	data, err := r.client.Get(ctx, fmt.Sprintf("alert_mute:%s:%s", orgID, muteID)).Bytes()
+	if err == redis.Nil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var m models.AlertMute
+	return &m, json.Unmarshal(data, &m)
+}
+
+// DeleteMute lifts a mute before it expires. It reports whether the mute existed.
+func (r *RedisRepo) DeleteMute(ctx context.Context, orgID, patientID, muteID string) (bool, error) {
+	var deleted *redis.IntCmd
+	_, err := r.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		deleted = pipe.Del(ctx, fmt.Sprintf("alert_mute:%s:%s", orgID, muteID))
+		pipe.ZRem(ctx, patientMutesKey(orgID, patientID), muteID)
+		return nil
+	})
+	if err != nil {
+		return false, err
+	}
+	return deleted.Val() > 0, nil
+}
+
+// MoveMutes carries the source patient's active mutes over to the target.
+func (r *RedisRepo) MoveMutes(ctx context.Context, orgID, sourceID, targetID string) error {
+	mutes, err := r.GetActiveMutes(ctx, orgID, sourceID)
+	if err != nil {
+		return err
+	}
+	for i := range mutes {
+		mutes[i].PatientID = targetID
+		if err := r.CreateMute(ctx, &mutes[i]); err != nil {
+			return err
+		}
+	}
+	return r.client.Del(ctx, patientMutesKey(orgID, sourceID)).Err()
+}
@@ -0,0 +1,127 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/redis/go-redis/v9"
+	"praana/internal/models"
+)
+
+// ============ CLINICAL NOTES ============
+
+func (r *RedisRepo) CreateNote(ctx context.Context, note *models.ClinicalNote) error {
+	data, _ := json.Marshal(note)
+	pipe := r.client.Pipeline()
+	pipe.Set(ctx, fmt.Sprintf("note:%s:%s", note.OrgID, note.ID), data, 0)
+	pipe.ZAdd(ctx, fmt.Sprintf("patient_notes:%s:%s", note.OrgID, note.PatientID), redis.Z{
+		Score:  float64(note.CreatedAt),
+		Member: note.ID,
+	})
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+func (r *RedisRepo) GetNote(ctx context.Context, orgID, noteID string) (*models.ClinicalNote, error) {
+	data, err := r.client.Get(ctx, fmt.Sprintf("note:%s:%s", orgID, noteID)).Bytes()
+	if err == redis.Nil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var note models.ClinicalNote
+	return &note, json.Unmarshal(data, &note)
+}
+
+func (r *RedisRepo) UpdateNote(ctx context.Context, note *models.ClinicalNote) error {
+	data, _ := json.Marshal(note)
+	return r.client.Set(ctx, fmt.Sprintf("note:%s:%s", note.OrgID, note.ID), data, 0).Err()
+}
+
+// GetPatientNotes returns a patient's notes, newest first.
+func (r *RedisRepo) GetPatientNotes(ctx context.Context, orgID, patientID string) ([]models.ClinicalNote, error) {
+	ids, err := r.client.ZRevRange(ctx, fmt.Sprintf("patient_notes:%s:%s", orgID, patientID), 0, -1).Result()
+	if err != nil {
+		return nil, err
+	}
+	var notes []models.ClinicalNote
+	for _, id := range ids {
+		note, err := r.GetNote(ctx, orgID, id)
+		if err != nil || note == nil {
+			continue
+		}
+		notes = append(notes, *note)
+	}
+	return notes, nil
+}
+
+// MoveNotes reassigns the source patient's notes to the target.
+func (r *RedisRepo) MoveNotes(ctx context.Context, orgID, sourceID, targetID string) error {
+	sourceKey := fmt.Sprintf("patient_notes:%s:%s", orgID, sourceID)
+	targetKey := fmt.Sprintf("patient_notes:%s:%s", orgID, targetID)
+	ids, err := r.client.ZRangeWithScores(ctx, sourceKey, 0, -1).Result()
+	if err != nil {
+		return err
+	}
+	for _, z := range ids {
+		noteID := z.Member.(string)
+		note, err := r.GetNote(ctx, orgID, noteID)
+		if err != nil {
+			return err
+		}
+		pipe := r.client.TxPipeline()
+		if note != nil {
+			note.PatientID = targetID
+			data, _ := json.Marshal(note)
+			pipe.Set(ctx, fmt.Sprintf("note:%s:%s", orgID, noteID), data, 0)
+		}
+		pipe.ZAdd(ctx, targetKey, redis.Z{Score: z.Score, Member: noteID})
+		pipe.ZRem(ctx, sourceKey, noteID)
+		if _, err := pipe.Exec(ctx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ============ STATUS LOG ============
+
+// AppendStatusChange stores the change itself as the sorted-set member, scored
+// by when it happened.
+func (r *RedisRepo) AppendStatusChange(ctx context.Context, orgID, patientID string, change *models.StatusChange) error {
+	data, _ := json.Marshal(change)
+	return r.client.ZAdd(ctx, fmt.Sprintf("patient_status_log:%s:%s", orgID, patientID), redis.Z{
+		Score:  float64(change.ChangedAt),
+		Member: string(data),
+	}).Err()
+}
+
+func (r *RedisRepo) GetStatusChanges(ctx context.Context, orgID, patientID string) ([]models.StatusChange, error) {
+	members, err := r.client.ZRange(ctx, fmt.Sprintf("patient_status_log:%s:%s", orgID, patientID), 0, -1).Result()
+	if err != nil {
+		return nil, err
+	}
+	var changes []models.StatusChange
+	for _, raw := range members {
+		var c models.StatusChange
+		if err := json.Unmarshal([]byte(raw), &c); err == nil {
+			changes = append(changes, c)
+		}
+	}
+	return changes, nil
+}
+
+// MoveStatusChanges folds the source patient's status log into the target's.
+// Members are whole entries, so repeating the union is harmless.
+func (r *RedisRepo) MoveStatusChanges(ctx context.Context, orgID, sourceID, targetID string) error {
+	sourceKey := fmt.Sprintf("patient_status_log:%s:%s", orgID, sourceID)
+	targetKey := fmt.Sprintf("patient_status_log:%s:%s", orgID, targetID)
+	_, err := r.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.ZUnionStore(ctx, targetKey, &redis.ZStore{Keys: []string{targetKey, sourceKey}, Aggregate: "MIN"})
+		pipe.Del(ctx, sourceKey)
+		return nil
+	})
+	return err
+}
@@ -5,11 +5,13 @@ import (
 	"encoding/json"
 	"fmt"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/redis/go-redis/v9"
 	"github.com/rs/zerolog/log"
 	"praana/internal/models"
+	"praana/internal/utils"
 )
 
 type RedisRepo struct {
@@ -58,6 +60,11 @@ func (r *RedisRepo) GetOrg(ctx context.Context, orgID string) (*models.Org, erro
 	return &org, json.Unmarshal(data, &org)
 }
 
+// GetOrgIDs returns every org, for background jobs that sweep all tenants.
+func (r *RedisRepo) GetOrgIDs(ctx context.Context) ([]string, error) {
+	return r.client.SMembers(ctx, "orgs:all").Result()
+}
+
 func (r *RedisRepo) UpdateOrg(ctx context.Context, org *models.Org) error {
 	data, _ := json.Marshal(org)
 	return r.client.Set(ctx, fmt.Sprintf("org:%s", org.ID), data, 0).Err()
@@ -65,15 +72,27 @@ func (r *RedisRepo) UpdateOrg(ctx context.Context, org *models.Org) error {
 
 // ============ USER ============
 
-// userRecord is the Redis storage shape — includes password which json:"-" excludes from API responses.
+// userRecord is the Redis storage shape — includes password and 2FA secrets which json:"-" excludes from API responses.
 type userRecord struct {
 	models.User
-	HashedPassword string `json:"hashed_password"`
+	HashedPassword string   `json:"hashed_password"`
+	TOTPSecret     string   `json:"totp_secret,omitempty"`
+	TOTPLastStep   int64    `json:"totp_last_step,omitempty"`
+	RecoveryCodes  []string `json:"recovery_codes,omitempty"`
+}
+
+func newUserRecord(user *models.User) userRecord {
+	return userRecord{
+		User:           *user,
+		HashedPassword: user.Password,
+		TOTPSecret:     user.TOTPSecret,
+		TOTPLastStep:   user.TOTPLastStep,
+		RecoveryCodes:  user.RecoveryCodes,
+	}
 }
 
 func (r *RedisRepo) CreateUser(ctx context.Context, user *models.User) error {
-	record := userRecord{User: *user, HashedPassword: user.Password}
-	data, _ := json.Marshal(record)
+	data, _ := json.Marshal(newUserRecord(user))
 	pipe := r.client.Pipeline()
 	pipe.Set(ctx, fmt.Sprintf("user:%s", user.ID), data, 0)
 	pipe.Set(ctx, fmt.Sprintf("user_email:%s", user.Email), user.ID, 0)
@@ -82,6 +101,33 @@ func (r *RedisRepo) CreateUser(ctx context.Context, user *models.User) error {
 	return err
 }
 
+// ClaimUserEmail reserves an email for a user about to be created, so two
+// concurrent sign-ups can't both create an account for it. It returns false
+// if the email is already taken.
+func (r *RedisRepo) ClaimUserEmail(ctx context.Context, email, userID string) (bool, error) {
+	return r.client.SetNX(ctx, fmt.Sprintf("user_email:%s", email), userID, 0).Result()
+}
+
+// releaseUserEmailScript deletes KEYS[1] only while it still points at ARGV[1].
+var releaseUserEmailScript = redis.NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	return redis.call('DEL', KEYS[1])
+end
+return 0
+`)
+
+// ReleaseUserEmail undoes ClaimUserEmail when the user wasn't created.
+func (r *RedisRepo) ReleaseUserEmail(ctx context.Context, email, userID string) error {
+	return releaseUserEmailScript.Run(ctx, r.client, []string{fmt.Sprintf("user_email:%s", email)}, userID).Err()
+}
+
+// UpdateUser rewrites a user's record. It doesn't touch the email index, so
+// it can't be used to change a user's email.
+func (r *RedisRepo) UpdateUser(ctx context.Context, user *models.User) error {
+	data, _ := json.Marshal(newUserRecord(user))
+	return r.client.Set(ctx, fmt.Sprintf("user:%s", user.ID), data, 0).Err()
+}
+
 func (r *RedisRepo) GetUser(ctx context.Context, userID string) (*models.User, error) {
 	data, err := r.client.Get(ctx, fmt.Sprintf("user:%s", userID)).Bytes()
 	if err == redis.Nil {
@@ -90,15 +136,62 @@ func (r *RedisRepo) GetUser(ctx context.Context, userID string) (*models.User, e
 	if err != nil {
 		return nil, err
 	}
+	return decodeUser(data)
+}
+
+func decodeUser(data []byte) (*models.User, error) {
 	var record userRecord
 	if err := json.Unmarshal(data, &record); err != nil {
 		return nil, err
 	}
 	user := record.User
 	user.Password = record.HashedPassword
+	user.TOTPSecret = record.TOTPSecret
+	user.TOTPLastStep = record.TOTPLastStep
+	user.RecoveryCodes = record.RecoveryCodes
 	return &user, nil
 }
 
+// ChangeUser applies change to the stored user under WATCH and saves the
+// result, so two requests can't both act on the same state (e.g. spend the
+// same one-time code). If change returns an error nothing is written. It
+// returns nil if the user doesn't exist.
+func (r *RedisRepo) ChangeUser(ctx context.Context, userID string, change func(*models.User) error) (*models.User, error) {
+	key := fmt.Sprintf("user:%s", userID)
+	var user *models.User
+	txf := func(tx *redis.Tx) error {
+		data, err := tx.Get(ctx, key).Bytes()
+		if err == redis.Nil {
+			user = nil
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		u, err := decodeUser(data)
+		if err != nil {
+			return err
+		}
+		if err := change(u); err != nil {
+			return err
+		}
+		updated, _ := json.Marshal(newUserRecord(u))
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.Set(ctx, key, updated, 0)
+			return nil
+		})
+		user = u
+		return err
+	}
+	for i := 0; i < 3; i++ {
+		err := r.client.Watch(ctx, txf, key)
+		if err != redis.TxFailedErr {
+			return user, err
+		}
+	}
+	return nil, fmt.Errorf("user was %w", utils.ErrConcurrentUpdate)
+}
+
 func (r *RedisRepo) GetUserByEmail(ctx context.Context, email string) (*models.User, error) {
 	userID, err := r.client.Get(ctx, fmt.Sprintf("user_email:%s", email)).Result()
 	if err == redis.Nil {
@@ -143,41 +236,179 @@ func (r *RedisRepo) GetOrgMemberCount(ctx context.Context, orgID string) (int64,
 	return r.client.SCard(ctx, fmt.Sprintf("org:%s:members", orgID)).Result()
 }
 
-// ============ SESSION ============
+// ============ PASSWORD RESET ============
 
-func (r *RedisRepo) CreateSession(ctx context.Context, jwtID, userID string, expiry time.Duration) error {
-	return r.client.Set(ctx, fmt.Sprintf("session:%s", jwtID), userID, expiry).Err()
+// SavePasswordReset stores a single-use reset token for the user, replacing
+// any token issued before it.
+func (r *RedisRepo) SavePasswordReset(ctx context.Context, token, userID string, ttl time.Duration) error {
+	userKey := fmt.Sprintf("user_password_reset:%s", userID)
+	previous, err := r.client.Get(ctx, userKey).Result()
+	if err != nil && err != redis.Nil {
+		return err
+	}
+	_, err = r.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		if previous != "" {
+			pipe.Del(ctx, fmt.Sprintf("password_reset:%s", previous))
+		}
+		pipe.Set(ctx, fmt.Sprintf("password_reset:%s", token), userID, ttl)
+		pipe.Set(ctx, userKey, token, ttl)
+		return nil
+	})
+	return err
 }
 
-func (r *RedisRepo) GetSession(ctx context.Context, jwtID string) (string, error) {
-	return r.client.Get(ctx, fmt.Sprintf("session:%s", jwtID)).Result()
+// ConsumePasswordReset deletes a reset token and returns the user it was
+// issued to, or "" if the token is unknown, used or expired.
+func (r *RedisRepo) ConsumePasswordReset(ctx context.Context, token string) (string, error) {
+	userID, err := r.client.GetDel(ctx, fmt.Sprintf("password_reset:%s", token)).Result()
+	if err == redis.Nil {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	r.client.Del(ctx, fmt.Sprintf("user_password_reset:%s", userID))
+	return userID, nil
 }
 
-func (r *RedisRepo) DeleteSession(ctx context.Context, jwtID string) error {
-	return r.client.Del(ctx, fmt.Sprintf("session:%s", jwtID)).Err()
+// ============ INVITE ============
+
+// inviteRecord is the stored form of an invite; models.Invite keeps the code
+// out of JSON so it can't leak through API responses.
+type inviteRecord struct {
+	models.Invite
+	Code string `json:"code"`
 }
 
-// ============ INVITE ============
+func orgInvitesKey(orgID string) string {
+	return fmt.Sprintf("org_invites:%s", orgID)
+}
+
+// SaveInvite stores an invite under its ID with a code lookup beside it. Both
+// expire at ExpiresAt, and the org index is scored by expiry so lapsed
+// entries can be trimmed. previousCode, if set, is a code being replaced.
+func (r *RedisRepo) SaveInvite(ctx context.Context, invite *models.Invite, previousCode string) error {
+	data, _ := json.Marshal(inviteRecord{Invite: *invite, Code: invite.Code})
+	key := fmt.Sprintf("invite:%s:%s", invite.OrgID, invite.ID)
+	codeKey := fmt.Sprintf("invite_code:%s", invite.Code)
+	expiresAt := time.Unix(invite.ExpiresAt, 0)
+	_, err := r.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		if previousCode != "" && previousCode != invite.Code {
+			pipe.Del(ctx, fmt.Sprintf("invite_code:%s", previousCode))
+		}
+		pipe.Set(ctx, key, data, 0)
+		pipe.ExpireAt(ctx, key, expiresAt)
+		pipe.Set(ctx, codeKey, invite.OrgID+":"+invite.ID, 0)
+		pipe.ExpireAt(ctx, codeKey, expiresAt)
+		pipe.ZAdd(ctx, orgInvitesKey(invite.OrgID), redis.Z{
+			Score:  float64(invite.ExpiresAt),
+			Member: invite.ID,
+		})
+		return nil
+	})
+	return err
+}
+
+func (r *RedisRepo) GetInvite(ctx context.Context, orgID, inviteID string) (*models.Invite, error) {
+	data, err := r.client.Get(ctx, fmt.Sprintf("invite:%s:%s", orgID, inviteID)).Bytes()
+	if err == redis.Nil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var rec inviteRecord
+	if err := json.Unmarshal(data, &rec); err != nil {
+		return nil, err
+	}
+	rec.Invite.Code = rec.Code
+	return &rec.Invite, nil
+}
 
-func (r *RedisRepo) CreateInvite(ctx context.Context, invite *models.Invite) error {
-	data, _ := json.Marshal(invite)
-	return r.client.Set(ctx, fmt.Sprintf("invite:%s", invite.Code), data, 72*time.Hour).Err()
+// GetInviteByCode finds the invite holding code. A code is only valid while it
+// is still the invite's current one.
+func (r *RedisRepo) GetInviteByCode(ctx context.Context, code string) (*models.Invite, error) {
+	ref, err := r.client.Get(ctx, fmt.Sprintf("invite_code:%s", code)).Result()
+	if err == redis.Nil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	orgID, inviteID, ok := strings.Cut(ref, ":")
+	if !ok {
+		return nil, nil
+	}
+	invite, err := r.GetInvite(ctx, orgID, inviteID)
+	if err != nil || invite == nil || invite.Code != code {
+		return nil, err
+	}
+	return invite, nil
 }
 
-func (r *RedisRepo) GetInvite(ctx context.Context, code string) (*models.Invite, error) {
-	data, err := r.client.Get(ctx, fmt.Sprintf("invite:%s", code)).Bytes()
+// ClaimInviteCode takes code's lookup with GETDEL, so only one caller can
+// accept an invite. It returns nil if the code was already taken or is no
+// longer the invite's current one.
+func (r *RedisRepo) ClaimInviteCode(ctx context.Context, code string) (*models.Invite, error) {
+	ref, err := r.client.GetDel(ctx, fmt.Sprintf("invite_code:%s", code)).Result()
 	if err == redis.Nil {
 		return nil, nil
 	}
 	if err != nil {
 		return nil, err
 	}
-	var invite models.Invite
-	return &invite, json.Unmarshal(data, &invite)
+	orgID, inviteID, ok := strings.Cut(ref, ":")
+	if !ok {
+		return nil, nil
+	}
+	invite, err := r.GetInvite(ctx, orgID, inviteID)
+	if err != nil || invite == nil || invite.Code != code {
+		return nil, err
+	}
+	return invite, nil
+}
+
+// GetPendingInvites returns the org's unexpired invites, soonest to expire first.
+func (r *RedisRepo) GetPendingInvites(ctx context.Context, orgID string) ([]models.Invite, error) {
+	key := orgInvitesKey(orgID)
+	now := strconv.FormatInt(time.Now().Unix(), 10)
+	r.client.ZRemRangeByScore(ctx, key, "-inf", "("+now)
+	ids, err := r.client.ZRangeByScore(ctx, key, &redis.ZRangeBy{Min: now, Max: "+inf"}).Result()
+	if err != nil || len(ids) == 0 {
+		return nil, err
+	}
+	keys := make([]string, len(ids))
+	for i, id := range ids {
+		keys[i] = fmt.Sprintf("invite:%s:%s", orgID, id)
+	}
+	vals, err := r.client.MGet(ctx, keys...).Result()
+	if err != nil {
+		return nil, err
+	}
+	invites := make([]models.Invite, 0, len(vals))
+	for _, v := range vals {
+		s, ok := v.(string)
+		if !ok {
+			continue
+		}
+		var rec inviteRecord
+		if json.Unmarshal([]byte(s), &rec) == nil {
+			rec.Invite.Code = rec.Code
+			invites = append(invites, rec.Invite)
+		}
+	}
+	return invites, nil
 }
 
-func (r *RedisRepo) DeleteInvite(ctx context.Context, code string) error {
-	return r.client.Del(ctx, fmt.Sprintf("invite:%s", code)).Err()
+// DeleteInvite removes an invite, its code lookup and its index entry.
+func (r *RedisRepo) DeleteInvite(ctx context.Context, invite *models.Invite) error {
+	_, err := r.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Del(ctx, fmt.Sprintf("invite:%s:%s", invite.OrgID, invite.ID))
+		pipe.Del(ctx, fmt.Sprintf("invite_code:%s", invite.Code))
+		pipe.ZRem(ctx, orgInvitesKey(invite.OrgID), invite.ID)
+		return nil
+	})
+	return err
 }
 
 // ============ PATIENT ============
@@ -191,8 +422,20 @@ func (r *RedisRepo) CreatePatient(ctx context.Context, patient *models.Patient)
 	return err
 }
 
+func observationIntervalsKey(orgID string) string {
+	return fmt.Sprintf("obs_intervals:%s", orgID)
+}
+
+// GetPatient loads the patient along with their observation interval, which
+// is stored apart from the record so setting it can't race patient edits.
+// Records from before that keep the interval saved on them until it is set.
 func (r *RedisRepo) GetPatient(ctx context.Context, orgID, patientID string) (*models.Patient, error) {
-	data, err := r.client.Get(ctx, fmt.Sprintf("patient:%s:%s", orgID, patientID)).Bytes()
+	pipe := r.client.Pipeline()
+	get := pipe.Get(ctx, fmt.Sprintf("patient:%s:%s", orgID, patientID))
+	interval := pipe.HGet(ctx, observationIntervalsKey(orgID), patientID)
+	_, _ = pipe.Exec(ctx)
+
+	data, err := get.Bytes()
 	if err == redis.Nil {
 		return nil, nil
 	}
@@ -200,7 +443,19 @@ func (r *RedisRepo) GetPatient(ctx context.Context, orgID, patientID string) (*m
 		return nil, err
 	}
 	var patient models.Patient
-	return &patient, json.Unmarshal(data, &patient)
+	if err := json.Unmarshal(data, &patient); err != nil {
+		return nil, err
+	}
+	if minutes, err := interval.Int(); err == nil {
+		patient.ObservationInterval = minutes
+	}
+	return &patient, nil
+}
+
+// SetObservationInterval overrides the patient's observation interval; zero
+// means no override.
+func (r *RedisRepo) SetObservationInterval(ctx context.Context, orgID, patientID string, minutes int) error {
+	return r.client.HSet(ctx, observationIntervalsKey(orgID), patientID, minutes).Err()
 }
 
 func (r *RedisRepo) UpdatePatient(ctx context.Context, patient *models.Patient) error {
@@ -208,16 +463,191 @@ func (r *RedisRepo) UpdatePatient(ctx context.Context, patient *models.Patient)
 	return r.client.Set(ctx, fmt.Sprintf("patient:%s:%s", patient.OrgID, patient.ID), data, 0).Err()
 }
 
-func (r *RedisRepo) DeletePatient(ctx context.Context, orgID, patientID string) error {
+// ChangePatient applies change to the stored patient under WATCH and saves
+// the result, so status changes (admit, transfer, discharge) can't interleave
+// and overwrite each other. If change returns an error nothing is written.
+// It returns nil if the patient doesn't exist.
+func (r *RedisRepo) ChangePatient(ctx context.Context, orgID, patientID string, change func(*models.Patient) error) (*models.Patient, error) {
+	key := fmt.Sprintf("patient:%s:%s", orgID, patientID)
+	var patient *models.Patient
+	txf := func(tx *redis.Tx) error {
+		data, err := tx.Get(ctx, key).Bytes()
+		if err == redis.Nil {
+			patient = nil
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		var p models.Patient
+		if err := json.Unmarshal(data, &p); err != nil {
+			return err
+		}
+		if minutes, err := tx.HGet(ctx, observationIntervalsKey(orgID), patientID).Int(); err == nil {
+			p.ObservationInterval = minutes
+		}
+		if err := change(&p); err != nil {
+			return err
+		}
+		updated, _ := json.Marshal(&p)
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.Set(ctx, key, updated, 0)
+			return nil
+		})
+		patient = &p
+		return err
+	}
+	for i := 0; i < 3; i++ {
+		err := r.client.Watch(ctx, txf, key)
+		if err != redis.TxFailedErr {
+			return patient, err
+		}
+	}
+	return nil, fmt.Errorf("patient was %w", utils.ErrConcurrentUpdate)
+}
+
+// ArchivePatient moves a discharged patient out of the active index. The
+// patient record and its history are kept.
+func (r *RedisRepo) ArchivePatient(ctx context.Context, orgID, patientID string) error {
+	return r.client.SMove(ctx, fmt.Sprintf("patients:%s", orgID), fmt.Sprintf("patients_archived:%s", orgID), patientID).Err()
+}
+
+// RestorePatient moves a readmitted patient back into the active index.
+func (r *RedisRepo) RestorePatient(ctx context.Context, orgID, patientID string) error {
 	pipe := r.client.Pipeline()
-	pipe.Del(ctx, fmt.Sprintf("patient:%s:%s", orgID, patientID))
-	pipe.SRem(ctx, fmt.Sprintf("patients:%s", orgID), patientID)
+	pipe.SRem(ctx, fmt.Sprintf("patients_archived:%s", orgID), patientID)
+	pipe.SAdd(ctx, fmt.Sprintf("patients:%s", orgID), patientID)
 	_, err := pipe.Exec(ctx)
 	return err
 }
 
+// PurgePatient permanently removes a patient and every key that belongs to
+// them: vitals, latest vitals, per-patient thresholds, episodes, alerts,
+// notes, the status log, handovers and alert mutes.
+// All deletes run in a single MULTI so a patient is never left half-purged.
+func (r *RedisRepo) PurgePatient(ctx context.Context, orgID, patientID string) error {
+	episodesKey := fmt.Sprintf("patient_episodes:%s:%s", orgID, patientID)
+	alertsKey := fmt.Sprintf("patient_alerts:%s:%s", orgID, patientID)
+	notesKey := fmt.Sprintf("patient_notes:%s:%s", orgID, patientID)
+	handoversKey := fmt.Sprintf("patient_handovers:%s:%s", orgID, patientID)
+	mutesKey := patientMutesKey(orgID, patientID)
+	assignmentsKey := fmt.Sprintf("patient_assignments:%s:%s", orgID, patientID)
+	mergedFromKey := fmt.Sprintf("patient_merged_from:%s:%s", orgID, patientID)
+
+	episodeIDs, err := r.client.ZRange(ctx, episodesKey, 0, -1).Result()
+	if err != nil {
+		return err
+	}
+	alertIDs, err := r.client.ZRange(ctx, alertsKey, 0, -1).Result()
+	if err != nil {
+		return err
+	}
+	noteIDs, err := r.client.ZRange(ctx, notesKey, 0, -1).Result()
+	if err != nil {
+		return err
+	}
+	handovers, err := r.client.ZRangeWithScores(ctx, handoversKey, 0, -1).Result()
+	if err != nil {
+		return err
+	}
+	muteIDs, err := r.client.ZRange(ctx, mutesKey, 0, -1).Result()
+	if err != nil {
+		return err
+	}
+	alerts, err := r.getAlerts(ctx, orgID, alertIDs)
+	if err != nil {
+		return err
+	}
+	assignmentIDs, err := r.client.ZRange(ctx, assignmentsKey, 0, -1).Result()
+	if err != nil {
+		return err
+	}
+	var assignments []*models.CareAssignment
+	for _, id := range assignmentIDs {
+		a, err := r.GetAssignment(ctx, orgID, id)
+		if err != nil {
+			return err
+		}
+		if a != nil {
+			assignments = append(assignments, a)
+		}
+	}
+	mergedFrom, err := r.client.SMembers(ctx, mergedFromKey).Result()
+	if err != nil {
+		return err
+	}
+
+	_, err = r.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Del(ctx,
+			fmt.Sprintf("patient:%s:%s", orgID, patientID),
+			fmt.Sprintf("vitals:%s:%s", orgID, patientID),
+			fmt.Sprintf("latest_vitals:%s:%s", orgID, patientID),
+			fmt.Sprintf("thresholds:%s:%s", orgID, patientID),
+			episodesKey,
+			alertsKey,
+			patientOpenAlertsKey(orgID, patientID),
+			notesKey,
+			handoversKey,
+			mutesKey,
+			fmt.Sprintf("patient_status_log:%s:%s", orgID, patientID),
+			fmt.Sprintf("obs_overdue_alerted:%s:%s", orgID, patientID),
+			patientBedKey(orgID, patientID),
+			assignmentsKey,
+			mergedFromKey,
+		)
+		pipe.SRem(ctx, fmt.Sprintf("patients:%s", orgID), patientID)
+		pipe.SRem(ctx, fmt.Sprintf("patients_archived:%s", orgID), patientID)
+		pipe.HDel(ctx, observationIntervalsKey(orgID), patientID)
+		for _, id := range episodeIDs {
+			pipe.Del(ctx, fmt.Sprintf("episode:%s:%s", orgID, id))
+		}
+		for _, id := range alertIDs {
+			pipe.Del(ctx, fmt.Sprintf("alert:%s:%s", orgID, id), fmt.Sprintf("alert_comments:%s:%s", orgID, id))
+		}
+		for i := range alerts {
+			a := &alerts[i]
+			for _, key := range alertIndexKeys(a) {
+				pipe.ZRem(ctx, key, a.ID)
+			}
+			for _, state := range models.AlertStates {
+				pipe.ZRem(ctx, alertStateKey(orgID, state), a.ID)
+			}
+		}
+		for _, id := range noteIDs {
+			pipe.Del(ctx, fmt.Sprintf("note:%s:%s", orgID, id))
+		}
+		for _, id := range muteIDs {
+			pipe.Del(ctx, fmt.Sprintf("alert_mute:%s:%s", orgID, id))
+		}
+		for _, z := range handovers {
+			id := z.Member.(string)
+			pipe.Del(ctx, fmt.Sprintf("handover:%s:%s", orgID, id))
+			pipe.ZRem(ctx, fmt.Sprintf("shift_handovers:%s:%d", orgID, int64(z.Score)), id)
+		}
+		for _, a := range assignments {
+			pipe.Del(ctx, fmt.Sprintf("assignment:%s:%s", orgID, a.ID))
+			for _, key := range assignmentIndexKeys(a) {
+				pipe.ZRem(ctx, key, a.ID)
+			}
+		}
+		for _, id := range mergedFrom {
+			pipe.Del(ctx, fmt.Sprintf("patient_redirect:%s:%s", orgID, id), fmt.Sprintf("patient_merge:%s:%s", orgID, id))
+		}
+		return nil
+	})
+	return err
+}
+
 func (r *RedisRepo) GetPatients(ctx context.Context, orgID string) ([]models.Patient, error) {
-	patientIDs, err := r.client.SMembers(ctx, fmt.Sprintf("patients:%s", orgID)).Result()
+	return r.getPatientsInIndex(ctx, orgID, fmt.Sprintf("patients:%s", orgID))
+}
+
+func (r *RedisRepo) GetArchivedPatients(ctx context.Context, orgID string) ([]models.Patient, error) {
+	return r.getPatientsInIndex(ctx, orgID, fmt.Sprintf("patients_archived:%s", orgID))
+}
+
+func (r *RedisRepo) getPatientsInIndex(ctx context.Context, orgID, indexKey string) ([]models.Patient, error) {
+	patientIDs, err := r.client.SMembers(ctx, indexKey).Result()
 	if err != nil {
 		return nil, err
 	}
@@ -236,6 +666,77 @@ func (r *RedisRepo) GetPatientCount(ctx context.Context, orgID string) (int64, e
 	return r.client.SCard(ctx, fmt.Sprintf("patients:%s", orgID)).Result()
 }
 
+// claimIdentifiersScript sets every KEYS[i] hash field ARGV[i+1] to the patient
+// ID in ARGV[1], unless one is already held by a different patient. Returns the
+// 1-based index of the first conflicting identifier, or 0 on success.
+var claimIdentifiersScript = redis.NewScript(`
+for i = 1, #KEYS do
+	local owner = redis.call('HGET', KEYS[i], ARGV[i + 1])
+	if owner and owner ~= ARGV[1] then
+		return i
+	end
+end
+for i = 1, #KEYS do
+	redis.call('HSET', KEYS[i], ARGV[i + 1], ARGV[1])
+end
+return 0
+`)
+
+// releaseIdentifiersScript removes identifier entries still owned by ARGV[1].
+var releaseIdentifiersScript = redis.NewScript(`
+for i = 1, #KEYS do
+	if redis.call('HGET', KEYS[i], ARGV[i + 1]) == ARGV[1] then
+		redis.call('HDEL', KEYS[i], ARGV[i + 1])
+	end
+end
+return 0
+`)
+
+func identifierKeysAndArgs(orgID, patientID string, ids []models.PatientIdentifier) ([]string, []interface{}) {
+	keys := make([]string, len(ids))
+	args := make([]interface{}, 0, len(ids)+1)
+	args = append(args, patientID)
+	for i, id := range ids {
+		keys[i] = fmt.Sprintf("patient_identifiers:%s:%s", orgID, id.System)
+		args = append(args, id.Value)
+	}
+	return keys, args
+}
+
+// ClaimPatientIdentifiers atomically reserves identifiers for a patient. If any
+// identifier belongs to another patient nothing is claimed and that identifier
+// is returned.
+func (r *RedisRepo) ClaimPatientIdentifiers(ctx context.Context, orgID, patientID string, ids []models.PatientIdentifier) (*models.PatientIdentifier, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	keys, args := identifierKeysAndArgs(orgID, patientID, ids)
+	idx, err := claimIdentifiersScript.Run(ctx, r.client, keys, args...).Int()
+	if err != nil {
+		return nil, err
+	}
+	if idx > 0 {
+		return &ids[idx-1], nil
+	}
+	return nil, nil
+}
+
+func (r *RedisRepo) ReleasePatientIdentifiers(ctx context.Context, orgID, patientID string, ids []models.PatientIdentifier) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	keys, args := identifierKeysAndArgs(orgID, patientID, ids)
+	return releaseIdentifiersScript.Run(ctx, r.client, keys, args...).Err()
+}
+
+func (r *RedisRepo) FindPatientIDByIdentifier(ctx context.Context, orgID, system, value string) (string, error) {
+	id, err := r.client.HGet(ctx, fmt.Sprintf("patient_identifiers:%s:%s", orgID, system), value).Result()
+	if err == redis.Nil {
+		return "", nil
+	}
+	return id, err
+}
+
 // ============ VITALS ============
 
 func (r *RedisRepo) RecordVitals(ctx context.Context, vitals *models.Vitals) error {
@@ -287,6 +788,42 @@ func (r *RedisRepo) GetVitalsHistory(ctx context.Context, orgID, patientID strin
 	return vitals, nil
 }
 
+// GetVitalsBefore pages backwards through a patient's vitals stream. end is a
+// stream ID bound ("+" for the newest entry, "(id" to exclude id). It returns
+// the entries, newest first, and the stream ID of the last one returned.
+func (r *RedisRepo) GetVitalsBefore(ctx context.Context, orgID, patientID, end string, count int64) ([]models.Vitals, string, error) {
+	msgs, err := r.client.XRevRangeN(ctx, fmt.Sprintf("vitals:%s:%s", orgID, patientID), end, "-", count).Result()
+	if err != nil || len(msgs) == 0 {
+		return nil, "", err
+	}
+	var vitals []models.Vitals
+	for _, msg := range msgs {
+		dataStr, ok := msg.Values["data"].(string)
+		if !ok {
+			continue
+		}
+		var v models.Vitals
+		if err := json.Unmarshal([]byte(dataStr), &v); err == nil {
+			vitals = append(vitals, v)
+		}
+	}
+	return vitals, msgs[len(msgs)-1].ID, nil
+}
+
+// MarkObservationOverdueAlerted records that the overdue alert for the
+// observation due at dueAt has been raised. It returns false if it already was.
+func (r *RedisRepo) MarkObservationOverdueAlerted(ctx context.Context, orgID, patientID string, dueAt int64) (bool, error) {
+	due := strconv.FormatInt(dueAt, 10)
+	prev, err := r.client.SetArgs(ctx, fmt.Sprintf("obs_overdue_alerted:%s:%s", orgID, patientID), due, redis.SetArgs{
+		Get: true,
+		TTL: 48 * time.Hour,
+	}).Result()
+	if err != nil && err != redis.Nil {
+		return false, err
+	}
+	return prev != due, nil
+}
+
 // ============ THRESHOLDS ============
 
 func (r *RedisRepo) SetThresholds(ctx context.Context, key string, t *models.Threshold) error {
@@ -308,13 +845,68 @@ func (r *RedisRepo) GetThresholds(ctx context.Context, key string) (*models.Thre
 
 // ============ ALERTS ============
 
+// Alerts are stored once under alert:<org>:<id> and indexed by sorted sets
+// scored by created_at: every alert, and one set per patient, severity, vital
+// type, assignee and state. The state and assignee indexes are kept in step
+// by UpdateAlert and TransitionAlert.
+
+func alertIndexKeys(a *models.Alert) []string {
+	keys := []string{
+		fmt.Sprintf("alerts_idx:%s", a.OrgID),
+		fmt.Sprintf("patient_alerts:%s:%s", a.OrgID, a.PatientID),
+		fmt.Sprintf("alerts_idx:%s:severity:%s", a.OrgID, a.Severity),
+		fmt.Sprintf("alerts_idx:%s:vital:%s", a.OrgID, a.VitalType),
+	}
+	if a.AssignedTo != "" {
+		keys = append(keys, alertAssigneeKey(a.OrgID, a.AssignedTo))
+	}
+	return keys
+}
+
+func alertAssigneeKey(orgID, userID string) string {
+	return fmt.Sprintf("alerts_idx:%s:assignee:%s", orgID, userID)
+}
+
+func alertStateKey(orgID string, state models.AlertState) string {
+	return fmt.Sprintf("alerts_idx:%s:state:%s", orgID, state)
+}
+
+func patientOpenAlertsKey(orgID, patientID string) string {
+	return fmt.Sprintf("patient_open_alerts:%s:%s", orgID, patientID)
+}
+
+// indexAlertState queues the writes that put an alert in the index for its
+// current state and out of the others. The org-wide and per-patient open
+// sets are what active-alert lists and counts read.
+func indexAlertState(ctx context.Context, pipe redis.Pipeliner, a *models.Alert) {
+	z := redis.Z{Score: float64(a.CreatedAt), Member: a.ID}
+	for _, state := range models.AlertStates {
+		if state != a.State {
+			pipe.ZRem(ctx, alertStateKey(a.OrgID, state), a.ID)
+		}
+	}
+	pipe.ZAdd(ctx, alertStateKey(a.OrgID, a.State), z)
+	if a.State == models.AlertOpen {
+		pipe.ZAdd(ctx, patientOpenAlertsKey(a.OrgID, a.PatientID), z)
+	} else {
+		pipe.ZRem(ctx, patientOpenAlertsKey(a.OrgID, a.PatientID), a.ID)
+	}
+}
+
 func (r *RedisRepo) CreateAlert(ctx context.Context, alert *models.Alert) error {
+	if alert.State == "" {
+		alert.State = models.AlertOpen
+	}
 	data, _ := json.Marshal(alert)
-	pipe := r.client.Pipeline()
-	pipe.Set(ctx, fmt.Sprintf("alert:%s:%s", alert.OrgID, alert.ID), data, 0)
-	pipe.LPush(ctx, fmt.Sprintf("alert_history:%s", alert.OrgID), data)
-	pipe.LTrim(ctx, fmt.Sprintf("alert_history:%s", alert.OrgID), 0, 499)
-	_, err := pipe.Exec(ctx)
+	z := redis.Z{Score: float64(alert.CreatedAt), Member: alert.ID}
+	_, err := r.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Set(ctx, fmt.Sprintf("alert:%s:%s", alert.OrgID, alert.ID), data, 0)
+		for _, key := range alertIndexKeys(alert) {
+			pipe.ZAdd(ctx, key, z)
+		}
+		indexAlertState(ctx, pipe, alert)
+		return nil
+	})
 	return err
 }
 
@@ -327,29 +919,432 @@ func (r *RedisRepo) GetAlert(ctx context.Context, orgID, alertID string) (*model
 		return nil, err
 	}
 	var alert models.Alert
-	return &alert, json.Unmarshal(data, &alert)
+	if err := json.Unmarshal(data, &alert); err != nil {
+		return nil, err
+	}
+	normalizeAlertState(&alert)
+	return &alert, nil
 }
 
+// normalizeAlertState fills State on alerts stored before it existed.
+func normalizeAlertState(a *models.Alert) {
+	if a.State != "" {
+		return
+	}
+	a.State = models.AlertOpen
+	if a.Acknowledged {
+		a.State = models.AlertAcknowledged
+	}
+}
+
+// UpdateAlert saves the alert and moves it to the index for its current state.
 func (r *RedisRepo) UpdateAlert(ctx context.Context, alert *models.Alert) error {
 	data, _ := json.Marshal(alert)
-	return r.client.Set(ctx, fmt.Sprintf("alert:%s:%s", alert.OrgID, alert.ID), data, 0).Err()
+	_, err := r.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Set(ctx, fmt.Sprintf("alert:%s:%s", alert.OrgID, alert.ID), data, 0)
+		indexAlertState(ctx, pipe, alert)
+		return nil
+	})
+	return err
+}
+
+// TransitionAlert applies change to the current alert and saves it together
+// with its state indexes. The alert key is watched, so two concurrent
+// transitions can't both act on the same prior state. It returns nil if the
+// alert doesn't exist.
+func (r *RedisRepo) TransitionAlert(ctx context.Context, orgID, alertID string, change func(*models.Alert) error) (*models.Alert, error) {
+	return r.transitionAlert(ctx, orgID, alertID, change, nil)
 }
 
-func (r *RedisRepo) GetAlertHistory(ctx context.Context, orgID string, limit int64) ([]models.Alert, error) {
-	results, err := r.client.LRange(ctx, fmt.Sprintf("alert_history:%s", orgID), 0, limit-1).Result()
+// transitionAlert is TransitionAlert with extra writes queued into the same
+// transaction; extra is also called, with nil, when the alert doesn't exist.
+func (r *RedisRepo) transitionAlert(ctx context.Context, orgID, alertID string, change func(*models.Alert) error, extra func(redis.Pipeliner, *models.Alert)) (*models.Alert, error) {
+	key := fmt.Sprintf("alert:%s:%s", orgID, alertID)
+	var alert *models.Alert
+	txf := func(tx *redis.Tx) error {
+		data, err := tx.Get(ctx, key).Bytes()
+		if err == redis.Nil {
+			alert = nil
+			if extra == nil {
+				return nil
+			}
+			_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+				extra(pipe, nil)
+				return nil
+			})
+			return err
+		}
+		if err != nil {
+			return err
+		}
+		var a models.Alert
+		if err := json.Unmarshal(data, &a); err != nil {
+			return err
+		}
+		normalizeAlertState(&a)
+		prevAssignee := a.AssignedTo
+		if err := change(&a); err != nil {
+			return err
+		}
+		updated, _ := json.Marshal(&a)
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.Set(ctx, key, updated, 0)
+			indexAlertState(ctx, pipe, &a)
+			if a.AssignedTo != prevAssignee {
+				if prevAssignee != "" {
+					pipe.ZRem(ctx, alertAssigneeKey(orgID, prevAssignee), a.ID)
+				}
+				if a.AssignedTo != "" {
+					pipe.ZAdd(ctx, alertAssigneeKey(orgID, a.AssignedTo), redis.Z{Score: float64(a.CreatedAt), Member: a.ID})
+				}
+			}
+			if extra != nil {
+				extra(pipe, &a)
+			}
+			return nil
+		})
+		alert = &a
+		return err
+	}
+	for i := 0; i < 3; i++ {
+		err := r.client.Watch(ctx, txf, key)
+		if err != redis.TxFailedErr {
+			return alert, err
+		}
+	}
+	return nil, fmt.Errorf("alert was %w", utils.ErrConcurrentUpdate)
+}
+
+// getAlerts loads alerts by ID in one round trip, skipping any that are gone.
+func (r *RedisRepo) getAlerts(ctx context.Context, orgID string, ids []string) ([]models.Alert, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	keys := make([]string, len(ids))
+	for i, id := range ids {
+		keys[i] = fmt.Sprintf("alert:%s:%s", orgID, id)
+	}
+	values, err := r.client.MGet(ctx, keys...).Result()
 	if err != nil {
 		return nil, err
 	}
-	var alerts []models.Alert
-	for _, raw := range results {
+	alerts := make([]models.Alert, 0, len(values))
+	for _, v := range values {
+		raw, ok := v.(string)
+		if !ok {
+			continue
+		}
 		var a models.Alert
-		if err := json.Unmarshal([]byte(raw), &a); err == nil {
+		if json.Unmarshal([]byte(raw), &a) == nil {
+			normalizeAlertState(&a)
 			alerts = append(alerts, a)
 		}
 	}
 	return alerts, nil
 }
 
+// alertQueryScanLimit caps how many alerts one QueryAlerts call reads, so a
+// filter that matches little of its index can't walk all of it.
+const alertQueryScanLimit = 2000
+
+// QueryAlerts pages through alerts newest first. When more than one indexed
+// filter is set, their indexes are intersected into a short-lived set that is
+// walked instead; keep reports whether an alert passes any extra caller-side
+// filter. The cursor is "<created_at>:<id>" of the last alert read. A page can
+// come back short with a next cursor when the scan limit was reached first.
+func (r *RedisRepo) QueryAlerts(ctx context.Context, orgID string, q *models.AlertQuery, keep func(*models.Alert) bool) (*models.AlertPage, error) {
+	var indexes []string
+	if q.PatientID != "" {
+		indexes = append(indexes, fmt.Sprintf("patient_alerts:%s:%s", orgID, q.PatientID))
+	}
+	if q.State != "" {
+		indexes = append(indexes, alertStateKey(orgID, q.State))
+	}
+	if q.VitalType != "" {
+		indexes = append(indexes, fmt.Sprintf("alerts_idx:%s:vital:%s", orgID, q.VitalType))
+	}
+	if q.Severity != "" {
+		indexes = append(indexes, fmt.Sprintf("alerts_idx:%s:severity:%s", orgID, q.Severity))
+	}
+	if q.AssignedTo != "" {
+		indexes = append(indexes, alertAssigneeKey(orgID, q.AssignedTo))
+	}
+	index := fmt.Sprintf("alerts_idx:%s", orgID)
+	switch len(indexes) {
+	case 0:
+	case 1:
+		index = indexes[0]
+	default:
+		index = fmt.Sprintf("alerts_query:%s:%s", orgID, utils.GenerateID())
+		_, err := r.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.ZInterStore(ctx, index, &redis.ZStore{Keys: indexes, Aggregate: "MAX"})
+			pipe.Expire(ctx, index, time.Minute)
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		defer r.client.Del(context.Background(), index)
+	}
+
+	max := "+inf"
+	if q.To > 0 {
+		max = strconv.FormatInt(q.To, 10)
+	}
+	var cursorAt int64
+	var cursorID string
+	if q.Cursor != "" {
+		parts := strings.SplitN(q.Cursor, ":", 2)
+		at, err := strconv.ParseInt(parts[0], 10, 64)
+		if len(parts) != 2 || err != nil {
+			return nil, fmt.Errorf("invalid cursor")
+		}
+		cursorAt, cursorID = at, parts[1]
+		if q.To == 0 || cursorAt < q.To {
+			max = strconv.FormatInt(cursorAt, 10)
+		}
+	}
+	min := "-inf"
+	if q.From > 0 {
+		min = strconv.FormatInt(q.From, 10)
+	}
+
+	matches := func(a *models.Alert) bool {
+		if q.Cursor != "" && (a.CreatedAt > cursorAt || (a.CreatedAt == cursorAt && a.ID >= cursorID)) {
+			return false
+		}
+		if q.PatientID != "" && a.PatientID != q.PatientID {
+			return false
+		}
+		if q.State != "" && a.State != q.State {
+			return false
+		}
+		if q.VitalType != "" && a.VitalType != q.VitalType {
+			return false
+		}
+		if q.Severity != "" && a.Severity != q.Severity {
+			return false
+		}
+		if q.AssignedTo != "" && a.AssignedTo != q.AssignedTo {
+			return false
+		}
+		return keep == nil || keep(a)
+	}
+
+	batch := int64(q.Limit) * 2
+	if batch < 50 {
+		batch = 50
+	}
+	page := &models.AlertPage{Alerts: []models.Alert{}}
+	var offset int64
+	var last *models.Alert
+	for len(page.Alerts) <= q.Limit {
+		if offset >= alertQueryScanLimit {
+			if last != nil {
+				page.NextCursor = fmt.Sprintf("%d:%s", last.CreatedAt, last.ID)
+			}
+			return page, nil
+		}
+		ids, err := r.client.ZRevRangeByScore(ctx, index, &redis.ZRangeBy{
+			Min: min, Max: max, Offset: offset, Count: batch,
+		}).Result()
+		if err != nil {
+			return nil, err
+		}
+		alerts, err := r.getAlerts(ctx, orgID, ids)
+		if err != nil {
+			return nil, err
+		}
+		for i := range alerts {
+			if matches(&alerts[i]) {
+				page.Alerts = append(page.Alerts, alerts[i])
+			}
+			last = &alerts[i]
+		}
+		if int64(len(ids)) < batch {
+			break
+		}
+		offset += batch
+	}
+
+	if len(page.Alerts) > q.Limit {
+		page.Alerts = page.Alerts[:q.Limit]
+		last := page.Alerts[q.Limit-1]
+		page.NextCursor = fmt.Sprintf("%d:%s", last.CreatedAt, last.ID)
+	}
+	return page, nil
+}
+
+// AddAlertComment appends a comment to the alert's thread and bumps the
+// alert's comment count in the same MULTI.
+func (r *RedisRepo) AddAlertComment(ctx context.Context, orgID string, comment *models.AlertComment) (*models.Alert, error) {
+	data, _ := json.Marshal(comment)
+	listKey := fmt.Sprintf("alert_comments:%s:%s", orgID, comment.AlertID)
+	alertKey := fmt.Sprintf("alert:%s:%s", orgID, comment.AlertID)
+	var alert *models.Alert
+	txf := func(tx *redis.Tx) error {
+		raw, err := tx.Get(ctx, alertKey).Bytes()
+		if err == redis.Nil {
+			alert = nil
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		var a models.Alert
+		if err := json.Unmarshal(raw, &a); err != nil {
+			return err
+		}
+		normalizeAlertState(&a)
+		a.CommentCount++
+		updated, _ := json.Marshal(&a)
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.RPush(ctx, listKey, data)
+			pipe.Set(ctx, alertKey, updated, 0)
+			return nil
+		})
+		alert = &a
+		return err
+	}
+	for i := 0; i < 3; i++ {
+		err := r.client.Watch(ctx, txf, alertKey)
+		if err != redis.TxFailedErr {
+			return alert, err
+		}
+	}
+	return nil, fmt.Errorf("alert was %w", utils.ErrConcurrentUpdate)
+}
+
+// GetAlertComments returns an alert's comments, oldest first.
+func (r *RedisRepo) GetAlertComments(ctx context.Context, orgID, alertID string) ([]models.AlertComment, error) {
+	results, err := r.client.LRange(ctx, fmt.Sprintf("alert_comments:%s:%s", orgID, alertID), 0, -1).Result()
+	if err != nil {
+		return nil, err
+	}
+	comments := make([]models.AlertComment, 0, len(results))
+	for _, raw := range results {
+		var c models.AlertComment
+		if err := json.Unmarshal([]byte(raw), &c); err == nil {
+			comments = append(comments, c)
+		}
+	}
+	return comments, nil
+}
+
+// alertIndexVersion is bumped whenever a new alert index is added so the
+// backfill runs again for existing orgs.
+const alertIndexVersion = "3"
+
+// BackfillAlertIndexes indexes alerts created before the alert indexes
+// existed, found through the legacy alert_history list and the per-patient
+// alert sets, then drops the legacy list. It runs once per org.
+func (r *RedisRepo) BackfillAlertIndexes(ctx context.Context, orgID string) error {
+	marker := fmt.Sprintf("alerts_idx_backfilled:%s", orgID)
+	done, err := r.client.Get(ctx, marker).Result()
+	if err != nil && err != redis.Nil {
+		return err
+	}
+	if done == alertIndexVersion {
+		return nil
+	}
+
+	historyKey := fmt.Sprintf("alert_history:%s", orgID)
+	seen := make(map[string]bool)
+	var ids []string
+	history, err := r.client.LRange(ctx, historyKey, 0, -1).Result()
+	if err != nil {
+		return err
+	}
+	for _, raw := range history {
+		var a models.Alert
+		if json.Unmarshal([]byte(raw), &a) == nil && !seen[a.ID] {
+			seen[a.ID] = true
+			ids = append(ids, a.ID)
+		}
+	}
+	for _, index := range []string{fmt.Sprintf("patients:%s", orgID), fmt.Sprintf("patients_archived:%s", orgID)} {
+		patientIDs, err := r.client.SMembers(ctx, index).Result()
+		if err != nil {
+			return err
+		}
+		for _, pid := range patientIDs {
+			alertIDs, err := r.client.ZRange(ctx, fmt.Sprintf("patient_alerts:%s:%s", orgID, pid), 0, -1).Result()
+			if err != nil {
+				return err
+			}
+			for _, id := range alertIDs {
+				if !seen[id] {
+					seen[id] = true
+					ids = append(ids, id)
+				}
+			}
+		}
+	}
+
+	alerts, err := r.getAlerts(ctx, orgID, ids)
+	if err != nil {
+		return err
+	}
+	_, err = r.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		for i := range alerts {
+			a := &alerts[i]
+			data, _ := json.Marshal(a)
+			z := redis.Z{Score: float64(a.CreatedAt), Member: a.ID}
+			pipe.Set(ctx, fmt.Sprintf("alert:%s:%s", orgID, a.ID), data, 0)
+			for _, key := range alertIndexKeys(a) {
+				pipe.ZAdd(ctx, key, z)
+			}
+			indexAlertState(ctx, pipe, a)
+		}
+		pipe.Del(ctx, historyKey)
+		pipe.Set(ctx, marker, alertIndexVersion, 0)
+		return nil
+	})
+	return err
+}
+
+// GetPatientAlertsSince returns the patient's alerts raised at or after since.
+func (r *RedisRepo) GetPatientAlertsSince(ctx context.Context, orgID, patientID string, since int64) ([]models.Alert, error) {
+	ids, err := r.client.ZRangeByScore(ctx, fmt.Sprintf("patient_alerts:%s:%s", orgID, patientID), &redis.ZRangeBy{
+		Min: strconv.FormatInt(since, 10),
+		Max: "+inf",
+	}).Result()
+	if err != nil {
+		return nil, err
+	}
+	return r.getAlerts(ctx, orgID, ids)
+}
+
+// GetPatientAlertsBetween returns the patient's alerts raised in [from, to),
+// oldest first.
+func (r *RedisRepo) GetPatientAlertsBetween(ctx context.Context, orgID, patientID string, from, to int64) ([]models.Alert, error) {
+	ids, err := r.client.ZRangeByScore(ctx, fmt.Sprintf("patient_alerts:%s:%s", orgID, patientID), &redis.ZRangeBy{
+		Min: strconv.FormatInt(from, 10),
+		Max: "(" + strconv.FormatInt(to, 10),
+	}).Result()
+	if err != nil {
+		return nil, err
+	}
+	return r.getAlerts(ctx, orgID, ids)
+}
+
+// GetPatientAlerts returns every alert raised for a patient, oldest first.
+func (r *RedisRepo) GetPatientAlerts(ctx context.Context, orgID, patientID string) ([]models.Alert, error) {
+	ids, err := r.client.ZRange(ctx, fmt.Sprintf("patient_alerts:%s:%s", orgID, patientID), 0, -1).Result()
+	if err != nil {
+		return nil, err
+	}
+	var alerts []models.Alert
+	for _, id := range ids {
+		a, err := r.GetAlert(ctx, orgID, id)
+		if err != nil || a == nil {
+			continue
+		}
+		alerts = append(alerts, *a)
+	}
+	return alerts, nil
+}
+
 func (r *RedisRepo) PublishAlert(ctx context.Context, orgID string, alert *models.Alert) error {
 	data, _ := json.Marshal(alert)
 	return r.client.Publish(ctx, fmt.Sprintf("alerts:%s", orgID), data).Err()
@@ -380,41 +1375,31 @@ func (r *RedisRepo) GetUsage(ctx context.Context, orgID, month string) (map[stri
 // ============ HELPERS ============
 
 func (r *RedisRepo) GetActiveAlertCount(ctx context.Context, orgID string) (int, error) {
-	alerts, err := r.GetAlertHistory(ctx, orgID, 100)
-	if err != nil {
-		return 0, err
-	}
-	count := 0
-	for _, a := range alerts {
-		if !a.Acknowledged {
-			count++
-		}
-	}
-	return count, nil
+	n, err := r.client.ZCard(ctx, alertStateKey(orgID, models.AlertOpen)).Result()
+	return int(n), err
 }
 
+// GetActiveAlerts returns open alerts, newest first.
 func (r *RedisRepo) GetActiveAlerts(ctx context.Context, orgID string) ([]models.Alert, error) {
-	alerts, err := r.GetAlertHistory(ctx, orgID, 500)
+	ids, err := r.client.ZRevRange(ctx, alertStateKey(orgID, models.AlertOpen), 0, -1).Result()
 	if err != nil {
 		return nil, err
 	}
-	var active []models.Alert
-	seen := make(map[string]bool)
-	for _, a := range alerts {
-		if seen[a.ID] {
-			continue
-		}
-		seen[a.ID] = true
-		// Re-fetch by key to get current acknowledged state
-		current, err := r.GetAlert(ctx, orgID, a.ID)
-		if err != nil || current == nil {
-			continue
-		}
-		if !current.Acknowledged {
-			active = append(active, *current)
-		}
+	return r.getAlerts(ctx, orgID, ids)
+}
+
+func (r *RedisRepo) GetPatientActiveAlertCount(ctx context.Context, orgID, patientID string) (int, error) {
+	n, err := r.client.ZCard(ctx, patientOpenAlertsKey(orgID, patientID)).Result()
+	return int(n), err
+}
+
+// GetPatientActiveAlerts returns a patient's open alerts, newest first.
+func (r *RedisRepo) GetPatientActiveAlerts(ctx context.Context, orgID, patientID string) ([]models.Alert, error) {
+	ids, err := r.client.ZRevRange(ctx, patientOpenAlertsKey(orgID, patientID), 0, -1).Result()
+	if err != nil {
+		return nil, err
 	}
-	return active, nil
+	return r.getAlerts(ctx, orgID, ids)
 }
 
 func MapToInt(m map[string]string, key string) int {
@@ -0,0 +1,184 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"praana/internal/models"
+)
+
+// ============ SESSION ============
+
+// A session is stored as session:<id> (the models.Session JSON) beside
+// session_refresh:<id>, a hash of the current and previous refresh token
+// hashes. Both share a TTL that each refresh slides forward, so an idle
+// session simply expires.
+
+func userSessionsKey(userID string) string {
+	return fmt.Sprintf("user_sessions:%s", userID)
+}
+
+func sessionRefreshKey(sessionID string) string {
+	return fmt.Sprintf("session_refresh:%s", sessionID)
+}
+
+// CreateSession stores a session with its first refresh token hash and
+// indexes it under the user so all of a user's sessions can be revoked
+// together.
+func (r *RedisRepo) CreateSession(ctx context.Context, session *models.Session, refreshHash string, ttl time.Duration) error {
+	data, _ := json.Marshal(session)
+	key := fmt.Sprintf("session:%s", session.ID)
+	refreshKey := sessionRefreshKey(session.ID)
+	_, err := r.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Set(ctx, key, data, ttl)
+		pipe.HSet(ctx, refreshKey, "current", refreshHash)
+		pipe.Expire(ctx, refreshKey, ttl)
+		pipe.SAdd(ctx, userSessionsKey(session.UserID), session.ID)
+		pipe.ExpireAt(ctx, userSessionsKey(session.UserID), time.Unix(session.ExpiresAt, 0))
+		return nil
+	})
+	return err
+}
+
+func (r *RedisRepo) GetSession(ctx context.Context, sessionID string) (*models.Session, error) {
+	data, err := r.client.Get(ctx, fmt.Sprintf("session:%s", sessionID)).Bytes()
+	if err == redis.Nil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var s models.Session
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// TouchSession saves the session's latest client details without changing
+// its TTL. A session deleted in the meantime stays deleted.
+func (r *RedisRepo) TouchSession(ctx context.Context, session *models.Session) error {
+	data, _ := json.Marshal(session)
+	err := r.client.SetArgs(ctx, fmt.Sprintf("session:%s", session.ID), data, redis.SetArgs{Mode: "XX", KeepTTL: true}).Err()
+	if err == redis.Nil {
+		return nil
+	}
+	return err
+}
+
+// GetUserSessions returns the user's live sessions, most recently seen
+// first, and drops index entries for sessions that have expired.
+func (r *RedisRepo) GetUserSessions(ctx context.Context, userID string) ([]models.Session, error) {
+	ids, err := r.client.SMembers(ctx, userSessionsKey(userID)).Result()
+	if err != nil || len(ids) == 0 {
+		return nil, err
+	}
+	keys := make([]string, len(ids))
+	for i, id := range ids {
+		keys[i] = fmt.Sprintf("session:%s", id)
+	}
+	vals, err := r.client.MGet(ctx, keys...).Result()
+	if err != nil {
+		return nil, err
+	}
+	sessions := make([]models.Session, 0, len(vals))
+	var stale []interface{}
+	for i, v := range vals {
+		raw, ok := v.(string)
+		if !ok {
+			stale = append(stale, ids[i])
+			continue
+		}
+		var s models.Session
+		if json.Unmarshal([]byte(raw), &s) == nil {
+			sessions = append(sessions, s)
+		}
+	}
+	if len(stale) > 0 {
+		r.client.SRem(ctx, userSessionsKey(userID), stale...)
+	}
+	sort.Slice(sessions, func(i, j int) bool { return sessions[i].LastSeenAt > sessions[j].LastSeenAt })
+	return sessions, nil
+}
+
+func (r *RedisRepo) DeleteSession(ctx context.Context, userID, sessionID string) error {
+	_, err := r.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Del(ctx, fmt.Sprintf("session:%s", sessionID), sessionRefreshKey(sessionID))
+		pipe.SRem(ctx, userSessionsKey(userID), sessionID)
+		return nil
+	})
+	return err
+}
+
+// RevokeUserSessions deletes every session of the user except keepSessionID
+// (which may be empty) and returns how many were revoked.
+func (r *RedisRepo) RevokeUserSessions(ctx context.Context, userID, keepSessionID string) (int, error) {
+	ids, err := r.client.SMembers(ctx, userSessionsKey(userID)).Result()
+	if err != nil {
+		return 0, err
+	}
+	var revoke []string
+	for _, id := range ids {
+		if id != keepSessionID {
+			revoke = append(revoke, id)
+		}
+	}
+	if len(revoke) == 0 {
+		return 0, nil
+	}
+	keys := make([]string, 0, 2*len(revoke))
+	members := make([]interface{}, len(revoke))
+	for i, id := range revoke {
+		keys = append(keys, fmt.Sprintf("session:%s", id), sessionRefreshKey(id))
+		members[i] = id
+	}
+	_, err = r.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Del(ctx, keys...)
+		pipe.SRem(ctx, userSessionsKey(userID), members...)
+		return nil
+	})
+	return len(revoke), err
+}
+
+// Outcomes of RotateRefreshToken.
+const (
+	RefreshRotated = "rotated"
+	RefreshRaced   = "raced"
+	RefreshReused  = "reused"
+	RefreshUnknown = "unknown"
+)
+
+// rotateRefreshScript swaps the session's current refresh hash ARGV[1] for
+// ARGV[2] and slides the session's TTL to ARGV[5] seconds. Presenting the
+// hash it just replaced within ARGV[4] seconds is a race between clients
+// sharing the token and changes nothing; presenting any other hash means an
+// old token was replayed, and the whole session is deleted.
+var rotateRefreshScript = redis.NewScript(`
+if redis.call('EXISTS', KEYS[1]) == 0 then
+	return 'unknown'
+end
+local state = redis.call('HMGET', KEYS[2], 'current', 'previous', 'rotated_at')
+if state[1] == ARGV[1] then
+	redis.call('HSET', KEYS[2], 'current', ARGV[2], 'previous', ARGV[1], 'rotated_at', ARGV[3])
+	redis.call('EXPIRE', KEYS[1], ARGV[5])
+	redis.call('EXPIRE', KEYS[2], ARGV[5])
+	return 'rotated'
+end
+if state[2] == ARGV[1] and tonumber(ARGV[3]) - tonumber(state[3] or 0) <= tonumber(ARGV[4]) then
+	return 'raced'
+end
+redis.call('DEL', KEYS[1], KEYS[2])
+return 'reused'
+`)
+
+// RotateRefreshToken replaces the session's refresh token hash if
+// presentedHash is the current one, returning one of the Refresh* outcomes.
+func (r *RedisRepo) RotateRefreshToken(ctx context.Context, sessionID, presentedHash, newHash string, grace, ttl time.Duration) (string, error) {
+	keys := []string{fmt.Sprintf("session:%s", sessionID), sessionRefreshKey(sessionID)}
+	return rotateRefreshScript.Run(ctx, r.client, keys,
+		presentedHash, newHash, time.Now().Unix(), int64(grace/time.Second), int64(ttl/time.Second)).Text()
+}
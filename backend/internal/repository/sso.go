@@ -0,0 +1,94 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"praana/internal/models"
+)
+
+// ============ SSO ============
+
+// ssoRecord is the stored form of an org's SSO config; models.SSOConfig keeps
+// the client secret out of JSON.
+type ssoRecord struct {
+	models.SSOConfig
+	StoredClientSecret string `json:"client_secret"`
+}
+
+// SaveSSOConfig writes the org's SSO config and points sso_domain:<domain>
+// at the org for each allowed domain, dropping previousDomains it no longer
+// lists.
+func (r *RedisRepo) SaveSSOConfig(ctx context.Context, cfg *models.SSOConfig, previousDomains []string) error {
+	data, _ := json.Marshal(ssoRecord{SSOConfig: *cfg, StoredClientSecret: cfg.ClientSecret})
+	_, err := r.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		for _, d := range previousDomains {
+			pipe.Del(ctx, fmt.Sprintf("sso_domain:%s", d))
+		}
+		for _, d := range cfg.AllowedDomains {
+			pipe.Set(ctx, fmt.Sprintf("sso_domain:%s", d), cfg.OrgID, 0)
+		}
+		pipe.Set(ctx, fmt.Sprintf("sso_config:%s", cfg.OrgID), data, 0)
+		return nil
+	})
+	return err
+}
+
+func (r *RedisRepo) GetSSOConfig(ctx context.Context, orgID string) (*models.SSOConfig, error) {
+	data, err := r.client.Get(ctx, fmt.Sprintf("sso_config:%s", orgID)).Bytes()
+	if err == redis.Nil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var rec ssoRecord
+	if err := json.Unmarshal(data, &rec); err != nil {
+		return nil, err
+	}
+	cfg := rec.SSOConfig
+	cfg.ClientSecret = rec.StoredClientSecret
+	return &cfg, nil
+}
+
+func (r *RedisRepo) DeleteSSOConfig(ctx context.Context, cfg *models.SSOConfig) error {
+	_, err := r.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		for _, d := range cfg.AllowedDomains {
+			pipe.Del(ctx, fmt.Sprintf("sso_domain:%s", d))
+		}
+		pipe.Del(ctx, fmt.Sprintf("sso_config:%s", cfg.OrgID))
+		return nil
+	})
+	return err
+}
+
+// GetSSOOrgByDomain returns the org that claims an email domain for SSO, or "".
+func (r *RedisRepo) GetSSOOrgByDomain(ctx context.Context, domain string) (string, error) {
+	orgID, err := r.client.Get(ctx, fmt.Sprintf("sso_domain:%s", domain)).Result()
+	if err == redis.Nil {
+		return "", nil
+	}
+	return orgID, err
+}
+
+func (r *RedisRepo) SaveSSOState(ctx context.Context, state string, s *models.SSOState, ttl time.Duration) error {
+	data, _ := json.Marshal(s)
+	return r.client.Set(ctx, fmt.Sprintf("sso_state:%s", state), data, ttl).Err()
+}
+
+// ConsumeSSOState deletes and returns a pending sign-in, or nil if the state
+// is unknown, used or expired.
+func (r *RedisRepo) ConsumeSSOState(ctx context.Context, state string) (*models.SSOState, error) {
+	data, err := r.client.GetDel(ctx, fmt.Sprintf("sso_state:%s", state)).Bytes()
+	if err == redis.Nil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var s models.SSOState
+	return &s, json.Unmarshal(data, &s)
+}
@@ -0,0 +1,282 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/redis/go-redis/v9"
+	"praana/internal/models"
+)
+
+// ============ WARDS ============
+
+func (r *RedisRepo) CreateWard(ctx context.Context, ward *models.Ward) error {
+	data, _ := json.Marshal(ward)
+	pipe := r.client.Pipeline()
+	pipe.Set(ctx, fmt.Sprintf("ward:%s:%s", ward.OrgID, ward.ID), data, 0)
+	pipe.SAdd(ctx, fmt.Sprintf("wards:%s", ward.OrgID), ward.ID)
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+func (r *RedisRepo) GetWard(ctx context.Context, orgID, wardID string) (*models.Ward, error) {
+	data, err := r.client.Get(ctx, fmt.Sprintf("ward:%s:%s", orgID, wardID)).Bytes()
+	if err == redis.Nil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var ward models.Ward
+	return &ward, json.Unmarshal(data, &ward)
+}
+
+func (r *RedisRepo) UpdateWard(ctx context.Context, ward *models.Ward) error {
+	data, _ := json.Marshal(ward)
+	return r.client.Set(ctx, fmt.Sprintf("ward:%s:%s", ward.OrgID, ward.ID), data, 0).Err()
+}
+
+func (r *RedisRepo) GetWards(ctx context.Context, orgID string) ([]models.Ward, error) {
+	ids, err := r.client.SMembers(ctx, fmt.Sprintf("wards:%s", orgID)).Result()
+	if err != nil {
+		return nil, err
+	}
+	var wards []models.Ward
+	for _, id := range ids {
+		w, err := r.GetWard(ctx, orgID, id)
+		if err != nil || w == nil {
+			continue
+		}
+		wards = append(wards, *w)
+	}
+	return wards, nil
+}
+
+// deleteWardScript removes the ward at KEYS[1] and the beds listed in
+// KEYS[2] (org ARGV[1]) unless one of them is occupied. Returns 1 when
+// deleted, 0 when a bed is occupied.
+var deleteWardScript = redis.NewScript(`
+local beds = redis.call('SMEMBERS', KEYS[2])
+for _, id in ipairs(beds) do
+	if redis.call('HEXISTS', 'bed_occupant:' .. ARGV[1] .. ':' .. id, 'patient_id') == 1 then
+		return 0
+	end
+end
+for _, id in ipairs(beds) do
+	redis.call('DEL', 'bed:' .. ARGV[1] .. ':' .. id, 'bed_occupant:' .. ARGV[1] .. ':' .. id)
+end
+redis.call('DEL', KEYS[1], KEYS[2])
+redis.call('SREM', KEYS[3], ARGV[2])
+return 1
+`)
+
+// DeleteWard removes the ward and all of its beds in one step. It reports
+// false, deleting nothing, if any bed is occupied.
+func (r *RedisRepo) DeleteWard(ctx context.Context, orgID, wardID string) (bool, error) {
+	keys := []string{
+		fmt.Sprintf("ward:%s:%s", orgID, wardID),
+		fmt.Sprintf("ward_beds:%s:%s", orgID, wardID),
+		fmt.Sprintf("wards:%s", orgID),
+	}
+	ok, err := deleteWardScript.Run(ctx, r.client, keys, orgID, wardID).Int()
+	if err != nil {
+		return false, err
+	}
+	return ok == 1, nil
+}
+
+// ============ BEDS ============
+
+// Results of CreateBed.
+const (
+	BedCreated        = 1
+	BedWardFull       = 0
+	BedWardMissing    = -1
+	BedLabelDuplicate = -2
+)
+
+// createBedScript adds bed ARGV[2] (JSON ARGV[3], label ARGV[4]) to the ward
+// at KEYS[1] if the ward exists, has room and has no bed with that label.
+// Bed keys are built from the prefix ARGV[1].
+var createBedScript = redis.NewScript(`
+local ward = redis.call('GET', KEYS[1])
+if not ward then
+	return -1
+end
+local ids = redis.call('SMEMBERS', KEYS[2])
+if #ids >= tonumber(cjson.decode(ward).capacity) then
+	return 0
+end
+local label = string.lower(ARGV[4])
+for _, id in ipairs(ids) do
+	local bed = redis.call('GET', ARGV[1] .. id)
+	if bed and string.lower(cjson.decode(bed).label) == label then
+		return -2
+	end
+end
+redis.call('SET', ARGV[1] .. ARGV[2], ARGV[3])
+redis.call('SADD', KEYS[2], ARGV[2])
+return 1
+`)
+
+// CreateBed adds a bed to its ward, checking the ward's capacity and label
+// uniqueness in the same step. It returns one of the Bed* results.
+func (r *RedisRepo) CreateBed(ctx context.Context, bed *models.Bed) (int, error) {
+	data, _ := json.Marshal(bed)
+	keys := []string{
+		fmt.Sprintf("ward:%s:%s", bed.OrgID, bed.WardID),
+		fmt.Sprintf("ward_beds:%s:%s", bed.OrgID, bed.WardID),
+	}
+	return createBedScript.Run(ctx, r.client, keys, fmt.Sprintf("bed:%s:", bed.OrgID), bed.ID, data, bed.Label).Int()
+}
+
+func (r *RedisRepo) GetBed(ctx context.Context, orgID, bedID string) (*models.Bed, error) {
+	data, err := r.client.Get(ctx, fmt.Sprintf("bed:%s:%s", orgID, bedID)).Bytes()
+	if err == redis.Nil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var bed models.Bed
+	if err := json.Unmarshal(data, &bed); err != nil {
+		return nil, err
+	}
+	occ, err := r.client.HGetAll(ctx, fmt.Sprintf("bed_occupant:%s:%s", orgID, bedID)).Result()
+	if err != nil {
+		return nil, err
+	}
+	bed.PatientID = occ["patient_id"]
+	bed.OccupiedAt, _ = strconv.ParseInt(occ["since"], 10, 64)
+	return &bed, nil
+}
+
+func (r *RedisRepo) GetWardBeds(ctx context.Context, orgID, wardID string) ([]models.Bed, error) {
+	ids, err := r.client.SMembers(ctx, fmt.Sprintf("ward_beds:%s:%s", orgID, wardID)).Result()
+	if err != nil {
+		return nil, err
+	}
+	var beds []models.Bed
+	for _, id := range ids {
+		b, err := r.GetBed(ctx, orgID, id)
+		if err != nil || b == nil {
+			continue
+		}
+		beds = append(beds, *b)
+	}
+	return beds, nil
+}
+
+func (r *RedisRepo) GetWardBedCount(ctx context.Context, orgID, wardID string) (int64, error) {
+	return r.client.SCard(ctx, fmt.Sprintf("ward_beds:%s:%s", orgID, wardID)).Result()
+}
+
+// deleteBedScript removes the bed at KEYS[1] unless KEYS[2] records an
+// occupant. Returns 1 when deleted, 0 when occupied.
+var deleteBedScript = redis.NewScript(`
+if redis.call('HEXISTS', KEYS[2], 'patient_id') == 1 then
+	return 0
+end
+redis.call('DEL', KEYS[1], KEYS[2])
+redis.call('SREM', KEYS[3], ARGV[1])
+return 1
+`)
+
+// DeleteBed removes an empty bed. It reports false if the bed is occupied.
+func (r *RedisRepo) DeleteBed(ctx context.Context, orgID, wardID, bedID string) (bool, error) {
+	keys := []string{
+		fmt.Sprintf("bed:%s:%s", orgID, bedID),
+		fmt.Sprintf("bed_occupant:%s:%s", orgID, bedID),
+		fmt.Sprintf("ward_beds:%s:%s", orgID, wardID),
+	}
+	ok, err := deleteBedScript.Run(ctx, r.client, keys, bedID).Int()
+	if err != nil {
+		return false, err
+	}
+	return ok == 1, nil
+}
+
+func patientBedKey(orgID, patientID string) string {
+	return fmt.Sprintf("patient_bed:%s:%s", orgID, patientID)
+}
+
+// occupyBedScript gives bed ARGV[3] (KEYS[1], occupancy at KEYS[2]) to
+// patient ARGV[1] unless the bed is gone or another patient holds it. The
+// patient's current bed is read from KEYS[3] in the same step and freed, so
+// a patient never holds two beds. ARGV[4] is the occupancy key prefix.
+// Returns {1, previous bed ID} on success, {0} if the bed is taken and {-1}
+// if it no longer exists.
+var occupyBedScript = redis.NewScript(`
+if redis.call('EXISTS', KEYS[1]) == 0 then
+	return {-1}
+end
+local owner = redis.call('HGET', KEYS[2], 'patient_id')
+if owner and owner ~= ARGV[1] then
+	return {0}
+end
+local previous = redis.call('GET', KEYS[3])
+if previous and previous ~= ARGV[3] then
+	local previousKey = ARGV[4] .. previous
+	if redis.call('HGET', previousKey, 'patient_id') == ARGV[1] then
+		redis.call('DEL', previousKey)
+	end
+else
+	previous = ''
+end
+redis.call('HSET', KEYS[2], 'patient_id', ARGV[1], 'since', ARGV[2])
+redis.call('SET', KEYS[3], ARGV[3])
+return {1, previous}
+`)
+
+// releaseBedScript frees bed ARGV[2], or whichever bed KEYS[1] says patient
+// ARGV[1] holds if ARGV[2] is empty. The bed is only freed while that
+// patient occupies it. ARGV[3] is the occupancy key prefix.
+var releaseBedScript = redis.NewScript(`
+local held = redis.call('GET', KEYS[1])
+local bed = ARGV[2]
+if bed == '' then
+	bed = held
+end
+if not bed then
+	return 0
+end
+local key = ARGV[3] .. bed
+if redis.call('HGET', key, 'patient_id') == ARGV[1] then
+	redis.call('DEL', key)
+end
+if held == bed then
+	redis.call('DEL', KEYS[1])
+end
+return 1
+`)
+
+// OccupyBed atomically assigns a bed to a patient, releasing the bed they
+// held before in the same step. It returns 1 and that previous bed ID (or
+// "") on success, 0 when the bed is held by someone else and -1 when the bed
+// has been deleted.
+func (r *RedisRepo) OccupyBed(ctx context.Context, orgID, bedID, patientID string, since int64) (int, string, error) {
+	keys := []string{
+		fmt.Sprintf("bed:%s:%s", orgID, bedID),
+		fmt.Sprintf("bed_occupant:%s:%s", orgID, bedID),
+		patientBedKey(orgID, patientID),
+	}
+	res, err := occupyBedScript.Run(ctx, r.client, keys, patientID, since, bedID, fmt.Sprintf("bed_occupant:%s:", orgID)).Slice()
+	if err != nil {
+		return 0, "", err
+	}
+	code, _ := res[0].(int64)
+	var previous string
+	if len(res) > 1 {
+		previous, _ = res[1].(string)
+	}
+	return int(code), previous, nil
+}
+
+// ReleaseBed frees bedID if the patient holds it, or whatever bed the
+// patient holds if bedID is empty.
+func (r *RedisRepo) ReleaseBed(ctx context.Context, orgID, bedID, patientID string) error {
+	keys := []string{patientBedKey(orgID, patientID)}
+	return releaseBedScript.Run(ctx, r.client, keys, patientID, bedID, fmt.Sprintf("bed_occupant:%s:", orgID)).Err()
+}
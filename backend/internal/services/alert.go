@@ -2,7 +2,11 @@ package services
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/rs/zerolog/log"
@@ -11,24 +15,28 @@ import (
 	"praana/internal/utils"
 )
 
+var (
+	ErrAlertNotOpen      = errors.New("alert is no longer open")
+	ErrInvalidAlertQuery = errors.New("invalid alert query")
+	ErrAlertNotFound     = errors.New("alert not found")
+	ErrAssigneeNotFound  = errors.New("member not found")
+	ErrParentNotFound    = errors.New("parent comment not found")
+)
+
 type AlertService struct {
-	repo *repository.RedisRepo
-	hub  *WSHub
+	repo          *repository.RedisRepo
+	hub           *WSHub
+	careTeam      *CareTeamService
+	notifications *NotificationService
+	webhooks      *WebhookService
 }
 
-func NewAlertService(repo *repository.RedisRepo, hub *WSHub) *AlertService {
-	return &AlertService{repo: repo, hub: hub}
+func NewAlertService(repo *repository.RedisRepo, hub *WSHub, careTeam *CareTeamService, notifications *NotificationService, webhooks *WebhookService) *AlertService {
+	return &AlertService{repo: repo, hub: hub, careTeam: careTeam, notifications: notifications, webhooks: webhooks}
 }
 
 func (s *AlertService) CheckVitals(ctx context.Context, patient *models.Patient, vitals *models.Vitals) {
-	// Get per-patient thresholds first, fallback to org-wide
-	thresholds, err := s.repo.GetThresholds(ctx, fmt.Sprintf("thresholds:%s:%s", vitals.OrgID, vitals.PatientID))
-	if err != nil || thresholds == nil {
-		thresholds, err = s.repo.GetThresholds(ctx, fmt.Sprintf("thresholds:%s", vitals.OrgID))
-		if err != nil || thresholds == nil {
-			thresholds = &models.DefaultThresholds
-		}
-	}
+	thresholds := patientThresholds(ctx, s.repo, vitals.OrgID, vitals.PatientID)
 
 	checks := []struct {
 		name    string
@@ -58,6 +66,7 @@ func (s *AlertService) CheckVitals(ctx context.Context, patient *models.Patient,
 				OrgID:       vitals.OrgID,
 				PatientID:   vitals.PatientID,
 				PatientName: patient.Name,
+				EpisodeID:   vitals.EpisodeID,
 				VitalType:   check.name,
 				Value:       check.value,
 				Threshold:   check.high,
@@ -75,6 +84,7 @@ func (s *AlertService) CheckVitals(ctx context.Context, patient *models.Patient,
 				OrgID:       vitals.OrgID,
 				PatientID:   vitals.PatientID,
 				PatientName: patient.Name,
+				EpisodeID:   vitals.EpisodeID,
 				VitalType:   check.name,
 				Value:       check.value,
 				Threshold:   check.low,
@@ -88,6 +98,7 @@ func (s *AlertService) CheckVitals(ctx context.Context, patient *models.Patient,
 				OrgID:       vitals.OrgID,
 				PatientID:   vitals.PatientID,
 				PatientName: patient.Name,
+				EpisodeID:   vitals.EpisodeID,
 				VitalType:   check.name,
 				Value:       check.value,
 				Threshold:   check.low,
@@ -98,42 +109,340 @@ func (s *AlertService) CheckVitals(ctx context.Context, patient *models.Patient,
 		}
 
 		if alert != nil {
-			if err := s.repo.CreateAlert(ctx, alert); err != nil {
-				log.Error().Err(err).Msg("Failed to create alert")
-				continue
-			}
-			_ = s.repo.PublishAlert(ctx, vitals.OrgID, alert)
-			if s.hub != nil {
-				s.hub.BroadcastToOrg(vitals.OrgID, alert)
+			s.raise(ctx, patient, alert)
+		}
+	}
+}
+
+// RaiseObservationsOverdue alerts the care team that a patient's next set of
+// vitals is late.
+func (s *AlertService) RaiseObservationsOverdue(ctx context.Context, patient *models.Patient, due *models.ObservationDue) {
+	severity := models.SeverityWarning
+	if patient.Status == models.StatusCritical {
+		severity = models.SeverityCritical
+	}
+	s.raise(ctx, patient, &models.Alert{
+		ID:          utils.GenerateID(),
+		OrgID:       patient.OrgID,
+		PatientID:   patient.ID,
+		PatientName: patient.Name,
+		EpisodeID:   patient.ActiveEpisodeID,
+		VitalType:   "observations",
+		Value:       float64(due.OverdueMinutes),
+		Threshold:   float64(due.IntervalMinutes),
+		Severity:    severity,
+		Message:     fmt.Sprintf("%s: observations overdue by %d min (due every %d min)", patient.Name, due.OverdueMinutes, due.IntervalMinutes),
+		CreatedAt:   time.Now().Unix(),
+	})
+}
+
+// ResolveObservationsOverdue resolves the patient's overdue-observation
+// alerts raised since their previous vitals, now that a new set is in.
+func (s *AlertService) ResolveObservationsOverdue(ctx context.Context, patient *models.Patient, since int64) {
+	alerts, err := s.repo.GetPatientAlertsSince(ctx, patient.OrgID, patient.ID, since)
+	if err != nil {
+		log.Error().Err(err).Str("patient", patient.ID).Msg("Failed to load overdue observation alerts")
+		return
+	}
+	for _, a := range alerts {
+		if a.VitalType != "observations" || a.State == models.AlertResolved {
+			continue
+		}
+		resolved, err := s.repo.TransitionAlert(ctx, patient.OrgID, a.ID, func(a *models.Alert) error {
+			if a.State == models.AlertResolved {
+				return ErrAlertNotOpen
 			}
-			// Update stats
-			s.repo.IncrStat(ctx, vitals.OrgID, time.Now().Format("2006-01-02"), "alerts_triggered", 1)
-			log.Warn().Str("alert", alert.Message).Msg("Alert triggered")
+			a.State = models.AlertResolved
+			a.ResolvedAt = time.Now().Unix()
+			a.Resolution = "Observations recorded"
+			return nil
+		})
+		if err != nil || resolved == nil {
+			continue
 		}
+		s.emit(ctx, models.EventAlertResolved, resolved)
 	}
 }
 
-func (s *AlertService) GetActive(ctx context.Context, orgID string) ([]models.Alert, error) {
-	return s.repo.GetActiveAlerts(ctx, orgID)
+// raise records an alert and notifies the care team. A breach of a muted
+// vital is recorded as already resolved and suppressed, and nobody is notified.
+func (s *AlertService) raise(ctx context.Context, patient *models.Patient, alert *models.Alert) {
+	if mute := s.activeMute(ctx, alert); mute != nil {
+		alert.Suppressed = true
+		alert.MuteID = mute.ID
+		alert.State = models.AlertResolved
+		alert.ResolvedAt = alert.CreatedAt
+		alert.Resolution = "Muted: " + mute.Reason
+		if err := s.repo.CreateAlert(ctx, alert); err != nil {
+			log.Error().Err(err).Msg("Failed to record suppressed alert")
+			return
+		}
+		log.Info().Str("alert", alert.Message).Str("mute", mute.ID).Msg("Alert suppressed")
+		return
+	}
+
+	if err := s.repo.CreateAlert(ctx, alert); err != nil {
+		log.Error().Err(err).Msg("Failed to create alert")
+		return
+	}
+	_ = s.repo.PublishAlert(ctx, alert.OrgID, alert)
+	s.broadcast(ctx, patient, alert)
+	if s.notifications != nil {
+		s.notifications.AlertRaised(ctx, patient, alert)
+	}
+	s.emit(ctx, models.EventAlertRaised, alert)
+	// Update stats
+	s.repo.IncrStat(ctx, alert.OrgID, clockFor(ctx, s.repo, alert.OrgID).Date(time.Now()), "alerts_triggered", 1)
+	log.Warn().Str("alert", alert.Message).Msg("Alert triggered")
 }
 
-func (s *AlertService) Acknowledge(ctx context.Context, orgID, alertID, userID string) error {
-	alert, err := s.repo.GetAlert(ctx, orgID, alertID)
-	if err != nil || alert == nil {
-		return fmt.Errorf("alert not found")
+func (s *AlertService) activeMute(ctx context.Context, alert *models.Alert) *models.AlertMute {
+	mutes, err := s.repo.GetActiveMutes(ctx, alert.OrgID, alert.PatientID)
+	if err != nil {
+		return nil
 	}
-	alert.Acknowledged = true
-	alert.AcknowledgedBy = userID
-	alert.AcknowledgedAt = time.Now().Unix()
-	if err := s.repo.UpdateAlert(ctx, alert); err != nil {
+	for i := range mutes {
+		if mutes[i].VitalType == alert.VitalType {
+			return &mutes[i]
+		}
+	}
+	return nil
+}
+
+// patientThresholds returns the patient's own thresholds, falling back to the
+// org-wide ones and then the defaults.
+func patientThresholds(ctx context.Context, repo *repository.RedisRepo, orgID, patientID string) *models.Threshold {
+	thresholds, err := repo.GetThresholds(ctx, fmt.Sprintf("thresholds:%s:%s", orgID, patientID))
+	if err != nil || thresholds == nil {
+		thresholds, err = repo.GetThresholds(ctx, fmt.Sprintf("thresholds:%s", orgID))
+		if err != nil || thresholds == nil {
+			thresholds = &models.DefaultThresholds
+		}
+	}
+	return thresholds
+}
+
+// broadcast pushes an alert over WebSocket according to the org's alert
+// audience: the whole org, the patient's care team first, or the care team only.
+func (s *AlertService) broadcast(ctx context.Context, patient *models.Patient, alert *models.Alert) {
+	if s.hub == nil {
+		return
+	}
+	org, _ := s.repo.GetOrg(ctx, alert.OrgID)
+	if org == nil || s.careTeam == nil || org.AlertAudience == "" || org.AlertAudience == models.AlertAudienceOrg {
+		s.hub.BroadcastToOrg(alert.OrgID, alert)
+		return
+	}
+	users, err := s.careTeam.AssignedUserIDs(ctx, patient)
+	if err != nil || len(users) == 0 {
+		// Nobody is assigned: never let an alert go unseen.
+		s.hub.BroadcastToOrg(alert.OrgID, alert)
+		return
+	}
+	s.hub.BroadcastToUsers(alert.OrgID, users, alert, org.AlertAudience == models.AlertAudienceAssignedFirst)
+}
+
+// GetActive returns open alerts, newest first, limited to the given patients
+// when patientIDs is non-nil.
+func (s *AlertService) GetActive(ctx context.Context, orgID string, patientIDs map[string]bool) ([]models.Alert, error) {
+	if patientIDs == nil {
+		return s.repo.GetActiveAlerts(ctx, orgID)
+	}
+	var alerts []models.Alert
+	for patientID := range patientIDs {
+		active, err := s.repo.GetPatientActiveAlerts(ctx, orgID, patientID)
+		if err != nil {
+			return nil, err
+		}
+		alerts = append(alerts, active...)
+	}
+	sort.Slice(alerts, func(i, j int) bool { return alerts[i].CreatedAt > alerts[j].CreatedAt })
+	return alerts, nil
+}
+
+func (s *AlertService) Acknowledge(ctx context.Context, orgID, alertID, userID string, req *models.AcknowledgeAlertRequest) error {
+	alert, err := s.repo.TransitionAlert(ctx, orgID, alertID, func(a *models.Alert) error {
+		if a.State != models.AlertOpen {
+			return fmt.Errorf("%w (%s)", ErrAlertNotOpen, a.State)
+		}
+		a.Acknowledged = true
+		a.State = models.AlertAcknowledged
+		a.AcknowledgedBy = userID
+		a.AcknowledgedAt = time.Now().Unix()
+		a.AckReason = req.Reason
+		a.AckAction = req.Action
+		return nil
+	})
+	if err != nil {
 		return err
 	}
-	s.repo.IncrStat(ctx, orgID, time.Now().Format("2006-01-02"), "alerts_acked", 1)
+	if alert == nil {
+		return ErrAlertNotFound
+	}
+	s.repo.IncrStat(ctx, orgID, clockFor(ctx, s.repo, orgID).Date(time.Now()), "alerts_acked", 1)
+	s.emit(ctx, models.EventAlertAcknowledged, alert)
 	return nil
 }
 
-func (s *AlertService) GetHistory(ctx context.Context, orgID string) ([]models.Alert, error) {
-	return s.repo.GetAlertHistory(ctx, orgID, 100)
+// Get returns an alert with its comment thread.
+func (s *AlertService) Get(ctx context.Context, orgID, alertID string) (*models.AlertDetail, error) {
+	alert, err := s.repo.GetAlert(ctx, orgID, alertID)
+	if err != nil {
+		return nil, err
+	}
+	if alert == nil {
+		return nil, ErrAlertNotFound
+	}
+	comments, err := s.repo.GetAlertComments(ctx, orgID, alertID)
+	if err != nil {
+		return nil, err
+	}
+	return &models.AlertDetail{Alert: *alert, Comments: comments}, nil
+}
+
+// Assign hands an unresolved alert to a member and notifies them directly.
+func (s *AlertService) Assign(ctx context.Context, orgID, alertID, assignedBy string, req *models.AssignAlertRequest) (*models.Alert, error) {
+	user, err := s.repo.GetUser(ctx, req.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil || user.OrgID != orgID {
+		return nil, ErrAssigneeNotFound
+	}
+	alert, err := s.repo.TransitionAlert(ctx, orgID, alertID, func(a *models.Alert) error {
+		if a.State == models.AlertResolved {
+			return fmt.Errorf("%w (%s)", ErrAlertNotOpen, a.State)
+		}
+		a.AssignedTo = user.ID
+		a.AssignedBy = assignedBy
+		a.AssignedAt = time.Now().Unix()
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if alert == nil {
+		return nil, ErrAlertNotFound
+	}
+	if s.hub != nil {
+		s.hub.BroadcastToUsers(orgID, map[string]bool{user.ID: true}, alert, false)
+	}
+	return alert, nil
+}
+
+// Comment adds a comment to an alert's thread, optionally as a reply.
+func (s *AlertService) Comment(ctx context.Context, orgID, alertID, userID string, req *models.AlertCommentRequest) (*models.AlertComment, error) {
+	author, err := s.repo.GetUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if author == nil {
+		return nil, fmt.Errorf("user not found")
+	}
+	if req.ParentID != "" {
+		comments, err := s.repo.GetAlertComments(ctx, orgID, alertID)
+		if err != nil {
+			return nil, err
+		}
+		found := false
+		for _, c := range comments {
+			if c.ID == req.ParentID {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, ErrParentNotFound
+		}
+	}
+
+	comment := &models.AlertComment{
+		ID:         utils.GenerateID(),
+		AlertID:    alertID,
+		ParentID:   req.ParentID,
+		AuthorID:   author.ID,
+		AuthorName: author.Name,
+		Body:       req.Body,
+		CreatedAt:  time.Now().Unix(),
+	}
+	alert, err := s.repo.AddAlertComment(ctx, orgID, comment)
+	if err != nil {
+		return nil, err
+	}
+	if alert == nil {
+		return nil, ErrAlertNotFound
+	}
+	return comment, nil
+}
+
+// Resolve closes an open or acknowledged alert.
+func (s *AlertService) Resolve(ctx context.Context, orgID, alertID, userID string, req *models.ResolveAlertRequest) (*models.Alert, error) {
+	alert, err := s.repo.TransitionAlert(ctx, orgID, alertID, func(a *models.Alert) error {
+		if a.State == models.AlertResolved {
+			return fmt.Errorf("%w (%s)", ErrAlertNotOpen, a.State)
+		}
+		a.State = models.AlertResolved
+		a.ResolvedBy = userID
+		a.ResolvedAt = time.Now().Unix()
+		a.Resolution = req.Resolution
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if alert == nil {
+		return nil, ErrAlertNotFound
+	}
+	s.emit(ctx, models.EventAlertResolved, alert)
+	return alert, nil
+}
+
+func (s *AlertService) emit(ctx context.Context, eventType string, alert *models.Alert) {
+	if s.webhooks == nil {
+		return
+	}
+	s.webhooks.Emit(ctx, alert.OrgID, eventType, alert)
+}
+
+// BackfillIndexes indexes alerts written before the alert indexes existed.
+// It is safe to run on every start; orgs already done are skipped.
+func (s *AlertService) BackfillIndexes(ctx context.Context) {
+	orgIDs, err := s.repo.GetOrgIDs(ctx)
+	if err != nil {
+		log.Error().Err(err).Msg("Alert index backfill: failed to list orgs")
+		return
+	}
+	for _, orgID := range orgIDs {
+		if err := s.repo.BackfillAlertIndexes(ctx, orgID); err != nil {
+			log.Error().Err(err).Str("org_id", orgID).Msg("Alert index backfill failed")
+		}
+	}
+}
+
+// GetHistory pages through alerts matching q, newest first, with their
+// current state. Results are limited to the given patients when patientIDs
+// is non-nil.
+func (s *AlertService) GetHistory(ctx context.Context, orgID string, q *models.AlertQuery, patientIDs map[string]bool) (*models.AlertPage, error) {
+	if q.Limit <= 0 {
+		q.Limit = 50
+	}
+	if q.Limit > 200 {
+		q.Limit = 200
+	}
+	if q.From > 0 && q.To > 0 && q.From > q.To {
+		return nil, fmt.Errorf("%w: from must be before to", ErrInvalidAlertQuery)
+	}
+	if q.Cursor != "" {
+		parts := strings.SplitN(q.Cursor, ":", 2)
+		if _, err := strconv.ParseInt(parts[0], 10, 64); len(parts) != 2 || err != nil {
+			return nil, fmt.Errorf("%w: invalid cursor", ErrInvalidAlertQuery)
+		}
+	}
+	var keep func(*models.Alert) bool
+	if patientIDs != nil {
+		keep = func(a *models.Alert) bool { return patientIDs[a.PatientID] }
+	}
+	return s.repo.QueryAlerts(ctx, orgID, q, keep)
 }
 
 func (s *AlertService) SetOrgThresholds(ctx context.Context, orgID string, req *models.SetThresholdRequest) (*models.Threshold, error) {
@@ -0,0 +1,44 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"praana/internal/models"
+	"praana/internal/repository"
+	"praana/internal/utils"
+)
+
+type AuditService struct {
+	repo *repository.RedisRepo
+}
+
+func NewAuditService(repo *repository.RedisRepo) *AuditService {
+	return &AuditService{repo: repo}
+}
+
+// Record appends an entry to the org's audit log. Failures are logged rather
+// than returned so auditing never blocks the action being audited.
+func (s *AuditService) Record(ctx context.Context, orgID, actorID, action, targetType, targetID string, details map[string]string) {
+	entry := &models.AuditEntry{
+		ID:         utils.GenerateID(),
+		OrgID:      orgID,
+		ActorID:    actorID,
+		Action:     action,
+		TargetType: targetType,
+		TargetID:   targetID,
+		Details:    details,
+		CreatedAt:  time.Now().Unix(),
+	}
+	if err := s.repo.AppendAudit(ctx, entry); err != nil {
+		log.Error().Err(err).Str("action", action).Msg("Failed to write audit entry")
+	}
+}
+
+func (s *AuditService) List(ctx context.Context, orgID string, limit int64) ([]models.AuditEntry, error) {
+	if limit <= 0 || limit > 500 {
+		limit = 100
+	}
+	return s.repo.GetAuditLog(ctx, orgID, limit)
+}
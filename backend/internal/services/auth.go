@@ -2,7 +2,11 @@ package services
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
@@ -13,30 +17,58 @@ import (
 	"praana/internal/utils"
 )
 
+// passwordResetTTL is how long a password reset link stays valid.
+const passwordResetTTL = time.Hour
+
+// refreshRaceGrace is how long after a rotation the old refresh token is
+// treated as a concurrent refresh (e.g. two tabs) rather than a replay.
+const refreshRaceGrace = 30 * time.Second
+
+var (
+	ErrInvalidRefreshToken = errors.New("invalid or expired refresh token")
+	ErrRefreshRaced        = errors.New("refresh token was just rotated; retry with the new token")
+	ErrSessionNotFound     = errors.New("session not found")
+	ErrInvalidCredentials  = errors.New("invalid email or password")
+)
+
 type AuthService struct {
-	repo      *repository.RedisRepo
-	jwtSecret string
-	jwtExpiry time.Duration
+	repo        *repository.RedisRepo
+	mail        *MailService
+	audit       *AuditService
+	hub         *WSHub
+	jwtSecret   string
+	jwtExpiry   time.Duration
+	idleTimeout time.Duration
+	maxAge      time.Duration
 }
 
+// Claims.SessionID ties an access token to the session it was issued from;
+// the token is only accepted while that session exists. MFASetup marks a
+// token that may only reach /api/auth until the user turns on the 2FA their
+// org requires.
 type Claims struct {
-	UserID string      `json:"user_id"`
-	Email  string      `json:"email"`
-	Name   string      `json:"name"`
-	OrgID  string      `json:"org_id"`
-	Role   models.Role `json:"role"`
+	UserID    string      `json:"user_id"`
+	Email     string      `json:"email"`
+	Name      string      `json:"name"`
+	OrgID     string      `json:"org_id"`
+	Role      models.Role `json:"role"`
+	SessionID string      `json:"sid"`
+	MFASetup  bool        `json:"mfa_setup,omitempty"`
 	jwt.RegisteredClaims
 }
 
-func NewAuthService(repo *repository.RedisRepo, jwtSecret string, jwtExpiry time.Duration) *AuthService {
-	return &AuthService{repo: repo, jwtSecret: jwtSecret, jwtExpiry: jwtExpiry}
+func NewAuthService(repo *repository.RedisRepo, mail *MailService, audit *AuditService, hub *WSHub, jwtSecret string, jwtExpiry, idleTimeout, maxAge time.Duration) *AuthService {
+	return &AuthService{repo: repo, mail: mail, audit: audit, hub: hub, jwtSecret: jwtSecret, jwtExpiry: jwtExpiry, idleTimeout: idleTimeout, maxAge: maxAge}
 }
 
-func (s *AuthService) Signup(ctx context.Context, req *models.SignupRequest) (*models.LoginResponse, error) {
+func (s *AuthService) Signup(ctx context.Context, req *models.SignupRequest, client models.ClientInfo) (*models.LoginResponse, error) {
 	existing, _ := s.repo.GetUserByEmail(ctx, req.Email)
 	if existing != nil {
 		return nil, fmt.Errorf("email already registered")
 	}
+	if err := utils.CheckPassword(req.Password, req.Email); err != nil {
+		return nil, err
+	}
 
 	hashedPwd, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
 	if err != nil {
@@ -69,35 +101,236 @@ func (s *AuthService) Signup(ctx context.Context, req *models.SignupRequest) (*m
 		return nil, fmt.Errorf("failed to create user: %w", err)
 	}
 
-	token, err := s.generateToken(user)
+	resp, err := s.startSession(ctx, user, client)
 	if err != nil {
 		return nil, err
 	}
 
 	log.Info().Str("email", user.Email).Str("org", org.Name).Msg("New signup")
-	return &models.LoginResponse{Token: token, User: *user}, nil
+	return resp, nil
 }
 
-func (s *AuthService) Login(ctx context.Context, req *models.LoginRequest) (*models.LoginResponse, error) {
+// Login checks the password, throttling repeated failures per account and
+// per IP. Unknown emails get the same errors, delays and timing as wrong
+// passwords.
+func (s *AuthService) Login(ctx context.Context, req *models.LoginRequest, client models.ClientInfo) (*models.LoginResponse, error) {
+	if err := s.checkLoginAllowed(ctx, req.Email, client.IP); err != nil {
+		return nil, err
+	}
+
 	user, err := s.repo.GetUserByEmail(ctx, req.Email)
-	if err != nil || user == nil {
-		return nil, fmt.Errorf("invalid email or password")
+	if err != nil {
+		return nil, fmt.Errorf("failed to load user: %w", err)
+	}
+	hash := dummyPasswordHash
+	if user != nil {
+		hash = []byte(user.Password)
+	}
+	if err := bcrypt.CompareHashAndPassword(hash, []byte(req.Password)); err != nil || user == nil {
+		s.loginFailed(ctx, req.Email, client.IP, user)
+		return nil, ErrInvalidCredentials
 	}
 
-	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.Password)); err != nil {
-		return nil, fmt.Errorf("invalid email or password")
+	if user.TOTPEnabled {
+		return s.beginMFAChallenge(ctx, user)
 	}
+	s.loginSucceeded(ctx, user.Email)
+	return s.startSession(ctx, user, client)
+}
+
+func (s *AuthService) Logout(ctx context.Context, userID, sessionID string) error {
+	return s.endSession(ctx, userID, sessionID)
+}
 
-	token, err := s.generateToken(user)
+// endSession deletes a session and closes the WebSocket connections opened
+// with it.
+func (s *AuthService) endSession(ctx context.Context, userID, sessionID string) error {
+	if err := s.repo.DeleteSession(ctx, userID, sessionID); err != nil {
+		return err
+	}
+	s.hub.DisconnectSession(sessionID)
+	return nil
+}
+
+// Refresh exchanges a refresh token for a new access and refresh token pair
+// and slides the session's idle timeout. Each refresh token works once:
+// replaying an old one ends the session, since it means the token leaked.
+func (s *AuthService) Refresh(ctx context.Context, req *models.RefreshRequest, client models.ClientInfo) (*models.LoginResponse, error) {
+	sessionID, secret, ok := strings.Cut(req.RefreshToken, ".")
+	if !ok || sessionID == "" || secret == "" {
+		return nil, ErrInvalidRefreshToken
+	}
+	session, err := s.repo.GetSession(ctx, sessionID)
 	if err != nil {
 		return nil, err
 	}
+	if session == nil {
+		return nil, ErrInvalidRefreshToken
+	}
+	user, err := s.repo.GetUser(ctx, session.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil || user.OrgID != session.OrgID {
+		_ = s.endSession(ctx, session.UserID, session.ID)
+		return nil, ErrInvalidRefreshToken
+	}
+
+	ttl := s.sessionTTL(ctx, session)
+	if ttl <= 0 {
+		_ = s.endSession(ctx, session.UserID, session.ID)
+		return nil, ErrInvalidRefreshToken
+	}
+	newSecret := randomSecret()
+	outcome, err := s.repo.RotateRefreshToken(ctx, session.ID, hashRefreshSecret(secret), hashRefreshSecret(newSecret), refreshRaceGrace, ttl)
+	if err != nil {
+		return nil, err
+	}
+	switch outcome {
+	case repository.RefreshRotated:
+	case repository.RefreshRaced:
+		return nil, ErrRefreshRaced
+	case repository.RefreshReused:
+		_ = s.endSession(ctx, session.UserID, session.ID)
+		log.Warn().Str("user", user.ID).Str("session", session.ID).Msg("Refresh token reused; session revoked")
+		return nil, ErrInvalidRefreshToken
+	default:
+		return nil, ErrInvalidRefreshToken
+	}
+	session.UserAgent, session.IP = clientUserAgent(client), client.IP
+	session.LastSeenAt = time.Now().Unix()
+	if err := s.repo.TouchSession(ctx, session); err != nil {
+		log.Warn().Err(err).Str("session", session.ID).Msg("Failed to update session details")
+	}
+	return s.issue(ctx, user, session, newSecret)
+}
+
+// Sessions lists the user's active sessions, marking currentSessionID.
+func (s *AuthService) Sessions(ctx context.Context, userID, currentSessionID string) ([]models.Session, error) {
+	sessions, err := s.repo.GetUserSessions(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if sessions == nil {
+		sessions = []models.Session{}
+	}
+	for i := range sessions {
+		sessions[i].Current = sessions[i].ID == currentSessionID
+	}
+	return sessions, nil
+}
+
+// RevokeSession signs out one of the user's sessions.
+func (s *AuthService) RevokeSession(ctx context.Context, userID, sessionID string) error {
+	session, err := s.repo.GetSession(ctx, sessionID)
+	if err != nil {
+		return err
+	}
+	if session == nil || session.UserID != userID {
+		return ErrSessionNotFound
+	}
+	return s.endSession(ctx, userID, sessionID)
+}
+
+// RevokeOtherSessions signs the user out everywhere but keepSessionID
+// (everywhere, if it is empty) and returns how many sessions ended.
+func (s *AuthService) RevokeOtherSessions(ctx context.Context, userID, keepSessionID string) (int, error) {
+	revoked, err := s.repo.RevokeUserSessions(ctx, userID, keepSessionID)
+	if err != nil {
+		return 0, err
+	}
+	s.hub.DisconnectUser(userID, keepSessionID)
+	return revoked, nil
+}
 
-	return &models.LoginResponse{Token: token, User: *user}, nil
+// ChangePassword sets a new password after checking the current one, then
+// signs the user out everywhere except the session making the change.
+func (s *AuthService) ChangePassword(ctx context.Context, userID, sessionID string, req *models.ChangePasswordRequest) error {
+	user, err := s.repo.GetUser(ctx, userID)
+	if err != nil || user == nil {
+		return fmt.Errorf("user not found")
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.CurrentPassword)); err != nil {
+		return fmt.Errorf("current password is incorrect")
+	}
+	if req.NewPassword == req.CurrentPassword {
+		return fmt.Errorf("new password must be different from the current one")
+	}
+	return s.setPassword(ctx, user, req.NewPassword, sessionID)
 }
 
-func (s *AuthService) Logout(ctx context.Context, jwtID string) error {
-	return s.repo.DeleteSession(ctx, jwtID)
+// passwordResetSendTimeout bounds the background send of a reset email.
+const passwordResetSendTimeout = time.Minute
+
+// ForgotPassword emails a single-use reset link if the email belongs to a
+// user. It never reports whether it does: the link is stored and sent in the
+// background, so the response takes as long either way.
+func (s *AuthService) ForgotPassword(ctx context.Context, req *models.ForgotPasswordRequest) {
+	user, err := s.repo.GetUserByEmail(ctx, req.Email)
+	if err != nil || user == nil {
+		return
+	}
+	go func() {
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), passwordResetSendTimeout)
+		defer cancel()
+		token := utils.GenerateUUID()
+		if err := s.repo.SavePasswordReset(ctx, token, user.ID, passwordResetTTL); err != nil {
+			log.Error().Err(err).Str("user", user.ID).Msg("Failed to store password reset")
+			return
+		}
+		if err := s.mail.SendPasswordReset(ctx, user, token, passwordResetTTL); err != nil {
+			log.Error().Err(err).Str("user", user.ID).Msg("Failed to send password reset email")
+			return
+		}
+		log.Info().Str("user", user.ID).Msg("Password reset requested")
+	}()
+}
+
+// ResetPassword sets a new password from a reset link. The link works once,
+// and every existing session is signed out.
+func (s *AuthService) ResetPassword(ctx context.Context, req *models.ResetPasswordRequest) error {
+	token, err := s.mail.verify(linkPasswordReset, req.Code)
+	if err != nil {
+		return fmt.Errorf("invalid or expired reset link")
+	}
+	userID, err := s.repo.ConsumePasswordReset(ctx, token)
+	if err != nil {
+		return err
+	}
+	user, _ := s.repo.GetUser(ctx, userID)
+	if userID == "" || user == nil {
+		return fmt.Errorf("invalid or expired reset link")
+	}
+	if err := s.setPassword(ctx, user, req.Password, ""); err != nil {
+		return err
+	}
+	// Proving control of the email is enough to lift a lockout.
+	s.loginSucceeded(ctx, user.Email)
+	return nil
+}
+
+// setPassword stores a new password hash and revokes the user's sessions
+// other than keepSessionID.
+func (s *AuthService) setPassword(ctx context.Context, user *models.User, password, keepSessionID string) error {
+	if err := utils.CheckPassword(password, user.Email); err != nil {
+		return err
+	}
+	hashedPwd, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return fmt.Errorf("failed to hash password: %w", err)
+	}
+	user.Password = string(hashedPwd)
+	user.PasswordChangedAt = time.Now().Unix()
+	if err := s.repo.UpdateUser(ctx, user); err != nil {
+		return err
+	}
+	revoked, err := s.repo.RevokeUserSessions(ctx, user.ID, keepSessionID)
+	if err != nil {
+		return err
+	}
+	s.hub.DisconnectUser(user.ID, keepSessionID)
+	log.Info().Str("user", user.ID).Int("sessions_revoked", revoked).Msg("Password changed")
+	return nil
 }
 
 func (s *AuthService) ValidateToken(tokenStr string) (*Claims, error) {
@@ -117,39 +350,93 @@ func (s *AuthService) ValidateToken(tokenStr string) (*Claims, error) {
 
 	// Check session exists
 	ctx := context.Background()
-	_, err = s.repo.GetSession(ctx, claims.ID)
-	if err != nil {
+	session, err := s.repo.GetSession(ctx, claims.SessionID)
+	if err != nil || session == nil || session.UserID != claims.UserID {
 		return nil, fmt.Errorf("session expired")
 	}
 
 	return claims, nil
 }
 
-func (s *AuthService) generateToken(user *models.User) (string, error) {
-	jwtID := utils.GenerateUUID()
+// startSession signs the user in with a new session.
+func (s *AuthService) startSession(ctx context.Context, user *models.User, client models.ClientInfo) (*models.LoginResponse, error) {
+	now := time.Now()
+	session := &models.Session{
+		ID:         utils.GenerateUUID(),
+		UserID:     user.ID,
+		OrgID:      user.OrgID,
+		UserAgent:  clientUserAgent(client),
+		IP:         client.IP,
+		CreatedAt:  now.Unix(),
+		LastSeenAt: now.Unix(),
+		ExpiresAt:  now.Add(s.maxAge).Unix(),
+	}
+	secret := randomSecret()
+	if err := s.repo.CreateSession(ctx, session, hashRefreshSecret(secret), s.sessionTTL(ctx, session)); err != nil {
+		return nil, err
+	}
+	return s.issue(ctx, user, session, secret)
+}
+
+// sessionTTL is how long the session may now go unrefreshed: the org's idle
+// timeout, cut short by the session's absolute expiry.
+func (s *AuthService) sessionTTL(ctx context.Context, session *models.Session) time.Duration {
+	ttl := s.idleTimeout
+	if org, _ := s.repo.GetOrg(ctx, session.OrgID); org != nil && org.IdleTimeoutMinutes > 0 {
+		ttl = time.Duration(org.IdleTimeoutMinutes) * time.Minute
+	}
+	if remaining := time.Until(time.Unix(session.ExpiresAt, 0)); remaining < ttl {
+		ttl = remaining.Truncate(time.Second)
+	}
+	return ttl
+}
+
+// issue signs an access token for the session and pairs it with the refresh
+// token "<session id>.<secret>".
+func (s *AuthService) issue(ctx context.Context, user *models.User, session *models.Session, refreshSecret string) (*models.LoginResponse, error) {
+	now := time.Now()
+	expiresAt := now.Add(s.jwtExpiry)
 	claims := &Claims{
-		UserID: user.ID,
-		Email:  user.Email,
-		Name:   user.Name,
-		OrgID:  user.OrgID,
-		Role:   user.Role,
+		UserID:    user.ID,
+		Email:     user.Email,
+		Name:      user.Name,
+		OrgID:     user.OrgID,
+		Role:      user.Role,
+		SessionID: session.ID,
+		MFASetup:  !user.TOTPEnabled && s.orgRequires2FA(ctx, user.OrgID),
 		RegisteredClaims: jwt.RegisteredClaims{
-			ID:        jwtID,
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(s.jwtExpiry)),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			ID:        utils.GenerateUUID(),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+			IssuedAt:  jwt.NewNumericDate(now),
 		},
 	}
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 	tokenStr, err := token.SignedString([]byte(s.jwtSecret))
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
-	ctx := context.Background()
-	if err := s.repo.CreateSession(ctx, jwtID, user.ID, s.jwtExpiry); err != nil {
-		return "", err
+	return &models.LoginResponse{
+		Token:            tokenStr,
+		RefreshToken:     session.ID + "." + refreshSecret,
+		ExpiresAt:        expiresAt.Unix(),
+		User:             user,
+		MFASetupRequired: claims.MFASetup,
+	}, nil
+}
+
+// clientUserAgent caps the stored user agent, which the client controls.
+func clientUserAgent(client models.ClientInfo) string {
+	if len(client.UserAgent) > 256 {
+		return client.UserAgent[:256]
 	}
+	return client.UserAgent
+}
 
-	return tokenStr, nil
+// hashRefreshSecret is how refresh tokens are stored, so a Redis dump
+// doesn't hand out usable tokens.
+func hashRefreshSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
 }
@@ -0,0 +1,156 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"praana/internal/models"
+	"praana/internal/repository"
+	"praana/internal/utils"
+)
+
+type CareTeamService struct {
+	repo *repository.RedisRepo
+}
+
+func NewCareTeamService(repo *repository.RedisRepo) *CareTeamService {
+	return &CareTeamService{repo: repo}
+}
+
+func (s *CareTeamService) Assign(ctx context.Context, orgID, createdBy string, req *models.CreateAssignmentRequest) (*models.CareAssignment, error) {
+	user, err := s.repo.GetUser(ctx, req.UserID)
+	if err != nil || user == nil || user.OrgID != orgID {
+		return nil, fmt.Errorf("member not found")
+	}
+	if req.PatientID != "" {
+		p, err := s.repo.GetPatient(ctx, orgID, req.PatientID)
+		if err != nil || p == nil {
+			return nil, fmt.Errorf("patient not found")
+		}
+	}
+	if req.WardID != "" {
+		w, err := s.repo.GetWard(ctx, orgID, req.WardID)
+		if err != nil || w == nil {
+			return nil, fmt.Errorf("ward not found")
+		}
+	}
+	if req.ShiftEnd <= time.Now().Unix() {
+		return nil, fmt.Errorf("shift has already ended")
+	}
+
+	a := &models.CareAssignment{
+		ID:         utils.GenerateID(),
+		OrgID:      orgID,
+		UserID:     user.ID,
+		UserName:   user.Name,
+		Role:       user.Role,
+		PatientID:  req.PatientID,
+		WardID:     req.WardID,
+		ShiftStart: req.ShiftStart,
+		ShiftEnd:   req.ShiftEnd,
+		CreatedBy:  createdBy,
+		CreatedAt:  time.Now().Unix(),
+	}
+	if err := s.repo.CreateAssignment(ctx, a); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// List returns current and upcoming assignments, optionally for a single user.
+func (s *CareTeamService) List(ctx context.Context, orgID, userID string) ([]models.CareAssignment, error) {
+	now := time.Now().Unix()
+	if userID != "" {
+		return s.repo.GetUserAssignments(ctx, orgID, userID, now)
+	}
+	return s.repo.GetOrgAssignments(ctx, orgID, now)
+}
+
+func (s *CareTeamService) Remove(ctx context.Context, orgID, assignmentID string) error {
+	a, err := s.repo.GetAssignment(ctx, orgID, assignmentID)
+	if err != nil || a == nil {
+		return fmt.Errorf("assignment not found")
+	}
+	return s.repo.DeleteAssignment(ctx, a)
+}
+
+// AssignedPatientIDs returns the patients a user is responsible for right now,
+// either directly or through a ward assignment.
+func (s *CareTeamService) AssignedPatientIDs(ctx context.Context, orgID, userID string) (map[string]bool, error) {
+	assignments, err := s.repo.GetUserAssignments(ctx, orgID, userID, time.Now().Unix())
+	if err != nil {
+		return nil, err
+	}
+	ids := make(map[string]bool)
+	wards := make(map[string]bool)
+	for _, a := range current(assignments) {
+		if a.PatientID != "" {
+			ids[a.PatientID] = true
+		}
+		if a.WardID != "" {
+			wards[a.WardID] = true
+		}
+	}
+	if len(wards) > 0 {
+		patients, err := s.repo.GetPatients(ctx, orgID)
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range patients {
+			if p.WardID != "" && wards[p.WardID] {
+				ids[p.ID] = true
+			}
+		}
+	}
+	return ids, nil
+}
+
+func (s *CareTeamService) MyPatients(ctx context.Context, orgID, userID string) ([]models.Patient, error) {
+	ids, err := s.AssignedPatientIDs(ctx, orgID, userID)
+	if err != nil {
+		return nil, err
+	}
+	var patients []models.Patient
+	for id := range ids {
+		p, err := s.repo.GetPatient(ctx, orgID, id)
+		if err != nil || p == nil {
+			continue
+		}
+		patients = append(patients, *p)
+	}
+	return patients, nil
+}
+
+// AssignedUserIDs returns the users currently on the patient's care team.
+func (s *CareTeamService) AssignedUserIDs(ctx context.Context, patient *models.Patient) (map[string]bool, error) {
+	now := time.Now().Unix()
+	assignments, err := s.repo.GetPatientAssignments(ctx, patient.OrgID, patient.ID, now)
+	if err != nil {
+		return nil, err
+	}
+	if patient.WardID != "" {
+		wardAssignments, err := s.repo.GetWardAssignments(ctx, patient.OrgID, patient.WardID, now)
+		if err != nil {
+			return nil, err
+		}
+		assignments = append(assignments, wardAssignments...)
+	}
+	users := make(map[string]bool)
+	for _, a := range current(assignments) {
+		users[a.UserID] = true
+	}
+	return users, nil
+}
+
+// current filters out assignments whose shift has not started yet.
+func current(assignments []models.CareAssignment) []models.CareAssignment {
+	now := time.Now().Unix()
+	var out []models.CareAssignment
+	for _, a := range assignments {
+		if a.ShiftStart <= now && now < a.ShiftEnd {
+			out = append(out, a)
+		}
+	}
+	return out
+}
@@ -0,0 +1,108 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"praana/internal/models"
+	"praana/internal/repository"
+)
+
+// orgClock answers "what day / which shift is it" in an org's own timezone
+// and shift pattern. Daily stats keys, usage months and shift windows all go
+// through it so sites in different zones bucket consistently.
+type orgClock struct {
+	loc    *time.Location
+	starts []time.Duration // offsets from local midnight, ascending
+}
+
+// clockFor loads the org's clock. An org without settings (or one that can't
+// be loaded) gets the server's local time and the default shift pattern.
+func clockFor(ctx context.Context, repo *repository.RedisRepo, orgID string) *orgClock {
+	org, _ := repo.GetOrg(ctx, orgID)
+	if org == nil {
+		c, _ := newOrgClock("", nil)
+		return c
+	}
+	c, err := newOrgClock(org.Timezone, org.ShiftStarts)
+	if err != nil {
+		c, _ = newOrgClock("", nil)
+	}
+	return c
+}
+
+func newOrgClock(timezone string, shiftStarts []string) (*orgClock, error) {
+	loc := time.Local
+	if timezone != "" {
+		var err error
+		if loc, err = time.LoadLocation(timezone); err != nil {
+			return nil, fmt.Errorf("unknown timezone %q", timezone)
+		}
+	}
+	starts, err := parseShiftStarts(shiftStarts)
+	if err != nil {
+		return nil, err
+	}
+	return &orgClock{loc: loc, starts: starts}, nil
+}
+
+// parseShiftStarts turns "HH:MM" strings into sorted offsets from midnight,
+// rejecting duplicates. An empty pattern means the default.
+func parseShiftStarts(shiftStarts []string) ([]time.Duration, error) {
+	if len(shiftStarts) == 0 {
+		shiftStarts = models.DefaultShiftStarts
+	}
+	seen := make(map[time.Duration]bool)
+	starts := make([]time.Duration, 0, len(shiftStarts))
+	for _, s := range shiftStarts {
+		t, err := time.Parse("15:04", s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid shift start %q, expected HH:MM", s)
+		}
+		d := time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute
+		if seen[d] {
+			return nil, fmt.Errorf("duplicate shift start %q", s)
+		}
+		seen[d] = true
+		starts = append(starts, d)
+	}
+	sort.Slice(starts, func(i, j int) bool { return starts[i] < starts[j] })
+	return starts, nil
+}
+
+func (c *orgClock) Now() time.Time {
+	return time.Now().In(c.loc)
+}
+
+// Date is the org-local day key used for daily stats.
+func (c *orgClock) Date(t time.Time) string {
+	return t.In(c.loc).Format("2006-01-02")
+}
+
+// Month is the org-local month key used for usage metering.
+func (c *orgClock) Month(t time.Time) string {
+	return t.In(c.loc).Format("2006-01")
+}
+
+// ShiftWindow returns the start and end of the shift containing t. Boundaries
+// are wall-clock times, so a shift spanning a DST change is an hour shorter
+// or longer.
+func (c *orgClock) ShiftWindow(t time.Time) (time.Time, time.Time) {
+	t = t.In(c.loc)
+	// Shift starts from yesterday through tomorrow always bracket t.
+	var boundaries []time.Time
+	for day := -1; day <= 1; day++ {
+		for _, off := range c.starts {
+			boundaries = append(boundaries, time.Date(t.Year(), t.Month(), t.Day()+day,
+				int(off/time.Hour), int(off%time.Hour/time.Minute), 0, 0, c.loc))
+		}
+	}
+	for i := len(boundaries) - 2; i >= 0; i-- {
+		if !boundaries[i].After(t) {
+			return boundaries[i], boundaries[i+1]
+		}
+	}
+	return boundaries[0], boundaries[1]
+}
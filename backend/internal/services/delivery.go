@@ -0,0 +1,204 @@
+package services
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"praana/internal/models"
+	"praana/internal/repository"
+	"praana/internal/utils"
+)
+
+const (
+	maxDeliveryAttempts = 6
+	baseDeliveryBackoff = 30 * time.Second
+	maxDeliveryBackoff  = time.Hour
+	deliveryBatchSize   = 50
+	// deliveryLease covers sending a whole batch at the outbound timeout.
+	deliveryLease = 10 * time.Minute
+)
+
+// DeliverySender sends one delivery. A returned error schedules a retry.
+type DeliverySender func(ctx context.Context, d *models.Delivery) error
+
+// DeliveryService is the outbound queue shared by alert notifications and
+// event webhooks. Producers enqueue; Run claims due deliveries, hands each to
+// the sender registered for its kind and retries failures with exponential
+// backoff until maxDeliveryAttempts, when the delivery is marked dead.
+type DeliveryService struct {
+	repo    *repository.RedisRepo
+	senders map[string]DeliverySender
+}
+
+func NewDeliveryService(repo *repository.RedisRepo) *DeliveryService {
+	return &DeliveryService{repo: repo, senders: make(map[string]DeliverySender)}
+}
+
+// Register sets the sender for a delivery kind. Call it before Run.
+func (s *DeliveryService) Register(kind string, sender DeliverySender) {
+	s.senders[kind] = sender
+}
+
+// Enqueue queues a delivery for immediate sending.
+func (s *DeliveryService) Enqueue(ctx context.Context, orgID, kind, targetID, event string, payload interface{}, subject, body string) (*models.Delivery, error) {
+	return s.EnqueueAt(ctx, orgID, kind, targetID, event, payload, subject, body, time.Now())
+}
+
+// EnqueueAt queues a delivery to be sent at at.
+func (s *DeliveryService) EnqueueAt(ctx context.Context, orgID, kind, targetID, event string, payload interface{}, subject, body string, at time.Time) (*models.Delivery, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now().Unix()
+	d := &models.Delivery{
+		ID:            utils.GenerateID(),
+		OrgID:         orgID,
+		Kind:          kind,
+		TargetID:      targetID,
+		Event:         event,
+		Subject:       subject,
+		Body:          body,
+		Payload:       data,
+		Status:        models.DeliveryPending,
+		NextAttemptAt: at.Unix(),
+		CreatedAt:     now,
+	}
+	if err := s.repo.EnqueueDelivery(ctx, d); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// Log returns the org's deliveries, newest first.
+func (s *DeliveryService) Log(ctx context.Context, orgID string, q *models.DeliveryQuery) (*models.DeliveryPage, error) {
+	if q.Limit <= 0 || q.Limit > 200 {
+		q.Limit = 50
+	}
+	return s.repo.QueryDeliveries(ctx, orgID, q)
+}
+
+var (
+	// ErrDeliveryQueued is returned when replaying a delivery that is still queued.
+	ErrDeliveryQueued   = errors.New("delivery is still queued")
+	ErrDeliveryNotFound = errors.New("delivery not found")
+)
+
+// Replay sends a delivered or dead-lettered delivery again, with a fresh set
+// of attempts.
+func (s *DeliveryService) Replay(ctx context.Context, orgID, deliveryID string) (*models.Delivery, error) {
+	d, err := s.repo.ChangeDelivery(ctx, orgID, deliveryID, func(d *models.Delivery) error {
+		if d.Status == models.DeliveryPending || d.Status == models.DeliveryRetrying {
+			return ErrDeliveryQueued
+		}
+		d.Status = models.DeliveryPending
+		d.Attempts = 0
+		d.LastError = ""
+		d.DeliveredAt = 0
+		d.NextAttemptAt = time.Now().Unix()
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if d == nil {
+		return nil, ErrDeliveryNotFound
+	}
+	return d, nil
+}
+
+// Run sends due deliveries every interval until ctx is cancelled.
+func (s *DeliveryService) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.sendDue(ctx)
+		}
+	}
+}
+
+func (s *DeliveryService) sendDue(ctx context.Context) {
+	for {
+		now := time.Now()
+		deliveries, err := s.repo.ClaimDueDeliveries(ctx, now.Unix(), now.Add(deliveryLease).Unix(), deliveryBatchSize)
+		if err != nil {
+			log.Error().Err(err).Msg("Delivery queue: failed to claim")
+			return
+		}
+		for i := range deliveries {
+			s.attempt(ctx, &deliveries[i])
+		}
+		if len(deliveries) < deliveryBatchSize {
+			return
+		}
+	}
+}
+
+func (s *DeliveryService) attempt(ctx context.Context, d *models.Delivery) {
+	sender, ok := s.senders[d.Kind]
+	var err error
+	if !ok {
+		err = fmt.Errorf("no sender for kind %q", d.Kind)
+	} else {
+		err = sender(ctx, d)
+	}
+	recordAttempt(d, err, time.Now())
+	if err := s.repo.UpdateDelivery(ctx, d); err != nil {
+		log.Error().Err(err).Str("delivery", d.ID).Msg("Failed to save delivery")
+	}
+}
+
+// recordAttempt applies the outcome of a send to d: delivered on success,
+// otherwise retrying after a backoff, or dead once it has used all of its
+// attempts.
+func recordAttempt(d *models.Delivery, err error, now time.Time) {
+	d.Attempts++
+	switch {
+	case err == nil:
+		d.Status = models.DeliveryDelivered
+		d.DeliveredAt = now.Unix()
+		d.LastError = ""
+		d.NextAttemptAt = 0
+	case d.Attempts >= maxDeliveryAttempts:
+		d.Status = models.DeliveryDead
+		d.LastError = err.Error()
+		d.NextAttemptAt = 0
+		log.Warn().Err(err).Str("delivery", d.ID).Str("kind", d.Kind).Msg("Delivery dead-lettered")
+	default:
+		d.Status = models.DeliveryRetrying
+		d.LastError = err.Error()
+		d.NextAttemptAt = now.Add(deliveryBackoff(d.Attempts)).Unix()
+	}
+}
+
+// deliveryBackoff doubles the wait after each failed attempt, up to an hour.
+func deliveryBackoff(attempts int) time.Duration {
+	wait := baseDeliveryBackoff << (attempts - 1)
+	if wait <= 0 || wait > maxDeliveryBackoff {
+		return maxDeliveryBackoff
+	}
+	return wait
+}
+
+// signPayload is the HMAC-SHA256 of "<timestamp>.<body>" under secret, hex
+// encoded. Receivers recompute it to verify the sender and reject replays
+// with stale timestamps.
+func signPayload(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,292 @@
+package services
+
+import (
+	"context"
+	"crypto/hmac"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"praana/internal/mailer"
+	"praana/internal/models"
+	"praana/internal/repository"
+	"praana/internal/utils"
+)
+
+// testRepo connects to the Redis at REDIS_ADDR (default localhost:6379),
+// using database 15, and skips the test when none is running.
+func testRepo(t *testing.T) *repository.RedisRepo {
+	t.Helper()
+	addr := os.Getenv("REDIS_ADDR")
+	if addr == "" {
+		addr = "localhost:6379"
+	}
+	repo, err := repository.NewRedisRepo(addr, "", 15)
+	if err != nil {
+		t.Skipf("redis not available: %v", err)
+	}
+	return repo
+}
+
+// allowLoopback lets outbound clients reach httptest servers for one test.
+func allowLoopback(t *testing.T) {
+	t.Helper()
+	utils.AllowPrivateNetworks = true
+	t.Cleanup(func() { utils.AllowPrivateNetworks = false })
+}
+
+// hookRequest is one request received by a hookServer.
+type hookRequest struct {
+	header http.Header
+	body   []byte
+}
+
+// hookServer records webhook requests and answers them with status.
+type hookServer struct {
+	*httptest.Server
+	mu       sync.Mutex
+	status   int
+	requests []hookRequest
+}
+
+func newHookServer(t *testing.T, status int) *hookServer {
+	t.Helper()
+	h := &hookServer{status: status}
+	h.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		h.requests = append(h.requests, hookRequest{header: r.Header.Clone(), body: body})
+		w.WriteHeader(h.status)
+	}))
+	t.Cleanup(h.Close)
+	return h
+}
+
+func (h *hookServer) setStatus(status int) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.status = status
+}
+
+func (h *hookServer) received() []hookRequest {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return append([]hookRequest(nil), h.requests...)
+}
+
+// checkSignature verifies a request the way a receiver would.
+func checkSignature(t *testing.T, secret string, req hookRequest) {
+	t.Helper()
+	ts, err := strconv.ParseInt(req.header.Get("X-Praana-Timestamp"), 10, 64)
+	if err != nil {
+		t.Fatalf("X-Praana-Timestamp = %q", req.header.Get("X-Praana-Timestamp"))
+	}
+	want := "sha256=" + signPayload(secret, ts, req.body)
+	if got := req.header.Get("X-Praana-Signature"); !hmac.Equal([]byte(got), []byte(want)) {
+		t.Fatalf("X-Praana-Signature = %q, want %q", got, want)
+	}
+}
+
+func TestWebhookNotifierSignsPayload(t *testing.T) {
+	allowLoopback(t)
+	hook := newHookServer(t, http.StatusNoContent)
+	ch := &models.NotificationChannel{Type: models.ChannelWebhook, URL: hook.URL, Secret: "s3cret"}
+	d := &models.Delivery{ID: "d1", Event: "alert.raised", Payload: []byte(`{"event":"alert.raised"}`)}
+
+	if err := (WebhookNotifier{}).Send(context.Background(), ch, d); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	reqs := hook.received()
+	if len(reqs) != 1 {
+		t.Fatalf("received %d requests, want 1", len(reqs))
+	}
+	req := reqs[0]
+	if string(req.body) != string(d.Payload) {
+		t.Fatalf("body = %s, want %s", req.body, d.Payload)
+	}
+	if req.header.Get("X-Praana-Event") != "alert.raised" || req.header.Get("X-Praana-Delivery") != "d1" {
+		t.Fatalf("event/delivery headers = %q/%q", req.header.Get("X-Praana-Event"), req.header.Get("X-Praana-Delivery"))
+	}
+	checkSignature(t, "s3cret", req)
+}
+
+func TestWebhookNotifierFailsOnErrorStatus(t *testing.T) {
+	allowLoopback(t)
+	hook := newHookServer(t, http.StatusInternalServerError)
+	ch := &models.NotificationChannel{Type: models.ChannelWebhook, URL: hook.URL, Secret: "s3cret"}
+	d := &models.Delivery{ID: "d1", Event: "alert.raised", Payload: []byte(`{}`)}
+
+	err := (WebhookNotifier{}).Send(context.Background(), ch, d)
+	if err == nil || !strings.Contains(err.Error(), "500") {
+		t.Fatalf("Send error = %v, want a 500 failure", err)
+	}
+}
+
+func TestWebhookNotifierRejectsPrivateAddresses(t *testing.T) {
+	hook := newHookServer(t, http.StatusNoContent)
+	ch := &models.NotificationChannel{Type: models.ChannelWebhook, URL: hook.URL}
+	d := &models.Delivery{ID: "d1", Payload: []byte(`{}`)}
+
+	if err := (WebhookNotifier{}).Send(context.Background(), ch, d); !errors.Is(err, utils.ErrPrivateAddress) {
+		t.Fatalf("Send(loopback) error = %v, want ErrPrivateAddress", err)
+	}
+	if n := len(hook.received()); n != 0 {
+		t.Fatalf("hook received %d requests", n)
+	}
+}
+
+// recordingMailer keeps the messages it is asked to send.
+type recordingMailer struct {
+	sent []*mailer.Message
+}
+
+func (m *recordingMailer) Send(ctx context.Context, msg *mailer.Message) error {
+	m.sent = append(m.sent, msg)
+	return nil
+}
+
+func TestEmailNotifierSendsDigest(t *testing.T) {
+	mail := &recordingMailer{}
+	n := EmailNotifier{Mail: NewMailService(mail, "secret", "https://app.example")}
+	ch := &models.NotificationChannel{Type: models.ChannelEmail, Recipients: []string{"ward@hospital.org"}}
+	d := &models.Delivery{
+		ID:      "d1",
+		Event:   eventAlertDigest,
+		Payload: []byte(`{"org":{"name":"General"},"alerts":[{"severity":"critical","patient":"Asha","message":"SpO2 88%","at":"10:00"}]}`),
+	}
+
+	if err := n.Send(context.Background(), ch, d); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	if len(mail.sent) != 1 {
+		t.Fatalf("sent %d messages, want 1", len(mail.sent))
+	}
+	msg := mail.sent[0]
+	if len(msg.To) != 1 || msg.To[0] != "ward@hospital.org" {
+		t.Fatalf("To = %v", msg.To)
+	}
+	if !strings.Contains(msg.Text, "SpO2 88%") {
+		t.Fatalf("digest text is missing the alert:\n%s", msg.Text)
+	}
+}
+
+func TestRecordAttempt(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	d := &models.Delivery{Status: models.DeliveryPending}
+	failure := errors.New("receiver down")
+
+	for i := 1; i < maxDeliveryAttempts; i++ {
+		recordAttempt(d, failure, now)
+		if d.Status != models.DeliveryRetrying || d.Attempts != i {
+			t.Fatalf("after failure %d: status %s, attempts %d", i, d.Status, d.Attempts)
+		}
+		if want := now.Add(deliveryBackoff(i)).Unix(); d.NextAttemptAt != want {
+			t.Fatalf("after failure %d: next attempt %d, want %d", i, d.NextAttemptAt, want)
+		}
+	}
+	recordAttempt(d, failure, now)
+	if d.Status != models.DeliveryDead || d.Attempts != maxDeliveryAttempts || d.NextAttemptAt != 0 {
+		t.Fatalf("after the last failure: status %s, attempts %d, next attempt %d", d.Status, d.Attempts, d.NextAttemptAt)
+	}
+	if d.LastError != "receiver down" {
+		t.Fatalf("LastError = %q", d.LastError)
+	}
+
+	d = &models.Delivery{Status: models.DeliveryRetrying, Attempts: 2, LastError: "receiver down"}
+	recordAttempt(d, nil, now)
+	if d.Status != models.DeliveryDelivered || d.DeliveredAt != now.Unix() || d.LastError != "" || d.NextAttemptAt != 0 {
+		t.Fatalf("after success: %+v", d)
+	}
+}
+
+func TestDeliveryBackoff(t *testing.T) {
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{1, 30 * time.Second},
+		{2, time.Minute},
+		{3, 2 * time.Minute},
+		{7, 32 * time.Minute},
+		{8, time.Hour},
+		{100, time.Hour},
+	}
+	for _, tt := range tests {
+		if got := deliveryBackoff(tt.attempts); got != tt.want {
+			t.Errorf("deliveryBackoff(%d) = %v, want %v", tt.attempts, got, tt.want)
+		}
+	}
+}
+
+// TestDeliveryRetriesUntilDead drives a channel test notification through
+// the queue against a failing receiver, then replays it once the receiver
+// recovers.
+func TestDeliveryRetriesUntilDead(t *testing.T) {
+	repo := testRepo(t)
+	allowLoopback(t)
+	ctx := context.Background()
+	hook := newHookServer(t, http.StatusServiceUnavailable)
+
+	deliveries := NewDeliveryService(repo)
+	notifications := NewNotificationService(repo, deliveries, WebhookNotifier{})
+	ch := &models.NotificationChannel{
+		ID:      utils.GenerateID(),
+		OrgID:   utils.GenerateID(),
+		Name:    "Ward hook",
+		Type:    models.ChannelWebhook,
+		Enabled: true,
+		URL:     hook.URL,
+		Secret:  "s3cret",
+	}
+	if err := repo.SaveChannel(ctx, ch); err != nil {
+		t.Fatal(err)
+	}
+	queued, err := notifications.Test(ctx, ch.OrgID, ch.ID)
+	if err != nil {
+		t.Fatalf("Test: %v", err)
+	}
+
+	for i := 1; i <= maxDeliveryAttempts; i++ {
+		d, err := repo.GetDelivery(ctx, ch.OrgID, queued.ID)
+		if err != nil || d == nil {
+			t.Fatalf("GetDelivery: %v, %v", d, err)
+		}
+		deliveries.attempt(ctx, d)
+	}
+	if n := len(hook.received()); n != maxDeliveryAttempts {
+		t.Fatalf("receiver got %d requests, want %d", n, maxDeliveryAttempts)
+	}
+	d, err := repo.GetDelivery(ctx, ch.OrgID, queued.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.Status != models.DeliveryDead || d.Attempts != maxDeliveryAttempts || !strings.Contains(d.LastError, "503") {
+		t.Fatalf("dead-lettered delivery = status %s, attempts %d, last error %q", d.Status, d.Attempts, d.LastError)
+	}
+
+	hook.setStatus(http.StatusNoContent)
+	d, err = deliveries.Replay(ctx, ch.OrgID, queued.ID)
+	if err != nil {
+		t.Fatalf("Replay: %v", err)
+	}
+	if d.Status != models.DeliveryPending || d.Attempts != 0 {
+		t.Fatalf("replayed delivery = status %s, attempts %d", d.Status, d.Attempts)
+	}
+	deliveries.attempt(ctx, d)
+	if d, err = repo.GetDelivery(ctx, ch.OrgID, queued.ID); err != nil {
+		t.Fatal(err)
+	}
+	if d.Status != models.DeliveryDelivered || d.Attempts != 1 {
+		t.Fatalf("delivery after replay = status %s, attempts %d", d.Status, d.Attempts)
+	}
+	reqs := hook.received()
+	checkSignature(t, "s3cret", reqs[len(reqs)-1])
+}
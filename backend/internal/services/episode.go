@@ -0,0 +1,314 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"praana/internal/models"
+	"praana/internal/repository"
+	"praana/internal/utils"
+)
+
+type EpisodeService struct {
+	repo     *repository.RedisRepo
+	wards    *WardService
+	webhooks *WebhookService
+}
+
+func NewEpisodeService(repo *repository.RedisRepo, wards *WardService, webhooks *WebhookService) *EpisodeService {
+	return &EpisodeService{repo: repo, wards: wards, webhooks: webhooks}
+}
+
+// Admit opens a new episode for a discharged patient. The bed and episode
+// are claimed first; if the patient was admitted concurrently in the
+// meantime, both are given back.
+func (s *EpisodeService) Admit(ctx context.Context, orgID, patientID, userID string, req *models.AdmitRequest) (*models.Episode, error) {
+	p, err := s.repo.GetPatient(ctx, orgID, patientID)
+	if err != nil || p == nil {
+		return nil, fmt.Errorf("patient not found")
+	}
+	if p.Status != models.StatusDischarged {
+		return nil, fmt.Errorf("patient is already admitted")
+	}
+
+	p.Ward = req.Ward
+	p.BedNumber = req.BedNumber
+	p.WardID = ""
+	p.BedID = req.BedID
+	if req.Diagnosis != "" {
+		p.Diagnosis = req.Diagnosis
+	}
+
+	ep, err := s.open(ctx, p, req.Reason, userID)
+	if err != nil {
+		return nil, err
+	}
+	admitted, err := s.repo.ChangePatient(ctx, orgID, patientID, func(cur *models.Patient) error {
+		if cur.Status != models.StatusDischarged {
+			return fmt.Errorf("patient is already admitted")
+		}
+		cur.Ward, cur.BedNumber = p.Ward, p.BedNumber
+		cur.WardID, cur.BedID = p.WardID, p.BedID
+		cur.Diagnosis = p.Diagnosis
+		cur.Status = models.StatusActive
+		cur.ActiveEpisodeID = p.ActiveEpisodeID
+		cur.AdmittedAt = p.AdmittedAt
+		cur.DischargedAt = 0
+		return nil
+	})
+	if err == nil && admitted == nil {
+		err = fmt.Errorf("patient not found")
+	}
+	if err != nil {
+		_ = s.wards.release(ctx, orgID, p.BedID, p.ID)
+		_ = s.repo.DeleteEpisode(ctx, ep)
+		return nil, err
+	}
+	if err := s.repo.RestorePatient(ctx, orgID, p.ID); err != nil {
+		return nil, err
+	}
+	s.emit(ctx, models.EventPatientAdmitted, admitted, ep)
+	return ep, nil
+}
+
+// Transfer moves an admitted patient to another bed or ward. The move only
+// applies if the patient is still where it started, so two transfers can't
+// both record a move from the same place.
+func (s *EpisodeService) Transfer(ctx context.Context, orgID, patientID, userID string, req *models.TransferRequest) (*models.Episode, error) {
+	p, err := s.repo.GetPatient(ctx, orgID, patientID)
+	if err != nil || p == nil {
+		return nil, fmt.Errorf("patient not found")
+	}
+	if p.Status == models.StatusDischarged {
+		return nil, fmt.Errorf("patient is not admitted")
+	}
+	ep, err := s.activeEpisode(ctx, p)
+	if err != nil {
+		return nil, err
+	}
+
+	toWard, toBedNumber, toWardID := req.Ward, req.BedNumber, ""
+	var previousBedID string
+	if req.BedID != "" {
+		ward, bed, previous, err := s.wards.occupy(ctx, orgID, p.ID, req.BedID)
+		if err != nil {
+			return nil, err
+		}
+		toWard, toBedNumber, toWardID = ward.Name, bed.Label, ward.ID
+		previousBedID = previous
+	} else if err := s.wards.release(ctx, orgID, "", p.ID); err != nil {
+		return nil, err
+	}
+
+	now := time.Now().Unix()
+	moved, err := s.repo.ChangePatient(ctx, orgID, patientID, func(cur *models.Patient) error {
+		if cur.Status == models.StatusDischarged {
+			return fmt.Errorf("patient is not admitted")
+		}
+		if cur.BedID != p.BedID || cur.Ward != p.Ward || (cur.ActiveEpisodeID != "" && cur.ActiveEpisodeID != ep.ID) {
+			return fmt.Errorf("patient was moved by someone else; reload and try again")
+		}
+		cur.Ward, cur.BedNumber = toWard, toBedNumber
+		cur.WardID, cur.BedID = toWardID, req.BedID
+		cur.ActiveEpisodeID = ep.ID
+		cur.UpdatedAt = now
+		return nil
+	})
+	if err == nil && moved == nil {
+		err = fmt.Errorf("patient not found")
+	}
+	if err != nil {
+		if req.BedID != "" {
+			s.wards.restore(ctx, orgID, p.ID, req.BedID, previousBedID)
+		}
+		return nil, err
+	}
+
+	ep.Movements = append(ep.Movements, models.EpisodeMovement{
+		FromWard:      ep.Ward,
+		FromBedNumber: ep.BedNumber,
+		FromBedID:     ep.BedID,
+		ToWard:        toWard,
+		ToBedNumber:   toBedNumber,
+		ToBedID:       req.BedID,
+		Reason:        req.Reason,
+		MovedBy:       userID,
+		MovedAt:       now,
+	})
+	ep.Ward, ep.BedNumber = toWard, toBedNumber
+	ep.WardID, ep.BedID = toWardID, req.BedID
+	ep.UpdatedAt = now
+	if err := s.repo.UpdateEpisode(ctx, ep); err != nil {
+		return nil, err
+	}
+	s.emit(ctx, models.EventPatientTransferred, moved, ep)
+	return ep, nil
+}
+
+// Discharge closes the patient's episode and frees their bed. Marking the
+// patient discharged comes first, so only one of several concurrent
+// discharges (or a racing transfer) takes effect.
+func (s *EpisodeService) Discharge(ctx context.Context, orgID, patientID, userID string, req *models.DischargeRequest) (*models.Episode, error) {
+	p, err := s.repo.GetPatient(ctx, orgID, patientID)
+	if err != nil || p == nil {
+		return nil, fmt.Errorf("patient not found")
+	}
+	if p.Status == models.StatusDischarged {
+		return nil, fmt.Errorf("patient is already discharged")
+	}
+	ep, err := s.activeEpisode(ctx, p)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().Unix()
+	discharged, err := s.repo.ChangePatient(ctx, orgID, patientID, func(cur *models.Patient) error {
+		if cur.Status == models.StatusDischarged {
+			return fmt.Errorf("patient is already discharged")
+		}
+		if cur.ActiveEpisodeID != "" && cur.ActiveEpisodeID != ep.ID {
+			return fmt.Errorf("patient was readmitted by someone else; reload and try again")
+		}
+		cur.Status = models.StatusDischarged
+		cur.DischargedAt = now
+		cur.ActiveEpisodeID = ""
+		cur.WardID = ""
+		cur.BedID = ""
+		cur.UpdatedAt = now
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if discharged == nil {
+		return nil, fmt.Errorf("patient not found")
+	}
+
+	ep.Status = models.EpisodeClosed
+	ep.DischargeReason = req.Reason
+	ep.DischargedBy = userID
+	ep.DischargedAt = now
+	ep.UpdatedAt = now
+	if err := s.repo.UpdateEpisode(ctx, ep); err != nil {
+		return nil, err
+	}
+	if err := s.wards.release(ctx, orgID, "", p.ID); err != nil {
+		return nil, err
+	}
+	if err := s.repo.ArchivePatient(ctx, orgID, p.ID); err != nil {
+		return nil, err
+	}
+	s.emit(ctx, models.EventPatientDischarged, discharged, ep)
+	return ep, nil
+}
+
+// emit publishes an admission, transfer or discharge to webhook subscribers.
+func (s *EpisodeService) emit(ctx context.Context, eventType string, p *models.Patient, ep *models.Episode) {
+	if s.webhooks == nil {
+		return
+	}
+	s.webhooks.Emit(ctx, p.OrgID, eventType, map[string]interface{}{"patient": p, "episode": ep})
+}
+
+func (s *EpisodeService) List(ctx context.Context, orgID, patientID string) ([]models.Episode, error) {
+	p, err := s.repo.GetPatient(ctx, orgID, patientID)
+	if err != nil || p == nil {
+		return nil, fmt.Errorf("patient not found")
+	}
+	return s.repo.GetPatientEpisodes(ctx, orgID, patientID)
+}
+
+// open starts a new episode from the patient's current ward, bed and diagnosis
+// and points the patient at it. If the patient has a BedID the bed is claimed
+// first. The caller is responsible for saving the patient, and for releasing
+// the bed and deleting the episode if that fails.
+func (s *EpisodeService) open(ctx context.Context, p *models.Patient, reason, userID string) (*models.Episode, error) {
+	if p.BedID != "" {
+		ward, bed, _, err := s.wards.occupy(ctx, p.OrgID, p.ID, p.BedID)
+		if err != nil {
+			return nil, err
+		}
+		p.Ward, p.BedNumber, p.WardID = ward.Name, bed.Label, ward.ID
+	}
+
+	now := time.Now().Unix()
+	ep := &models.Episode{
+		ID:          utils.GenerateID(),
+		OrgID:       p.OrgID,
+		PatientID:   p.ID,
+		Status:      models.EpisodeActive,
+		Ward:        p.Ward,
+		BedNumber:   p.BedNumber,
+		WardID:      p.WardID,
+		BedID:       p.BedID,
+		Diagnosis:   p.Diagnosis,
+		AdmitReason: reason,
+		AdmittedBy:  userID,
+		AdmittedAt:  now,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	if err := s.repo.CreateEpisode(ctx, ep); err != nil {
+		_ = s.wards.release(ctx, p.OrgID, p.BedID, p.ID)
+		return nil, err
+	}
+	p.ActiveEpisodeID = ep.ID
+	p.AdmittedAt = now
+	p.DischargedAt = 0
+	return ep, nil
+}
+
+// activeEpisode returns the patient's open episode. Patients registered before
+// episodes existed get one backfilled from their current admission.
+func (s *EpisodeService) activeEpisode(ctx context.Context, p *models.Patient) (*models.Episode, error) {
+	if p.ActiveEpisodeID != "" {
+		ep, err := s.repo.GetEpisode(ctx, p.OrgID, p.ActiveEpisodeID)
+		if err != nil {
+			return nil, err
+		}
+		if ep != nil {
+			return ep, nil
+		}
+	}
+
+	ep := &models.Episode{
+		ID:         utils.GenerateID(),
+		OrgID:      p.OrgID,
+		PatientID:  p.ID,
+		Status:     models.EpisodeActive,
+		Ward:       p.Ward,
+		BedNumber:  p.BedNumber,
+		WardID:     p.WardID,
+		BedID:      p.BedID,
+		Diagnosis:  p.Diagnosis,
+		AdmittedAt: p.AdmittedAt,
+		CreatedAt:  time.Now().Unix(),
+		UpdatedAt:  time.Now().Unix(),
+	}
+	if err := s.repo.CreateEpisode(ctx, ep); err != nil {
+		return nil, err
+	}
+	p.ActiveEpisodeID = ep.ID
+	return ep, nil
+}
+
+// syncFromPatient copies corrected ward, bed or diagnosis details from the
+// patient record onto the active episode.
+func (s *EpisodeService) syncFromPatient(ctx context.Context, p *models.Patient) error {
+	if p.ActiveEpisodeID == "" {
+		return nil
+	}
+	ep, err := s.repo.GetEpisode(ctx, p.OrgID, p.ActiveEpisodeID)
+	if err != nil || ep == nil {
+		return err
+	}
+	if ep.Ward == p.Ward && ep.BedNumber == p.BedNumber && ep.Diagnosis == p.Diagnosis {
+		return nil
+	}
+	ep.Ward = p.Ward
+	ep.BedNumber = p.BedNumber
+	ep.Diagnosis = p.Diagnosis
+	ep.UpdatedAt = time.Now().Unix()
+	return s.repo.UpdateEpisode(ctx, ep)
+}
@@ -0,0 +1,490 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"praana/internal/models"
+	"praana/internal/repository"
+	"praana/internal/utils"
+)
+
+var ErrHandoverSigned = errors.New("handover has been signed off and can no longer change")
+
+// errHandoverKept stops regeneration of a draft that was edited or signed
+// since it was read.
+var errHandoverKept = errors.New("handover was edited or signed")
+
+type HandoverService struct {
+	repo     *repository.RedisRepo
+	patients *PatientService
+}
+
+func NewHandoverService(repo *repository.RedisRepo, patients *PatientService) *HandoverService {
+	return &HandoverService{repo: repo, patients: patients}
+}
+
+// Generate drafts SBAR handovers for the current shift. Existing drafts that
+// nobody has edited are regenerated from the latest data; edited drafts and
+// signed reports are returned as they are.
+func (s *HandoverService) Generate(ctx context.Context, orgID, userID string, req *models.GenerateHandoverRequest) ([]models.HandoverReport, error) {
+	start, end := clockFor(ctx, s.repo, orgID).ShiftWindow(time.Now())
+
+	owner := utils.GenerateID()
+	ok, err := s.repo.AcquireHandoverLock(ctx, orgID, start.Unix(), owner, time.Minute)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, fmt.Errorf("handovers for this shift are already being generated")
+	}
+	defer s.repo.ReleaseHandoverLock(ctx, orgID, start.Unix(), owner)
+
+	var patients []models.Patient
+	if len(req.PatientIDs) == 0 {
+		patients, err = s.repo.GetPatients(ctx, orgID)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		for _, id := range req.PatientIDs {
+			p, err := s.patients.Get(ctx, orgID, id)
+			if err != nil {
+				return nil, fmt.Errorf("patient %s not found", id)
+			}
+			patients = append(patients, *p)
+		}
+	}
+
+	existing, err := s.repo.GetShiftHandovers(ctx, orgID, start.Unix())
+	if err != nil {
+		return nil, err
+	}
+	byPatient := make(map[string]*models.HandoverReport)
+	for i := range existing {
+		byPatient[existing[i].PatientID] = &existing[i]
+	}
+
+	reports := make([]models.HandoverReport, 0, len(patients))
+	for i := range patients {
+		p := &patients[i]
+		if p.Status == models.StatusDischarged {
+			continue
+		}
+		prev := byPatient[p.ID]
+		if prev != nil && (prev.Status == models.HandoverSigned || prev.EditedAt > 0) {
+			reports = append(reports, *prev)
+			continue
+		}
+
+		h, err := s.draft(ctx, p, start, end)
+		if err != nil {
+			return nil, err
+		}
+		h.GeneratedBy = userID
+		if prev == nil {
+			h.ID = utils.GenerateID()
+			if err := s.repo.CreateHandover(ctx, h); err != nil {
+				return nil, err
+			}
+			reports = append(reports, *h)
+			continue
+		}
+		h.ID = prev.ID
+		saved, err := s.repo.ChangeHandover(ctx, orgID, prev.ID, func(cur *models.HandoverReport) error {
+			if cur.Status == models.HandoverSigned || cur.EditedAt > 0 {
+				*h = *cur
+				return errHandoverKept
+			}
+			*cur = *h
+			return nil
+		})
+		if err != nil && !errors.Is(err, errHandoverKept) {
+			return nil, err
+		}
+		if saved == nil {
+			saved = h
+		}
+		reports = append(reports, *saved)
+	}
+	return reports, nil
+}
+
+// List returns the handovers for the shift starting at shiftStart, or for the
+// most recent shift with handovers when shiftStart is 0. Results are limited
+// to the given patients when patientIDs is non-nil.
+func (s *HandoverService) List(ctx context.Context, orgID string, shiftStart int64, patientIDs map[string]bool) ([]models.HandoverReport, error) {
+	if shiftStart == 0 {
+		latest, err := s.repo.GetLatestHandoverShift(ctx, orgID)
+		if err != nil || latest == 0 {
+			return []models.HandoverReport{}, err
+		}
+		shiftStart = latest
+	}
+	reports, err := s.repo.GetShiftHandovers(ctx, orgID, shiftStart)
+	if err != nil {
+		return nil, err
+	}
+	filtered := make([]models.HandoverReport, 0, len(reports))
+	for _, h := range reports {
+		if patientIDs == nil || patientIDs[h.PatientID] {
+			filtered = append(filtered, h)
+		}
+	}
+	return filtered, nil
+}
+
+func (s *HandoverService) ListForPatient(ctx context.Context, orgID, patientID string) ([]models.HandoverReport, error) {
+	p, err := s.patients.Get(ctx, orgID, patientID)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.GetPatientHandovers(ctx, orgID, p.ID)
+}
+
+func (s *HandoverService) Get(ctx context.Context, orgID, handoverID string) (*models.HandoverReport, error) {
+	h, err := s.repo.GetHandover(ctx, orgID, handoverID)
+	if err != nil || h == nil {
+		return nil, fmt.Errorf("handover not found")
+	}
+	return h, nil
+}
+
+func (s *HandoverService) Update(ctx context.Context, orgID, handoverID, userID string, req *models.UpdateHandoverRequest) (*models.HandoverReport, error) {
+	h, err := s.repo.ChangeHandover(ctx, orgID, handoverID, func(h *models.HandoverReport) error {
+		if h.Status == models.HandoverSigned {
+			return ErrHandoverSigned
+		}
+		if req.Situation != nil {
+			h.Situation = *req.Situation
+		}
+		if req.Background != nil {
+			h.Background = *req.Background
+		}
+		if req.Assessment != nil {
+			h.Assessment = *req.Assessment
+		}
+		if req.Recommendation != nil {
+			h.Recommendation = *req.Recommendation
+		}
+		h.EditedBy = userID
+		h.EditedAt = time.Now().Unix()
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if h == nil {
+		return nil, fmt.Errorf("handover not found")
+	}
+	return h, nil
+}
+
+// Sign marks the handover as reviewed by the outgoing shift. Signed handovers
+// are read-only.
+func (s *HandoverService) Sign(ctx context.Context, orgID, handoverID, userID string) (*models.HandoverReport, error) {
+	h, err := s.repo.ChangeHandover(ctx, orgID, handoverID, func(h *models.HandoverReport) error {
+		if h.Status == models.HandoverSigned {
+			return ErrHandoverSigned
+		}
+		h.Status = models.HandoverSigned
+		h.SignedBy = userID
+		h.SignedAt = time.Now().Unix()
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if h == nil {
+		return nil, fmt.Errorf("handover not found")
+	}
+	return h, nil
+}
+
+// draft builds the SBAR text for a patient from what happened between start and end.
+func (s *HandoverService) draft(ctx context.Context, p *models.Patient, start, end time.Time) (*models.HandoverReport, error) {
+	from, to := start.Unix(), end.Unix()
+	inShift := func(at int64) bool { return at >= from && at < to }
+
+	history, err := s.repo.GetVitalsHistory(ctx, p.OrgID, p.ID, start)
+	if err != nil {
+		return nil, err
+	}
+	var vitals []models.Vitals
+	for _, v := range history {
+		if inShift(v.RecordedAt) {
+			vitals = append(vitals, v)
+		}
+	}
+
+	alerts, err := s.repo.GetPatientAlerts(ctx, p.OrgID, p.ID)
+	if err != nil {
+		return nil, err
+	}
+	var shiftAlerts, unacked []models.Alert
+	stats := models.HandoverStats{VitalsRecorded: len(vitals)}
+	critical := 0
+	for _, a := range alerts {
+		if a.Acknowledged && inShift(a.AcknowledgedAt) {
+			stats.AlertsAcked++
+		}
+		if a.State == models.AlertOpen {
+			unacked = append(unacked, a)
+		}
+		if !inShift(a.CreatedAt) {
+			continue
+		}
+		shiftAlerts = append(shiftAlerts, a)
+		if a.Severity == models.SeverityCritical {
+			critical++
+		}
+	}
+	stats.AlertsTriggered = len(shiftAlerts)
+
+	allNotes, err := s.repo.GetPatientNotes(ctx, p.OrgID, p.ID)
+	if err != nil {
+		return nil, err
+	}
+	var notes []models.ClinicalNote
+	for _, n := range allNotes {
+		if inShift(n.CreatedAt) {
+			notes = append(notes, n)
+		}
+	}
+	stats.NotesWritten = len(notes)
+
+	allChanges, err := s.repo.GetStatusChanges(ctx, p.OrgID, p.ID)
+	if err != nil {
+		return nil, err
+	}
+	var changes []models.StatusChange
+	for _, c := range allChanges {
+		if inShift(c.ChangedAt) {
+			changes = append(changes, c)
+		}
+	}
+	stats.StatusChanges = len(changes)
+
+	var episode *models.Episode
+	if p.ActiveEpisodeID != "" {
+		episode, _ = s.repo.GetEpisode(ctx, p.OrgID, p.ActiveEpisodeID)
+	}
+	thresholds := patientThresholds(ctx, s.repo, p.OrgID, p.ID)
+
+	// Situation
+	var sit strings.Builder
+	fmt.Fprintf(&sit, "%s, %d-year-old %s", p.Name, p.Age, p.Gender)
+	if p.Ward != "" {
+		fmt.Fprintf(&sit, ", %s bed %s", p.Ward, p.BedNumber)
+	}
+	fmt.Fprintf(&sit, ". Current status: %s.", p.Status)
+	if len(shiftAlerts) == 0 {
+		sit.WriteString(" No alerts this shift.")
+	} else {
+		fmt.Fprintf(&sit, " %d alert(s) this shift (%d critical), %d unacknowledged.", len(shiftAlerts), critical, len(unacked))
+	}
+
+	// Background
+	var bg strings.Builder
+	if episode != nil {
+		fmt.Fprintf(&bg, "Admitted %s", time.Unix(episode.AdmittedAt, 0).In(start.Location()).Format("2 Jan 2006"))
+		if episode.AdmitReason != "" {
+			fmt.Fprintf(&bg, " for %s", episode.AdmitReason)
+		}
+		bg.WriteString(".")
+	}
+	if p.Diagnosis != "" {
+		fmt.Fprintf(&bg, " Diagnosis: %s.", p.Diagnosis)
+	}
+	if episode != nil {
+		for _, m := range episode.Movements {
+			if inShift(m.MovedAt) {
+				fmt.Fprintf(&bg, " Moved from %s bed %s to %s bed %s at %s (%s).",
+					m.FromWard, m.FromBedNumber, m.ToWard, m.ToBedNumber, clock(m.MovedAt, start.Location()), m.Reason)
+			}
+		}
+	}
+	for _, c := range changes {
+		fmt.Fprintf(&bg, " Status changed from %s to %s at %s.", c.From, c.To, clock(c.ChangedAt, start.Location()))
+	}
+
+	// Assessment
+	var assess []string
+	if len(vitals) == 0 {
+		assess = append(assess, "No vitals recorded this shift.")
+	}
+	trends := vitalTrends(vitals)
+	for _, t := range trends {
+		assess = append(assess, t.describe())
+	}
+	for _, cat := range []models.NoteCategory{models.NoteMedical, models.NoteNursing} {
+		if n := latestNote(notes, cat); n != nil {
+			assess = append(assess, fmt.Sprintf("Latest %s note (%s): %s", cat, n.AuthorName, excerpt(n.Body, 300)))
+		}
+	}
+
+	// Recommendation
+	var rec []string
+	if len(unacked) > 0 {
+		msgs := make([]string, 0, len(unacked))
+		for _, a := range unacked {
+			msgs = append(msgs, a.Message)
+		}
+		rec = append(rec, fmt.Sprintf("Review %d unacknowledged alert(s): %s.", len(unacked), strings.Join(msgs, "; ")))
+	}
+	for _, t := range trends {
+		if r := t.outOfRange(thresholds); r != "" {
+			rec = append(rec, r)
+		}
+	}
+	if p.Status == models.StatusCritical {
+		rec = append(rec, "Patient is critical; continue close monitoring.")
+	}
+	if len(vitals) == 0 {
+		rec = append(rec, "Record a full set of vitals early in the shift.")
+	}
+	if n := latestNote(notes, models.NoteHandover); n != nil {
+		rec = append(rec, fmt.Sprintf("Handover note (%s): %s", n.AuthorName, excerpt(n.Body, 500)))
+	}
+	if len(rec) == 0 {
+		rec = append(rec, "Continue routine observations.")
+	}
+
+	now := time.Now().Unix()
+	h := &models.HandoverReport{
+		OrgID:          p.OrgID,
+		PatientID:      p.ID,
+		PatientName:    p.Name,
+		EpisodeID:      p.ActiveEpisodeID,
+		Ward:           p.Ward,
+		BedNumber:      p.BedNumber,
+		ShiftStart:     from,
+		ShiftEnd:       to,
+		Situation:      sit.String(),
+		Background:     strings.TrimSpace(bg.String()),
+		Assessment:     strings.Join(assess, "\n"),
+		Recommendation: strings.Join(rec, "\n"),
+		Stats:          stats,
+		Status:         models.HandoverDraft,
+		GeneratedAt:    now,
+	}
+	return h, nil
+}
+
+// vitalTrend summarises one vital sign over a shift.
+type vitalTrend struct {
+	name, label, unit     string
+	first, last, min, max float64
+}
+
+func (t vitalTrend) describe() string {
+	direction := "stable"
+	if t.first != 0 {
+		change := (t.last - t.first) / t.first
+		if change > 0.05 {
+			direction = "rising"
+		} else if change < -0.05 {
+			direction = "falling"
+		}
+	}
+	return fmt.Sprintf("%s: %s → %s%s (range %s–%s), %s.",
+		t.label, fmtVital(t.first), fmtVital(t.last), t.unit, fmtVital(t.min), fmtVital(t.max), direction)
+}
+
+// outOfRange returns a recommendation if the latest reading is outside the
+// patient's thresholds.
+func (t vitalTrend) outOfRange(th *models.Threshold) string {
+	var high, low float64
+	switch t.name {
+	case "heart_rate":
+		high, low = th.HeartRateHigh, th.HeartRateLow
+	case "systolic_bp":
+		high, low = th.SystolicBPHigh, th.SystolicBPLow
+	case "diastolic_bp":
+		high, low = th.DiastolicBPHigh, th.DiastolicBPLow
+	case "temperature":
+		high, low = th.TemperatureHigh, th.TemperatureLow
+	case "spo2":
+		low = th.SpO2Low
+	case "respiratory_rate":
+		high, low = th.RespiratoryRateHigh, th.RespiratoryRateLow
+	}
+	switch {
+	case high > 0 && t.last > high:
+		return fmt.Sprintf("Recheck %s: last %s%s is above %s.", strings.ToLower(t.label), fmtVital(t.last), t.unit, fmtVital(high))
+	case low > 0 && t.last < low:
+		return fmt.Sprintf("Recheck %s: last %s%s is below %s.", strings.ToLower(t.label), fmtVital(t.last), t.unit, fmtVital(low))
+	}
+	return ""
+}
+
+// vitalTrends returns a trend for each vital sign recorded at least once, in
+// a fixed order. vitals must be oldest first.
+func vitalTrends(vitals []models.Vitals) []vitalTrend {
+	fields := []struct {
+		name, label, unit string
+		get               func(v models.Vitals) float64
+	}{
+		{"heart_rate", "Heart rate", " bpm", func(v models.Vitals) float64 { return v.HeartRate }},
+		{"systolic_bp", "Systolic BP", " mmHg", func(v models.Vitals) float64 { return v.SystolicBP }},
+		{"diastolic_bp", "Diastolic BP", " mmHg", func(v models.Vitals) float64 { return v.DiastolicBP }},
+		{"temperature", "Temperature", " °C", func(v models.Vitals) float64 { return v.Temperature }},
+		{"spo2", "SpO2", "%", func(v models.Vitals) float64 { return v.SpO2 }},
+		{"respiratory_rate", "Respiratory rate", " /min", func(v models.Vitals) float64 { return v.RespiratoryRate }},
+	}
+
+	var trends []vitalTrend
+	for _, f := range fields {
+		var t *vitalTrend
+		for _, v := range vitals {
+			val := f.get(v)
+			if val == 0 {
+				continue
+			}
+			if t == nil {
+				t = &vitalTrend{name: f.name, label: f.label, unit: f.unit, first: val, min: val, max: val}
+			}
+			t.last = val
+			if val < t.min {
+				t.min = val
+			}
+			if val > t.max {
+				t.max = val
+			}
+		}
+		if t != nil {
+			trends = append(trends, *t)
+		}
+	}
+	return trends
+}
+
+func latestNote(notes []models.ClinicalNote, category models.NoteCategory) *models.ClinicalNote {
+	var latest *models.ClinicalNote
+	for i := range notes {
+		if notes[i].Category == category && (latest == nil || notes[i].CreatedAt > latest.CreatedAt) {
+			latest = &notes[i]
+		}
+	}
+	return latest
+}
+
+func excerpt(s string, max int) string {
+	s = strings.Join(strings.Fields(s), " ")
+	r := []rune(s)
+	if len(r) <= max {
+		return s
+	}
+	return string(r[:max]) + "…"
+}
+
+func fmtVital(v float64) string {
+	return strings.TrimSuffix(fmt.Sprintf("%.1f", v), ".0")
+}
+
+func clock(unix int64, loc *time.Location) string {
+	return time.Unix(unix, 0).In(loc).Format("15:04")
+}
@@ -0,0 +1,93 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"praana/internal/mailer"
+	"praana/internal/models"
+	"praana/internal/utils"
+)
+
+// Signed link purposes.
+const (
+	linkInvite        = "invite"
+	linkPasswordReset = "password_reset"
+)
+
+// MailService renders and sends the app's emails. Links in them carry tokens
+// signed with the server secret, so codes are never sent or accepted bare.
+type MailService struct {
+	mailer mailer.Mailer
+	secret string
+	appURL string
+}
+
+func NewMailService(m mailer.Mailer, secret, appURL string) *MailService {
+	return &MailService{mailer: m, secret: secret, appURL: strings.TrimRight(appURL, "/")}
+}
+
+// link builds an app URL whose "code" query parameter is a signed token.
+func (s *MailService) link(path, purpose, value string, expiresAt int64) string {
+	token := utils.SignToken(s.secret, purpose, value, expiresAt)
+	return fmt.Sprintf("%s%s?code=%s", s.appURL, path, url.QueryEscape(token))
+}
+
+// verify returns the value inside a token from link.
+func (s *MailService) verify(purpose, token string) (string, error) {
+	return utils.VerifyToken(s.secret, purpose, token)
+}
+
+func (s *MailService) SendInvite(ctx context.Context, org *models.Org, invitedBy string, invite *models.Invite) error {
+	msg, err := mailer.Render("invite", []string{invite.Email}, mailer.InviteData{
+		OrgName:   org.Name,
+		InvitedBy: invitedBy,
+		Role:      string(invite.Role),
+		Link:      s.link("/auth/invite", linkInvite, invite.Code, invite.ExpiresAt),
+		ExpiresAt: time.Unix(invite.ExpiresAt, 0).UTC().Format("Mon 2 Jan 2006 15:04 MST"),
+	})
+	if err != nil {
+		return err
+	}
+	return s.mailer.Send(ctx, msg)
+}
+
+func (s *MailService) SendPasswordReset(ctx context.Context, user *models.User, token string, ttl time.Duration) error {
+	msg, err := mailer.Render("password_reset", []string{user.Email}, mailer.PasswordResetData{
+		Name:      user.Name,
+		Link:      s.link("/auth/reset-password", linkPasswordReset, token, time.Now().Add(ttl).Unix()),
+		ExpiresIn: expiresIn(ttl),
+	})
+	if err != nil {
+		return err
+	}
+	return s.mailer.Send(ctx, msg)
+}
+
+// expiresIn describes a link lifetime for an email, e.g. "1 hour" or "30 minutes".
+func expiresIn(ttl time.Duration) string {
+	n, unit := int(ttl/time.Minute), "minute"
+	if ttl >= time.Hour && ttl%time.Hour == 0 {
+		n, unit = int(ttl/time.Hour), "hour"
+	}
+	if n != 1 {
+		unit += "s"
+	}
+	return fmt.Sprintf("%d %s", n, unit)
+}
+
+func (s *MailService) SendAlertDigest(ctx context.Context, to []string, orgName string, alerts []mailer.DigestAlert) error {
+	msg, err := mailer.Render("alert_digest", to, mailer.AlertDigestData{OrgName: orgName, Alerts: alerts})
+	if err != nil {
+		return err
+	}
+	return s.mailer.Send(ctx, msg)
+}
+
+// SendText sends a plain-text email.
+func (s *MailService) SendText(ctx context.Context, to []string, subject, text string) error {
+	return s.mailer.Send(ctx, &mailer.Message{To: to, Subject: subject, Text: text})
+}
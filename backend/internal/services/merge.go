@@ -0,0 +1,218 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"praana/internal/models"
+	"praana/internal/repository"
+)
+
+// mergeSteps run in order. Each is idempotent, so an interrupted merge is
+// resumed by calling Merge again with the same patients.
+var mergeSteps = []string{"episodes", "vitals", "alerts", "identifiers", "thresholds", "mutes", "notes", "status_log", "handovers", "assignments", "tombstone", "audit"}
+
+type MergeService struct {
+	repo     *repository.RedisRepo
+	episodes *EpisodeService
+	audit    *AuditService
+}
+
+func NewMergeService(repo *repository.RedisRepo, episodes *EpisodeService, audit *AuditService) *MergeService {
+	return &MergeService{repo: repo, episodes: episodes, audit: audit}
+}
+
+// Merge folds a duplicate source registration into the target patient:
+//   - vitals streams are combined in time order and deduplicated
+//   - alerts and episodes are re-keyed to the target
+//   - identifiers are unioned; the source's identifiers resolve to the target
+//   - the target's own thresholds win; otherwise the source's are adopted
+//   - care team assignments for the source now cover the target
+//   - the source ID becomes a redirect to the target
+func (s *MergeService) Merge(ctx context.Context, orgID, targetID, sourceID, userID string) (*models.PatientMerge, error) {
+	if sourceID == targetID {
+		return nil, fmt.Errorf("cannot merge a patient into itself")
+	}
+	unlock, err := s.lock(ctx, orgID, sourceID, targetID)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	target, err := s.repo.GetPatient(ctx, orgID, targetID)
+	if err != nil || target == nil {
+		return nil, fmt.Errorf("target patient not found")
+	}
+	source, err := s.repo.GetPatient(ctx, orgID, sourceID)
+	if err != nil {
+		return nil, err
+	}
+
+	m, err := s.repo.GetPatientMerge(ctx, orgID, sourceID)
+	if err != nil {
+		return nil, err
+	}
+	if m != nil && m.TargetID != targetID {
+		return nil, fmt.Errorf("patient is already merged into %s", m.TargetID)
+	}
+	if m != nil && m.Status == models.MergeCompleted {
+		return m, nil
+	}
+	if m == nil {
+		if source == nil {
+			return nil, fmt.Errorf("source patient not found")
+		}
+		if redirect, _ := s.repo.GetPatientRedirect(ctx, orgID, targetID); redirect != "" {
+			return nil, fmt.Errorf("target patient was merged into %s", redirect)
+		}
+		if source.Status != models.StatusDischarged && target.Status == models.StatusDischarged {
+			return nil, fmt.Errorf("source is admitted but target is discharged; readmit the target first")
+		}
+		m = &models.PatientMerge{
+			OrgID:     orgID,
+			SourceID:  sourceID,
+			TargetID:  targetID,
+			Status:    models.MergeInProgress,
+			MergedBy:  userID,
+			StartedAt: time.Now().Unix(),
+		}
+		if err := s.repo.SavePatientMerge(ctx, m); err != nil {
+			return nil, err
+		}
+	}
+
+	done := make(map[string]bool)
+	for _, step := range m.CompletedSteps {
+		done[step] = true
+	}
+	for _, step := range mergeSteps {
+		if done[step] {
+			continue
+		}
+		if err := s.runStep(ctx, step, m, source, target); err != nil {
+			return m, fmt.Errorf("merge stopped at %s (retry to resume): %w", step, err)
+		}
+		m.CompletedSteps = append(m.CompletedSteps, step)
+		if err := s.repo.SavePatientMerge(ctx, m); err != nil {
+			return m, err
+		}
+	}
+
+	m.Status = models.MergeCompleted
+	m.CompletedAt = time.Now().Unix()
+	if err := s.repo.SavePatientMerge(ctx, m); err != nil {
+		return m, err
+	}
+	return m, nil
+}
+
+// lock takes the merge lock of both patients, in a fixed order, so no other
+// merge can involve either of them (A into B racing B into A, or A into B
+// racing B into C). The returned func releases both.
+func (s *MergeService) lock(ctx context.Context, orgID string, ids ...string) (func(), error) {
+	sort.Strings(ids)
+	var held []string
+	unlock := func() {
+		for _, id := range held {
+			_ = s.repo.ReleaseMergeLock(ctx, orgID, id)
+		}
+	}
+	for _, id := range ids {
+		locked, err := s.repo.AcquireMergeLock(ctx, orgID, id, 5*time.Minute)
+		if err != nil {
+			unlock()
+			return nil, err
+		}
+		if !locked {
+			unlock()
+			return nil, fmt.Errorf("a merge involving patient %s is already running", id)
+		}
+		held = append(held, id)
+	}
+	return unlock, nil
+}
+
+func (s *MergeService) runStep(ctx context.Context, step string, m *models.PatientMerge, source, target *models.Patient) error {
+	switch step {
+	case "episodes":
+		if source != nil && source.Status != models.StatusDischarged {
+			reason := fmt.Sprintf("Merged into patient %s", target.ID)
+			if _, err := s.episodes.Discharge(ctx, m.OrgID, source.ID, m.MergedBy, &models.DischargeRequest{Reason: reason}); err != nil {
+				return err
+			}
+		}
+		return s.repo.MoveEpisodes(ctx, m.OrgID, m.SourceID, m.TargetID)
+
+	case "vitals":
+		return s.repo.MergeVitalsStreams(ctx, m.OrgID, m.SourceID, m.TargetID)
+
+	case "alerts":
+		return s.repo.MoveAlerts(ctx, m.OrgID, m.SourceID, m.TargetID, target.Name)
+
+	case "identifiers":
+		if source == nil || len(source.Identifiers) == 0 {
+			return nil
+		}
+		// Re-read the target so edits made since the merge started are kept.
+		updated, err := s.repo.ChangePatient(ctx, m.OrgID, m.TargetID, func(p *models.Patient) error {
+			have := make(map[string]bool)
+			for _, id := range p.Identifiers {
+				have[id.System+"|"+id.Value] = true
+			}
+			for _, id := range source.Identifiers {
+				if !have[id.System+"|"+id.Value] {
+					p.Identifiers = append(p.Identifiers, id)
+				}
+			}
+			p.UpdatedAt = time.Now().Unix()
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		if updated == nil {
+			return fmt.Errorf("target patient not found")
+		}
+		*target = *updated
+		return s.repo.TransferPatientIdentifiers(ctx, m.OrgID, m.SourceID, m.TargetID, source.Identifiers)
+
+	case "thresholds":
+		return s.repo.MovePatientThresholds(ctx, m.OrgID, m.SourceID, m.TargetID)
+
+	case "mutes":
+		return s.repo.MoveMutes(ctx, m.OrgID, m.SourceID, m.TargetID)
+
+	case "notes":
+		return s.repo.MoveNotes(ctx, m.OrgID, m.SourceID, m.TargetID)
+
+	case "status_log":
+		return s.repo.MoveStatusChanges(ctx, m.OrgID, m.SourceID, m.TargetID)
+
+	case "handovers":
+		return s.repo.MoveHandovers(ctx, m.OrgID, m.SourceID, m.TargetID, target.Name)
+
+	case "assignments":
+		return s.repo.MoveAssignments(ctx, m.OrgID, m.SourceID, m.TargetID)
+
+	case "tombstone":
+		ok, err := s.repo.TombstonePatient(ctx, m.OrgID, m.SourceID, m.TargetID)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return fmt.Errorf("target patient was merged into another patient")
+		}
+		return nil
+
+	case "audit":
+		details := map[string]string{"source_id": m.SourceID}
+		if source != nil {
+			details["source_name"] = source.Name
+		}
+		s.audit.Record(ctx, m.OrgID, m.MergedBy, "patient.merge", "patient", m.TargetID, details)
+		return nil
+	}
+	return fmt.Errorf("unknown merge step %q", step)
+}
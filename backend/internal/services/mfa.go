@@ -0,0 +1,254 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"golang.org/x/crypto/bcrypt"
+	"praana/internal/models"
+	"praana/internal/utils"
+)
+
+const (
+	totpIssuer        = "Praana"
+	totpEnrolmentTTL  = 15 * time.Minute
+	mfaChallengeTTL   = 5 * time.Minute
+	mfaMaxAttempts    = 5
+	recoveryCodeCount = 10
+)
+
+var (
+	ErrInvalidMFACode      = errors.New("invalid two-factor code")
+	ErrMFARequiredByOrg    = errors.New("your organization requires two-factor authentication")
+	ErrMFAChallengeExpired = errors.New("sign-in expired; enter your password again")
+
+	// errSecondFactorRejected aborts checkSecondFactor's transaction without saving.
+	errSecondFactorRejected = errors.New("second factor rejected")
+)
+
+// beginMFAChallenge is the first half of a two-step login: the password was
+// right, and the client must now send a code with the returned token.
+func (s *AuthService) beginMFAChallenge(ctx context.Context, user *models.User) (*models.LoginResponse, error) {
+	token := utils.GenerateUUID()
+	if err := s.repo.CreateMFAChallenge(ctx, token, user.ID, mfaChallengeTTL); err != nil {
+		return nil, err
+	}
+	return &models.LoginResponse{MFARequired: true, MFAToken: token}, nil
+}
+
+// LoginMFA completes a two-step login with a TOTP or recovery code. A
+// challenge allows a few wrong codes before the login has to start over.
+func (s *AuthService) LoginMFA(ctx context.Context, req *models.LoginMFARequest, client models.ClientInfo) (*models.LoginResponse, error) {
+	userID, err := s.repo.GetMFAChallenge(ctx, req.MFAToken)
+	if err != nil {
+		return nil, err
+	}
+	if userID == "" {
+		return nil, ErrMFAChallengeExpired
+	}
+	user, err := s.repo.GetUser(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load user: %w", err)
+	}
+	if user == nil {
+		return nil, ErrMFAChallengeExpired
+	}
+	if err := s.checkLoginAllowed(ctx, user.Email, client.IP); err != nil {
+		return nil, err
+	}
+	ok, err := s.checkSecondFactor(ctx, user, req.Code, true)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		if err := s.repo.FailMFAChallenge(ctx, req.MFAToken, mfaMaxAttempts); err != nil {
+			return nil, err
+		}
+		s.loginFailed(ctx, user.Email, client.IP, user)
+		return nil, ErrInvalidMFACode
+	}
+	_ = s.repo.DeleteMFAChallenge(ctx, req.MFAToken)
+	s.loginSucceeded(ctx, user.Email)
+	return s.startSession(ctx, user, client)
+}
+
+// checkSecondFactor accepts a TOTP code not used before or, if
+// allowRecovery, an unused recovery code, which is then spent. The check and
+// the write happen in one transaction so a code can't be used twice by
+// concurrent requests. On success user is refreshed from the saved record.
+func (s *AuthService) checkSecondFactor(ctx context.Context, user *models.User, code string, allowRecovery bool) (bool, error) {
+	now := time.Now()
+	updated, err := s.repo.ChangeUser(ctx, user.ID, func(u *models.User) error {
+		if !u.TOTPEnabled {
+			return errSecondFactorRejected
+		}
+		if step, ok := utils.VerifyTOTP(u.TOTPSecret, code, now); ok {
+			if step <= u.TOTPLastStep {
+				return errSecondFactorRejected
+			}
+			u.TOTPLastStep = step
+			return nil
+		}
+		if !allowRecovery {
+			return errSecondFactorRejected
+		}
+		for i, h := range u.RecoveryCodes {
+			if utils.MatchRecoveryCode(s.jwtSecret, code, h) {
+				u.RecoveryCodes = append(u.RecoveryCodes[:i:i], u.RecoveryCodes[i+1:]...)
+				log.Info().Str("user", u.ID).Int("remaining", len(u.RecoveryCodes)).Msg("Recovery code used")
+				return nil
+			}
+		}
+		return errSecondFactorRejected
+	})
+	if errors.Is(err, errSecondFactorRejected) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if updated == nil {
+		return false, nil
+	}
+	*user = *updated
+	return true, nil
+}
+
+func (s *AuthService) TwoFactorStatus(ctx context.Context, userID string) (*models.TwoFactorStatus, error) {
+	user, err := s.repo.GetUser(ctx, userID)
+	if err != nil || user == nil {
+		return nil, fmt.Errorf("user not found")
+	}
+	return &models.TwoFactorStatus{
+		Enabled:                user.TOTPEnabled,
+		Required:               s.orgRequires2FA(ctx, user.OrgID),
+		RecoveryCodesRemaining: len(user.RecoveryCodes),
+	}, nil
+}
+
+// SetupTOTP starts enrolment with a new secret, which takes effect once
+// EnableTOTP confirms the user's app produces matching codes.
+func (s *AuthService) SetupTOTP(ctx context.Context, userID string) (*models.TOTPSetup, error) {
+	user, err := s.repo.GetUser(ctx, userID)
+	if err != nil || user == nil {
+		return nil, fmt.Errorf("user not found")
+	}
+	if user.TOTPEnabled {
+		return nil, fmt.Errorf("two-factor authentication is already enabled")
+	}
+	secret := utils.GenerateTOTPSecret()
+	if err := s.repo.SaveTOTPEnrolment(ctx, user.ID, secret, totpEnrolmentTTL); err != nil {
+		return nil, err
+	}
+	return &models.TOTPSetup{
+		Secret:    secret,
+		URI:       utils.TOTPProvisioningURI(totpIssuer, user.Email, secret),
+		ExpiresAt: time.Now().Add(totpEnrolmentTTL).Unix(),
+	}, nil
+}
+
+// EnableTOTP confirms enrolment with a code from the user's app and returns
+// the recovery codes, which are only ever shown this once.
+func (s *AuthService) EnableTOTP(ctx context.Context, userID string, req *models.TOTPCodeRequest) (*models.RecoveryCodes, error) {
+	user, err := s.repo.GetUser(ctx, userID)
+	if err != nil || user == nil {
+		return nil, fmt.Errorf("user not found")
+	}
+	if user.TOTPEnabled {
+		return nil, fmt.Errorf("two-factor authentication is already enabled")
+	}
+	secret, err := s.repo.GetTOTPEnrolment(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if secret == "" {
+		return nil, fmt.Errorf("setup expired; start again")
+	}
+	step, ok := utils.VerifyTOTP(secret, req.Code, time.Now())
+	if !ok {
+		return nil, ErrInvalidMFACode
+	}
+	codes := utils.GenerateRecoveryCodes(recoveryCodeCount)
+	user.TOTPEnabled = true
+	user.TOTPSecret = secret
+	user.TOTPLastStep = step
+	user.RecoveryCodes = s.hashRecoveryCodes(codes)
+	if err := s.repo.UpdateUser(ctx, user); err != nil {
+		return nil, err
+	}
+	_ = s.repo.DeleteTOTPEnrolment(ctx, user.ID)
+	log.Info().Str("user", user.ID).Msg("Two-factor authentication enabled")
+	return &models.RecoveryCodes{RecoveryCodes: codes}, nil
+}
+
+// RegenerateRecoveryCodes replaces all recovery codes after checking a TOTP code.
+func (s *AuthService) RegenerateRecoveryCodes(ctx context.Context, userID string, req *models.TOTPCodeRequest) (*models.RecoveryCodes, error) {
+	user, err := s.repo.GetUser(ctx, userID)
+	if err != nil || user == nil {
+		return nil, fmt.Errorf("user not found")
+	}
+	ok, err := s.checkSecondFactor(ctx, user, req.Code, false)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrInvalidMFACode
+	}
+	codes := utils.GenerateRecoveryCodes(recoveryCodeCount)
+	user.RecoveryCodes = s.hashRecoveryCodes(codes)
+	if err := s.repo.UpdateUser(ctx, user); err != nil {
+		return nil, err
+	}
+	return &models.RecoveryCodes{RecoveryCodes: codes}, nil
+}
+
+// DisableTOTP turns 2FA off after checking the password and a code, unless
+// the org requires it.
+func (s *AuthService) DisableTOTP(ctx context.Context, userID string, req *models.DisableTOTPRequest) error {
+	user, err := s.repo.GetUser(ctx, userID)
+	if err != nil || user == nil {
+		return fmt.Errorf("user not found")
+	}
+	if s.orgRequires2FA(ctx, user.OrgID) {
+		return ErrMFARequiredByOrg
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.Password)); err != nil {
+		return fmt.Errorf("password is incorrect")
+	}
+	ok, err := s.checkSecondFactor(ctx, user, req.Code, true)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrInvalidMFACode
+	}
+	clearTOTP(user)
+	if err := s.repo.UpdateUser(ctx, user); err != nil {
+		return err
+	}
+	log.Info().Str("user", user.ID).Msg("Two-factor authentication disabled")
+	return nil
+}
+
+func (s *AuthService) orgRequires2FA(ctx context.Context, orgID string) bool {
+	org, _ := s.repo.GetOrg(ctx, orgID)
+	return org != nil && org.Require2FA
+}
+
+func (s *AuthService) hashRecoveryCodes(codes []string) []string {
+	hashes := make([]string, len(codes))
+	for i, c := range codes {
+		hashes[i] = utils.HashRecoveryCode(s.jwtSecret, c)
+	}
+	return hashes
+}
+
+func clearTOTP(user *models.User) {
+	user.TOTPEnabled = false
+	user.TOTPSecret = ""
+	user.TOTPLastStep = 0
+	user.RecoveryCodes = nil
+}
@@ -0,0 +1,100 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"praana/internal/models"
+	"praana/internal/repository"
+	"praana/internal/utils"
+)
+
+var ErrMuteNotFound = errors.New("mute not found")
+
+type MuteService struct {
+	repo     *repository.RedisRepo
+	patients *PatientService
+	audit    *AuditService
+}
+
+func NewMuteService(repo *repository.RedisRepo, patients *PatientService, audit *AuditService) *MuteService {
+	return &MuteService{repo: repo, patients: patients, audit: audit}
+}
+
+// Create silences alerts for one of the patient's vitals for the given duration.
+func (s *MuteService) Create(ctx context.Context, orgID, patientID, userID string, req *models.CreateMuteRequest) (*models.AlertMute, error) {
+	p, err := s.patients.Get(ctx, orgID, patientID)
+	if err != nil {
+		return nil, err
+	}
+	author, err := s.repo.GetUser(ctx, userID)
+	if err != nil || author == nil {
+		return nil, fmt.Errorf("user not found")
+	}
+
+	now := time.Now()
+	mute := &models.AlertMute{
+		ID:            utils.GenerateID(),
+		OrgID:         orgID,
+		PatientID:     p.ID,
+		VitalType:     req.VitalType,
+		Reason:        req.Reason,
+		CreatedBy:     author.ID,
+		CreatedByName: author.Name,
+		CreatedAt:     now.Unix(),
+		ExpiresAt:     now.Add(time.Duration(req.DurationMinutes) * time.Minute).Unix(),
+	}
+	if err := s.repo.CreateMute(ctx, mute); err != nil {
+		return nil, err
+	}
+	s.audit.Record(ctx, orgID, userID, "alert.mute", "patient", p.ID, map[string]string{
+		"mute_id":          mute.ID,
+		"vital_type":       mute.VitalType,
+		"duration_minutes": strconv.Itoa(req.DurationMinutes),
+		"reason":           mute.Reason,
+	})
+	return mute, nil
+}
+
+// Active returns the patient's unexpired mutes.
+func (s *MuteService) Active(ctx context.Context, orgID, patientID string) ([]models.AlertMute, error) {
+	p, err := s.patients.Get(ctx, orgID, patientID)
+	if err != nil {
+		return nil, err
+	}
+	mutes, err := s.repo.GetActiveMutes(ctx, orgID, p.ID)
+	if err != nil {
+		return nil, err
+	}
+	if mutes == nil {
+		mutes = []models.AlertMute{}
+	}
+	return mutes, nil
+}
+
+// Delete lifts one of the patient's mutes early.
+func (s *MuteService) Delete(ctx context.Context, orgID, patientID, muteID, userID string) error {
+	p, err := s.patients.Get(ctx, orgID, patientID)
+	if err != nil {
+		return err
+	}
+	mute, err := s.repo.GetMute(ctx, orgID, muteID)
+	if err != nil {
+		return err
+	}
+	if mute == nil || mute.PatientID != p.ID {
+		return ErrMuteNotFound
+	}
+	ok, err := s.repo.DeleteMute(ctx, orgID, p.ID, muteID)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrMuteNotFound
+	}
+	s.audit.Record(ctx, orgID, userID, "alert.unmute", "patient", p.ID, map[string]string{"mute_id": muteID})
+	return nil
+}
@@ -0,0 +1,97 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"praana/internal/models"
+	"praana/internal/repository"
+	"praana/internal/utils"
+)
+
+var ErrNotNoteAuthor = errors.New("only the author or an admin can amend this note")
+
+type NoteService struct {
+	repo     *repository.RedisRepo
+	patients *PatientService
+}
+
+func NewNoteService(repo *repository.RedisRepo, patients *PatientService) *NoteService {
+	return &NoteService{repo: repo, patients: patients}
+}
+
+func (s *NoteService) Create(ctx context.Context, orgID, patientID, userID string, req *models.CreateNoteRequest) (*models.ClinicalNote, error) {
+	p, err := s.patients.Get(ctx, orgID, patientID)
+	if err != nil {
+		return nil, err
+	}
+	author, err := s.repo.GetUser(ctx, userID)
+	if err != nil || author == nil {
+		return nil, fmt.Errorf("user not found")
+	}
+
+	now := time.Now().Unix()
+	note := &models.ClinicalNote{
+		ID:         utils.GenerateID(),
+		OrgID:      orgID,
+		PatientID:  p.ID,
+		EpisodeID:  p.ActiveEpisodeID,
+		Category:   req.Category,
+		Body:       req.Body,
+		AuthorID:   author.ID,
+		AuthorName: author.Name,
+		AuthorRole: author.Role,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+	if err := s.repo.CreateNote(ctx, note); err != nil {
+		return nil, err
+	}
+	return note, nil
+}
+
+// List returns a patient's notes, newest first, optionally limited to one category.
+func (s *NoteService) List(ctx context.Context, orgID, patientID string, category models.NoteCategory) ([]models.ClinicalNote, error) {
+	p, err := s.patients.Get(ctx, orgID, patientID)
+	if err != nil {
+		return nil, err
+	}
+	notes, err := s.repo.GetPatientNotes(ctx, orgID, p.ID)
+	if err != nil || category == "" {
+		return notes, err
+	}
+	var filtered []models.ClinicalNote
+	for _, n := range notes {
+		if n.Category == category {
+			filtered = append(filtered, n)
+		}
+	}
+	return filtered, nil
+}
+
+// Amend replaces a note's body, keeping the previous text in its amendment history.
+func (s *NoteService) Amend(ctx context.Context, orgID, noteID, userID string, role models.Role, req *models.AmendNoteRequest) (*models.ClinicalNote, error) {
+	note, err := s.repo.GetNote(ctx, orgID, noteID)
+	if err != nil || note == nil {
+		return nil, fmt.Errorf("note not found")
+	}
+	if note.AuthorID != userID && role != models.RoleAdmin {
+		return nil, ErrNotNoteAuthor
+	}
+
+	now := time.Now().Unix()
+	note.Amendments = append(note.Amendments, models.NoteAmendment{
+		PreviousBody: note.Body,
+		Reason:       req.Reason,
+		AmendedBy:    userID,
+		AmendedAt:    now,
+	})
+	note.Body = req.Body
+	note.UpdatedAt = now
+	if err := s.repo.UpdateNote(ctx, note); err != nil {
+		return nil, err
+	}
+	return note, nil
+}
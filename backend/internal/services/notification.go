@@ -0,0 +1,361 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"praana/internal/mailer"
+	"praana/internal/models"
+	"praana/internal/repository"
+	"praana/internal/utils"
+)
+
+// deliveryKindNotification marks deliveries to alert notification channels.
+const deliveryKindNotification = "notification"
+
+// Email channels get alerts as digests: alerts raised within digestWindow of
+// the first one are sent together.
+const (
+	eventAlertDigest = "alert.digest"
+	digestWindow     = time.Minute
+)
+
+var (
+	ErrChannelNotFound = errors.New("channel not found")
+	ErrInvalidChannel  = errors.New("invalid channel")
+)
+
+// NotificationService manages each org's notification channels and routes
+// raised alerts to them through the delivery queue.
+type NotificationService struct {
+	repo       *repository.RedisRepo
+	deliveries *DeliveryService
+	notifiers  map[models.ChannelType]Notifier
+}
+
+func NewNotificationService(repo *repository.RedisRepo, deliveries *DeliveryService, notifiers ...Notifier) *NotificationService {
+	s := &NotificationService{repo: repo, deliveries: deliveries, notifiers: make(map[models.ChannelType]Notifier)}
+	for _, n := range notifiers {
+		s.notifiers[n.Type()] = n
+	}
+	deliveries.Register(deliveryKindNotification, s.send)
+	return s
+}
+
+func (s *NotificationService) Create(ctx context.Context, orgID string, req *models.ChannelRequest) (*models.NotificationChannel, error) {
+	now := time.Now().Unix()
+	ch := &models.NotificationChannel{
+		ID:        utils.GenerateID(),
+		OrgID:     orgID,
+		CreatedAt: now,
+	}
+	if err := s.apply(ctx, ch, req); err != nil {
+		return nil, err
+	}
+	if err := s.repo.SaveChannel(ctx, ch); err != nil {
+		return nil, err
+	}
+	return ch, nil
+}
+
+func (s *NotificationService) Update(ctx context.Context, orgID, channelID string, req *models.ChannelRequest) (*models.NotificationChannel, error) {
+	ch, err := s.repo.GetChannel(ctx, orgID, channelID)
+	if err != nil {
+		return nil, err
+	}
+	if ch == nil {
+		return nil, ErrChannelNotFound
+	}
+	if req.Type == ch.Type {
+		if req.Secret == "" {
+			req.Secret = ch.Secret
+		}
+		if req.AuthToken == "" {
+			req.AuthToken = ch.AuthToken
+		}
+	}
+	if err := s.apply(ctx, ch, req); err != nil {
+		return nil, err
+	}
+	if err := s.repo.SaveChannel(ctx, ch); err != nil {
+		return nil, err
+	}
+	return ch, nil
+}
+
+// apply copies a validated request onto the channel. Webhooks without a
+// secret are given a random one. Email recipients must be addresses, and
+// webhook and pager URLs must resolve to public addresses.
+func (s *NotificationService) apply(ctx context.Context, ch *models.NotificationChannel, req *models.ChannelRequest) error {
+	if _, ok := s.notifiers[req.Type]; !ok {
+		return fmt.Errorf("%w: %s channels are not available", ErrInvalidChannel, req.Type)
+	}
+	if req.Type == models.ChannelEmail {
+		if err := utils.ValidateVar(req.Recipients, "dive,email"); err != nil {
+			return fmt.Errorf("%w: recipients must be email addresses", ErrInvalidChannel)
+		}
+	} else if err := utils.CheckPublicURL(ctx, req.URL); err != nil {
+		return fmt.Errorf("%w: url: %v", ErrInvalidChannel, err)
+	}
+	ch.Name = strings.TrimSpace(req.Name)
+	ch.Type = req.Type
+	ch.Enabled = req.Enabled == nil || *req.Enabled
+	ch.URL = req.URL
+	ch.Secret = ""
+	ch.AuthToken = ""
+	ch.Recipients = req.Recipients
+	ch.Severities = req.Severities
+	ch.WardIDs = req.WardIDs
+	ch.UpdatedAt = time.Now().Unix()
+	switch ch.Type {
+	case models.ChannelWebhook:
+		ch.Recipients = nil
+		ch.Secret = req.Secret
+		if ch.Secret == "" {
+			ch.Secret = randomSecret()
+		}
+	case models.ChannelEmail:
+		ch.URL = ""
+	case models.ChannelPager:
+		ch.AuthToken = req.AuthToken
+	}
+	ch.HasSecret = ch.Secret != "" || ch.AuthToken != ""
+	return nil
+}
+
+func randomSecret() string {
+	b := make([]byte, 32)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+func (s *NotificationService) List(ctx context.Context, orgID string) ([]models.NotificationChannel, error) {
+	channels, err := s.repo.GetChannels(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+	if channels == nil {
+		channels = []models.NotificationChannel{}
+	}
+	return channels, nil
+}
+
+func (s *NotificationService) Delete(ctx context.Context, orgID, channelID string) error {
+	ch, err := s.repo.GetChannel(ctx, orgID, channelID)
+	if err != nil {
+		return err
+	}
+	if ch == nil {
+		return ErrChannelNotFound
+	}
+	return s.repo.DeleteChannel(ctx, orgID, channelID)
+}
+
+// Test queues a sample notification to the channel.
+func (s *NotificationService) Test(ctx context.Context, orgID, channelID string) (*models.Delivery, error) {
+	ch, err := s.repo.GetChannel(ctx, orgID, channelID)
+	if err != nil {
+		return nil, err
+	}
+	if ch == nil {
+		return nil, ErrChannelNotFound
+	}
+	subject := fmt.Sprintf("[TEST] Praana test notification for %s", ch.Name)
+	payload := map[string]interface{}{"event": "notification.test", "channel_id": ch.ID}
+	return s.deliveries.Enqueue(ctx, orgID, deliveryKindNotification, ch.ID, "notification.test", payload, subject, subject+"\n")
+}
+
+// AlertRaised queues the alert for every enabled channel whose routing
+// matches its severity and the patient's ward.
+func (s *NotificationService) AlertRaised(ctx context.Context, patient *models.Patient, alert *models.Alert) {
+	channels, err := s.repo.GetChannels(ctx, alert.OrgID)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to load notification channels")
+		return
+	}
+	if len(channels) == 0 {
+		return
+	}
+
+	subject := fmt.Sprintf("[%s] %s", strings.ToUpper(string(alert.Severity)), alert.Message)
+	var body strings.Builder
+	fmt.Fprintf(&body, "%s\n\n", alert.Message)
+	fmt.Fprintf(&body, "Patient: %s\n", patient.Name)
+	if patient.Ward != "" || patient.BedNumber != "" {
+		fmt.Fprintf(&body, "Ward/bed: %s %s\n", patient.Ward, patient.BedNumber)
+	}
+	fmt.Fprintf(&body, "Vital: %s = %.1f (threshold %.1f)\n", alert.VitalType, alert.Value, alert.Threshold)
+	fmt.Fprintf(&body, "Raised: %s\n", time.Unix(alert.CreatedAt, 0).UTC().Format(time.RFC3339))
+	orgName := ""
+	if org, _ := s.repo.GetOrg(ctx, alert.OrgID); org != nil {
+		orgName = org.Name
+	}
+	payload := map[string]interface{}{
+		"event": "alert.raised",
+		"org":   map[string]string{"id": alert.OrgID, "name": orgName},
+		"alert": alert,
+		"patient": map[string]string{
+			"id":         patient.ID,
+			"name":       patient.Name,
+			"ward":       patient.Ward,
+			"ward_id":    patient.WardID,
+			"bed_number": patient.BedNumber,
+		},
+	}
+
+	digestEntry, _ := json.Marshal(mailer.DigestAlert{
+		Severity: strings.ToUpper(string(alert.Severity)),
+		Patient:  patient.Name,
+		Location: strings.TrimSpace(patient.Ward + " " + patient.BedNumber),
+		Message:  alert.Message,
+		At:       time.Unix(alert.CreatedAt, 0).UTC().Format(time.RFC1123),
+	})
+
+	for i := range channels {
+		ch := &channels[i]
+		if !routes(ch, alert, patient) {
+			continue
+		}
+		if ch.Type == models.ChannelEmail {
+			s.addToDigest(ctx, ch, string(digestEntry))
+			continue
+		}
+		if _, err := s.deliveries.Enqueue(ctx, alert.OrgID, deliveryKindNotification, ch.ID, "alert.raised", payload, subject, body.String()); err != nil {
+			log.Error().Err(err).Str("channel", ch.ID).Msg("Failed to queue alert notification")
+		}
+	}
+}
+
+// addToDigest adds an alert to the channel's next digest, queueing the digest
+// delivery if this is the first alert since the last one was sent.
+func (s *NotificationService) addToDigest(ctx context.Context, ch *models.NotificationChannel, entry string) {
+	first, err := s.repo.AddToDigest(ctx, ch.OrgID, ch.ID, entry)
+	if err != nil {
+		log.Error().Err(err).Str("channel", ch.ID).Msg("Failed to add alert to digest")
+		return
+	}
+	if first {
+		s.queueDigest(ctx, ch)
+	}
+}
+
+func (s *NotificationService) queueDigest(ctx context.Context, ch *models.NotificationChannel) {
+	payload := map[string]interface{}{"event": eventAlertDigest, "channel_id": ch.ID}
+	subject := fmt.Sprintf("Alert digest for %s", ch.Name)
+	if _, err := s.deliveries.EnqueueAt(ctx, ch.OrgID, deliveryKindNotification, ch.ID, eventAlertDigest, payload, subject, "", time.Now().Add(digestWindow)); err != nil {
+		log.Error().Err(err).Str("channel", ch.ID).Msg("Failed to queue alert digest")
+	}
+}
+
+// sendDigest sends every alert collected for the channel in one email. The
+// alerts are kept until the send succeeds, so a retry includes any raised in
+// the meantime, and are saved on the delivery for the log and for replays.
+func (s *NotificationService) sendDigest(ctx context.Context, ch *models.NotificationChannel, n Notifier, d *models.Delivery) error {
+	entries, err := s.repo.GetDigest(ctx, d.OrgID, ch.ID)
+	if err != nil {
+		return err
+	}
+	if ch.Type != models.ChannelEmail {
+		// The channel stopped being an email channel; drop what it collected.
+		_, err := s.repo.TrimDigest(ctx, d.OrgID, ch.ID, len(entries))
+		return err
+	}
+	if len(entries) == 0 {
+		// A replay: resend the alerts saved on the delivery, if any.
+		var sent struct {
+			Alerts []mailer.DigestAlert `json:"alerts"`
+		}
+		if json.Unmarshal(d.Payload, &sent) != nil || len(sent.Alerts) == 0 {
+			return nil
+		}
+		return n.Send(ctx, ch, d)
+	}
+
+	alerts := make([]mailer.DigestAlert, 0, len(entries))
+	for _, e := range entries {
+		var a mailer.DigestAlert
+		if json.Unmarshal([]byte(e), &a) == nil {
+			alerts = append(alerts, a)
+		}
+	}
+	orgName := ""
+	if org, _ := s.repo.GetOrg(ctx, d.OrgID); org != nil {
+		orgName = org.Name
+	}
+	d.Payload, _ = json.Marshal(map[string]interface{}{
+		"event":      eventAlertDigest,
+		"channel_id": ch.ID,
+		"org":        map[string]string{"id": d.OrgID, "name": orgName},
+		"alerts":     alerts,
+	})
+	if err := n.Send(ctx, ch, d); err != nil {
+		return err
+	}
+
+	more, err := s.repo.TrimDigest(ctx, d.OrgID, ch.ID, len(entries))
+	if err != nil {
+		log.Error().Err(err).Str("channel", ch.ID).Msg("Failed to trim sent alert digest")
+		return nil
+	}
+	if more {
+		s.queueDigest(ctx, ch)
+	}
+	return nil
+}
+
+func routes(ch *models.NotificationChannel, alert *models.Alert, patient *models.Patient) bool {
+	if !ch.Enabled {
+		return false
+	}
+	if len(ch.Severities) > 0 {
+		ok := false
+		for _, sev := range ch.Severities {
+			if sev == alert.Severity {
+				ok = true
+				break
+			}
+		}
+		if !ok {
+			return false
+		}
+	}
+	if len(ch.WardIDs) > 0 {
+		ok := false
+		for _, id := range ch.WardIDs {
+			if id == patient.WardID {
+				ok = true
+				break
+			}
+		}
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// send is the delivery queue's sender for notification channels.
+func (s *NotificationService) send(ctx context.Context, d *models.Delivery) error {
+	ch, err := s.repo.GetChannel(ctx, d.OrgID, d.TargetID)
+	if err != nil {
+		return err
+	}
+	if ch == nil {
+		return fmt.Errorf("channel %s no longer exists", d.TargetID)
+	}
+	n, ok := s.notifiers[ch.Type]
+	if !ok {
+		return fmt.Errorf("no notifier for %s channels", ch.Type)
+	}
+	if d.Event == eventAlertDigest {
+		return s.sendDigest(ctx, ch, n, d)
+	}
+	return n.Send(ctx, ch, d)
+}
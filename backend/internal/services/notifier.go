@@ -0,0 +1,107 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"praana/internal/mailer"
+	"praana/internal/models"
+	"praana/internal/utils"
+)
+
+// Notifier sends a delivery to one type of notification channel.
+type Notifier interface {
+	Type() models.ChannelType
+	Send(ctx context.Context, ch *models.NotificationChannel, d *models.Delivery) error
+}
+
+var outboundClient = utils.NewPublicHTTPClient(10 * time.Second)
+
+// postJSON POSTs body to url and treats any non-2xx response as a failure.
+func postJSON(ctx context.Context, url string, body []byte, header http.Header) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	resp, err := outboundClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		snippet, _ := io.ReadAll(io.LimitReader(resp.Body, 256))
+		return fmt.Errorf("%s responded %d: %s", url, resp.StatusCode, strings.TrimSpace(string(snippet)))
+	}
+	return nil
+}
+
+// signedHeaders identifies and signs a webhook delivery.
+func signedHeaders(secret string, d *models.Delivery) http.Header {
+	ts := time.Now().Unix()
+	h := http.Header{}
+	h.Set("X-Praana-Event", d.Event)
+	h.Set("X-Praana-Delivery", d.ID)
+	h.Set("X-Praana-Timestamp", strconv.FormatInt(ts, 10))
+	h.Set("X-Praana-Signature", "sha256="+signPayload(secret, ts, d.Payload))
+	return h
+}
+
+// WebhookNotifier POSTs the JSON payload, signed with the channel secret.
+type WebhookNotifier struct{}
+
+func (WebhookNotifier) Type() models.ChannelType { return models.ChannelWebhook }
+
+func (WebhookNotifier) Send(ctx context.Context, ch *models.NotificationChannel, d *models.Delivery) error {
+	return postJSON(ctx, ch.URL, d.Payload, signedHeaders(ch.Secret, d))
+}
+
+// EmailNotifier sends alert digests, and anything else (such as channel
+// tests) as plain text.
+type EmailNotifier struct {
+	Mail *MailService
+}
+
+func (EmailNotifier) Type() models.ChannelType { return models.ChannelEmail }
+
+func (n EmailNotifier) Send(ctx context.Context, ch *models.NotificationChannel, d *models.Delivery) error {
+	var payload struct {
+		Org struct {
+			Name string `json:"name"`
+		} `json:"org"`
+		Alerts []mailer.DigestAlert `json:"alerts"`
+	}
+	if err := json.Unmarshal(d.Payload, &payload); err != nil || len(payload.Alerts) == 0 {
+		return n.Mail.SendText(ctx, ch.Recipients, d.Subject, d.Body)
+	}
+	return n.Mail.SendAlertDigest(ctx, ch.Recipients, payload.Org.Name, payload.Alerts)
+}
+
+// PagerNotifier POSTs {"to": [...], "message": "..."} to a pager or SMS
+// gateway, with the channel's auth token as a bearer token.
+type PagerNotifier struct{}
+
+func (PagerNotifier) Type() models.ChannelType { return models.ChannelPager }
+
+func (PagerNotifier) Send(ctx context.Context, ch *models.NotificationChannel, d *models.Delivery) error {
+	body, _ := json.Marshal(map[string]interface{}{
+		"to":      ch.Recipients,
+		"message": d.Subject,
+		"id":      d.ID,
+	})
+	h := http.Header{}
+	if ch.AuthToken != "" {
+		h.Set("Authorization", "Bearer "+ch.AuthToken)
+	}
+	return postJSON(ctx, ch.URL, body, h)
+}
@@ -0,0 +1,263 @@
+package services
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"praana/internal/models"
+	"praana/internal/repository"
+)
+
+type ObservationService struct {
+	repo     *repository.RedisRepo
+	patients *PatientService
+	alerts   *AlertService
+}
+
+func NewObservationService(repo *repository.RedisRepo, patients *PatientService, alerts *AlertService) *ObservationService {
+	return &ObservationService{repo: repo, patients: patients, alerts: alerts}
+}
+
+// Due lists when each admitted patient's next observations are due, soonest
+// first. It is limited to the given patients when patientIDs is non-nil.
+func (s *ObservationService) Due(ctx context.Context, orgID string, patientIDs map[string]bool, overdueOnly bool) ([]models.ObservationDue, error) {
+	patients, err := s.repo.GetPatients(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now().Unix()
+	due := make([]models.ObservationDue, 0, len(patients))
+	for i := range patients {
+		p := &patients[i]
+		if p.Status == models.StatusDischarged || (patientIDs != nil && !patientIDs[p.ID]) {
+			continue
+		}
+		latest, _ := s.repo.GetLatestVitals(ctx, orgID, p.ID)
+		d := observationDue(p, latest, now)
+		if overdueOnly && !d.Overdue {
+			continue
+		}
+		due = append(due, d)
+	}
+	sort.Slice(due, func(i, j int) bool { return due[i].DueAt < due[j].DueAt })
+	return due, nil
+}
+
+func (s *ObservationService) ForPatient(ctx context.Context, orgID, patientID string) (*models.ObservationDue, error) {
+	p, err := s.patients.Get(ctx, orgID, patientID)
+	if err != nil {
+		return nil, err
+	}
+	latest, _ := s.repo.GetLatestVitals(ctx, orgID, p.ID)
+	d := observationDue(p, latest, time.Now().Unix())
+	return &d, nil
+}
+
+// SetSchedule overrides the patient's observation interval; zero clears it.
+func (s *ObservationService) SetSchedule(ctx context.Context, orgID, patientID string, req *models.SetObservationScheduleRequest) (*models.ObservationDue, error) {
+	p, err := s.patients.Get(ctx, orgID, patientID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.SetObservationInterval(ctx, orgID, p.ID, req.IntervalMinutes); err != nil {
+		return nil, err
+	}
+	p.ObservationInterval = req.IntervalMinutes
+	latest, _ := s.repo.GetLatestVitals(ctx, orgID, p.ID)
+	d := observationDue(p, latest, time.Now().Unix())
+	return &d, nil
+}
+
+// Run checks every org for overdue observations each interval until ctx is
+// cancelled. Each overdue period raises one alert.
+func (s *ObservationService) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.checkAll(ctx)
+		}
+	}
+}
+
+func (s *ObservationService) checkAll(ctx context.Context) {
+	orgIDs, err := s.repo.GetOrgIDs(ctx)
+	if err != nil {
+		log.Error().Err(err).Msg("Observation check: failed to list orgs")
+		return
+	}
+	for _, orgID := range orgIDs {
+		if err := s.check(ctx, orgID); err != nil {
+			log.Error().Err(err).Str("org_id", orgID).Msg("Observation check failed")
+		}
+	}
+}
+
+func (s *ObservationService) check(ctx context.Context, orgID string) error {
+	patients, err := s.repo.GetPatients(ctx, orgID)
+	if err != nil {
+		return err
+	}
+	now := time.Now().Unix()
+	for i := range patients {
+		p := &patients[i]
+		if p.Status == models.StatusDischarged {
+			continue
+		}
+		latest, _ := s.repo.GetLatestVitals(ctx, orgID, p.ID)
+		d := observationDue(p, latest, now)
+		if !d.Overdue {
+			continue
+		}
+		first, err := s.repo.MarkObservationOverdueAlerted(ctx, orgID, p.ID, d.DueAt)
+		if err != nil {
+			return err
+		}
+		if first {
+			s.alerts.RaiseObservationsOverdue(ctx, p, &d)
+		}
+	}
+	return nil
+}
+
+// observationDue works out the patient's observation interval and when their
+// next vitals are due. A manual interval wins; otherwise the shorter of the
+// status default and the NEWS2-driven interval applies.
+func observationDue(p *models.Patient, latest *models.Vitals, now int64) models.ObservationDue {
+	d := models.ObservationDue{
+		PatientID:   p.ID,
+		PatientName: p.Name,
+		Ward:        p.Ward,
+		BedNumber:   p.BedNumber,
+	}
+
+	var red bool
+	if latest != nil {
+		d.NEWS2, red = news2Score(latest)
+		d.LastObservedAt = latest.RecordedAt
+	}
+
+	switch {
+	case p.ObservationInterval > 0:
+		d.IntervalMinutes, d.Source = p.ObservationInterval, models.ObservationManual
+	default:
+		d.IntervalMinutes, d.Source = models.DefaultObservationMinutes[p.Status], models.ObservationFromStatus
+		if d.IntervalMinutes == 0 {
+			d.IntervalMinutes = 240
+		}
+		if latest != nil {
+			if m := news2Interval(d.NEWS2, red); m < d.IntervalMinutes {
+				d.IntervalMinutes, d.Source = m, models.ObservationFromNEWS2
+			}
+		}
+	}
+
+	since := d.LastObservedAt
+	if since == 0 {
+		since = p.AdmittedAt
+	}
+	if since == 0 {
+		since = p.CreatedAt
+	}
+	d.DueAt = since + int64(d.IntervalMinutes)*60
+	if now > d.DueAt {
+		d.Overdue = true
+		d.OverdueMinutes = int((now - d.DueAt) / 60)
+	}
+	return d
+}
+
+// news2Score computes the NEWS2 early warning score from the parameters
+// Praana records. Consciousness and supplemental oxygen aren't captured and
+// score zero. red reports whether any single parameter scored 3.
+func news2Score(v *models.Vitals) (score int, red bool) {
+	add := func(points int) {
+		score += points
+		if points == 3 {
+			red = true
+		}
+	}
+	if rr := v.RespiratoryRate; rr > 0 {
+		switch {
+		case rr <= 8:
+			add(3)
+		case rr <= 11:
+			add(1)
+		case rr <= 20:
+		case rr <= 24:
+			add(2)
+		default:
+			add(3)
+		}
+	}
+	if spo2 := v.SpO2; spo2 > 0 {
+		switch {
+		case spo2 <= 91:
+			add(3)
+		case spo2 <= 93:
+			add(2)
+		case spo2 <= 95:
+			add(1)
+		}
+	}
+	if sbp := v.SystolicBP; sbp > 0 {
+		switch {
+		case sbp <= 90:
+			add(3)
+		case sbp <= 100:
+			add(2)
+		case sbp <= 110:
+			add(1)
+		case sbp >= 220:
+			add(3)
+		}
+	}
+	if hr := v.HeartRate; hr > 0 {
+		switch {
+		case hr <= 40:
+			add(3)
+		case hr <= 50:
+			add(1)
+		case hr <= 90:
+		case hr <= 110:
+			add(1)
+		case hr <= 130:
+			add(2)
+		default:
+			add(3)
+		}
+	}
+	if t := v.Temperature; t > 0 {
+		switch {
+		case t <= 35.0:
+			add(3)
+		case t <= 36.0:
+			add(1)
+		case t <= 38.0:
+		case t <= 39.0:
+			add(1)
+		default:
+			add(2)
+		}
+	}
+	return score, red
+}
+
+// news2Interval maps a NEWS2 score to the minimum observation frequency, in minutes.
+func news2Interval(score int, red bool) int {
+	switch {
+	case score >= 7:
+		return 15
+	case score >= 5 || red:
+		return 60
+	case score >= 1:
+		return 240
+	default:
+		return 720
+	}
+}
@@ -2,7 +2,9 @@ package services
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/rs/zerolog/log"
@@ -12,12 +14,25 @@ import (
 	"praana/internal/utils"
 )
 
+var ErrInviteNotFound = errors.New("invite not found")
+
+// inviteTTL is how long the org's invite links stay valid.
+func inviteTTL(org *models.Org) time.Duration {
+	if org.InviteExpiryHours > 0 {
+		return time.Duration(org.InviteExpiryHours) * time.Hour
+	}
+	return models.DefaultInviteExpiryHours * time.Hour
+}
+
 type OrgService struct {
-	repo *repository.RedisRepo
+	repo  *repository.RedisRepo
+	mail  *MailService
+	audit *AuditService
+	hub   *WSHub
 }
 
-func NewOrgService(repo *repository.RedisRepo) *OrgService {
-	return &OrgService{repo: repo}
+func NewOrgService(repo *repository.RedisRepo, mail *MailService, audit *AuditService, hub *WSHub) *OrgService {
+	return &OrgService{repo: repo, mail: mail, audit: audit, hub: hub}
 }
 
 func (s *OrgService) GetOrg(ctx context.Context, orgID string) (*models.Org, error) {
@@ -30,6 +45,31 @@ func (s *OrgService) UpdateOrg(ctx context.Context, orgID string, req *models.Or
 		return nil, fmt.Errorf("org not found")
 	}
 	org.Name = req.Name
+	if req.AlertAudience != "" {
+		org.AlertAudience = req.AlertAudience
+	}
+	if req.Timezone != "" || req.ShiftStarts != nil {
+		timezone, shiftStarts := org.Timezone, org.ShiftStarts
+		if req.Timezone != "" {
+			timezone = req.Timezone
+		}
+		if req.ShiftStarts != nil {
+			shiftStarts = req.ShiftStarts
+		}
+		if _, err := newOrgClock(timezone, shiftStarts); err != nil {
+			return nil, err
+		}
+		org.Timezone, org.ShiftStarts = timezone, shiftStarts
+	}
+	if req.InviteExpiryHours > 0 {
+		org.InviteExpiryHours = req.InviteExpiryHours
+	}
+	if req.IdleTimeoutMinutes > 0 {
+		org.IdleTimeoutMinutes = req.IdleTimeoutMinutes
+	}
+	if req.Require2FA != nil {
+		org.Require2FA = *req.Require2FA
+	}
 	org.UpdatedAt = time.Now().Unix()
 	if err := s.repo.UpdateOrg(ctx, org); err != nil {
 		return nil, err
@@ -37,8 +77,19 @@ func (s *OrgService) UpdateOrg(ctx context.Context, orgID string, req *models.Or
 	return org, nil
 }
 
+// GetMembers lists the org's members, with LockedUntil set on any whose
+// login is locked after repeated failures.
 func (s *OrgService) GetMembers(ctx context.Context, orgID string) ([]models.User, error) {
-	return s.repo.GetOrgMembers(ctx, orgID)
+	members, err := s.repo.GetOrgMembers(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range members {
+		if wait, _ := s.repo.LoginBlocked(ctx, lockoutScopeAccount, lockoutKey(members[i].Email)); wait > loginMaxDelay {
+			members[i].LockedUntil = time.Now().Add(wait).Unix()
+		}
+	}
+	return members, nil
 }
 
 func (s *OrgService) RemoveMember(ctx context.Context, orgID, memberID string) error {
@@ -52,50 +103,228 @@ func (s *OrgService) RemoveMember(ctx context.Context, orgID, memberID string) e
 	if user.Role == models.RoleAdmin {
 		return fmt.Errorf("cannot remove admin")
 	}
-	return s.repo.RemoveOrgMember(ctx, orgID, memberID)
+	if err := s.repo.RemoveOrgMember(ctx, orgID, memberID); err != nil {
+		return err
+	}
+	revoked, err := s.repo.RevokeUserSessions(ctx, memberID, "")
+	if err != nil {
+		return err
+	}
+	s.hub.DisconnectUser(memberID, "")
+	log.Info().Str("org", orgID).Str("member", memberID).Int("sessions_revoked", revoked).Msg("Member removed")
+	return nil
+}
+
+// member returns the org's member, or an error if the user isn't in the org.
+func (s *OrgService) member(ctx context.Context, orgID, memberID string) (*models.User, error) {
+	user, err := s.repo.GetUser(ctx, memberID)
+	if err != nil || user == nil || user.OrgID != orgID {
+		return nil, fmt.Errorf("member not found")
+	}
+	return user, nil
+}
+
+// MemberSessions lists a member's active sessions.
+func (s *OrgService) MemberSessions(ctx context.Context, orgID, memberID string) ([]models.Session, error) {
+	if _, err := s.member(ctx, orgID, memberID); err != nil {
+		return nil, err
+	}
+	sessions, err := s.repo.GetUserSessions(ctx, memberID)
+	if err != nil {
+		return nil, err
+	}
+	if sessions == nil {
+		sessions = []models.Session{}
+	}
+	return sessions, nil
+}
+
+// RevokeMemberSessions force-logs-out a member: one session if sessionID is
+// set, otherwise all of them. It returns how many sessions ended.
+func (s *OrgService) RevokeMemberSessions(ctx context.Context, orgID, memberID, sessionID string) (int, error) {
+	if _, err := s.member(ctx, orgID, memberID); err != nil {
+		return 0, err
+	}
+	if sessionID == "" {
+		revoked, err := s.repo.RevokeUserSessions(ctx, memberID, "")
+		if err == nil {
+			s.hub.DisconnectUser(memberID, "")
+			log.Info().Str("org", orgID).Str("member", memberID).Int("sessions_revoked", revoked).Msg("Member signed out everywhere")
+		}
+		return revoked, err
+	}
+	session, err := s.repo.GetSession(ctx, sessionID)
+	if err != nil {
+		return 0, err
+	}
+	if session == nil || session.UserID != memberID {
+		return 0, ErrSessionNotFound
+	}
+	if err := s.repo.DeleteSession(ctx, memberID, sessionID); err != nil {
+		return 0, err
+	}
+	s.hub.DisconnectSession(sessionID)
+	log.Info().Str("org", orgID).Str("member", memberID).Str("session", sessionID).Msg("Member session revoked")
+	return 1, nil
+}
+
+// ResetMemberTOTP turns off 2FA for a member who lost their authenticator
+// and signs them out; they log in with just their password and, if the org
+// requires 2FA, enrol again.
+func (s *OrgService) ResetMemberTOTP(ctx context.Context, orgID, memberID string) error {
+	user, err := s.member(ctx, orgID, memberID)
+	if err != nil {
+		return err
+	}
+	clearTOTP(user)
+	if err := s.repo.UpdateUser(ctx, user); err != nil {
+		return err
+	}
+	if _, err := s.repo.RevokeUserSessions(ctx, memberID, ""); err != nil {
+		return err
+	}
+	s.hub.DisconnectUser(memberID, "")
+	log.Info().Str("org", orgID).Str("member", memberID).Msg("Member two-factor authentication reset")
+	return nil
+}
+
+// UnlockMember lifts a login lockout on a member and resets their failed
+// attempts. Failures counted against the IPs they signed in from still apply.
+func (s *OrgService) UnlockMember(ctx context.Context, orgID, adminID, memberID string) error {
+	user, err := s.member(ctx, orgID, memberID)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.ClearLoginFailures(ctx, lockoutScopeAccount, lockoutKey(user.Email)); err != nil {
+		return err
+	}
+	s.audit.Record(ctx, orgID, adminID, "auth.unlock", "user", memberID, nil)
+	return nil
 }
 
-func (s *OrgService) CreateInvite(ctx context.Context, orgID string, req *models.InviteRequest) (*models.Invite, error) {
+// CreateInvite emails the invitee a signed link to join the org. Inviting an
+// email that already has a pending invite re-sends that invite with the new
+// role instead of adding another.
+func (s *OrgService) CreateInvite(ctx context.Context, orgID, userID string, req *models.InviteRequest) (*models.Invite, error) {
 	org, err := s.repo.GetOrg(ctx, orgID)
 	if err != nil || org == nil {
 		return nil, fmt.Errorf("org not found")
 	}
-
-	limits := models.PlanConfig[org.Plan]
-	if limits.MaxMembers > 0 {
-		count, _ := s.repo.GetOrgMemberCount(ctx, orgID)
-		if int(count) >= limits.MaxMembers {
-			return nil, fmt.Errorf("member limit reached for %s plan", org.Plan)
+	pending, err := s.repo.GetPendingInvites(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range pending {
+		if strings.EqualFold(pending[i].Email, req.Email) {
+			pending[i].Role = req.Role
+			pending[i].InvitedBy = userID
+			return s.sendInvite(ctx, org, &pending[i], userID)
 		}
 	}
 
+	if existing, _ := s.repo.GetUserByEmail(ctx, req.Email); existing != nil {
+		return nil, fmt.Errorf("email already registered")
+	}
+	if err := s.checkMemberLimit(ctx, org, len(pending)); err != nil {
+		return nil, err
+	}
+
 	invite := &models.Invite{
-		Code:      utils.GenerateInviteCode(),
+		ID:        utils.GenerateID(),
 		Email:     req.Email,
 		Role:      req.Role,
 		OrgID:     orgID,
+		InvitedBy: userID,
 		CreatedAt: time.Now().Unix(),
 	}
-	if err := s.repo.CreateInvite(ctx, invite); err != nil {
+	return s.sendInvite(ctx, org, invite, userID)
+}
+
+// ListInvites returns the org's pending invites, soonest to expire first.
+func (s *OrgService) ListInvites(ctx context.Context, orgID string) ([]models.Invite, error) {
+	invites, err := s.repo.GetPendingInvites(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+	if invites == nil {
+		invites = []models.Invite{}
+	}
+	return invites, nil
+}
+
+// ResendInvite emails a fresh link for a pending invite. The previous link
+// stops working and the expiry restarts.
+func (s *OrgService) ResendInvite(ctx context.Context, orgID, inviteID, userID string) (*models.Invite, error) {
+	org, err := s.repo.GetOrg(ctx, orgID)
+	if err != nil || org == nil {
+		return nil, fmt.Errorf("org not found")
+	}
+	invite, err := s.repo.GetInvite(ctx, orgID, inviteID)
+	if err != nil {
 		return nil, err
 	}
+	if invite == nil {
+		return nil, ErrInviteNotFound
+	}
+	return s.sendInvite(ctx, org, invite, userID)
+}
+
+// RevokeInvite cancels a pending invite so its link can no longer be used.
+func (s *OrgService) RevokeInvite(ctx context.Context, orgID, inviteID string) error {
+	invite, err := s.repo.GetInvite(ctx, orgID, inviteID)
+	if err != nil {
+		return err
+	}
+	if invite == nil {
+		return ErrInviteNotFound
+	}
+	if err := s.repo.DeleteInvite(ctx, invite); err != nil {
+		return err
+	}
+	log.Info().Str("org", orgID).Str("invite", inviteID).Msg("Invite revoked")
+	return nil
+}
 
-	log.Info().
-		Str("email", req.Email).
-		Str("code", invite.Code).
-		Str("org", org.Name).
-		Msg("[MOCK EMAIL] Invite sent")
+// sendInvite gives the invite a new code and expiry, saves it and emails the
+// link. If the email can't be sent the invite is put back as it was.
+func (s *OrgService) sendInvite(ctx context.Context, org *models.Org, invite *models.Invite, senderID string) (*models.Invite, error) {
+	previous := *invite
+	now := time.Now()
+	invite.Code = utils.GenerateInviteCode()
+	invite.ExpiresAt = now.Add(inviteTTL(org)).Unix()
+	invite.SendCount++
+	invite.LastSentAt = now.Unix()
+	if err := s.repo.SaveInvite(ctx, invite, previous.Code); err != nil {
+		return nil, err
+	}
 
+	senderName := ""
+	if sender, _ := s.repo.GetUser(ctx, senderID); sender != nil {
+		senderName = sender.Name
+	}
+	if err := s.mail.SendInvite(ctx, org, senderName, invite); err != nil {
+		if previous.Code == "" {
+			_ = s.repo.DeleteInvite(ctx, invite)
+		} else {
+			_ = s.repo.SaveInvite(ctx, &previous, invite.Code)
+		}
+		return nil, fmt.Errorf("failed to send invite email: %w", err)
+	}
+	log.Info().Str("org", org.ID).Str("invite", invite.ID).Int("send_count", invite.SendCount).Msg("Invite sent")
 	return invite, nil
 }
 
 func (s *OrgService) AcceptInvite(ctx context.Context, req *models.AcceptInviteRequest) (*models.User, error) {
-	invite, err := s.repo.GetInvite(ctx, req.Code)
+	code, err := s.mail.verify(linkInvite, req.Code)
+	if err != nil {
+		return nil, fmt.Errorf("invalid or expired invite code")
+	}
+	invite, err := s.repo.GetInviteByCode(ctx, code)
 	if err != nil || invite == nil {
 		return nil, fmt.Errorf("invalid or expired invite code")
 	}
 
-	if invite.Email != req.Email {
+	if !strings.EqualFold(invite.Email, req.Email) {
 		return nil, fmt.Errorf("email does not match invite")
 	}
 
@@ -103,6 +332,9 @@ func (s *OrgService) AcceptInvite(ctx context.Context, req *models.AcceptInviteR
 	if existing != nil {
 		return nil, fmt.Errorf("email already registered")
 	}
+	if err := utils.CheckPassword(req.Password, req.Email); err != nil {
+		return nil, err
+	}
 
 	hashedPwd, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
 	if err != nil {
@@ -114,18 +346,73 @@ func (s *OrgService) AcceptInvite(ctx context.Context, req *models.AcceptInviteR
 		Email:     req.Email,
 		Password:  string(hashedPwd),
 		Name:      req.Name,
-		Role:      invite.Role,
-		OrgID:     invite.OrgID,
 		CreatedAt: time.Now().Unix(),
 	}
-	if err := s.repo.CreateUser(ctx, user); err != nil {
+	// Reserve the email first, so a concurrent sign-up or SSO provisioning
+	// can't create a second account for it.
+	claimed, err := s.repo.ClaimUserEmail(ctx, user.Email, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if !claimed {
+		return nil, fmt.Errorf("email already registered")
+	}
+
+	// Claim the code before creating anything, so two requests racing with
+	// the same link can't both create an account.
+	invite, err = s.repo.ClaimInviteCode(ctx, code)
+	if err == nil && invite == nil {
+		err = fmt.Errorf("invalid or expired invite code")
+	}
+	if err != nil {
+		s.releaseEmail(ctx, user)
+		return nil, err
+	}
+	if err := s.repo.DeleteInvite(ctx, invite); err != nil {
+		s.restoreInvite(ctx, invite)
+		s.releaseEmail(ctx, user)
 		return nil, err
 	}
 
-	_ = s.repo.DeleteInvite(ctx, req.Code)
+	user.Role = invite.Role
+	user.OrgID = invite.OrgID
+	if err := s.repo.CreateUser(ctx, user); err != nil {
+		s.restoreInvite(ctx, invite)
+		s.releaseEmail(ctx, user)
+		return nil, err
+	}
 	return user, nil
 }
 
+// restoreInvite puts back an invite claimed by an acceptance that failed, so
+// the link still works.
+func (s *OrgService) restoreInvite(ctx context.Context, invite *models.Invite) {
+	if err := s.repo.SaveInvite(ctx, invite, ""); err != nil {
+		log.Error().Err(err).Str("invite", invite.ID).Msg("Failed to restore invite after failed acceptance")
+	}
+}
+
+// releaseEmail frees the email reserved by an acceptance that failed.
+func (s *OrgService) releaseEmail(ctx context.Context, user *models.User) {
+	if err := s.repo.ReleaseUserEmail(ctx, user.Email, user.ID); err != nil {
+		log.Error().Err(err).Str("email", user.Email).Msg("Failed to release email after failed acceptance")
+	}
+}
+
+// checkMemberLimit counts pending invites as seats already taken, so an org
+// can't send more invites than its plan has room for.
+func (s *OrgService) checkMemberLimit(ctx context.Context, org *models.Org, pendingInvites int) error {
+	limits := models.PlanConfig[org.Plan]
+	if limits.MaxMembers <= 0 {
+		return nil
+	}
+	count, _ := s.repo.GetOrgMemberCount(ctx, org.ID)
+	if int(count)+pendingInvites >= limits.MaxMembers {
+		return fmt.Errorf("member limit reached for %s plan (max %d, including pending invites)", org.Plan, limits.MaxMembers)
+	}
+	return nil
+}
+
 func (s *OrgService) CheckPlanLimit(ctx context.Context, orgID string, resource string) error {
 	org, err := s.repo.GetOrg(ctx, orgID)
 	if err != nil || org == nil {
@@ -142,12 +429,8 @@ func (s *OrgService) CheckPlanLimit(ctx context.Context, orgID string, resource
 			}
 		}
 	case "members":
-		if limits.MaxMembers > 0 {
-			count, _ := s.repo.GetOrgMemberCount(ctx, orgID)
-			if int(count) >= limits.MaxMembers {
-				return fmt.Errorf("member limit reached for %s plan (max %d)", org.Plan, limits.MaxMembers)
-			}
-		}
+		pending, _ := s.repo.GetPendingInvites(ctx, orgID)
+		return s.checkMemberLimit(ctx, org, len(pending))
 	}
 	return nil
 }
@@ -2,7 +2,9 @@ package services
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"praana/internal/models"
@@ -10,89 +12,263 @@ import (
 	"praana/internal/utils"
 )
 
+var (
+	ErrIdentifierInUse   = errors.New("identifier already assigned to another patient")
+	ErrInvalidIdentifier = errors.New("invalid identifier")
+	ErrPatientNotFound   = errors.New("patient not found")
+	ErrPatientHasBed     = errors.New("patient has an assigned bed; use transfer to move them")
+	ErrPatientDischarged = errors.New("patient is discharged; readmit to change status")
+)
+
 type PatientService struct {
-	repo *repository.RedisRepo
+	repo     *repository.RedisRepo
+	episodes *EpisodeService
 }
 
-func NewPatientService(repo *repository.RedisRepo) *PatientService {
-	return &PatientService{repo: repo}
+func NewPatientService(repo *repository.RedisRepo, episodes *EpisodeService) *PatientService {
+	return &PatientService{repo: repo, episodes: episodes}
 }
 
-func (s *PatientService) Create(ctx context.Context, orgID string, req *models.CreatePatientRequest) (*models.Patient, error) {
+func (s *PatientService) Create(ctx context.Context, orgID, userID string, req *models.CreatePatientRequest) (*models.Patient, error) {
+	identifiers, err := normalizeIdentifiers(req.Identifiers)
+	if err != nil {
+		return nil, err
+	}
+
 	patient := &models.Patient{
-		ID:         utils.GenerateID(),
-		OrgID:      orgID,
-		Name:       req.Name,
-		Age:        req.Age,
-		Gender:     req.Gender,
-		BedNumber:  req.BedNumber,
-		Ward:       req.Ward,
-		Diagnosis:  req.Diagnosis,
-		Status:     models.StatusActive,
-		AdmittedAt: time.Now().Unix(),
-		CreatedAt:  time.Now().Unix(),
-		UpdatedAt:  time.Now().Unix(),
+		ID:          utils.GenerateID(),
+		OrgID:       orgID,
+		Name:        req.Name,
+		Age:         req.Age,
+		Gender:      req.Gender,
+		BedNumber:   req.BedNumber,
+		Ward:        req.Ward,
+		BedID:       req.BedID,
+		Diagnosis:   req.Diagnosis,
+		Identifiers: identifiers,
+		Status:      models.StatusActive,
+		CreatedAt:   time.Now().Unix(),
+		UpdatedAt:   time.Now().Unix(),
+	}
+
+	if err := s.claimIdentifiers(ctx, patient, identifiers); err != nil {
+		return nil, err
+	}
+	ep, err := s.episodes.open(ctx, patient, req.AdmitReason, userID)
+	if err != nil {
+		_ = s.repo.ReleasePatientIdentifiers(ctx, orgID, patient.ID, identifiers)
+		return nil, err
 	}
 	if err := s.repo.CreatePatient(ctx, patient); err != nil {
+		_ = s.repo.ReleasePatientIdentifiers(ctx, orgID, patient.ID, identifiers)
+		_ = s.episodes.wards.release(ctx, orgID, patient.BedID, patient.ID)
 		return nil, err
 	}
+	s.episodes.emit(ctx, models.EventPatientAdmitted, patient, ep)
 	return patient, nil
 }
 
-func (s *PatientService) List(ctx context.Context, orgID string) ([]models.Patient, error) {
+// List returns admitted patients, or discharged (archived) patients when archived is set.
+func (s *PatientService) List(ctx context.Context, orgID string, archived bool) ([]models.Patient, error) {
+	if archived {
+		return s.repo.GetArchivedPatients(ctx, orgID)
+	}
 	return s.repo.GetPatients(ctx, orgID)
 }
 
+// Get returns the patient, following the redirect left behind if the ID was
+// merged into another patient.
 func (s *PatientService) Get(ctx context.Context, orgID, patientID string) (*models.Patient, error) {
 	p, err := s.repo.GetPatient(ctx, orgID, patientID)
 	if err != nil {
 		return nil, err
 	}
 	if p == nil {
-		return nil, fmt.Errorf("patient not found")
+		if target, _ := s.repo.GetPatientRedirect(ctx, orgID, patientID); target != "" {
+			p, err = s.repo.GetPatient(ctx, orgID, target)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	if p == nil {
+		return nil, ErrPatientNotFound
 	}
 	return p, nil
 }
 
-func (s *PatientService) Update(ctx context.Context, orgID, patientID string, req *models.UpdatePatientRequest) (*models.Patient, error) {
-	p, err := s.repo.GetPatient(ctx, orgID, patientID)
-	if err != nil || p == nil {
-		return nil, fmt.Errorf("patient not found")
+func (s *PatientService) FindByIdentifier(ctx context.Context, orgID, system, value string) (*models.Patient, error) {
+	patientID, err := s.repo.FindPatientIDByIdentifier(ctx, orgID, strings.TrimSpace(system), strings.TrimSpace(value))
+	if err != nil {
+		return nil, err
 	}
+	if patientID == "" {
+		return nil, ErrPatientNotFound
+	}
+	return s.Get(ctx, orgID, patientID)
+}
 
-	if req.Name != "" {
-		p.Name = req.Name
+// Update edits the patient's details. The edits are applied to the stored
+// record under WATCH, so they can't undo a concurrent admission, transfer or
+// discharge.
+func (s *PatientService) Update(ctx context.Context, orgID, patientID, userID string, req *models.UpdatePatientRequest) (*models.Patient, error) {
+	p, err := s.repo.GetPatient(ctx, orgID, patientID)
+	if err != nil {
+		return nil, err
 	}
-	if req.Age > 0 {
-		p.Age = req.Age
+	if p == nil {
+		return nil, ErrPatientNotFound
 	}
-	if req.BedNumber != "" {
-		p.BedNumber = req.BedNumber
+
+	var identifiers, claimed []models.PatientIdentifier
+	if req.Identifiers != nil {
+		if identifiers, err = normalizeIdentifiers(req.Identifiers); err != nil {
+			return nil, err
+		}
+		if err := s.claimIdentifiers(ctx, p, identifiers); err != nil {
+			return nil, err
+		}
+		claimed = identifierDiff(identifiers, p.Identifiers)
 	}
-	if req.Ward != "" {
-		p.Ward = req.Ward
+
+	var statusChange *models.StatusChange
+	var released []models.PatientIdentifier
+	updated, err := s.repo.ChangePatient(ctx, orgID, patientID, func(cur *models.Patient) error {
+		statusChange, released = nil, nil
+		bedChanged := (req.Ward != "" && req.Ward != cur.Ward) || (req.BedNumber != "" && req.BedNumber != cur.BedNumber)
+		if cur.BedID != "" && bedChanged {
+			return ErrPatientHasBed
+		}
+
+		if req.Name != "" {
+			cur.Name = req.Name
+		}
+		if req.Age > 0 {
+			cur.Age = req.Age
+		}
+		if req.BedNumber != "" {
+			cur.BedNumber = req.BedNumber
+		}
+		if req.Ward != "" {
+			cur.Ward = req.Ward
+		}
+		if req.Diagnosis != "" {
+			cur.Diagnosis = req.Diagnosis
+		}
+		if req.Status != "" {
+			if cur.Status == models.StatusDischarged {
+				return ErrPatientDischarged
+			}
+			if models.PatientStatus(req.Status) != cur.Status {
+				statusChange = &models.StatusChange{
+					ID:        utils.GenerateID(),
+					From:      cur.Status,
+					To:        models.PatientStatus(req.Status),
+					ChangedBy: userID,
+					ChangedAt: time.Now().Unix(),
+				}
+			}
+			cur.Status = models.PatientStatus(req.Status)
+		}
+		if req.Identifiers != nil {
+			released = identifierDiff(cur.Identifiers, identifiers)
+			cur.Identifiers = identifiers
+		}
+		cur.UpdatedAt = time.Now().Unix()
+		return nil
+	})
+	if err == nil && updated == nil {
+		err = ErrPatientNotFound
 	}
-	if req.Diagnosis != "" {
-		p.Diagnosis = req.Diagnosis
+	if err != nil {
+		_ = s.repo.ReleasePatientIdentifiers(ctx, orgID, patientID, claimed)
+		return nil, err
 	}
-	if req.Status != "" {
-		p.Status = models.PatientStatus(req.Status)
-		if p.Status == models.StatusDischarged {
-			p.DischargedAt = time.Now().Unix()
+	_ = s.repo.ReleasePatientIdentifiers(ctx, orgID, patientID, released)
+	if statusChange != nil {
+		if err := s.repo.AppendStatusChange(ctx, orgID, patientID, statusChange); err != nil {
+			return nil, err
 		}
 	}
-	p.UpdatedAt = time.Now().Unix()
-
-	if err := s.repo.UpdatePatient(ctx, p); err != nil {
+	if err := s.episodes.syncFromPatient(ctx, updated); err != nil {
 		return nil, err
 	}
-	return p, nil
+	return updated, nil
 }
 
-func (s *PatientService) Delete(ctx context.Context, orgID, patientID string) error {
+// Discharge closes the patient's episode, frees their bed and archives them.
+// Their record, vitals and alerts stay queryable.
+func (s *PatientService) Discharge(ctx context.Context, orgID, patientID, userID, reason string) (*models.Episode, error) {
+	if reason == "" {
+		reason = "Discharged"
+	}
+	return s.episodes.Discharge(ctx, orgID, patientID, userID, &models.DischargeRequest{Reason: reason})
+}
+
+// Purge permanently deletes a patient and all of their data.
+func (s *PatientService) Purge(ctx context.Context, orgID, patientID string) error {
 	p, err := s.repo.GetPatient(ctx, orgID, patientID)
-	if err != nil || p == nil {
-		return fmt.Errorf("patient not found")
+	if err != nil {
+		return fmt.Errorf("failed to load patient: %w", err)
+	}
+	if p == nil {
+		return ErrPatientNotFound
+	}
+	if err := s.episodes.wards.release(ctx, orgID, "", patientID); err != nil {
+		return fmt.Errorf("failed to release bed: %w", err)
+	}
+	if err := s.repo.ReleasePatientIdentifiers(ctx, orgID, patientID, p.Identifiers); err != nil {
+		return fmt.Errorf("failed to release identifiers: %w", err)
+	}
+	if err := s.repo.PurgePatient(ctx, orgID, patientID); err != nil {
+		return fmt.Errorf("failed to purge patient: %w", err)
+	}
+	return nil
+}
+
+func (s *PatientService) claimIdentifiers(ctx context.Context, p *models.Patient, ids []models.PatientIdentifier) error {
+	conflict, err := s.repo.ClaimPatientIdentifiers(ctx, p.OrgID, p.ID, ids)
+	if err != nil {
+		return err
+	}
+	if conflict != nil {
+		return fmt.Errorf("%w: %s %s", ErrIdentifierInUse, conflict.Type, conflict.Value)
+	}
+	return nil
+}
+
+// normalizeIdentifiers trims identifiers, validates the trimmed values and
+// rejects a system/value pair listed twice.
+func normalizeIdentifiers(ids []models.PatientIdentifier) ([]models.PatientIdentifier, error) {
+	seen := make(map[string]bool)
+	out := make([]models.PatientIdentifier, 0, len(ids))
+	for _, id := range ids {
+		id.System = strings.TrimSpace(id.System)
+		id.Value = strings.TrimSpace(id.Value)
+		if err := utils.Validate(&id); err != nil {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidIdentifier, err)
+		}
+		key := id.System + "|" + id.Value
+		if seen[key] {
+			return nil, fmt.Errorf("%w: %s %s listed twice", ErrInvalidIdentifier, id.Type, id.Value)
+		}
+		seen[key] = true
+		out = append(out, id)
+	}
+	return out, nil
+}
+
+// identifierDiff returns the identifiers in old that are absent from updated.
+func identifierDiff(old, updated []models.PatientIdentifier) []models.PatientIdentifier {
+	keep := make(map[string]bool)
+	for _, id := range updated {
+		keep[id.System+"|"+id.Value] = true
+	}
+	var removed []models.PatientIdentifier
+	for _, id := range old {
+		if !keep[id.System+"|"+id.Value] {
+			removed = append(removed, id)
+		}
 	}
-	return s.repo.DeletePatient(ctx, orgID, patientID)
+	return removed
 }
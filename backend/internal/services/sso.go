@@ -0,0 +1,405 @@
+package services
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/rs/zerolog/log"
+	"golang.org/x/crypto/bcrypt"
+	"praana/internal/models"
+	"praana/internal/oidc"
+	"praana/internal/repository"
+	"praana/internal/utils"
+)
+
+// ssoStateTTL is how long a user has to finish signing in at the provider.
+const ssoStateTTL = 10 * time.Minute
+
+var defaultSSOScopes = []string{"openid", "email", "profile"}
+
+var (
+	ErrSSONotConfigured    = errors.New("single sign-on is not set up for this organization")
+	ErrSSODomainTaken      = errors.New("another organization already uses this email domain for single sign-on")
+	ErrSSOFailed           = errors.New("single sign-on failed; try again or contact your administrator")
+	ErrInvalidSSOConfig    = errors.New("invalid single sign-on settings")
+	ErrSSODomainUnverified = errors.New("email domain is not verified")
+)
+
+// lookupTXT resolves the TXT records that prove an org controls a domain.
+var lookupTXT = net.DefaultResolver.LookupTXT
+
+type SSOService struct {
+	repo   *repository.RedisRepo
+	auth   *AuthService
+	orgs   *OrgService
+	audit  *AuditService
+	oidc   *oidc.Client
+	appURL string
+}
+
+func NewSSOService(repo *repository.RedisRepo, auth *AuthService, orgs *OrgService, audit *AuditService, oidcClient *oidc.Client, appURL string) *SSOService {
+	return &SSOService{repo: repo, auth: auth, orgs: orgs, audit: audit, oidc: oidcClient, appURL: strings.TrimSuffix(appURL, "/")}
+}
+
+// redirectURI is the app route the provider sends users back to; it posts
+// the code and state on to Callback.
+func (s *SSOService) redirectURI() string {
+	return s.appURL + "/auth/sso/callback"
+}
+
+func (s *SSOService) GetConfig(ctx context.Context, orgID string) (*models.SSOConfig, error) {
+	cfg, err := s.repo.GetSSOConfig(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+	if cfg == nil {
+		return nil, ErrSSONotConfigured
+	}
+	cfg.HasClientSecret = cfg.ClientSecret != ""
+	cfg.DomainVerification = s.domainVerificationRecord(orgID)
+	return cfg, nil
+}
+
+// domainVerificationRecord is the DNS TXT record an org publishes on a
+// domain to claim it for SSO. It is derived from the org ID with the server
+// secret, so it can't be guessed for another org.
+func (s *SSOService) domainVerificationRecord(orgID string) string {
+	mac := hmac.New(sha256.New, []byte(s.auth.jwtSecret))
+	mac.Write([]byte("sso-domain:" + orgID))
+	return "praana-verification=" + hex.EncodeToString(mac.Sum(nil))[:32]
+}
+
+// verifyDomain checks that the domain publishes the org's verification record.
+func (s *SSOService) verifyDomain(ctx context.Context, orgID, domain string) error {
+	record := s.domainVerificationRecord(orgID)
+	records, err := lookupTXT(ctx, domain)
+	if err == nil && slices.Contains(records, record) {
+		return nil
+	}
+	return fmt.Errorf("%w: add a DNS TXT record %q to %s and save again", ErrSSODomainUnverified, record, domain)
+}
+
+// SaveConfig sets the org's provider. Newly added domains must publish the
+// org's verification record. Enabling checks that the issuer's discovery
+// document can be fetched, so a typo fails here rather than at the first
+// sign-in.
+func (s *SSOService) SaveConfig(ctx context.Context, orgID string, req *models.SSOConfigRequest) (*models.SSOConfig, error) {
+	existing, err := s.repo.GetSSOConfig(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+	cfg := &models.SSOConfig{
+		OrgID:         orgID,
+		Enabled:       req.Enabled,
+		Issuer:        strings.TrimSuffix(req.Issuer, "/"),
+		ClientID:      req.ClientID,
+		ClientSecret:  req.ClientSecret,
+		Scopes:        req.Scopes,
+		RoleClaim:     req.RoleClaim,
+		RoleMapping:   req.RoleMapping,
+		DefaultRole:   req.DefaultRole,
+		AutoProvision: req.AutoProvision,
+		UpdatedAt:     time.Now().Unix(),
+	}
+	var previousDomains []string
+	if existing != nil {
+		previousDomains = existing.AllowedDomains
+		if cfg.ClientSecret == "" {
+			cfg.ClientSecret = existing.ClientSecret
+		}
+	}
+	if cfg.ClientSecret == "" {
+		return nil, fmt.Errorf("%w: client_secret is required", ErrInvalidSSOConfig)
+	}
+	if err := utils.CheckPublicURL(ctx, cfg.Issuer); err != nil {
+		return nil, fmt.Errorf("%w: issuer must be an http(s) URL on a public address", ErrInvalidSSOConfig)
+	}
+	if len(cfg.Scopes) == 0 {
+		cfg.Scopes = defaultSSOScopes
+	} else if !slices.Contains(cfg.Scopes, "openid") {
+		cfg.Scopes = append([]string{"openid"}, cfg.Scopes...)
+	}
+	for _, d := range req.AllowedDomains {
+		d = strings.ToLower(d)
+		if slices.Contains(cfg.AllowedDomains, d) {
+			continue
+		}
+		owner, err := s.repo.GetSSOOrgByDomain(ctx, d)
+		if err != nil {
+			return nil, err
+		}
+		if owner != "" && owner != orgID {
+			return nil, ErrSSODomainTaken
+		}
+		if !slices.Contains(previousDomains, d) {
+			if err := s.verifyDomain(ctx, orgID, d); err != nil {
+				return nil, err
+			}
+		}
+		cfg.AllowedDomains = append(cfg.AllowedDomains, d)
+	}
+	if cfg.Enabled {
+		// The provider's own error isn't shown: it could reveal what answers
+		// at an address the admin doesn't control.
+		if _, err := s.oidc.Discover(ctx, cfg.Issuer); err != nil {
+			log.Warn().Err(err).Str("org", orgID).Str("issuer", cfg.Issuer).Msg("SSO discovery failed")
+			return nil, fmt.Errorf("%w: could not fetch the identity provider's discovery document; check the issuer URL", ErrInvalidSSOConfig)
+		}
+	}
+
+	if err := s.repo.SaveSSOConfig(ctx, cfg, previousDomains); err != nil {
+		return nil, err
+	}
+	log.Info().Str("org", orgID).Str("issuer", cfg.Issuer).Bool("enabled", cfg.Enabled).Msg("SSO configured")
+	cfg.HasClientSecret = true
+	cfg.DomainVerification = s.domainVerificationRecord(orgID)
+	return cfg, nil
+}
+
+func (s *SSOService) DeleteConfig(ctx context.Context, orgID string) error {
+	cfg, err := s.repo.GetSSOConfig(ctx, orgID)
+	if err != nil {
+		return err
+	}
+	if cfg == nil {
+		return ErrSSONotConfigured
+	}
+	return s.repo.DeleteSSOConfig(ctx, cfg)
+}
+
+// Start picks the org from the email's domain and returns the provider URL
+// to send the browser to.
+func (s *SSOService) Start(ctx context.Context, req *models.SSOStartRequest) (*models.SSOStartResponse, error) {
+	_, domain, _ := strings.Cut(strings.ToLower(req.Email), "@")
+	orgID, err := s.repo.GetSSOOrgByDomain(ctx, domain)
+	if err != nil {
+		return nil, err
+	}
+	cfg, err := s.repo.GetSSOConfig(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+	if orgID == "" || cfg == nil || !cfg.Enabled {
+		return nil, fmt.Errorf("single sign-on is not set up for %s", domain)
+	}
+	provider, err := s.oidc.Discover(ctx, cfg.Issuer)
+	if err != nil {
+		log.Error().Err(err).Str("org", orgID).Msg("SSO discovery failed")
+		return nil, ErrSSOFailed
+	}
+
+	state := utils.GenerateToken()
+	browserKey := utils.GenerateToken()
+	pending := &models.SSOState{
+		OrgID:          orgID,
+		Nonce:          utils.GenerateToken(),
+		Verifier:       utils.GenerateToken(),
+		BrowserKeyHash: hashBrowserKey(browserKey),
+	}
+	if err := s.repo.SaveSSOState(ctx, state, pending, ssoStateTTL); err != nil {
+		return nil, err
+	}
+	return &models.SSOStartResponse{
+		RedirectURL: provider.AuthURL(oidc.AuthRequest{
+			ClientID:    cfg.ClientID,
+			RedirectURI: s.redirectURI(),
+			Scopes:      cfg.Scopes,
+			State:       state,
+			Nonce:       pending.Nonce,
+			Verifier:    pending.Verifier,
+		}),
+		BrowserKey: browserKey,
+	}, nil
+}
+
+// hashBrowserKey is how a sign-in's browser key is kept server-side, so the
+// stored state alone can't complete a callback.
+func hashBrowserKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// Callback finishes an SSO sign-in: it redeems the code, verifies the ID
+// token and signs in the matching org member, creating them first if the
+// org allows it. Users with Praana 2FA still have to enter a code.
+func (s *SSOService) Callback(ctx context.Context, req *models.SSOCallbackRequest, client models.ClientInfo) (*models.LoginResponse, error) {
+	pending, err := s.repo.ConsumeSSOState(ctx, req.State)
+	if err != nil {
+		return nil, err
+	}
+	if pending == nil {
+		return nil, fmt.Errorf("sign-in expired; start again")
+	}
+	// A callback carrying someone else's state (e.g. a link planted by an
+	// attacker to sign the victim into the attacker's account) lacks the key.
+	if subtle.ConstantTimeCompare([]byte(hashBrowserKey(req.BrowserKey)), []byte(pending.BrowserKeyHash)) != 1 {
+		log.Warn().Str("org", pending.OrgID).Msg("SSO callback from a different browser")
+		return nil, fmt.Errorf("sign-in was started in another browser; start again")
+	}
+	cfg, err := s.repo.GetSSOConfig(ctx, pending.OrgID)
+	if err != nil {
+		return nil, err
+	}
+	if cfg == nil || !cfg.Enabled {
+		return nil, ErrSSONotConfigured
+	}
+
+	claims, err := s.verify(ctx, cfg, req.Code, pending)
+	if err != nil {
+		log.Warn().Err(err).Str("org", cfg.OrgID).Msg("SSO sign-in rejected")
+		return nil, ErrSSOFailed
+	}
+	email, err := oidc.AllowedEmail(claims, cfg.AllowedDomains)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.repo.GetUserByEmail(ctx, email)
+	if rawEmail, _ := claims["email"].(string); err == nil && user == nil && rawEmail != email {
+		// Accounts created by password sign-up keep the email as typed.
+		user, err = s.repo.GetUserByEmail(ctx, rawEmail)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if user != nil && user.OrgID != cfg.OrgID {
+		return nil, fmt.Errorf("this email belongs to another organization")
+	}
+	if user == nil {
+		if user, err = s.provision(ctx, cfg, email, claims); err != nil {
+			return nil, err
+		}
+	}
+
+	if user.TOTPEnabled {
+		return s.auth.beginMFAChallenge(ctx, user)
+	}
+	return s.auth.startSession(ctx, user, client)
+}
+
+func (s *SSOService) verify(ctx context.Context, cfg *models.SSOConfig, code string, pending *models.SSOState) (jwt.MapClaims, error) {
+	provider, err := s.oidc.Discover(ctx, cfg.Issuer)
+	if err != nil {
+		return nil, err
+	}
+	rawIDToken, err := provider.Exchange(ctx, cfg.ClientID, cfg.ClientSecret, code, s.redirectURI(), pending.Verifier)
+	if err != nil {
+		return nil, err
+	}
+	return provider.Verify(ctx, rawIDToken, cfg.ClientID, pending.Nonce)
+}
+
+// provision creates an account on first SSO sign-in for a normalised email.
+// A pending invite for the email decides the role and is used up; otherwise
+// the role comes from the ID token. The random password can't be used until
+// the user resets it.
+func (s *SSOService) provision(ctx context.Context, cfg *models.SSOConfig, email string, claims jwt.MapClaims) (*models.User, error) {
+	if !cfg.AutoProvision {
+		return nil, fmt.Errorf("no Praana account for %s; ask your administrator for an invite", email)
+	}
+	org, err := s.repo.GetOrg(ctx, cfg.OrgID)
+	if err != nil || org == nil {
+		return nil, fmt.Errorf("org not found")
+	}
+	pending, err := s.repo.GetPendingInvites(ctx, org.ID)
+	if err != nil {
+		return nil, err
+	}
+	var invite *models.Invite
+	for i := range pending {
+		if strings.EqualFold(pending[i].Email, email) {
+			invite = &pending[i]
+		}
+	}
+
+	role := ssoRole(cfg, claims)
+	if invite != nil {
+		role = invite.Role
+	} else if err := s.orgs.checkMemberLimit(ctx, org, len(pending)); err != nil {
+		return nil, err
+	}
+	if role == "" {
+		return nil, fmt.Errorf("your identity provider account has no Praana role; ask your administrator")
+	}
+
+	hashedPwd, err := bcrypt.GenerateFromPassword([]byte(utils.GenerateToken()), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash password: %w", err)
+	}
+	name, _ := claims["name"].(string)
+	if name == "" {
+		name, _, _ = strings.Cut(email, "@")
+	}
+	user := &models.User{
+		ID:        utils.GenerateID(),
+		Email:     email,
+		Password:  string(hashedPwd),
+		Name:      name,
+		Role:      role,
+		OrgID:     org.ID,
+		CreatedAt: time.Now().Unix(),
+	}
+	// Concurrent first sign-ins for the same email must not both create an
+	// account (and both count towards the member limit).
+	claimed, err := s.repo.ClaimUserEmail(ctx, email, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if !claimed {
+		return nil, fmt.Errorf("an account for %s was just created; sign in again", email)
+	}
+	if err := s.repo.CreateUser(ctx, user); err != nil {
+		if err := s.repo.ReleaseUserEmail(ctx, email, user.ID); err != nil {
+			log.Error().Err(err).Str("email", email).Msg("Failed to release email after SSO provisioning failed")
+		}
+		return nil, err
+	}
+	if invite != nil {
+		_ = s.repo.DeleteInvite(ctx, invite)
+	}
+	s.audit.Record(ctx, org.ID, "", "auth.sso_provision", "user", user.ID, map[string]string{
+		"email":  email,
+		"role":   string(role),
+		"issuer": cfg.Issuer,
+	})
+	log.Info().Str("org", org.ID).Str("user", user.ID).Str("role", string(role)).Msg("User provisioned via SSO")
+	return user, nil
+}
+
+// ssoRole maps the configured claim to a role. If several values match, the
+// least privileged role wins; with no match it is DefaultRole.
+func ssoRole(cfg *models.SSOConfig, claims jwt.MapClaims) models.Role {
+	var values []string
+	switch v := claims[cfg.RoleClaim].(type) {
+	case string:
+		values = []string{v}
+	case []interface{}:
+		for _, item := range v {
+			if str, ok := item.(string); ok {
+				values = append(values, str)
+			}
+		}
+	}
+	var matched []models.Role
+	for _, v := range values {
+		if role, ok := cfg.RoleMapping[v]; ok {
+			matched = append(matched, role)
+		}
+	}
+	for _, role := range []models.Role{models.RoleNurse, models.RoleDoctor, models.RoleAdmin} {
+		if slices.Contains(matched, role) {
+			return role
+		}
+	}
+	return cfg.DefaultRole
+}
@@ -0,0 +1,437 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"praana/internal/models"
+	"praana/internal/oidc"
+	"praana/internal/repository"
+	"praana/internal/utils"
+)
+
+const (
+	ssoClientID = "praana"
+	ssoCode     = "code-1"
+)
+
+// ssoIdP is a stub OpenID provider. Its token endpoint redeems ssoCode, when
+// the PKCE verifier matches challenge, for an ID token carrying claims.
+type ssoIdP struct {
+	server    *httptest.Server
+	key       *rsa.PrivateKey
+	challenge string
+	claims    jwt.MapClaims
+}
+
+func newSSOIdP(t *testing.T) *ssoIdP {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	idp := &ssoIdP{key: key}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 idp.server.URL,
+			"authorization_endpoint": idp.server.URL + "/authorize",
+			"token_endpoint":         idp.server.URL + "/token",
+			"jwks_uri":               idp.server.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"keys": []map[string]string{{
+			"kid": "k1",
+			"kty": "RSA",
+			"use": "sig",
+			"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}}})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		sum := sha256.Sum256([]byte(r.FormValue("code_verifier")))
+		if r.FormValue("code") != ssoCode || base64.RawURLEncoding.EncodeToString(sum[:]) != idp.challenge {
+			w.WriteHeader(http.StatusBadRequest)
+			_ = json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+			return
+		}
+		token := jwt.NewWithClaims(jwt.SigningMethodRS256, idp.claims)
+		token.Header["kid"] = "k1"
+		raw, err := token.SignedString(key)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]string{"id_token": raw, "token_type": "Bearer"})
+	})
+	idp.server = httptest.NewServer(mux)
+	t.Cleanup(idp.server.Close)
+	return idp
+}
+
+// ssoFixture is an org with SSO set up against a stub IdP for a fresh domain.
+type ssoFixture struct {
+	repo   *repository.RedisRepo
+	sso    *SSOService
+	idp    *ssoIdP
+	org    *models.Org
+	cfg    *models.SSOConfig
+	domain string
+}
+
+func newSSOFixture(t *testing.T) *ssoFixture {
+	t.Helper()
+	repo := testRepo(t)
+	allowLoopback(t)
+	ctx := context.Background()
+
+	mail := NewMailService(&recordingMailer{}, "test-secret", "https://app.example")
+	audit := NewAuditService(repo)
+	hub := NewWSHub()
+	auth := NewAuthService(repo, mail, audit, hub, "test-secret", time.Hour, time.Hour, 24*time.Hour)
+	orgs := NewOrgService(repo, mail, audit, hub)
+
+	f := &ssoFixture{
+		repo:   repo,
+		sso:    NewSSOService(repo, auth, orgs, audit, &oidc.Client{}, "https://app.example"),
+		idp:    newSSOIdP(t),
+		org:    &models.Org{ID: utils.GenerateID(), Name: "General Hospital", Plan: models.PlanEnterprise, CreatedAt: time.Now().Unix()},
+		domain: strings.ToLower(utils.GenerateID()) + ".example",
+	}
+	if err := repo.CreateOrg(ctx, f.org); err != nil {
+		t.Fatal(err)
+	}
+	f.cfg = &models.SSOConfig{
+		OrgID:          f.org.ID,
+		Enabled:        true,
+		Issuer:         f.idp.server.URL,
+		ClientID:       ssoClientID,
+		ClientSecret:   "client-secret",
+		Scopes:         defaultSSOScopes,
+		RoleClaim:      "groups",
+		RoleMapping:    map[string]models.Role{"icu-doctors": models.RoleDoctor, "icu-admins": models.RoleAdmin},
+		DefaultRole:    models.RoleNurse,
+		AllowedDomains: []string{f.domain},
+		AutoProvision:  true,
+		UpdatedAt:      time.Now().Unix(),
+	}
+	if err := repo.SaveSSOConfig(ctx, f.cfg, nil); err != nil {
+		t.Fatal(err)
+	}
+	return f
+}
+
+// signIn starts an SSO sign-in for email and completes the callback with an
+// ID token for email carrying extra claims.
+func (f *ssoFixture) signIn(t *testing.T, email string, extra jwt.MapClaims) (*models.LoginResponse, error) {
+	t.Helper()
+	ctx := context.Background()
+	start, err := f.sso.Start(ctx, &models.SSOStartRequest{Email: email})
+	if err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	redirect, err := url.Parse(start.RedirectURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	q := redirect.Query()
+	f.idp.challenge = q.Get("code_challenge")
+	f.idp.claims = jwt.MapClaims{
+		"iss":            f.idp.server.URL,
+		"sub":            "idp-" + email,
+		"aud":            ssoClientID,
+		"exp":            time.Now().Add(time.Hour).Unix(),
+		"iat":            time.Now().Unix(),
+		"nonce":          q.Get("nonce"),
+		"email":          email,
+		"email_verified": true,
+	}
+	for k, v := range extra {
+		if v == nil {
+			delete(f.idp.claims, k)
+		} else {
+			f.idp.claims[k] = v
+		}
+	}
+	return f.sso.Callback(ctx, &models.SSOCallbackRequest{
+		Code:       ssoCode,
+		State:      q.Get("state"),
+		BrowserKey: start.BrowserKey,
+	}, models.ClientInfo{IP: "203.0.113.7"})
+}
+
+func (f *ssoFixture) createUser(t *testing.T, orgID, email string, role models.Role) *models.User {
+	t.Helper()
+	user := &models.User{ID: utils.GenerateID(), Email: email, Name: "Existing", Role: role, OrgID: orgID, CreatedAt: time.Now().Unix()}
+	if err := f.repo.CreateUser(context.Background(), user); err != nil {
+		t.Fatal(err)
+	}
+	return user
+}
+
+func TestSSOCallbackProvisionsNewUser(t *testing.T) {
+	f := newSSOFixture(t)
+	email := "Doctor@" + strings.ToUpper(f.domain)
+
+	resp, err := f.signIn(t, email, jwt.MapClaims{"name": "Dr Rao", "groups": []interface{}{"icu-admins", "icu-doctors"}})
+	if err != nil {
+		t.Fatalf("Callback: %v", err)
+	}
+	if resp.Token == "" || resp.User == nil {
+		t.Fatalf("Callback response = %+v, want a session", resp)
+	}
+	user, err := f.repo.GetUserByEmail(context.Background(), "doctor@"+f.domain)
+	if err != nil || user == nil {
+		t.Fatalf("provisioned user not found: %v", err)
+	}
+	if user.ID != resp.User.ID || user.OrgID != f.org.ID || user.Name != "Dr Rao" {
+		t.Fatalf("provisioned user = %+v", user)
+	}
+	if user.Role != models.RoleDoctor {
+		t.Fatalf("role = %s, want the least privileged match %s", user.Role, models.RoleDoctor)
+	}
+
+	// A second sign-in links to the same account instead of creating another.
+	again, err := f.signIn(t, email, nil)
+	if err != nil {
+		t.Fatalf("second Callback: %v", err)
+	}
+	if again.User.ID != user.ID {
+		t.Fatalf("second sign-in user = %s, want %s", again.User.ID, user.ID)
+	}
+}
+
+func TestSSOCallbackUsesPendingInvite(t *testing.T) {
+	f := newSSOFixture(t)
+	ctx := context.Background()
+	invite := &models.Invite{
+		ID:        utils.GenerateID(),
+		Code:      utils.GenerateToken(),
+		Email:     "admin@" + f.domain,
+		Role:      models.RoleAdmin,
+		OrgID:     f.org.ID,
+		CreatedAt: time.Now().Unix(),
+		ExpiresAt: time.Now().Add(time.Hour).Unix(),
+	}
+	if err := f.repo.SaveInvite(ctx, invite, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := f.signIn(t, "admin@"+f.domain, nil)
+	if err != nil {
+		t.Fatalf("Callback: %v", err)
+	}
+	if resp.User.Role != models.RoleAdmin {
+		t.Fatalf("role = %s, want the invite's %s", resp.User.Role, models.RoleAdmin)
+	}
+	pending, err := f.repo.GetPendingInvites(ctx, f.org.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pending) != 0 {
+		t.Fatalf("%d invites still pending after provisioning", len(pending))
+	}
+}
+
+func TestSSOCallbackLinksExistingMember(t *testing.T) {
+	f := newSSOFixture(t)
+	// Password sign-up keeps the email as typed.
+	existing := f.createUser(t, f.org.ID, "Nurse@"+f.domain, models.RoleNurse)
+
+	resp, err := f.signIn(t, "Nurse@"+f.domain, jwt.MapClaims{"groups": "icu-admins"})
+	if err != nil {
+		t.Fatalf("Callback: %v", err)
+	}
+	if resp.User.ID != existing.ID {
+		t.Fatalf("signed in as %s, want existing member %s", resp.User.ID, existing.ID)
+	}
+	if resp.User.Role != models.RoleNurse {
+		t.Fatalf("role = %s; SSO must not change an existing member's role", resp.User.Role)
+	}
+	if user, _ := f.repo.GetUserByEmail(context.Background(), "nurse@"+f.domain); user != nil {
+		t.Fatalf("a second account %s was created for the member", user.ID)
+	}
+}
+
+func TestSSOCallbackRejects(t *testing.T) {
+	f := newSSOFixture(t)
+	other := &models.Org{ID: utils.GenerateID(), Name: "Elsewhere", Plan: models.PlanEnterprise, CreatedAt: time.Now().Unix()}
+	if err := f.repo.CreateOrg(context.Background(), other); err != nil {
+		t.Fatal(err)
+	}
+	f.createUser(t, other.ID, "moved@"+f.domain, models.RoleNurse)
+
+	tests := []struct {
+		name  string
+		email string
+		extra jwt.MapClaims
+		want  error
+	}{
+		{name: "unverified email", email: "a@" + f.domain, extra: jwt.MapClaims{"email_verified": false}, want: oidc.ErrEmailNotVerified},
+		{name: "missing email_verified", email: "b@" + f.domain, extra: jwt.MapClaims{"email_verified": nil}, want: oidc.ErrEmailNotVerified},
+		{name: "email outside the domains", email: "c@" + f.domain, extra: jwt.MapClaims{"email": "c@elsewhere.example"}, want: oidc.ErrDomainNotAllowed},
+		{name: "wrong nonce", email: "d@" + f.domain, extra: jwt.MapClaims{"nonce": "replayed"}, want: ErrSSOFailed},
+		{name: "member of another org", email: "moved@" + f.domain},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := f.signIn(t, tt.email, tt.extra)
+			if err == nil {
+				t.Fatal("Callback succeeded")
+			}
+			if tt.want != nil && !errors.Is(err, tt.want) {
+				t.Fatalf("Callback error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+	members, err := f.repo.GetOrgMembers(context.Background(), f.org.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(members) != 0 {
+		t.Fatalf("rejected sign-ins created %d members", len(members))
+	}
+}
+
+func TestSSOCallbackWithoutAutoProvision(t *testing.T) {
+	f := newSSOFixture(t)
+	f.cfg.AutoProvision = false
+	if err := f.repo.SaveSSOConfig(context.Background(), f.cfg, f.cfg.AllowedDomains); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.signIn(t, "new@"+f.domain, nil); err == nil {
+		t.Fatal("Callback provisioned a user with auto_provision off")
+	}
+
+	existing := f.createUser(t, f.org.ID, "member@"+f.domain, models.RoleDoctor)
+	resp, err := f.signIn(t, "member@"+f.domain, nil)
+	if err != nil {
+		t.Fatalf("Callback for an existing member: %v", err)
+	}
+	if resp.User.ID != existing.ID {
+		t.Fatalf("signed in as %s, want %s", resp.User.ID, existing.ID)
+	}
+}
+
+func TestSSOCallbackEmailAlreadyClaimed(t *testing.T) {
+	f := newSSOFixture(t)
+	ctx := context.Background()
+	// Another request is provisioning the same email right now.
+	if _, err := f.repo.ClaimUserEmail(ctx, "race@"+f.domain, "someone-else"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.signIn(t, "race@"+f.domain, nil); err == nil {
+		t.Fatal("Callback provisioned over a claimed email")
+	}
+	members, err := f.repo.GetOrgMembers(ctx, f.org.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(members) != 0 {
+		t.Fatalf("%d members created for a claimed email", len(members))
+	}
+}
+
+func TestSSOCallbackFromAnotherBrowser(t *testing.T) {
+	f := newSSOFixture(t)
+	ctx := context.Background()
+	start, err := f.sso.Start(ctx, &models.SSOStartRequest{Email: "nurse@" + f.domain})
+	if err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	redirect, _ := url.Parse(start.RedirectURL)
+	_, err = f.sso.Callback(ctx, &models.SSOCallbackRequest{
+		Code:       ssoCode,
+		State:      redirect.Query().Get("state"),
+		BrowserKey: "someone-elses-key",
+	}, models.ClientInfo{})
+	if err == nil {
+		t.Fatal("Callback accepted another browser's key")
+	}
+}
+
+func TestSSOSaveConfigVerifiesDomain(t *testing.T) {
+	f := newSSOFixture(t)
+	ctx := context.Background()
+	var published []string
+	original := lookupTXT
+	lookupTXT = func(ctx context.Context, name string) ([]string, error) { return published, nil }
+	t.Cleanup(func() { lookupTXT = original })
+
+	newDomain := strings.ToLower(utils.GenerateID()) + ".example"
+	req := &models.SSOConfigRequest{
+		Enabled:        true,
+		Issuer:         f.idp.server.URL,
+		ClientID:       ssoClientID,
+		AllowedDomains: []string{f.domain, newDomain},
+		DefaultRole:    models.RoleNurse,
+	}
+	if _, err := f.sso.SaveConfig(ctx, f.org.ID, req); !errors.Is(err, ErrSSODomainUnverified) {
+		t.Fatalf("SaveConfig with an unverified domain: %v, want ErrSSODomainUnverified", err)
+	}
+
+	published = []string{f.sso.domainVerificationRecord(f.org.ID)}
+	cfg, err := f.sso.SaveConfig(ctx, f.org.ID, req)
+	if err != nil {
+		t.Fatalf("SaveConfig with a verified domain: %v", err)
+	}
+	if len(cfg.AllowedDomains) != 2 {
+		t.Fatalf("allowed domains = %v", cfg.AllowedDomains)
+	}
+
+	// The record is tied to the org: another org can't reuse it.
+	other := &models.Org{ID: utils.GenerateID(), Name: "Elsewhere", Plan: models.PlanEnterprise}
+	if err := f.repo.CreateOrg(ctx, other); err != nil {
+		t.Fatal(err)
+	}
+	otherDomain := strings.ToLower(utils.GenerateID()) + ".example"
+	req.ClientSecret = "client-secret"
+	req.AllowedDomains = []string{otherDomain}
+	if _, err := f.sso.SaveConfig(ctx, other.ID, req); !errors.Is(err, ErrSSODomainUnverified) {
+		t.Fatalf("SaveConfig with another org's record: %v, want ErrSSODomainUnverified", err)
+	}
+	req.AllowedDomains = []string{newDomain}
+	published = []string{f.sso.domainVerificationRecord(other.ID)}
+	if _, err := f.sso.SaveConfig(ctx, other.ID, req); !errors.Is(err, ErrSSODomainTaken) {
+		t.Fatalf("SaveConfig with a domain taken by another org: %v, want ErrSSODomainTaken", err)
+	}
+}
+
+func TestSSORole(t *testing.T) {
+	cfg := &models.SSOConfig{
+		RoleClaim:   "groups",
+		RoleMapping: map[string]models.Role{"docs": models.RoleDoctor, "admins": models.RoleAdmin, "nurses": models.RoleNurse},
+		DefaultRole: models.RoleNurse,
+	}
+	tests := []struct {
+		name   string
+		claims jwt.MapClaims
+		want   models.Role
+	}{
+		{name: "single value", claims: jwt.MapClaims{"groups": "admins"}, want: models.RoleAdmin},
+		{name: "least privileged wins", claims: jwt.MapClaims{"groups": []interface{}{"admins", "docs"}}, want: models.RoleDoctor},
+		{name: "no match", claims: jwt.MapClaims{"groups": []interface{}{"finance"}}, want: models.RoleNurse},
+		{name: "missing claim", claims: jwt.MapClaims{}, want: models.RoleNurse},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ssoRole(cfg, tt.claims); got != tt.want {
+				t.Fatalf("ssoRole = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
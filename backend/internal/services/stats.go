@@ -2,13 +2,21 @@ package services
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"sort"
 	"time"
 
 	"praana/internal/models"
 	"praana/internal/repository"
 )
 
+var ErrShiftNotStarted = errors.New("shift has not started yet")
+
+// shiftAckLookback is how long before a shift an alert may have been raised
+// and still count as acknowledged during it.
+const shiftAckLookback = 24 * time.Hour
+
 type StatsService struct {
 	repo *repository.RedisRepo
 }
@@ -18,23 +26,29 @@ func NewStatsService(repo *repository.RedisRepo) *StatsService {
 }
 
 func (s *StatsService) OnVitalsRecorded(ctx context.Context, orgID string) {
-	date := time.Now().Format("2006-01-02")
-	month := time.Now().Format("2006-01")
-	s.repo.IncrStat(ctx, orgID, date, "vitals_recorded", 1)
-	s.repo.IncrUsage(ctx, orgID, month, "vitals_recorded", 1)
+	clock := clockFor(ctx, s.repo, orgID)
+	now := time.Now()
+	s.repo.IncrStat(ctx, orgID, clock.Date(now), "vitals_recorded", 1)
+	s.repo.IncrUsage(ctx, orgID, clock.Month(now), "vitals_recorded", 1)
 }
 
-func (s *StatsService) GetDashboardOverview(ctx context.Context, orgID string) (*models.DashboardOverview, error) {
+// GetDashboardOverview summarises admitted patients, limited to the given
+// patients when patientIDs is non-nil.
+func (s *StatsService) GetDashboardOverview(ctx context.Context, orgID string, patientIDs map[string]bool) (*models.DashboardOverview, error) {
 	patients, err := s.repo.GetPatients(ctx, orgID)
 	if err != nil {
 		return nil, err
 	}
 
 	overview := &models.DashboardOverview{}
+	now := time.Now().Unix()
 	for _, p := range patients {
 		if p.Status == models.StatusDischarged {
 			continue
 		}
+		if patientIDs != nil && !patientIDs[p.ID] {
+			continue
+		}
 		overview.TotalPatients++
 		if p.Status == models.StatusCritical {
 			overview.CriticalCount++
@@ -47,11 +61,23 @@ func (s *StatsService) GetDashboardOverview(ctx context.Context, orgID string) (
 		if vitals != nil {
 			summary.LatestVitals = vitals
 		}
+		summary.AlertCount, _ = s.repo.GetPatientActiveAlertCount(ctx, orgID, p.ID)
+		due := observationDue(&p, vitals, now)
+		summary.Observation = &due
+		if due.Overdue {
+			overview.ObservationsOverdue++
+		}
 		overview.Patients = append(overview.Patients, summary)
 	}
 
-	alertCount, _ := s.repo.GetActiveAlertCount(ctx, orgID)
-	overview.ActiveAlerts = alertCount
+	if patientIDs != nil {
+		for _, summary := range overview.Patients {
+			overview.ActiveAlerts += summary.AlertCount
+		}
+	} else {
+		alertCount, _ := s.repo.GetActiveAlertCount(ctx, orgID)
+		overview.ActiveAlerts = alertCount
+	}
 
 	return overview, nil
 }
@@ -61,34 +87,108 @@ func (s *StatsService) GetPatientTrends(ctx context.Context, orgID, patientID st
 	return s.repo.GetVitalsHistory(ctx, orgID, patientID, since)
 }
 
-func (s *StatsService) GetShiftSummary(ctx context.Context, orgID string) (*models.ShiftSummary, error) {
-	now := time.Now()
-	var shiftStart time.Time
-
-	hour := now.Hour()
-	switch {
-	case hour >= 7 && hour < 15:
-		shiftStart = time.Date(now.Year(), now.Month(), now.Day(), 7, 0, 0, 0, now.Location())
-	case hour >= 15 && hour < 23:
-		shiftStart = time.Date(now.Year(), now.Month(), now.Day(), 15, 0, 0, 0, now.Location())
-	default:
-		if hour < 7 {
-			shiftStart = time.Date(now.Year(), now.Month(), now.Day()-1, 23, 0, 0, 0, now.Location())
-		} else {
-			shiftStart = time.Date(now.Year(), now.Month(), now.Day(), 23, 0, 0, 0, now.Location())
+// GetShiftSummary reports on the shift containing at (the current shift when
+// at is zero), counting only vitals and alerts that fall inside the shift
+// window. Acknowledgements count for alerts raised up to shiftAckLookback
+// before the shift. Overdue patients are measured at the end of the shift,
+// or now for the current shift.
+func (s *StatsService) GetShiftSummary(ctx context.Context, orgID string, at time.Time) (*models.ShiftSummary, error) {
+	clock := clockFor(ctx, s.repo, orgID)
+	now := clock.Now()
+	if at.IsZero() {
+		at = now
+	}
+	if at.After(now) {
+		return nil, ErrShiftNotStarted
+	}
+	shiftStart, shiftEnd := clock.ShiftWindow(at)
+	from, to := shiftStart.Unix(), shiftEnd.Unix()
+	ref := to
+	if now.Unix() < ref {
+		ref = now.Unix()
+	}
+
+	active, err := s.repo.GetPatients(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+	archived, err := s.repo.GetArchivedPatients(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+	patients := active
+	for _, p := range archived {
+		if p.DischargedAt >= from {
+			patients = append(patients, p)
 		}
 	}
 
-	date := now.Format("2006-01-02")
-	stats, _ := s.repo.GetStats(ctx, orgID, date)
+	summary := &models.ShiftSummary{ShiftStart: from, ShiftEnd: to}
+	var ackTimes []int64
+	for _, p := range patients {
+		vitals, err := s.repo.GetVitalsHistory(ctx, orgID, p.ID, shiftStart)
+		if err != nil {
+			return nil, err
+		}
+		var last *models.Vitals
+		checked := false
+		for i, v := range vitals {
+			if v.RecordedAt < from || v.RecordedAt >= to {
+				continue
+			}
+			summary.VitalsRecorded++
+			checked = true
+			if v.RecordedAt <= ref && (last == nil || v.RecordedAt > last.RecordedAt) {
+				last = &vitals[i]
+			}
+		}
+		if checked {
+			summary.PatientsChecked++
+		}
 
-	return &models.ShiftSummary{
-		ShiftStart:      shiftStart.Unix(),
-		ShiftEnd:        shiftStart.Add(8 * time.Hour).Unix(),
-		VitalsRecorded:  repository.MapToInt(stats, "vitals_recorded"),
-		AlertsTriggered: repository.MapToInt(stats, "alerts_triggered"),
-		AlertsAcked:     repository.MapToInt(stats, "alerts_acked"),
-	}, nil
+		alerts, err := s.repo.GetPatientAlertsBetween(ctx, orgID, p.ID, from-int64(shiftAckLookback.Seconds()), to)
+		if err != nil {
+			return nil, err
+		}
+		for _, a := range alerts {
+			if a.CreatedAt >= from && a.CreatedAt < to {
+				summary.AlertsTriggered++
+			}
+			if a.Acknowledged && a.AcknowledgedAt >= from && a.AcknowledgedAt < to {
+				summary.AlertsAcked++
+				ackTimes = append(ackTimes, a.AcknowledgedAt-a.CreatedAt)
+			}
+		}
+
+		admitted := p.AdmittedAt <= ref && (p.DischargedAt == 0 || p.DischargedAt > ref)
+		if !admitted {
+			continue
+		}
+		if last == nil {
+			prior, _, err := s.repo.GetVitalsBefore(ctx, orgID, p.ID, fmt.Sprintf("%d", shiftStart.UnixMilli()-1), 1)
+			if err != nil {
+				return nil, err
+			}
+			if len(prior) > 0 {
+				last = &prior[0]
+			}
+		}
+		if observationDue(&p, last, ref).Overdue {
+			summary.PatientsOverdue++
+			summary.OverduePatients = append(summary.OverduePatients, p.ID)
+		}
+	}
+
+	if len(ackTimes) > 0 {
+		sort.Slice(ackTimes, func(i, j int) bool { return ackTimes[i] < ackTimes[j] })
+		mid := len(ackTimes) / 2
+		if len(ackTimes)%2 == 1 {
+			summary.MedianAckSeconds = ackTimes[mid]
+		} else {
+			summary.MedianAckSeconds = (ackTimes[mid-1] + ackTimes[mid]) / 2
+		}
+	}
+	return summary, nil
 }
 
 func (s *StatsService) GetOrgStats(ctx context.Context, orgID string) (*models.OrgStats, error) {
@@ -104,12 +204,13 @@ func (s *StatsService) GetOrgStats(ctx context.Context, orgID string) (*models.O
 		}
 	}
 
+	archived, _ := s.repo.GetArchivedPatients(ctx, orgID)
 	members, _ := s.repo.GetOrgMembers(ctx, orgID)
-	month := time.Now().Format("2006-01")
+	month := clockFor(ctx, s.repo, orgID).Month(time.Now())
 	usage, _ := s.repo.GetUsage(ctx, orgID, month)
 
 	return &models.OrgStats{
-		TotalPatients:  len(patients),
+		TotalPatients:  len(patients) + len(archived),
 		ActivePatients: active,
 		TotalMembers:   len(members),
 		TotalVitals:    repository.MapToInt(usage, "vitals_recorded"),
@@ -118,7 +219,7 @@ func (s *StatsService) GetOrgStats(ctx context.Context, orgID string) (*models.O
 }
 
 func (s *StatsService) GetUsage(ctx context.Context, orgID string) (*models.UsageStats, error) {
-	month := time.Now().Format("2006-01")
+	month := clockFor(ctx, s.repo, orgID).Month(time.Now())
 	usage, err := s.repo.GetUsage(ctx, orgID, month)
 	if err != nil {
 		return nil, err
@@ -0,0 +1,195 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"praana/internal/models"
+	"praana/internal/repository"
+)
+
+const (
+	defaultTimelineLimit = 50
+	maxTimelineLimit     = 200
+)
+
+type TimelineService struct {
+	repo     *repository.RedisRepo
+	patients *PatientService
+}
+
+func NewTimelineService(repo *repository.RedisRepo, patients *PatientService) *TimelineService {
+	return &TimelineService{repo: repo, patients: patients}
+}
+
+// timelineCursor points at the last entry of the previous page. Entries are
+// ordered newest first by (At, ID), so the next page starts strictly after it.
+type timelineCursor struct {
+	at int64
+	id string
+}
+
+func parseTimelineCursor(raw string) (*timelineCursor, error) {
+	if raw == "" {
+		return nil, nil
+	}
+	parts := strings.SplitN(raw, ":", 2)
+	if len(parts) != 2 || parts[1] == "" {
+		return nil, fmt.Errorf("invalid cursor")
+	}
+	at, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cursor")
+	}
+	return &timelineCursor{at: at, id: parts[1]}, nil
+}
+
+// before reports whether e sorts after the cursor, i.e. belongs on a later page.
+func (c *timelineCursor) before(e models.TimelineEntry) bool {
+	if c == nil {
+		return true
+	}
+	return e.At < c.at || (e.At == c.at && e.ID < c.id)
+}
+
+// Get returns one page of the patient's timeline, newest first: notes, vitals,
+// alerts with their acknowledgements and resolutions, admissions, transfers, discharges and
+// status changes.
+func (s *TimelineService) Get(ctx context.Context, orgID, patientID, cursor string, limit int) (*models.TimelinePage, error) {
+	p, err := s.patients.Get(ctx, orgID, patientID)
+	if err != nil {
+		return nil, err
+	}
+	cur, err := parseTimelineCursor(cursor)
+	if err != nil {
+		return nil, err
+	}
+	if limit <= 0 {
+		limit = defaultTimelineLimit
+	}
+	if limit > maxTimelineLimit {
+		limit = maxTimelineLimit
+	}
+
+	var entries []models.TimelineEntry
+	add := func(e models.TimelineEntry) {
+		if cur.before(e) {
+			entries = append(entries, e)
+		}
+	}
+
+	notes, err := s.repo.GetPatientNotes(ctx, orgID, p.ID)
+	if err != nil {
+		return nil, err
+	}
+	for _, n := range notes {
+		add(models.TimelineEntry{ID: n.ID, Type: models.TimelineNote, At: n.CreatedAt, Data: n})
+	}
+
+	alerts, err := s.repo.GetPatientAlerts(ctx, orgID, p.ID)
+	if err != nil {
+		return nil, err
+	}
+	for _, a := range alerts {
+		add(models.TimelineEntry{ID: a.ID, Type: models.TimelineAlert, At: a.CreatedAt, Data: a})
+		if a.Acknowledged {
+			add(models.TimelineEntry{ID: a.ID + ".ack", Type: models.TimelineAcknowledged, At: a.AcknowledgedAt, Data: a})
+		}
+		if a.State == models.AlertResolved {
+			add(models.TimelineEntry{ID: a.ID + ".resolve", Type: models.TimelineResolved, At: a.ResolvedAt, Data: a})
+		}
+		if a.AssignedTo != "" {
+			add(models.TimelineEntry{ID: a.ID + ".assign", Type: models.TimelineAssigned, At: a.AssignedAt, Data: a})
+		}
+		if a.CommentCount > 0 {
+			comments, err := s.repo.GetAlertComments(ctx, orgID, a.ID)
+			if err != nil {
+				return nil, err
+			}
+			for _, cm := range comments {
+				add(models.TimelineEntry{ID: cm.ID, Type: models.TimelineComment, At: cm.CreatedAt, Data: cm})
+			}
+		}
+	}
+
+	episodes, err := s.repo.GetPatientEpisodes(ctx, orgID, p.ID)
+	if err != nil {
+		return nil, err
+	}
+	for _, ep := range episodes {
+		add(models.TimelineEntry{ID: ep.ID + ".admit", Type: models.TimelineAdmission, At: ep.AdmittedAt, Data: ep})
+		for i, m := range ep.Movements {
+			add(models.TimelineEntry{ID: fmt.Sprintf("%s.transfer.%d", ep.ID, i), Type: models.TimelineTransfer, At: m.MovedAt, Data: m})
+		}
+		if ep.Status == models.EpisodeClosed {
+			add(models.TimelineEntry{ID: ep.ID + ".discharge", Type: models.TimelineDischarge, At: ep.DischargedAt, Data: ep})
+		}
+	}
+
+	changes, err := s.repo.GetStatusChanges(ctx, orgID, p.ID)
+	if err != nil {
+		return nil, err
+	}
+	for _, sc := range changes {
+		add(models.TimelineEntry{ID: sc.ID, Type: models.TimelineStatus, At: sc.ChangedAt, Data: sc})
+	}
+
+	vitals, err := s.vitalsPage(ctx, orgID, p.ID, cur, limit+1)
+	if err != nil {
+		return nil, err
+	}
+	for _, v := range vitals {
+		add(models.TimelineEntry{ID: v.ID, Type: models.TimelineVitals, At: v.RecordedAt, Data: v})
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].At != entries[j].At {
+			return entries[i].At > entries[j].At
+		}
+		return entries[i].ID > entries[j].ID
+	})
+
+	page := &models.TimelinePage{Entries: entries}
+	if len(entries) > limit {
+		page.Entries = entries[:limit]
+		last := page.Entries[limit-1]
+		page.NextCursor = fmt.Sprintf("%d:%s", last.At, last.ID)
+	}
+	if page.Entries == nil {
+		page.Entries = []models.TimelineEntry{}
+	}
+	return page, nil
+}
+
+// vitalsPage reads the vitals stream backwards from the cursor until it has
+// want entries past the cursor or the stream runs out. Vitals can be the
+// bulk of a patient's history, so unlike the other sources they are not
+// loaded in full.
+func (s *TimelineService) vitalsPage(ctx context.Context, orgID, patientID string, cur *timelineCursor, want int) ([]models.Vitals, error) {
+	end := "+"
+	if cur != nil {
+		// Stream IDs are millisecond timestamps; start at the last
+		// millisecond of the cursor's second.
+		end = strconv.FormatInt((cur.at+1)*1000-1, 10)
+	}
+	var out []models.Vitals
+	for len(out) < want {
+		batch, lastID, err := s.repo.GetVitalsBefore(ctx, orgID, patientID, end, int64(want))
+		if err != nil {
+			return nil, err
+		}
+		if lastID == "" {
+			break
+		}
+		for _, v := range batch {
+			if cur.before(models.TimelineEntry{ID: v.ID, At: v.RecordedAt}) {
+				out = append(out, v)
+			}
+		}
+		end = "(" + lastID
+	}
+	return out, nil
+}
@@ -14,10 +14,11 @@ type VitalsService struct {
 	repo         *repository.RedisRepo
 	alertService *AlertService
 	statsService *StatsService
+	webhooks     *WebhookService
 }
 
-func NewVitalsService(repo *repository.RedisRepo, alertService *AlertService, statsService *StatsService) *VitalsService {
-	return &VitalsService{repo: repo, alertService: alertService, statsService: statsService}
+func NewVitalsService(repo *repository.RedisRepo, alertService *AlertService, statsService *StatsService, webhooks *WebhookService) *VitalsService {
+	return &VitalsService{repo: repo, alertService: alertService, statsService: statsService, webhooks: webhooks}
 }
 
 func (s *VitalsService) Record(ctx context.Context, orgID, patientID, recordedBy string, req *models.RecordVitalsRequest) (*models.Vitals, error) {
@@ -30,6 +31,7 @@ func (s *VitalsService) Record(ctx context.Context, orgID, patientID, recordedBy
 		ID:              utils.GenerateID(),
 		PatientID:       patientID,
 		OrgID:           orgID,
+		EpisodeID:       patient.ActiveEpisodeID,
 		HeartRate:       req.HeartRate,
 		SystolicBP:      req.SystolicBP,
 		DiastolicBP:     req.DiastolicBP,
@@ -41,13 +43,21 @@ func (s *VitalsService) Record(ctx context.Context, orgID, patientID, recordedBy
 		Notes:           req.Notes,
 	}
 
+	var previousAt int64
+	if prev, _ := s.repo.GetLatestVitals(ctx, orgID, patientID); prev != nil {
+		previousAt = prev.RecordedAt
+	}
 	if err := s.repo.RecordVitals(ctx, vitals); err != nil {
 		return nil, err
 	}
+	if s.webhooks != nil {
+		s.webhooks.Emit(ctx, orgID, models.EventVitalsRecorded, vitals)
+	}
 
 	// Check thresholds and generate alerts
 	if s.alertService != nil {
 		s.alertService.CheckVitals(ctx, patient, vitals)
+		s.alertService.ResolveObservationsOverdue(ctx, patient, previousAt)
 	}
 
 	// Update stats
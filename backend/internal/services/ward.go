@@ -0,0 +1,250 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"praana/internal/models"
+	"praana/internal/repository"
+	"praana/internal/utils"
+)
+
+var ErrBedOccupied = errors.New("bed is already occupied")
+
+type WardService struct {
+	repo *repository.RedisRepo
+}
+
+func NewWardService(repo *repository.RedisRepo) *WardService {
+	return &WardService{repo: repo}
+}
+
+func (s *WardService) Create(ctx context.Context, orgID string, req *models.CreateWardRequest) (*models.Ward, error) {
+	ward := &models.Ward{
+		ID:        utils.GenerateID(),
+		OrgID:     orgID,
+		Name:      strings.TrimSpace(req.Name),
+		Capacity:  req.Capacity,
+		CreatedAt: time.Now().Unix(),
+		UpdatedAt: time.Now().Unix(),
+	}
+	if err := s.repo.CreateWard(ctx, ward); err != nil {
+		return nil, err
+	}
+	return ward, nil
+}
+
+func (s *WardService) List(ctx context.Context, orgID string) ([]models.Ward, error) {
+	wards, err := s.repo.GetWards(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(wards, func(i, j int) bool { return wards[i].Name < wards[j].Name })
+	return wards, nil
+}
+
+func (s *WardService) Get(ctx context.Context, orgID, wardID string) (*models.Ward, error) {
+	ward, err := s.repo.GetWard(ctx, orgID, wardID)
+	if err != nil {
+		return nil, err
+	}
+	if ward == nil {
+		return nil, fmt.Errorf("ward not found")
+	}
+	return ward, nil
+}
+
+func (s *WardService) Update(ctx context.Context, orgID, wardID string, req *models.UpdateWardRequest) (*models.Ward, error) {
+	ward, err := s.repo.GetWard(ctx, orgID, wardID)
+	if err != nil || ward == nil {
+		return nil, fmt.Errorf("ward not found")
+	}
+	if req.Name != "" {
+		ward.Name = strings.TrimSpace(req.Name)
+	}
+	if req.Capacity > 0 {
+		count, err := s.repo.GetWardBedCount(ctx, orgID, wardID)
+		if err != nil {
+			return nil, err
+		}
+		if int64(req.Capacity) < count {
+			return nil, fmt.Errorf("ward already has %d beds; remove beds before lowering capacity", count)
+		}
+		ward.Capacity = req.Capacity
+	}
+	ward.UpdatedAt = time.Now().Unix()
+	if err := s.repo.UpdateWard(ctx, ward); err != nil {
+		return nil, err
+	}
+	return ward, nil
+}
+
+func (s *WardService) Delete(ctx context.Context, orgID, wardID string) error {
+	ward, err := s.repo.GetWard(ctx, orgID, wardID)
+	if err != nil || ward == nil {
+		return fmt.Errorf("ward not found")
+	}
+	ok, err := s.repo.DeleteWard(ctx, orgID, wardID)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("ward has occupied beds")
+	}
+	return nil
+}
+
+func (s *WardService) AddBed(ctx context.Context, orgID, wardID string, req *models.CreateBedRequest) (*models.Bed, error) {
+	ward, err := s.repo.GetWard(ctx, orgID, wardID)
+	if err != nil || ward == nil {
+		return nil, fmt.Errorf("ward not found")
+	}
+
+	bed := &models.Bed{
+		ID:        utils.GenerateID(),
+		OrgID:     orgID,
+		WardID:    wardID,
+		Label:     strings.TrimSpace(req.Label),
+		CreatedAt: time.Now().Unix(),
+	}
+	result, err := s.repo.CreateBed(ctx, bed)
+	if err != nil {
+		return nil, err
+	}
+	switch result {
+	case repository.BedWardMissing:
+		return nil, fmt.Errorf("ward not found")
+	case repository.BedWardFull:
+		return nil, fmt.Errorf("ward is at capacity (%d beds)", ward.Capacity)
+	case repository.BedLabelDuplicate:
+		return nil, fmt.Errorf("bed %s already exists in %s", bed.Label, ward.Name)
+	}
+	return bed, nil
+}
+
+func (s *WardService) ListBeds(ctx context.Context, orgID, wardID string) ([]models.Bed, error) {
+	ward, err := s.repo.GetWard(ctx, orgID, wardID)
+	if err != nil || ward == nil {
+		return nil, fmt.Errorf("ward not found")
+	}
+	beds, err := s.repo.GetWardBeds(ctx, orgID, wardID)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(beds, func(i, j int) bool { return beds[i].Label < beds[j].Label })
+	return beds, nil
+}
+
+func (s *WardService) DeleteBed(ctx context.Context, orgID, wardID, bedID string) error {
+	bed, err := s.repo.GetBed(ctx, orgID, bedID)
+	if err != nil || bed == nil || bed.WardID != wardID {
+		return fmt.Errorf("bed not found")
+	}
+	ok, err := s.repo.DeleteBed(ctx, orgID, wardID, bedID)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrBedOccupied
+	}
+	return nil
+}
+
+// Occupancy returns the bed board for one ward.
+func (s *WardService) Occupancy(ctx context.Context, orgID, wardID string) (*models.WardOccupancy, error) {
+	ward, err := s.repo.GetWard(ctx, orgID, wardID)
+	if err != nil || ward == nil {
+		return nil, fmt.Errorf("ward not found")
+	}
+	beds, err := s.ListBeds(ctx, orgID, wardID)
+	if err != nil {
+		return nil, err
+	}
+	occ := wardOccupancy(ward, beds)
+	occ.Beds = beds
+	return occ, nil
+}
+
+// OccupancySummary returns bed counts for every ward in the org.
+func (s *WardService) OccupancySummary(ctx context.Context, orgID string) ([]models.WardOccupancy, error) {
+	wards, err := s.List(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+	summary := make([]models.WardOccupancy, 0, len(wards))
+	for i := range wards {
+		beds, err := s.repo.GetWardBeds(ctx, orgID, wards[i].ID)
+		if err != nil {
+			return nil, err
+		}
+		summary = append(summary, *wardOccupancy(&wards[i], beds))
+	}
+	return summary, nil
+}
+
+func wardOccupancy(ward *models.Ward, beds []models.Bed) *models.WardOccupancy {
+	occ := &models.WardOccupancy{
+		WardID:    ward.ID,
+		WardName:  ward.Name,
+		Capacity:  ward.Capacity,
+		TotalBeds: len(beds),
+	}
+	for _, b := range beds {
+		if b.PatientID != "" {
+			occ.Occupied++
+		}
+	}
+	occ.Available = occ.TotalBeds - occ.Occupied
+	return occ
+}
+
+// occupy assigns bedID to the patient, atomically vacating the bed they held
+// before, and returns that bed's ID so the move can be undone.
+func (s *WardService) occupy(ctx context.Context, orgID, patientID, bedID string) (*models.Ward, *models.Bed, string, error) {
+	result, previous, err := s.repo.OccupyBed(ctx, orgID, bedID, patientID, time.Now().Unix())
+	if err != nil {
+		return nil, nil, "", err
+	}
+	if result < 0 {
+		return nil, nil, "", fmt.Errorf("bed not found")
+	}
+	bed, err := s.repo.GetBed(ctx, orgID, bedID)
+	if err != nil {
+		return nil, nil, "", err
+	}
+	if bed == nil {
+		return nil, nil, "", fmt.Errorf("bed not found")
+	}
+	ward, err := s.repo.GetWard(ctx, orgID, bed.WardID)
+	if err != nil || ward == nil {
+		if result == 1 {
+			s.restore(ctx, orgID, patientID, bedID, previous)
+		}
+		return nil, nil, "", fmt.Errorf("ward not found")
+	}
+	if result == 0 {
+		return nil, nil, "", fmt.Errorf("%w: %s %s", ErrBedOccupied, ward.Name, bed.Label)
+	}
+	return ward, bed, previous, nil
+}
+
+// restore undoes occupy after a later step fails: the patient goes back to
+// previousBedID (if it is still free) and bedID is released.
+func (s *WardService) restore(ctx context.Context, orgID, patientID, bedID, previousBedID string) {
+	if previousBedID != "" {
+		if result, _, err := s.repo.OccupyBed(ctx, orgID, previousBedID, patientID, time.Now().Unix()); err == nil && result == 1 {
+			return
+		}
+	}
+	_ = s.release(ctx, orgID, bedID, patientID)
+}
+
+// release frees bedID if the patient holds it or, with bedID empty, whatever
+// bed the patient holds.
+func (s *WardService) release(ctx context.Context, orgID, bedID, patientID string) error {
+	return s.repo.ReleaseBed(ctx, orgID, bedID, patientID)
+}
@@ -0,0 +1,156 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"praana/internal/models"
+	"praana/internal/repository"
+	"praana/internal/utils"
+)
+
+// deliveryKindWebhook marks deliveries of domain events to webhook endpoints.
+const deliveryKindWebhook = "webhook"
+
+// WebhookService manages org webhook endpoints and fans domain events out to
+// the subscribed ones through the delivery queue.
+type WebhookService struct {
+	repo       *repository.RedisRepo
+	deliveries *DeliveryService
+}
+
+func NewWebhookService(repo *repository.RedisRepo, deliveries *DeliveryService) *WebhookService {
+	s := &WebhookService{repo: repo, deliveries: deliveries}
+	deliveries.Register(deliveryKindWebhook, s.send)
+	return s
+}
+
+func (s *WebhookService) Create(ctx context.Context, orgID string, req *models.WebhookEndpointRequest) (*models.WebhookEndpoint, error) {
+	ep := &models.WebhookEndpoint{
+		ID:        utils.GenerateID(),
+		OrgID:     orgID,
+		Secret:    randomSecret(),
+		CreatedAt: time.Now().Unix(),
+	}
+	applyWebhookRequest(ep, req)
+	if err := s.repo.SaveWebhookEndpoint(ctx, ep); err != nil {
+		return nil, err
+	}
+	return ep, nil
+}
+
+func (s *WebhookService) Update(ctx context.Context, orgID, id string, req *models.WebhookEndpointRequest) (*models.WebhookEndpoint, error) {
+	ep, err := s.repo.GetWebhookEndpoint(ctx, orgID, id)
+	if err != nil || ep == nil {
+		return nil, fmt.Errorf("webhook not found")
+	}
+	applyWebhookRequest(ep, req)
+	if err := s.repo.SaveWebhookEndpoint(ctx, ep); err != nil {
+		return nil, err
+	}
+	return ep, nil
+}
+
+// applyWebhookRequest copies a validated request onto the endpoint, keeping
+// the current secret unless a new one is given.
+func applyWebhookRequest(ep *models.WebhookEndpoint, req *models.WebhookEndpointRequest) {
+	ep.URL = req.URL
+	ep.Description = req.Description
+	ep.Events = req.Events
+	ep.Enabled = req.Enabled == nil || *req.Enabled
+	if req.Secret != "" {
+		ep.Secret = req.Secret
+	}
+	ep.UpdatedAt = time.Now().Unix()
+}
+
+func (s *WebhookService) Get(ctx context.Context, orgID, id string) (*models.WebhookEndpoint, error) {
+	ep, err := s.repo.GetWebhookEndpoint(ctx, orgID, id)
+	if err != nil || ep == nil {
+		return nil, fmt.Errorf("webhook not found")
+	}
+	return ep, nil
+}
+
+func (s *WebhookService) List(ctx context.Context, orgID string) ([]models.WebhookEndpoint, error) {
+	endpoints, err := s.repo.GetWebhookEndpoints(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+	if endpoints == nil {
+		endpoints = []models.WebhookEndpoint{}
+	}
+	return endpoints, nil
+}
+
+func (s *WebhookService) Delete(ctx context.Context, orgID, id string) error {
+	if _, err := s.Get(ctx, orgID, id); err != nil {
+		return err
+	}
+	return s.repo.DeleteWebhookEndpoint(ctx, orgID, id)
+}
+
+// Deliveries returns the endpoint's delivery log; filter on status "dead" for
+// its dead letters.
+func (s *WebhookService) Deliveries(ctx context.Context, orgID, id string, q *models.DeliveryQuery) (*models.DeliveryPage, error) {
+	if _, err := s.Get(ctx, orgID, id); err != nil {
+		return nil, err
+	}
+	q.Kind = deliveryKindWebhook
+	q.TargetID = id
+	return s.deliveries.Log(ctx, orgID, q)
+}
+
+// Emit queues an event for every enabled endpoint subscribed to its type.
+// Failures are logged; they never fail the change that produced the event.
+func (s *WebhookService) Emit(ctx context.Context, orgID, eventType string, data interface{}) {
+	endpoints, err := s.repo.GetWebhookEndpoints(ctx, orgID)
+	if err != nil {
+		log.Error().Err(err).Str("event", eventType).Msg("Failed to load webhook endpoints")
+		return
+	}
+	if len(endpoints) == 0 {
+		return
+	}
+
+	event := &models.WebhookEvent{
+		ID:         utils.GenerateID(),
+		Type:       eventType,
+		OrgID:      orgID,
+		OccurredAt: time.Now().Unix(),
+		Data:       data,
+	}
+	for i := range endpoints {
+		ep := &endpoints[i]
+		if !ep.Enabled || !subscribed(ep, eventType) {
+			continue
+		}
+		if _, err := s.deliveries.Enqueue(ctx, orgID, deliveryKindWebhook, ep.ID, eventType, event, "", ""); err != nil {
+			log.Error().Err(err).Str("webhook", ep.ID).Str("event", eventType).Msg("Failed to queue webhook")
+		}
+	}
+}
+
+func subscribed(ep *models.WebhookEndpoint, eventType string) bool {
+	for _, e := range ep.Events {
+		if e == "*" || e == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// send is the delivery queue's sender for webhook endpoints. Each attempt is
+// signed with a fresh timestamp, including replays.
+func (s *WebhookService) send(ctx context.Context, d *models.Delivery) error {
+	ep, err := s.repo.GetWebhookEndpoint(ctx, d.OrgID, d.TargetID)
+	if err != nil {
+		return err
+	}
+	if ep == nil {
+		return fmt.Errorf("webhook %s no longer exists", d.TargetID)
+	}
+	return postJSON(ctx, ep.URL, d.Payload, signedHeaders(ep.Secret, d))
+}
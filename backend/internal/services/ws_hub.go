@@ -9,9 +9,11 @@ import (
 )
 
 type WSClient struct {
-	Conn  *websocket.Conn
-	OrgID string
-	Send  chan []byte
+	Conn      *websocket.Conn
+	OrgID     string
+	UserID    string
+	SessionID string
+	Send      chan []byte
 }
 
 type WSHub struct {
@@ -66,6 +68,41 @@ func (h *WSHub) Unregister(client *WSClient) {
 	h.unregister <- client
 }
 
+// DisconnectSession closes the connections opened with a session that has
+// been revoked.
+func (h *WSHub) DisconnectSession(sessionID string) {
+	h.disconnect(func(c *WSClient) bool { return c.SessionID == sessionID })
+}
+
+// DisconnectUser closes the user's connections except those opened with
+// keepSessionID (all of them, if it is empty).
+func (h *WSHub) DisconnectUser(userID, keepSessionID string) {
+	h.disconnect(func(c *WSClient) bool {
+		return c.UserID == userID && (keepSessionID == "" || c.SessionID != keepSessionID)
+	})
+}
+
+// disconnect closes matching clients' send channels, which ends their write
+// pumps and connections; the read pumps then unregister them.
+func (h *WSHub) disconnect(match func(*WSClient) bool) {
+	if h == nil {
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for orgID, orgClients := range h.clients {
+		for client := range orgClients {
+			if match(client) {
+				close(client.Send)
+				delete(orgClients, client)
+			}
+		}
+		if len(orgClients) == 0 {
+			delete(h.clients, orgID)
+		}
+	}
+}
+
 func (h *WSHub) BroadcastToOrg(orgID string, data interface{}) {
 	msg, err := json.Marshal(data)
 	if err != nil {
@@ -77,16 +114,50 @@ func (h *WSHub) BroadcastToOrg(orgID string, data interface{}) {
 
 	if orgClients, ok := h.clients[orgID]; ok {
 		for client := range orgClients {
-			select {
-			case client.Send <- msg:
-			default:
-				close(client.Send)
-				delete(orgClients, client)
-			}
+			send(orgClients, client, msg)
 		}
 	}
 }
 
+// BroadcastToUsers delivers to the given users' connections first. If others
+// is set, the rest of the org receives the message afterwards.
+func (h *WSHub) BroadcastToUsers(orgID string, userIDs map[string]bool, data interface{}, others bool) {
+	msg, err := json.Marshal(data)
+	if err != nil {
+		return
+	}
+
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	orgClients, ok := h.clients[orgID]
+	if !ok {
+		return
+	}
+	var rest []*WSClient
+	for client := range orgClients {
+		if userIDs[client.UserID] {
+			send(orgClients, client, msg)
+		} else {
+			rest = append(rest, client)
+		}
+	}
+	if others {
+		for _, client := range rest {
+			send(orgClients, client, msg)
+		}
+	}
+}
+
+func send(orgClients map[*WSClient]bool, client *WSClient, msg []byte) {
+	select {
+	case client.Send <- msg:
+	default:
+		close(client.Send)
+		delete(orgClients, client)
+	}
+}
+
 func WritePump(client *WSClient) {
 	defer func() {
 		client.Conn.Close()
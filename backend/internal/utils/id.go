@@ -1,7 +1,10 @@
 package utils
 
 import (
+	"crypto/rand"
+	"encoding/base64"
 	"fmt"
+	"strings"
 	"sync"
 
 	"github.com/google/uuid"
@@ -32,6 +35,15 @@ func GenerateUUID() string {
 	return uuid.New().String()
 }
 
+// GenerateInviteCode returns an unguessable invite code. Codes only ever
+// leave the server inside signed links.
 func GenerateInviteCode() string {
-	return uuid.New().String()[:8]
+	return strings.ReplaceAll(uuid.New().String(), "-", "")
+}
+
+// GenerateToken returns 256 random bits, base64url-encoded (43 characters).
+func GenerateToken() string {
+	b := make([]byte, 32)
+	_, _ = rand.Read(b)
+	return base64.RawURLEncoding.EncodeToString(b)
 }
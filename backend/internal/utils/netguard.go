@@ -0,0 +1,79 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"syscall"
+	"time"
+)
+
+// AllowPrivateNetworks lets outbound requests reach loopback, private and
+// link-local addresses. It is set from ALLOW_PRIVATE_NETWORKS and is meant for
+// local development only.
+var AllowPrivateNetworks bool
+
+var ErrPrivateAddress = errors.New("destination is not a public address")
+
+// sharedAddressSpace is carrier-grade NAT (RFC 6598), which net.IP doesn't
+// count as private.
+var sharedAddressSpace = &net.IPNet{IP: net.IPv4(100, 64, 0, 0), Mask: net.CIDRMask(10, 32)}
+
+func isPublicIP(ip net.IP) bool {
+	return !(ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() ||
+		sharedAddressSpace.Contains(ip))
+}
+
+// CheckPublicURL rejects URLs that aren't http(s) or whose host resolves to
+// an address that isn't public, so user-supplied endpoints can't be pointed
+// at the server's own network.
+func CheckPublicURL(ctx context.Context, raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Hostname() == "" {
+		return fmt.Errorf("invalid URL")
+	}
+	if AllowPrivateNetworks {
+		return nil
+	}
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, u.Hostname())
+	if err != nil {
+		return fmt.Errorf("could not resolve %s", u.Hostname())
+	}
+	for _, addr := range addrs {
+		if !isPublicIP(addr.IP) {
+			return fmt.Errorf("%s: %w", u.Hostname(), ErrPrivateAddress)
+		}
+	}
+	return nil
+}
+
+// NewPublicHTTPClient returns a client that refuses to connect to addresses
+// that aren't public. The check runs on every dial, so redirects and DNS
+// changes after CheckPublicURL can't reach the internal network either.
+func NewPublicHTTPClient(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: timeout,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			if AllowPrivateNetworks {
+				return nil
+			}
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || !isPublicIP(ip) {
+				return ErrPrivateAddress
+			}
+			return nil
+		},
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return &http.Client{Timeout: timeout, Transport: transport}
+}
@@ -0,0 +1,45 @@
+package utils
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// commonPasswords are rejected outright even though they pass the other rules.
+var commonPasswords = map[string]bool{
+	"password1": true, "password123": true, "passw0rd": true, "p@ssw0rd": true,
+	"12345678a": true, "abc12345": true, "qwerty123": true, "letmein1": true,
+	"welcome1": true, "iloveyou1": true, "admin123": true, "praana123": true,
+}
+
+// CheckPassword enforces the password rules: 8 to 72 bytes (bcrypt ignores
+// anything longer), at least one letter and one digit or symbol, not a common
+// password and not built from the account's email.
+func CheckPassword(password, email string) error {
+	if len(password) < 8 {
+		return fmt.Errorf("password must be at least 8 characters")
+	}
+	if len(password) > 72 {
+		return fmt.Errorf("password must be at most 72 bytes")
+	}
+	var letter, other bool
+	for _, r := range password {
+		if unicode.IsLetter(r) {
+			letter = true
+		} else if !unicode.IsSpace(r) {
+			other = true
+		}
+	}
+	if !letter || !other {
+		return fmt.Errorf("password must contain a letter and a digit or symbol")
+	}
+	lower := strings.ToLower(password)
+	if commonPasswords[lower] {
+		return fmt.Errorf("password is too common")
+	}
+	if local, _, _ := strings.Cut(strings.ToLower(email), "@"); len(local) >= 3 && strings.Contains(lower, local) {
+		return fmt.Errorf("password must not contain your email address")
+	}
+	return nil
+}
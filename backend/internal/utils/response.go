@@ -1,11 +1,17 @@
 package utils
 
 import (
+	"errors"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
 )
 
+// ErrConcurrentUpdate means a write kept losing to concurrent writes to the
+// same record and gave up; handlers answer it with Conflict so the client
+// can retry.
+var ErrConcurrentUpdate = errors.New("changed concurrently; retry")
+
 type APIResponse struct {
 	Success bool        `json:"success"`
 	Data    interface{} `json:"data,omitempty"`
@@ -0,0 +1,44 @@
+package utils
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var ErrInvalidToken = errors.New("invalid or expired link")
+
+// SignToken returns "<value>.<expiresAt>.<signature>", an HMAC-SHA256 over the
+// purpose, value and expiry. The purpose keeps a token issued for one flow
+// (e.g. "invite") from being accepted by another.
+func SignToken(secret, purpose, value string, expiresAt int64) string {
+	exp := strconv.FormatInt(expiresAt, 10)
+	return value + "." + exp + "." + tokenSignature(secret, purpose, value, exp)
+}
+
+// VerifyToken checks a token from SignToken and returns its value.
+func VerifyToken(secret, purpose, token string) (string, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 || parts[0] == "" {
+		return "", ErrInvalidToken
+	}
+	value, exp, sig := parts[0], parts[1], parts[2]
+	if !hmac.Equal([]byte(sig), []byte(tokenSignature(secret, purpose, value, exp))) {
+		return "", ErrInvalidToken
+	}
+	expiresAt, err := strconv.ParseInt(exp, 10, 64)
+	if err != nil || time.Now().Unix() > expiresAt {
+		return "", ErrInvalidToken
+	}
+	return value, nil
+}
+
+func tokenSignature(secret, purpose, value, exp string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(purpose + "\x00" + value + "\x00" + exp))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
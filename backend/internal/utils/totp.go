@@ -0,0 +1,118 @@
+package utils
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// TOTP parameters (RFC 6238), the defaults every authenticator app supports.
+const (
+	totpPeriod = 30
+	totpDigits = 6
+	totpSkew   = 1
+)
+
+var totpEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// GenerateTOTPSecret returns a random 160-bit secret, base32-encoded.
+func GenerateTOTPSecret() string {
+	b := make([]byte, 20)
+	_, _ = rand.Read(b)
+	return totpEncoding.EncodeToString(b)
+}
+
+// TOTPProvisioningURI is the otpauth:// URI authenticator apps scan as a QR code.
+func TOTPProvisioningURI(issuer, account, secret string) string {
+	v := url.Values{}
+	v.Set("secret", secret)
+	v.Set("issuer", issuer)
+	v.Set("algorithm", "SHA1")
+	v.Set("digits", fmt.Sprint(totpDigits))
+	v.Set("period", fmt.Sprint(totpPeriod))
+	label := url.PathEscape(issuer) + ":" + url.PathEscape(account)
+	return "otpauth://totp/" + label + "?" + v.Encode()
+}
+
+// VerifyTOTP checks code against the secret at time t, allowing one period
+// of clock drift either way. It returns the matching time step so callers
+// can refuse a step that was already used; ok is false if nothing matched.
+func VerifyTOTP(secret, code string, t time.Time) (step int64, ok bool) {
+	key, err := totpEncoding.DecodeString(strings.ToUpper(strings.TrimSpace(secret)))
+	if err != nil {
+		return 0, false
+	}
+	code = strings.ReplaceAll(strings.TrimSpace(code), " ", "")
+	if len(code) != totpDigits {
+		return 0, false
+	}
+	current := t.Unix() / totpPeriod
+	for s := current - totpSkew; s <= current+totpSkew; s++ {
+		if subtle.ConstantTimeCompare([]byte(totpCode(key, s)), []byte(code)) == 1 {
+			return s, true
+		}
+	}
+	return 0, false
+}
+
+// totpCode is the HOTP value (RFC 4226) for the given counter.
+func totpCode(key []byte, counter int64) string {
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], uint64(counter))
+	mac := hmac.New(sha1.New, key)
+	mac.Write(msg[:])
+	sum := mac.Sum(nil)
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+	return fmt.Sprintf("%0*d", totpDigits, value%1000000)
+}
+
+// GenerateRecoveryCodes returns n one-time codes like "k7qm-3xfp".
+func GenerateRecoveryCodes(n int) []string {
+	const alphabet = "abcdefghjkmnpqrstuvwxyz23456789"
+	// Bytes at or above limit are redrawn so every symbol is equally likely;
+	// 256 isn't a multiple of the alphabet size.
+	const limit = 256 - 256%len(alphabet)
+	codes := make([]string, n)
+	buf := make([]byte, 16)
+	for i := range codes {
+		code := make([]byte, 0, 8)
+		for len(code) < 8 {
+			_, _ = rand.Read(buf)
+			for _, c := range buf {
+				if int(c) < limit && len(code) < 8 {
+					code = append(code, alphabet[int(c)%len(alphabet)])
+				}
+			}
+		}
+		codes[i] = string(code[:4]) + "-" + string(code[4:])
+	}
+	return codes
+}
+
+// HashRecoveryCode normalises a recovery code and hashes it for storage with
+// HMAC-SHA256 under the server secret, so the short codes can't be brute
+// forced from a copy of the database alone.
+func HashRecoveryCode(secret, code string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte("recovery-code:" + normalizeRecoveryCode(code)))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// MatchRecoveryCode reports whether hash is the stored form of code.
+func MatchRecoveryCode(secret, code, hash string) bool {
+	return hmac.Equal([]byte(HashRecoveryCode(secret, code)), []byte(hash))
+}
+
+func normalizeRecoveryCode(code string) string {
+	return strings.ToLower(strings.ReplaceAll(strings.TrimSpace(code), "-", ""))
+}
@@ -13,3 +13,14 @@ func BindAndValidate(c *gin.Context, obj interface{}) error {
 	}
 	return validate.Struct(obj)
 }
+
+// ValidateVar checks a single value against a validate tag.
+func ValidateVar(field interface{}, tag string) error {
+	return validate.Var(field, tag)
+}
+
+// Validate checks obj against its validate tags, for values a service has
+// normalized after binding.
+func Validate(obj interface{}) error {
+	return validate.Struct(obj)
+}
@@ -77,6 +77,10 @@ import { ApiService } from './core/services/api.service';
 
           <!-- Logout -->
           <div class="sidebar-footer">
+            <a class="nav-item" routerLink="/account" routerLinkActive="nav-active" (click)="closeMobile(sidenav)">
+              <mat-icon class="nav-icon">manage_accounts</mat-icon>
+              <span>Account</span>
+            </a>
             <button class="nav-item w-full text-gray-500" (click)="auth.logout()">
               <mat-icon class="nav-icon">logout</mat-icon>
               <span>Sign Out</span>
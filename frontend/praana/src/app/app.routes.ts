@@ -10,6 +10,9 @@ export const routes: Routes = [
       { path: 'login', loadComponent: () => import('./features/auth/login/login.component').then(m => m.LoginComponent) },
       { path: 'signup', loadComponent: () => import('./features/auth/signup/signup.component').then(m => m.SignupComponent) },
       { path: 'invite', loadComponent: () => import('./features/auth/invite/invite.component').then(m => m.InviteComponent) },
+      { path: 'reset-password', loadComponent: () => import('./features/auth/reset-password/reset-password.component').then(m => m.ResetPasswordComponent) },
+      { path: 'sso', loadComponent: () => import('./features/auth/sso/sso.component').then(m => m.SsoComponent) },
+      { path: 'sso/callback', loadComponent: () => import('./features/auth/sso/sso.component').then(m => m.SsoComponent) },
     ]
   },
   {
@@ -44,6 +47,11 @@ export const routes: Routes = [
       { path: 'thresholds', loadComponent: () => import('./features/alerts/threshold-config/threshold-config.component').then(m => m.ThresholdConfigComponent) },
     ]
   },
+  {
+    path: 'account',
+    canActivate: [authGuard],
+    loadComponent: () => import('./features/account/account.component').then(m => m.AccountComponent),
+  },
   {
     path: 'settings',
     canActivate: [authGuard, adminGuard],
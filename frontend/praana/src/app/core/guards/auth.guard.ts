@@ -2,9 +2,13 @@ import { inject } from '@angular/core';
 import { Router, CanActivateFn } from '@angular/router';
 import { AuthService } from '../services/auth.service';
 
-export const authGuard: CanActivateFn = () => {
+export const authGuard: CanActivateFn = (_route, state) => {
   const auth = inject(AuthService);
   const router = inject(Router);
+  if (auth.isLoggedIn() && auth.mfaSetupRequired() && !state.url.startsWith('/account')) {
+    router.navigate(['/account']);
+    return false;
+  }
   if (auth.isLoggedIn()) {
     return true;
   }
@@ -1,11 +1,29 @@
-import { HttpInterceptorFn } from '@angular/common/http';
+import { HttpErrorResponse, HttpInterceptorFn, HttpRequest } from '@angular/common/http';
+import { inject } from '@angular/core';
+import { catchError, switchMap, throwError } from 'rxjs';
+import { AuthService } from '../services/auth.service';
+
+// Requests that must not trigger a token refresh when they fail with 401.
+const NO_REFRESH = ['/auth/login', '/auth/signup', '/auth/refresh', '/auth/logout'];
+
+const withToken = (req: HttpRequest<unknown>, token: string | null) =>
+  token ? req.clone({ setHeaders: { Authorization: `Bearer ${token}` } }) : req;
 
 export const authInterceptor: HttpInterceptorFn = (req, next) => {
-  const token = localStorage.getItem('token');
-  if (token) {
-    req = req.clone({
-      setHeaders: { Authorization: `Bearer ${token}` }
-    });
-  }
-  return next(req);
+  const auth = inject(AuthService);
+  return next(withToken(req, auth.getToken())).pipe(
+    catchError((err: HttpErrorResponse) => {
+      if (err.status !== 401 || !auth.getRefreshToken() || NO_REFRESH.some(path => req.url.includes(path))) {
+        return throwError(() => err);
+      }
+      // The access token expired: refresh it once and retry.
+      return auth.refresh().pipe(
+        catchError(() => {
+          auth.endSession();
+          return throwError(() => err);
+        }),
+        switchMap(token => next(withToken(req, token))),
+      );
+    })
+  );
 };
@@ -11,6 +11,8 @@ export interface User {
   name: string;
   role: 'admin' | 'doctor' | 'nurse';
   org_id: string;
+  totp_enabled?: boolean;
+  locked_until?: number;
   created_at: number;
 }
 
@@ -18,13 +20,48 @@ export interface Org {
   id: string;
   name: string;
   plan: 'free' | 'pro' | 'enterprise';
+  require_2fa?: boolean;
   created_at: number;
   updated_at: number;
 }
 
+/** With 2FA on, login returns only mfa_required and mfa_token; the tokens come from /auth/login/2fa. */
 export interface LoginResponse {
-  token: string;
-  user: User;
+  token?: string;
+  refresh_token?: string;
+  expires_at?: number;
+  user?: User;
+  mfa_required?: boolean;
+  mfa_token?: string;
+  mfa_setup_required?: boolean;
+}
+
+export interface SSOConfig {
+  org_id: string;
+  enabled: boolean;
+  issuer: string;
+  client_id: string;
+  has_client_secret: boolean;
+  scopes: string[];
+  role_claim?: string;
+  role_mapping?: Record<string, User['role']>;
+  default_role?: User['role'];
+  allowed_domains: string[];
+  auto_provision: boolean;
+  updated_at: number;
+  domain_verification?: string;
+}
+
+export interface TwoFactorStatus {
+  enabled: boolean;
+  required: boolean;
+  recovery_codes_remaining: number;
+}
+
+export interface TOTPSetup {
+  secret: string;
+  uri: string;
+  expires_at: number;
 }
 
 export interface Patient {
@@ -88,11 +125,28 @@ export interface Threshold {
   respiratory_rate_low: number;
 }
 
+export interface Session {
+  id: string;
+  user_id: string;
+  org_id: string;
+  user_agent?: string;
+  ip?: string;
+  created_at: number;
+  last_seen_at: number;
+  expires_at: number;
+  current?: boolean;
+}
+
 export interface Invite {
-  code: string;
+  id: string;
   email: string;
   role: string;
   org_id: string;
+  invited_by?: string;
+  send_count: number;
+  last_sent_at: number;
+  created_at: number;
+  expires_at: number;
 }
 
 export interface DashboardOverview {
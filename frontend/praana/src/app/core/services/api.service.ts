@@ -4,7 +4,7 @@ import { Observable, of, switchMap } from 'rxjs';
 import { environment } from '../../../environments/environment';
 import {
   ApiResponse, Patient, Vitals, Alert, Threshold, Invite,
-  Org, User, DashboardOverview, ShiftSummary, OrgStats, UsageStats
+  Org, User, DashboardOverview, ShiftSummary, OrgStats, UsageStats, SSOConfig
 } from '../models';
 import { DemoService } from './demo.service';
 
@@ -27,8 +27,8 @@ export class ApiService {
     return this.http.get<ApiResponse<Org>>(`${this.api}/org`);
   }
 
-  updateOrg(name: string): Observable<ApiResponse<Org>> {
-    return this.http.put<ApiResponse<Org>>(`${this.api}/org`, { name });
+  updateOrg(name: string, require2FA?: boolean): Observable<ApiResponse<Org>> {
+    return this.http.put<ApiResponse<Org>>(`${this.api}/org`, { name, require_2fa: require2FA });
   }
 
   getMembers(): Observable<ApiResponse<User[]>> {
@@ -39,10 +39,46 @@ export class ApiService {
     return this.http.delete<ApiResponse<any>>(`${this.api}/org/members/${id}`);
   }
 
+  revokeMemberSessions(id: string): Observable<ApiResponse<{ revoked: number }>> {
+    return this.http.delete<ApiResponse<{ revoked: number }>>(`${this.api}/org/members/${id}/sessions`);
+  }
+
+  getSSO(): Observable<ApiResponse<SSOConfig>> {
+    return this.http.get<ApiResponse<SSOConfig>>(`${this.api}/org/sso`);
+  }
+
+  saveSSO(config: Partial<SSOConfig> & { client_secret?: string }): Observable<ApiResponse<SSOConfig>> {
+    return this.http.put<ApiResponse<SSOConfig>>(`${this.api}/org/sso`, config);
+  }
+
+  deleteSSO(): Observable<ApiResponse<any>> {
+    return this.http.delete<ApiResponse<any>>(`${this.api}/org/sso`);
+  }
+
+  unlockMember(id: string): Observable<ApiResponse<any>> {
+    return this.http.post<ApiResponse<any>>(`${this.api}/org/members/${id}/unlock`, {});
+  }
+
+  resetMemberTOTP(id: string): Observable<ApiResponse<any>> {
+    return this.http.delete<ApiResponse<any>>(`${this.api}/org/members/${id}/2fa`);
+  }
+
   createInvite(email: string, role: string): Observable<ApiResponse<Invite>> {
     return this.http.post<ApiResponse<Invite>>(`${this.api}/org/invite`, { email, role });
   }
 
+  getInvites(): Observable<ApiResponse<Invite[]>> {
+    return this.http.get<ApiResponse<Invite[]>>(`${this.api}/org/invites`);
+  }
+
+  resendInvite(id: string): Observable<ApiResponse<Invite>> {
+    return this.http.post<ApiResponse<Invite>>(`${this.api}/org/invites/${id}/resend`, {});
+  }
+
+  revokeInvite(id: string): Observable<ApiResponse<any>> {
+    return this.http.delete<ApiResponse<any>>(`${this.api}/org/invites/${id}`);
+  }
+
   // Patients — fallback to demo when org has no real patients
   getPatients(): Observable<ApiResponse<Patient[]>> {
     return this.http.get<ApiResponse<Patient[]>>(`${this.api}/patients`).pipe(
@@ -97,8 +133,8 @@ export class ApiService {
     return this.http.get<ApiResponse<Alert[]>>(`${this.api}/alerts`);
   }
 
-  acknowledgeAlert(id: string): Observable<ApiResponse<any>> {
-    return this.http.post<ApiResponse<any>>(`${this.api}/alerts/${id}/acknowledge`, {});
+  acknowledgeAlert(id: string, reason: string, action?: string): Observable<ApiResponse<any>> {
+    return this.http.post<ApiResponse<any>>(`${this.api}/alerts/${id}/acknowledge`, { reason, action });
   }
 
   getAlertHistory(): Observable<ApiResponse<Alert[]>> {
@@ -1,18 +1,23 @@
 import { Injectable, signal, computed } from '@angular/core';
 import { HttpClient } from '@angular/common/http';
 import { Router } from '@angular/router';
-import { tap } from 'rxjs/operators';
+import { Observable, of, throwError, timer } from 'rxjs';
+import { catchError, finalize, map, shareReplay, switchMap, tap } from 'rxjs/operators';
 import { environment } from '../../../environments/environment';
-import { ApiResponse, LoginResponse, User } from '../models';
+import { ApiResponse, LoginResponse, Session, TOTPSetup, TwoFactorStatus, User } from '../models';
 
 @Injectable({ providedIn: 'root' })
 export class AuthService {
   private readonly apiUrl = environment.apiUrl;
   private currentUser = signal<User | null>(null);
+  private mfaSetup = signal(false);
+  private refreshing: Observable<string> | null = null;
 
   user = this.currentUser.asReadonly();
   isLoggedIn = computed(() => !!this.currentUser());
   isAdmin = computed(() => this.currentUser()?.role === 'admin');
+  /** The org requires 2FA and this user hasn't set it up; only /account is usable. */
+  mfaSetupRequired = this.mfaSetup.asReadonly();
 
   constructor(private http: HttpClient, private router: Router) {
     this.loadFromStorage();
@@ -23,6 +28,7 @@ export class AuthService {
     const user = localStorage.getItem('user');
     if (token && user) {
       this.currentUser.set(JSON.parse(user));
+      this.mfaSetup.set(localStorage.getItem('mfa_setup') === 'true');
     }
   }
 
@@ -36,8 +42,19 @@ export class AuthService {
     );
   }
 
+  /** Signs in, unless the account has 2FA: then the response carries an mfa_token for loginMFA. */
   login(email: string, password: string) {
     return this.http.post<ApiResponse<LoginResponse>>(`${this.apiUrl}/auth/login`, { email, password }).pipe(
+      tap(res => {
+        if (res.success && res.data?.token) {
+          this.setSession(res.data);
+        }
+      })
+    );
+  }
+
+  loginMFA(mfaToken: string, code: string) {
+    return this.http.post<ApiResponse<LoginResponse>>(`${this.apiUrl}/auth/login/2fa`, { mfa_token: mfaToken, code }).pipe(
       tap(res => {
         if (res.success && res.data) {
           this.setSession(res.data);
@@ -46,15 +63,120 @@ export class AuthService {
     );
   }
 
+  ssoStart(email: string) {
+    return this.http.post<ApiResponse<{ redirect_url: string; browser_key: string }>>(`${this.apiUrl}/auth/sso/start`, { email });
+  }
+
+  /** Finishes SSO; like login, the response may instead ask for a 2FA code. */
+  ssoCallback(code: string, state: string, browserKey: string) {
+    return this.http.post<ApiResponse<LoginResponse>>(`${this.apiUrl}/auth/sso/callback`, { code, state, browser_key: browserKey }).pipe(
+      tap(res => {
+        if (res.success && res.data?.token) {
+          this.setSession(res.data);
+        }
+      })
+    );
+  }
+
   acceptInvite(data: { code: string; email: string; password: string; name: string }) {
     return this.http.post<ApiResponse<User>>(`${this.apiUrl}/auth/accept-invite`, data);
   }
 
+  changePassword(currentPassword: string, newPassword: string) {
+    return this.http.post<ApiResponse<any>>(`${this.apiUrl}/auth/change-password`, {
+      current_password: currentPassword, new_password: newPassword,
+    });
+  }
+
+  forgotPassword(email: string) {
+    return this.http.post<ApiResponse<any>>(`${this.apiUrl}/auth/forgot-password`, { email });
+  }
+
+  resetPassword(code: string, password: string) {
+    return this.http.post<ApiResponse<any>>(`${this.apiUrl}/auth/reset-password`, { code, password });
+  }
+
+  getSessions() {
+    return this.http.get<ApiResponse<Session[]>>(`${this.apiUrl}/auth/sessions`);
+  }
+
+  revokeSession(id: string) {
+    return this.http.delete<ApiResponse<any>>(`${this.apiUrl}/auth/sessions/${id}`);
+  }
+
+  revokeOtherSessions() {
+    return this.http.delete<ApiResponse<{ revoked: number }>>(`${this.apiUrl}/auth/sessions`);
+  }
+
+  getTwoFactorStatus() {
+    return this.http.get<ApiResponse<TwoFactorStatus>>(`${this.apiUrl}/auth/2fa`);
+  }
+
+  setupTOTP() {
+    return this.http.post<ApiResponse<TOTPSetup>>(`${this.apiUrl}/auth/2fa/setup`, {});
+  }
+
+  /** Turns 2FA on, then refreshes so a setup-only token becomes a full one. */
+  enableTOTP(code: string) {
+    return this.http.post<ApiResponse<{ recovery_codes: string[] }>>(`${this.apiUrl}/auth/2fa/enable`, { code }).pipe(
+      switchMap(res => this.refresh().pipe(map(() => res), catchError(() => of(res)))),
+    );
+  }
+
+  regenerateRecoveryCodes(code: string) {
+    return this.http.post<ApiResponse<{ recovery_codes: string[] }>>(`${this.apiUrl}/auth/2fa/recovery-codes`, { code });
+  }
+
+  disableTOTP(password: string, code: string) {
+    return this.http.post<ApiResponse<any>>(`${this.apiUrl}/auth/2fa/disable`, { password, code });
+  }
+
+  /** Exchanges the refresh token for new tokens. Concurrent callers share one request. */
+  refresh(): Observable<string> {
+    if (!this.refreshing) {
+      const refreshToken = this.getRefreshToken();
+      this.refreshing = this.http.post<ApiResponse<LoginResponse>>(`${this.apiUrl}/auth/refresh`, { refresh_token: refreshToken }).pipe(
+        map(res => {
+          this.setSession(res.data!);
+          return res.data!.token!;
+        }),
+        catchError(err => {
+          // 409: another tab refreshed with the same token a moment ago and
+          // is about to store the new pair, so wait for it and use that.
+          if (err.status === 409) {
+            return timer(1000).pipe(switchMap(() =>
+              this.getRefreshToken() !== refreshToken ? of(this.getToken()!) : throwError(() => err)));
+          }
+          return throwError(() => err);
+        }),
+        finalize(() => this.refreshing = null),
+        shareReplay(1),
+      );
+    }
+    return this.refreshing;
+  }
+
+  /** The access token, refreshed first if it is about to expire. */
+  freshToken(): Observable<string | null> {
+    const expiresAt = Number(localStorage.getItem('token_expires_at') || 0);
+    if (!this.getRefreshToken() || expiresAt * 1000 > Date.now() + 30_000) return of(this.getToken());
+    return this.refresh().pipe(catchError(() => of(null)));
+  }
+
   logout() {
     this.http.post(`${this.apiUrl}/auth/logout`, {}).subscribe();
+    this.endSession();
+  }
+
+  /** Forgets the session locally, e.g. once it can no longer be refreshed. */
+  endSession() {
     localStorage.removeItem('token');
+    localStorage.removeItem('token_expires_at');
+    localStorage.removeItem('refresh_token');
     localStorage.removeItem('user');
+    localStorage.removeItem('mfa_setup');
     this.currentUser.set(null);
+    this.mfaSetup.set(false);
     this.router.navigate(['/auth/login']);
   }
 
@@ -62,9 +184,17 @@ export class AuthService {
     return localStorage.getItem('token');
   }
 
+  getRefreshToken(): string | null {
+    return localStorage.getItem('refresh_token');
+  }
+
   private setSession(data: LoginResponse) {
-    localStorage.setItem('token', data.token);
+    localStorage.setItem('token', data.token!);
+    localStorage.setItem('token_expires_at', String(data.expires_at));
+    localStorage.setItem('refresh_token', data.refresh_token!);
     localStorage.setItem('user', JSON.stringify(data.user));
-    this.currentUser.set(data.user);
+    localStorage.setItem('mfa_setup', String(!!data.mfa_setup_required));
+    this.currentUser.set(data.user!);
+    this.mfaSetup.set(!!data.mfa_setup_required);
   }
 }
@@ -14,9 +14,12 @@ export class WebSocketService {
   constructor(private auth: AuthService) {}
 
   connect() {
-    const token = this.auth.getToken();
-    if (!token) return;
+    this.auth.freshToken().subscribe(token => {
+      if (token) this.open(token);
+    });
+  }
 
+  private open(token: string) {
     this.ws = new WebSocket(`${environment.wsUrl}?token=${token}`);
 
     this.ws.onopen = () => {
@@ -0,0 +1,293 @@
+import { Component, OnInit, signal } from '@angular/core';
+import { CommonModule } from '@angular/common';
+import { FormsModule } from '@angular/forms';
+import { MatButtonModule } from '@angular/material/button';
+import { MatIconModule } from '@angular/material/icon';
+import { MatSnackBar, MatSnackBarModule } from '@angular/material/snack-bar';
+import { AuthService } from '../../core/services/auth.service';
+import { Session, TOTPSetup, TwoFactorStatus } from '../../core/models';
+
+@Component({
+  selector: 'app-account',
+  standalone: true,
+  imports: [CommonModule, FormsModule, MatButtonModule, MatIconModule, MatSnackBarModule],
+  template: `
+    <div class="mb-6">
+      <h2 class="text-xl font-bold text-gray-900">Account</h2>
+      <p class="text-gray-500 text-sm mt-0.5">{{ auth.user()?.name }} · {{ auth.user()?.email }}</p>
+    </div>
+
+    @if (auth.mfaSetupRequired()) {
+      <div class="notice max-w-lg mb-5">
+        <mat-icon class="!text-base flex-shrink-0">shield</mat-icon>
+        <span>Your organization requires two-factor authentication. Set it up below to continue using Praana.</span>
+      </div>
+    }
+
+    <div class="prana-card p-5 max-w-lg">
+      <p class="section-label mb-4">Change Password</p>
+      <form (ngSubmit)="changePassword()" class="flex flex-col gap-4">
+        <div class="form-group">
+          <label class="form-label">Current Password</label>
+          <input class="form-input" type="password" [(ngModel)]="currentPassword" name="currentPassword" required>
+        </div>
+        <div class="form-group">
+          <label class="form-label">New Password</label>
+          <input class="form-input" type="password" [(ngModel)]="newPassword" name="newPassword" required minlength="8">
+          <p class="text-xs text-gray-400 mt-1">At least 8 characters, with a letter and a digit or symbol. Your other sessions will be signed out.</p>
+        </div>
+        <button type="submit" class="submit-btn w-fit" [disabled]="saving()">Change Password</button>
+      </form>
+    </div>
+
+    <div class="prana-card p-5 max-w-lg mt-5">
+      <p class="section-label mb-4">Two-Factor Authentication</p>
+      @if (recoveryCodes().length) {
+        <p class="text-sm text-gray-700 mb-3">Save these recovery codes somewhere safe. Each one signs you in once if you lose your authenticator; they won't be shown again.</p>
+        <div class="code-grid mb-4">
+          @for (c of recoveryCodes(); track c) { <code>{{ c }}</code> }
+        </div>
+        <button class="submit-btn w-fit" (click)="recoveryCodes.set([])">I've saved them</button>
+      } @else if (setup()) {
+        <p class="text-sm text-gray-700 mb-3">Add Praana to your authenticator app with this key, or open the link on a device that has the app, then enter the 6-digit code it shows.</p>
+        <div class="secret mb-2">{{ setup()!.secret }}</div>
+        <a [href]="setup()!.uri" class="text-xs font-semibold text-pink-600 hover:text-pink-700">Open in authenticator app</a>
+        <form (ngSubmit)="enable()" class="flex flex-col gap-4 mt-4">
+          <div class="form-group">
+            <label class="form-label">Code</label>
+            <input class="form-input" type="text" [(ngModel)]="totpCode" name="totpCode" required autocomplete="one-time-code">
+          </div>
+          <div class="flex gap-3">
+            <button type="submit" class="submit-btn w-fit" [disabled]="saving()">Turn On</button>
+            <button type="button" class="text-sm text-gray-500 hover:text-gray-700" (click)="setup.set(null)">Cancel</button>
+          </div>
+        </form>
+      } @else if (twoFactor()?.enabled) {
+        <p class="text-sm text-gray-700 mb-4">
+          <span class="current-badge !ml-0 mr-1">On</span>
+          {{ twoFactor()!.recovery_codes_remaining }} recovery codes left.
+        </p>
+        <form class="flex flex-col gap-4">
+          <div class="form-group">
+            <label class="form-label">Code</label>
+            <input class="form-input" type="text" [(ngModel)]="totpCode" name="totpCode" autocomplete="one-time-code">
+            <p class="text-xs text-gray-400 mt-1">A code from your authenticator app; to turn 2FA off, a recovery code also works.</p>
+          </div>
+          @if (!twoFactor()!.required) {
+            <div class="form-group">
+              <label class="form-label">Password (to turn off)</label>
+              <input class="form-input" type="password" [(ngModel)]="disablePassword" name="disablePassword">
+            </div>
+          }
+          <div class="flex gap-3">
+            <button type="button" class="submit-btn w-fit" [disabled]="saving() || !totpCode" (click)="regenerateCodes()">New Recovery Codes</button>
+            @if (!twoFactor()!.required) {
+              <button type="button" class="danger-btn w-fit" [disabled]="saving() || !totpCode || !disablePassword" (click)="disable()">Turn Off</button>
+            }
+          </div>
+        </form>
+      } @else {
+        <p class="text-sm text-gray-700 mb-4">Require a code from an authenticator app, as well as your password, when you sign in.</p>
+        <button class="submit-btn w-fit" [disabled]="saving()" (click)="startSetup()">Set Up</button>
+      }
+    </div>
+
+    <div class="prana-card p-5 max-w-lg mt-5">
+      <div class="flex items-center justify-between mb-4">
+        <p class="section-label">Active Sessions</p>
+        @if (sessions().length > 1) {
+          <button class="text-xs font-semibold text-pink-600 hover:text-pink-700" (click)="revokeOthers()">Sign out other sessions</button>
+        }
+      </div>
+      @for (s of sessions(); track s.id) {
+        <div class="flex items-center gap-3 py-3 border-b border-gray-100 last:border-0">
+          <mat-icon class="!text-gray-400">{{ deviceIcon(s) }}</mat-icon>
+          <div class="flex-1 min-w-0">
+            <p class="text-sm font-medium text-gray-800 truncate" [title]="s.user_agent || ''">
+              {{ deviceName(s) }}
+              @if (s.current) { <span class="current-badge">This device</span> }
+            </p>
+            <p class="text-xs text-gray-400">{{ s.ip || 'Unknown IP' }} · last active {{ s.last_seen_at * 1000 | date:'d MMM, HH:mm' }}</p>
+          </div>
+          @if (!s.current) {
+            <button mat-icon-button class="!text-gray-400 hover:!text-red-500" title="Sign out" (click)="revoke(s)">
+              <mat-icon class="!text-base">logout</mat-icon>
+            </button>
+          }
+        </div>
+      }
+    </div>
+  `,
+  styles: [`
+    .section-label {
+      font-size: 11px; font-weight: 600; text-transform: uppercase;
+      letter-spacing: 0.06em; color: #6b7280; margin: 0;
+    }
+    .submit-btn {
+      height: 42px; padding: 0 20px;
+      background: #db2777; color: #ffffff;
+      border: none; border-radius: 8px;
+      font-size: 14px; font-weight: 600; font-family: inherit; cursor: pointer;
+      &:hover { background: #be185d; }
+      &:disabled { opacity: 0.6; cursor: not-allowed; }
+    }
+    .danger-btn {
+      height: 42px; padding: 0 20px;
+      background: #ffffff; color: #b91c1c;
+      border: 1px solid #fecaca; border-radius: 8px;
+      font-size: 14px; font-weight: 600; font-family: inherit; cursor: pointer;
+      &:hover { background: #fff1f2; }
+      &:disabled { opacity: 0.6; cursor: not-allowed; }
+    }
+    .notice {
+      background: #fdf2f8; color: #9d174d; padding: 10px 14px;
+      border-radius: 8px; font-size: 13px; border: 1px solid #fbcfe8;
+      display: flex; align-items: center; gap: 8px;
+    }
+    .secret, .code-grid code {
+      font-family: 'Courier New', monospace; font-size: 13px; color: #111827;
+      background: #f9fafb; border: 1px solid #f3f4f6; border-radius: 6px;
+    }
+    .secret { padding: 8px 10px; word-break: break-all; }
+    .code-grid {
+      display: grid; grid-template-columns: 1fr 1fr; gap: 6px;
+      code { padding: 4px 8px; text-align: center; }
+    }
+    .current-badge {
+      font-size: 10px; font-weight: 600; text-transform: uppercase;
+      padding: 1px 6px; border-radius: 4px; margin-left: 6px;
+      background: #d1fae5; color: #065f46; border: 1px solid #a7f3d0;
+    }
+  `]
+})
+export class AccountComponent implements OnInit {
+  currentPassword = '';
+  newPassword = '';
+  saving = signal(false);
+  sessions = signal<Session[]>([]);
+  twoFactor = signal<TwoFactorStatus | null>(null);
+  setup = signal<TOTPSetup | null>(null);
+  recoveryCodes = signal<string[]>([]);
+  totpCode = '';
+  disablePassword = '';
+
+  constructor(public auth: AuthService, private snackBar: MatSnackBar) {}
+
+  ngOnInit() {
+    this.loadSessions();
+    this.loadTwoFactor();
+  }
+
+  loadTwoFactor() {
+    this.auth.getTwoFactorStatus().subscribe(res => {
+      if (res.success && res.data) this.twoFactor.set(res.data);
+    });
+  }
+
+  startSetup() {
+    this.auth.setupTOTP().subscribe({
+      next: (res) => { if (res.success && res.data) this.setup.set(res.data); },
+      error: (err) => { this.snackBar.open(err.error?.error || 'Failed', 'OK', { duration: 3000 }); }
+    });
+  }
+
+  enable() {
+    this.saving.set(true);
+    this.auth.enableTOTP(this.totpCode.trim()).subscribe({
+      next: (res) => {
+        this.setup.set(null);
+        this.recoveryCodes.set(res.data?.recovery_codes || []);
+        this.totpCode = '';
+        this.loadTwoFactor();
+        this.saving.set(false);
+      },
+      error: (err) => {
+        this.snackBar.open(err.error?.error || 'Failed to turn on 2FA', 'OK', { duration: 3000 });
+        this.saving.set(false);
+      }
+    });
+  }
+
+  regenerateCodes() {
+    this.saving.set(true);
+    this.auth.regenerateRecoveryCodes(this.totpCode.trim()).subscribe({
+      next: (res) => {
+        this.recoveryCodes.set(res.data?.recovery_codes || []);
+        this.totpCode = '';
+        this.loadTwoFactor();
+        this.saving.set(false);
+      },
+      error: (err) => {
+        this.snackBar.open(err.error?.error || 'Failed', 'OK', { duration: 3000 });
+        this.saving.set(false);
+      }
+    });
+  }
+
+  disable() {
+    if (!confirm('Turn off two-factor authentication?')) return;
+    this.saving.set(true);
+    this.auth.disableTOTP(this.disablePassword, this.totpCode.trim()).subscribe({
+      next: () => {
+        this.snackBar.open('Two-factor authentication turned off', 'OK', { duration: 2000 });
+        this.totpCode = '';
+        this.disablePassword = '';
+        this.loadTwoFactor();
+        this.saving.set(false);
+      },
+      error: (err) => {
+        this.snackBar.open(err.error?.error || 'Failed to turn off 2FA', 'OK', { duration: 3000 });
+        this.saving.set(false);
+      }
+    });
+  }
+
+  loadSessions() {
+    this.auth.getSessions().subscribe(res => {
+      if (res.success && res.data) this.sessions.set(res.data);
+    });
+  }
+
+  deviceName(s: Session): string {
+    const ua = s.user_agent || '';
+    const browser = /Edg\//.test(ua) ? 'Edge' : /Chrome\//.test(ua) ? 'Chrome' : /Firefox\//.test(ua) ? 'Firefox' : /Safari\//.test(ua) ? 'Safari' : '';
+    const os = /iPad|iPhone/.test(ua) ? 'iOS' : /Android/.test(ua) ? 'Android' : /Windows/.test(ua) ? 'Windows' : /Mac OS/.test(ua) ? 'macOS' : /Linux/.test(ua) ? 'Linux' : '';
+    return [browser, os].filter(Boolean).join(' on ') || 'Unknown device';
+  }
+
+  deviceIcon(s: Session): string {
+    return /iPad|Android(?!.*Mobile)|Tablet/.test(s.user_agent || '') ? 'tablet' : /Mobile|iPhone/.test(s.user_agent || '') ? 'smartphone' : 'computer';
+  }
+
+  revoke(s: Session) {
+    this.auth.revokeSession(s.id).subscribe({
+      next: () => { this.snackBar.open('Session signed out', 'OK', { duration: 2000 }); this.loadSessions(); },
+      error: (err) => { this.snackBar.open(err.error?.error || 'Failed', 'OK', { duration: 3000 }); }
+    });
+  }
+
+  revokeOthers() {
+    if (!confirm('Sign out all your other sessions?')) return;
+    this.auth.revokeOtherSessions().subscribe({
+      next: () => { this.snackBar.open('Other sessions signed out', 'OK', { duration: 2000 }); this.loadSessions(); },
+      error: (err) => { this.snackBar.open(err.error?.error || 'Failed', 'OK', { duration: 3000 }); }
+    });
+  }
+
+  changePassword() {
+    this.saving.set(true);
+    this.auth.changePassword(this.currentPassword, this.newPassword).subscribe({
+      next: () => {
+        this.snackBar.open('Password changed', 'OK', { duration: 2000 });
+        this.loadSessions();
+        this.currentPassword = '';
+        this.newPassword = '';
+        this.saving.set(false);
+      },
+      error: (err) => {
+        this.snackBar.open(err.error?.error || 'Failed to change password', 'OK', { duration: 3000 });
+        this.saving.set(false);
+      }
+    });
+  }
+}
@@ -6,6 +6,7 @@ import { MatButtonModule } from '@angular/material/button';
 import { MatIconModule } from '@angular/material/icon';
 import { MatChipsModule } from '@angular/material/chips';
 import { MatTabsModule } from '@angular/material/tabs';
+import { MatMenuModule } from '@angular/material/menu';
 import { MatProgressSpinnerModule } from '@angular/material/progress-spinner';
 import { MatSnackBar, MatSnackBarModule } from '@angular/material/snack-bar';
 import { ApiService } from '../../../core/services/api.service';
@@ -18,7 +19,7 @@ import { format } from 'date-fns';
   imports: [
     CommonModule, RouterLink,
     MatCardModule, MatButtonModule, MatIconModule,
-    MatChipsModule, MatTabsModule, MatProgressSpinnerModule, MatSnackBarModule,
+    MatChipsModule, MatTabsModule, MatMenuModule, MatProgressSpinnerModule, MatSnackBarModule,
   ],
   template: `
     <div class="flex flex-wrap justify-between items-center gap-3 mb-6">
@@ -61,9 +62,14 @@ import { format } from 'date-fns';
                       &middot; {{ formatTime(alert.created_at) }}
                     </p>
                   </div>
-                  <button mat-flat-button color="primary" (click)="acknowledge(alert.id)" class="!rounded-lg !text-sm flex-shrink-0">
+                  <button mat-flat-button color="primary" [matMenuTriggerFor]="ackMenu" class="!rounded-lg !text-sm flex-shrink-0">
                     <mat-icon class="!text-base">check</mat-icon> Acknowledge
                   </button>
+                  <mat-menu #ackMenu="matMenu">
+                    @for (r of ackReasons; track r.value) {
+                      <button mat-menu-item (click)="acknowledge(alert.id, r.value)">{{ r.label }}</button>
+                    }
+                  </mat-menu>
                 </div>
               </div>
             }
@@ -121,6 +127,13 @@ export class AlertListComponent implements OnInit {
   activeAlerts = signal<Alert[]>([]);
   alertHistory = signal<Alert[]>([]);
   loading = signal(true);
+  ackReasons = [
+    { value: 'reassessed', label: 'Patient reassessed' },
+    { value: 'doctor_informed', label: 'Doctor informed' },
+    { value: 'treatment_given', label: 'Treatment given' },
+    { value: 'escalated', label: 'Escalated' },
+    { value: 'false_reading', label: 'False reading' },
+  ];
 
   constructor(private api: ApiService, private snackBar: MatSnackBar) {}
 
@@ -141,9 +154,9 @@ export class AlertListComponent implements OnInit {
     });
   }
 
-  acknowledge(alertId: string) {
+  acknowledge(alertId: string, reason: string) {
     this.activeAlerts.update(list => list.filter(a => a.id !== alertId));
-    this.api.acknowledgeAlert(alertId).subscribe(res => {
+    this.api.acknowledgeAlert(alertId, reason).subscribe(res => {
       if (res.success) {
         this.snackBar.open('Alert acknowledged', 'OK', { duration: 2000 });
         this.api.refreshAlertCount();
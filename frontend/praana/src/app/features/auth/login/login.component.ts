@@ -24,64 +24,96 @@ const DEMO_PASSWORD = 'Demo@123';
           <p class="text-sm text-gray-500 mt-1">Sign in to Praana</p>
         </div>
 
-        <!-- Demo credentials banner -->
-        <div class="demo-banner">
-          <div class="demo-banner-header">
-            <span class="demo-title">Demo Account</span>
-            <button type="button" class="demo-fill-btn" (click)="fillDemo()">Auto-fill</button>
-          </div>
-          <div class="demo-row">
-            <span class="demo-label">Email</span>
-            <span class="demo-value">{{ demoEmail }}</span>
-            <button type="button" class="copy-btn" (click)="copy('email')" [attr.aria-label]="'Copy email'">
-              <mat-icon class="copy-icon">{{ copied() === 'email' ? 'check' : 'content_copy' }}</mat-icon>
+        @if (mfaToken()) {
+          @if (error()) {
+            <div class="alert-error">
+              <mat-icon class="!text-base flex-shrink-0">error_outline</mat-icon>
+              <span>{{ error() }}</span>
+            </div>
+          }
+
+          <form (ngSubmit)="onVerify()" class="flex flex-col gap-5">
+            <div class="form-group">
+              <label class="form-label">Authentication code</label>
+              <input class="form-input" type="text" [(ngModel)]="code" name="code" required
+                     autocomplete="one-time-code" autofocus>
+              <p class="text-xs text-gray-400 mt-1">Enter the 6-digit code from your authenticator app, or one of your recovery codes.</p>
+            </div>
+
+            <button type="submit" [disabled]="loading()" class="auth-btn">
+              @if (loading()) {
+                <mat-spinner diameter="20"></mat-spinner>
+              } @else {
+                Verify
+              }
             </button>
+          </form>
+
+          <div class="flex justify-center mt-7 pt-6 border-t border-gray-100">
+            <button type="button" class="auth-link link-btn" (click)="cancelMFA()">Use a different account</button>
           </div>
-          <div class="demo-row">
-            <span class="demo-label">Password</span>
-            <span class="demo-value">{{ demoPassword }}</span>
-            <button type="button" class="copy-btn" (click)="copy('password')" [attr.aria-label]="'Copy password'">
-              <mat-icon class="copy-icon">{{ copied() === 'password' ? 'check' : 'content_copy' }}</mat-icon>
-            </button>
+        } @else {
+          <!-- Demo credentials banner -->
+          <div class="demo-banner">
+            <div class="demo-banner-header">
+              <span class="demo-title">Demo Account</span>
+              <button type="button" class="demo-fill-btn" (click)="fillDemo()">Auto-fill</button>
+            </div>
+            <div class="demo-row">
+              <span class="demo-label">Email</span>
+              <span class="demo-value">{{ demoEmail }}</span>
+              <button type="button" class="copy-btn" (click)="copy('email')" [attr.aria-label]="'Copy email'">
+                <mat-icon class="copy-icon">{{ copied() === 'email' ? 'check' : 'content_copy' }}</mat-icon>
+              </button>
+            </div>
+            <div class="demo-row">
+              <span class="demo-label">Password</span>
+              <span class="demo-value">{{ demoPassword }}</span>
+              <button type="button" class="copy-btn" (click)="copy('password')" [attr.aria-label]="'Copy password'">
+                <mat-icon class="copy-icon">{{ copied() === 'password' ? 'check' : 'content_copy' }}</mat-icon>
+              </button>
+            </div>
           </div>
-        </div>
 
-        @if (error()) {
-          <div class="alert-error">
-            <mat-icon class="!text-base flex-shrink-0">error_outline</mat-icon>
-            <span>{{ error() }}</span>
-          </div>
-        }
+          @if (error()) {
+            <div class="alert-error">
+              <mat-icon class="!text-base flex-shrink-0">error_outline</mat-icon>
+              <span>{{ error() }}</span>
+            </div>
+          }
 
-        <form (ngSubmit)="onLogin()" class="flex flex-col gap-5">
-          <div class="form-group">
-            <label class="form-label">Email</label>
-            <input class="form-input" type="email" [(ngModel)]="email" name="email" required>
-          </div>
+          <form (ngSubmit)="onLogin()" class="flex flex-col gap-5">
+            <div class="form-group">
+              <label class="form-label">Email</label>
+              <input class="form-input" type="email" [(ngModel)]="email" name="email" required>
+            </div>
 
-          <div class="form-group">
-            <label class="form-label">Password</label>
-            <div class="input-wrap">
-              <input class="form-input" [type]="showPwd ? 'text' : 'password'" [(ngModel)]="password" name="password" required>
-              <button type="button" class="input-suffix-btn" (click)="showPwd = !showPwd">
-                <mat-icon class="!text-lg">{{ showPwd ? 'visibility_off' : 'visibility' }}</mat-icon>
-              </button>
+            <div class="form-group">
+              <label class="form-label">Password</label>
+              <div class="input-wrap">
+                <input class="form-input" [type]="showPwd ? 'text' : 'password'" [(ngModel)]="password" name="password" required>
+                <button type="button" class="input-suffix-btn" (click)="showPwd = !showPwd">
+                  <mat-icon class="!text-lg">{{ showPwd ? 'visibility_off' : 'visibility' }}</mat-icon>
+                </button>
+              </div>
+              <a routerLink="/auth/reset-password" class="auth-link text-right mt-1">Forgot password?</a>
             </div>
-          </div>
 
-          <button type="submit" [disabled]="loading()" class="auth-btn">
-            @if (loading()) {
-              <mat-spinner diameter="20"></mat-spinner>
-            } @else {
-              Sign In
-            }
-          </button>
-        </form>
-
-        <div class="flex justify-between items-center mt-7 pt-6 border-t border-gray-100">
-          <a routerLink="/auth/signup" class="auth-link">Create account</a>
-          <a routerLink="/auth/invite" class="auth-link">Have an invite?</a>
-        </div>
+            <button type="submit" [disabled]="loading()" class="auth-btn">
+              @if (loading()) {
+                <mat-spinner diameter="20"></mat-spinner>
+              } @else {
+                Sign In
+              }
+            </button>
+          </form>
+
+          <div class="flex justify-between items-center mt-7 pt-6 border-t border-gray-100">
+            <a routerLink="/auth/signup" class="auth-link">Create account</a>
+            <a routerLink="/auth/sso" class="auth-link">Use single sign-on</a>
+            <a routerLink="/auth/invite" class="auth-link">Have an invite?</a>
+          </div>
+        }
       </div>
     </div>
   `,
@@ -182,6 +214,10 @@ const DEMO_PASSWORD = 'Demo@123';
       text-decoration: none; font-weight: 500;
       &:hover { color: #be185d; }
     }
+    .link-btn {
+      background: none; border: none; cursor: pointer;
+      padding: 0; font-family: inherit;
+    }
   `]
 })
 export class LoginComponent {
@@ -191,11 +227,17 @@ export class LoginComponent {
   loading = signal(false);
   error = signal('');
   copied = signal<'email' | 'password' | null>(null);
+  mfaToken = signal('');
+  code = '';
 
   readonly demoEmail = DEMO_EMAIL;
   readonly demoPassword = DEMO_PASSWORD;
 
-  constructor(private auth: AuthService, private router: Router) {}
+  constructor(private auth: AuthService, private router: Router) {
+    // Set when single sign-on hands over to the 2FA step.
+    const mfaToken = this.router.getCurrentNavigation()?.extras.state?.['mfaToken'];
+    if (mfaToken) this.mfaToken.set(mfaToken);
+  }
 
   fillDemo() {
     this.email = DEMO_EMAIL;
@@ -215,8 +257,10 @@ export class LoginComponent {
     this.error.set('');
     this.auth.login(this.email, this.password).subscribe({
       next: (res) => {
-        if (res.success) {
-          this.router.navigate(['/dashboard']);
+        if (res.success && res.data?.mfa_required) {
+          this.mfaToken.set(res.data.mfa_token!);
+        } else if (res.success) {
+          this.signedIn(!!res.data?.mfa_setup_required);
         } else {
           this.error.set(res.error || 'Login failed');
         }
@@ -228,4 +272,35 @@ export class LoginComponent {
       }
     });
   }
+
+  onVerify() {
+    this.loading.set(true);
+    this.error.set('');
+    this.auth.loginMFA(this.mfaToken(), this.code.trim()).subscribe({
+      next: (res) => {
+        if (res.success) {
+          this.signedIn(!!res.data?.mfa_setup_required);
+        } else {
+          this.error.set(res.error || 'Verification failed');
+        }
+        this.loading.set(false);
+      },
+      error: (err) => {
+        this.error.set(err.error?.error || 'Verification failed');
+        this.code = '';
+        this.loading.set(false);
+      }
+    });
+  }
+
+  cancelMFA() {
+    this.mfaToken.set('');
+    this.code = '';
+    this.password = '';
+    this.error.set('');
+  }
+
+  private signedIn(mfaSetupRequired: boolean) {
+    this.router.navigate([mfaSetupRequired ? '/account' : '/dashboard']);
+  }
 }
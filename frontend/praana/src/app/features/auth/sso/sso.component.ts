@@ -0,0 +1,155 @@
+import { Component, signal } from '@angular/core';
+import { CommonModule } from '@angular/common';
+import { FormsModule } from '@angular/forms';
+import { Router, RouterLink, ActivatedRoute } from '@angular/router';
+import { MatIconModule } from '@angular/material/icon';
+import { MatProgressSpinnerModule } from '@angular/material/progress-spinner';
+import { AuthService } from '../../../core/services/auth.service';
+
+const SSO_BROWSER_KEY = 'sso_browser_key';
+
+@Component({
+  selector: 'app-sso',
+  standalone: true,
+  imports: [CommonModule, FormsModule, RouterLink, MatIconModule, MatProgressSpinnerModule],
+  template: `
+    <div class="auth-bg">
+      <div class="auth-card">
+        <div class="text-center mb-8">
+          <div class="logo-icon">
+            <mat-icon class="!text-3xl !w-8 !h-8 text-pink-600">domain</mat-icon>
+          </div>
+          <h1 class="text-2xl font-bold text-gray-900 mt-4">Single sign-on</h1>
+          <p class="text-sm text-gray-500 mt-1">Sign in with your organization's account</p>
+        </div>
+
+        @if (error()) {
+          <div class="alert-error">
+            <mat-icon class="!text-base flex-shrink-0">error_outline</mat-icon>
+            <span>{{ error() }}</span>
+          </div>
+        }
+
+        @if (callback && !error()) {
+          <div class="flex justify-center py-6"><mat-spinner diameter="32"></mat-spinner></div>
+        } @else {
+          <form (ngSubmit)="onStart()" class="flex flex-col gap-4">
+            <div class="form-group">
+              <label class="form-label">Work Email</label>
+              <input class="form-input" type="email" [(ngModel)]="email" name="email" required>
+            </div>
+            <button type="submit" [disabled]="loading()" class="auth-btn mt-1">
+              @if (loading()) { <mat-spinner diameter="20"></mat-spinner> } @else { Continue }
+            </button>
+          </form>
+        }
+
+        <div class="text-center mt-7 pt-6 border-t border-gray-100">
+          <a routerLink="/auth/login" class="auth-link">Sign in with a password</a>
+        </div>
+      </div>
+    </div>
+  `,
+  styles: [`
+    .auth-bg {
+      min-height: 100vh;
+      display: flex; align-items: center; justify-content: center;
+      background: #f7f8fa; padding: 20px;
+    }
+    .auth-card {
+      width: 100%; max-width: 400px;
+      background: #ffffff;
+      border: 1px solid #e5e7eb;
+      border-radius: 12px;
+      padding: 36px 32px;
+      box-shadow: 0 4px 24px rgba(0,0,0,0.08), 0 1px 4px rgba(0,0,0,0.04);
+    }
+    .logo-icon {
+      width: 56px; height: 56px; border-radius: 10px;
+      background: #fce7f3; border: 1px solid #fbcfe8;
+      display: inline-flex; align-items: center; justify-content: center;
+    }
+    .alert-error {
+      background: #fff1f2; color: #b91c1c; padding: 10px 14px;
+      border-radius: 8px; font-size: 13px; margin-bottom: 8px;
+      display: flex; align-items: center; gap: 8px; border: 1px solid #fecaca;
+    }
+    .alert-success {
+      background: #ecfdf5; color: #065f46; padding: 10px 14px;
+      border-radius: 8px; font-size: 13px; margin-bottom: 8px;
+      display: flex; align-items: center; gap: 8px; border: 1px solid #a7f3d0;
+    }
+    .auth-btn {
+      width: 100%; height: 44px;
+      background: #db2777; color: #ffffff;
+      border: none; border-radius: 8px;
+      font-size: 14px; font-weight: 600;
+      font-family: inherit; cursor: pointer;
+      display: flex; align-items: center; justify-content: center;
+      &:hover:not(:disabled) { background: #be185d; }
+      &:disabled { opacity: 0.6; cursor: not-allowed; }
+    }
+    .auth-link {
+      font-size: 13px; color: #db2777;
+      text-decoration: none; font-weight: 500;
+      &:hover { color: #be185d; }
+    }
+  `]
+})
+export class SsoComponent {
+  email = '';
+  callback = false;
+  loading = signal(false);
+  error = signal('');
+
+  constructor(private auth: AuthService, private route: ActivatedRoute, private router: Router) {
+    const params = this.route.snapshot.queryParams;
+    if (params['error']) {
+      sessionStorage.removeItem(SSO_BROWSER_KEY);
+      this.callback = true;
+      this.error.set(params['error_description'] || 'Sign-in was cancelled at your identity provider');
+    } else if (params['code'] && params['state']) {
+      this.callback = true;
+      this.finish(params['code'], params['state']);
+    }
+  }
+
+  onStart() {
+    this.loading.set(true);
+    this.error.set('');
+    this.auth.ssoStart(this.email).subscribe({
+      next: (res) => {
+        // Only this tab can finish the sign-in; a callback link opened
+        // anywhere else is refused.
+        sessionStorage.setItem(SSO_BROWSER_KEY, res.data!.browser_key);
+        window.location.href = res.data!.redirect_url;
+      },
+      error: (err) => {
+        this.error.set(err.error?.error || 'Single sign-on is not available');
+        this.loading.set(false);
+      }
+    });
+  }
+
+  private finish(code: string, state: string) {
+    const browserKey = sessionStorage.getItem(SSO_BROWSER_KEY);
+    sessionStorage.removeItem(SSO_BROWSER_KEY);
+    if (!browserKey) {
+      this.error.set('This sign-in was not started in this browser; start again');
+      return;
+    }
+    this.auth.ssoCallback(code, state, browserKey).subscribe({
+      next: (res) => {
+        if (res.data?.mfa_required) {
+          // Accounts with Praana 2FA still enter a code, on the login page.
+          this.router.navigate(['/auth/login'], { state: { mfaToken: res.data.mfa_token } });
+        } else {
+          this.router.navigate([res.data?.mfa_setup_required ? '/account' : '/dashboard']);
+        }
+      },
+      error: (err) => {
+        this.error.set(err.error?.error || 'Single sign-on failed');
+      }
+    });
+  }
+}
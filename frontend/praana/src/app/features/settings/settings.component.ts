@@ -5,7 +5,7 @@ import { MatIconModule } from '@angular/material/icon';
 import { MatSnackBar, MatSnackBarModule } from '@angular/material/snack-bar';
 import { MatProgressSpinnerModule } from '@angular/material/progress-spinner';
 import { ApiService } from '../../core/services/api.service';
-import { Org, OrgStats, UsageStats } from '../../core/models';
+import { Org, OrgStats, SSOConfig, UsageStats } from '../../core/models';
 
 @Component({
   selector: 'app-settings',
@@ -33,6 +33,12 @@ import { Org, OrgStats, UsageStats } from '../../core/models';
               <span class="text-xs text-gray-500 font-medium">Plan:</span>
               <span class="plan-badge">{{ org()?.plan }}</span>
             </div>
+            <label class="flex items-start gap-2 text-sm text-gray-700 cursor-pointer">
+              <input type="checkbox" class="mt-0.5" [(ngModel)]="require2FA" name="require2FA">
+              <span>Require two-factor authentication
+                <span class="block text-xs text-gray-400">Members without it must set it up the next time they sign in.</span>
+              </span>
+            </label>
             <button type="submit" class="submit-btn w-fit">Save Changes</button>
           </form>
         </div>
@@ -62,6 +68,66 @@ import { Org, OrgStats, UsageStats } from '../../core/models';
           </div>
         }
 
+        <!-- Single sign-on -->
+        <div class="prana-card p-5 lg:col-span-2">
+          <div class="flex items-center justify-between mb-4">
+            <p class="section-label">Single Sign-On (OpenID Connect)</p>
+            @if (sso()) {
+              <button type="button" class="text-xs font-semibold text-red-600 hover:text-red-700" (click)="deleteSSO()">Remove</button>
+            }
+          </div>
+          <form (ngSubmit)="saveSSO()" class="grid grid-cols-1 sm:grid-cols-2 gap-4">
+            <div class="form-group sm:col-span-2">
+              <label class="form-label">Issuer URL</label>
+              <input class="form-input" type="url" [(ngModel)]="ssoForm.issuer" name="issuer" placeholder="https://login.hospital.org" required>
+              <p class="text-xs text-gray-400 mt-1">Register {{ redirectUri }} as the redirect URI with your identity provider.</p>
+            </div>
+            <div class="form-group">
+              <label class="form-label">Client ID</label>
+              <input class="form-input" type="text" [(ngModel)]="ssoForm.client_id" name="clientId" required>
+            </div>
+            <div class="form-group">
+              <label class="form-label">Client Secret</label>
+              <input class="form-input" type="password" [(ngModel)]="ssoForm.client_secret" name="clientSecret"
+                     [placeholder]="sso()?.has_client_secret ? 'Unchanged' : ''">
+            </div>
+            <div class="form-group">
+              <label class="form-label">Allowed Email Domains</label>
+              <input class="form-input" type="text" [(ngModel)]="ssoForm.domains" name="domains" placeholder="hospital.org, staff.hospital.org" required>
+              @if (sso()?.domain_verification) {
+                <p class="text-xs text-gray-400 mt-1">New domains need a DNS TXT record: {{ sso()?.domain_verification }}</p>
+              }
+            </div>
+            <div class="form-group">
+              <label class="form-label">Role Claim</label>
+              <input class="form-input" type="text" [(ngModel)]="ssoForm.role_claim" name="roleClaim" placeholder="groups">
+            </div>
+            <div class="form-group">
+              <label class="form-label">Role Mapping</label>
+              <textarea class="form-input !h-24" [(ngModel)]="ssoForm.mapping" name="mapping" placeholder="icu-doctors = doctor&#10;icu-nurses = nurse"></textarea>
+              <p class="text-xs text-gray-400 mt-1">One "claim value = role" per line.</p>
+            </div>
+            <div class="form-group">
+              <label class="form-label">Default Role</label>
+              <select class="form-input" [(ngModel)]="ssoForm.default_role" name="defaultRole">
+                <option value="">None (refuse unmapped users)</option>
+                <option value="nurse">Nurse</option>
+                <option value="doctor">Doctor</option>
+                <option value="admin">Admin</option>
+              </select>
+            </div>
+            <label class="flex items-center gap-2 text-sm text-gray-700 cursor-pointer">
+              <input type="checkbox" [(ngModel)]="ssoForm.auto_provision" name="autoProvision">
+              Create accounts on first sign-in
+            </label>
+            <label class="flex items-center gap-2 text-sm text-gray-700 cursor-pointer">
+              <input type="checkbox" [(ngModel)]="ssoForm.enabled" name="ssoEnabled">
+              Enabled
+            </label>
+            <button type="submit" class="submit-btn w-fit" [disabled]="ssoSaving()">Save Single Sign-On</button>
+          </form>
+        </div>
+
         @if (usage()) {
           <div class="prana-card p-5 lg:col-span-2">
             <p class="section-label mb-4">Usage — {{ usage()!.month }}</p>
@@ -120,19 +186,82 @@ export class SettingsComponent implements OnInit {
 
   ngOnInit() {
     this.api.getOrg().subscribe(res => {
-      if (res.success && res.data) { this.org.set(res.data); this.orgName = res.data.name; }
+      if (res.success && res.data) { this.org.set(res.data); this.orgName = res.data.name; this.require2FA = !!res.data.require_2fa; }
       this.loading.set(false);
     });
     this.api.getOrgStats().subscribe(res => { if (res.success && res.data) this.stats.set(res.data); });
     this.api.getUsage().subscribe(res => { if (res.success && res.data) this.usage.set(res.data); });
+    this.api.getSSO().subscribe({
+      next: res => { if (res.success && res.data) { this.sso.set(res.data); this.ssoForm = this.toSSOForm(res.data); } },
+      error: () => {}, // 404 until SSO is set up
+    });
   }
 
   orgName = '';
+  require2FA = false;
+  sso = signal<SSOConfig | null>(null);
+  ssoSaving = signal(false);
+  ssoForm = this.toSSOForm(null);
+  readonly redirectUri = `${window.location.origin}/auth/sso/callback`;
 
   updateOrg() {
-    this.api.updateOrg(this.orgName).subscribe(res => {
+    this.api.updateOrg(this.orgName, this.require2FA).subscribe(res => {
       if (res.success) this.snackBar.open('Organization updated', 'OK', { duration: 2000 });
     });
   }
 
+  saveSSO() {
+    const mapping: Record<string, string> = {};
+    for (const line of this.ssoForm.mapping.split('\n')) {
+      const [value, role] = line.split('=').map(p => p.trim());
+      if (value && role) mapping[value] = role;
+    }
+    this.ssoSaving.set(true);
+    this.api.saveSSO({
+      enabled: this.ssoForm.enabled,
+      issuer: this.ssoForm.issuer.trim(),
+      client_id: this.ssoForm.client_id.trim(),
+      client_secret: this.ssoForm.client_secret || undefined,
+      role_claim: this.ssoForm.role_claim.trim(),
+      role_mapping: mapping as SSOConfig['role_mapping'],
+      default_role: this.ssoForm.default_role || undefined,
+      allowed_domains: this.ssoForm.domains.split(',').map(d => d.trim()).filter(Boolean),
+      auto_provision: this.ssoForm.auto_provision,
+    }).subscribe({
+      next: (res) => {
+        if (res.success && res.data) { this.sso.set(res.data); this.ssoForm = this.toSSOForm(res.data); }
+        this.snackBar.open('Single sign-on saved', 'OK', { duration: 2000 });
+        this.ssoSaving.set(false);
+      },
+      error: (err) => {
+        this.snackBar.open(err.error?.error || 'Failed to save single sign-on', 'OK', { duration: 4000 });
+        this.ssoSaving.set(false);
+      }
+    });
+  }
+
+  deleteSSO() {
+    if (!confirm('Remove single sign-on? Members will need their Praana password to sign in.')) return;
+    this.api.deleteSSO().subscribe(res => {
+      if (res.success) {
+        this.sso.set(null);
+        this.ssoForm = this.toSSOForm(null);
+        this.snackBar.open('Single sign-on removed', 'OK', { duration: 2000 });
+      }
+    });
+  }
+
+  private toSSOForm(c: SSOConfig | null) {
+    return {
+      enabled: c?.enabled ?? true,
+      issuer: c?.issuer ?? '',
+      client_id: c?.client_id ?? '',
+      client_secret: '',
+      role_claim: c?.role_claim ?? '',
+      mapping: Object.entries(c?.role_mapping ?? {}).map(([v, r]) => `${v} = ${r}`).join('\n'),
+      default_role: (c?.default_role ?? '') as string,
+      domains: (c?.allowed_domains ?? []).join(', '),
+      auto_provision: c?.auto_provision ?? false,
+    };
+  }
 }
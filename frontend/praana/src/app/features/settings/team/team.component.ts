@@ -7,7 +7,7 @@ import { MatTableModule } from '@angular/material/table';
 import { MatSnackBar, MatSnackBarModule } from '@angular/material/snack-bar';
 import { MatProgressSpinnerModule } from '@angular/material/progress-spinner';
 import { ApiService } from '../../../core/services/api.service';
-import { User } from '../../../core/models';
+import { Invite, User } from '../../../core/models';
 
 @Component({
   selector: 'app-team',
@@ -27,13 +27,12 @@ import { User } from '../../../core/models';
     <div class="prana-card p-5 mb-5 max-w-2xl">
       <p class="section-label mb-4">Invite Team Member</p>
 
-      @if (inviteCode()) {
+      @if (invitedEmail()) {
         <div class="alert-success mb-4">
           <mat-icon class="!text-base flex-shrink-0">check_circle</mat-icon>
           <div>
-            <p class="font-medium">Invite created</p>
-            <p class="text-sm mt-0.5">Code: <strong class="font-mono">{{ inviteCode() }}</strong></p>
-            <p class="text-xs text-gray-400 mt-0.5">In production this would be emailed automatically</p>
+            <p class="font-medium">Invite sent</p>
+            <p class="text-sm mt-0.5">An invite link was emailed to <strong>{{ invitedEmail() }}</strong></p>
           </div>
         </div>
       }
@@ -56,6 +55,33 @@ import { User } from '../../../core/models';
       </form>
     </div>
 
+    <!-- Pending invites -->
+    @if (invites().length) {
+      <div class="prana-card overflow-hidden mb-5">
+        <div class="px-5 pt-5 pb-3 border-b border-gray-100">
+          <p class="section-label">Pending Invites ({{ invites().length }})</p>
+        </div>
+        @for (inv of invites(); track inv.id) {
+          <div class="flex items-center gap-4 px-5 py-3 border-b border-gray-100 last:border-0">
+            <div class="flex-1 min-w-0">
+              <p class="text-sm font-medium text-gray-800 truncate">{{ inv.email }}</p>
+              <p class="text-xs text-gray-400">
+                Sent {{ inv.last_sent_at * 1000 | date:'d MMM, HH:mm' }}{{ inv.send_count > 1 ? ' (' + inv.send_count + ' times)' : '' }}
+                · expires {{ inv.expires_at * 1000 | date:'d MMM, HH:mm' }}
+              </p>
+            </div>
+            <span class="role-badge">{{ inv.role }}</span>
+            <button mat-icon-button class="!text-gray-400 hover:!text-pink-600" title="Resend" (click)="resendInvite(inv)">
+              <mat-icon class="!text-base">forward_to_inbox</mat-icon>
+            </button>
+            <button mat-icon-button class="!text-gray-400 hover:!text-red-500" title="Revoke" (click)="revokeInvite(inv)">
+              <mat-icon class="!text-base">cancel</mat-icon>
+            </button>
+          </div>
+        }
+      </div>
+    }
+
     <!-- Members list -->
     @if (loading()) {
       <div class="flex justify-center py-12"><mat-spinner diameter="36"></mat-spinner></div>
@@ -78,11 +104,27 @@ import { User } from '../../../core/models';
             <th mat-header-cell *matHeaderCellDef>Role</th>
             <td mat-cell *matCellDef="let m">
               <span class="role-badge" [class.role-badge--admin]="m.role === 'admin'">{{ m.role }}</span>
+              @if (m.locked_until) {
+                <span class="locked-badge" [title]="'Locked until ' + (m.locked_until * 1000 | date:'HH:mm')">locked</span>
+              }
             </td>
           </ng-container>
           <ng-container matColumnDef="actions">
             <th mat-header-cell *matHeaderCellDef></th>
             <td mat-cell *matCellDef="let m">
+              @if (m.locked_until) {
+                <button mat-icon-button class="!text-gray-400 hover:!text-pink-600" title="Unlock sign-in" (click)="unlockMember(m)">
+                  <mat-icon class="!text-base">lock_open</mat-icon>
+                </button>
+              }
+              @if (m.totp_enabled) {
+                <button mat-icon-button class="!text-gray-400 hover:!text-pink-600" title="Reset two-factor authentication" (click)="resetMemberTOTP(m)">
+                  <mat-icon class="!text-base">phonelink_erase</mat-icon>
+                </button>
+              }
+              <button mat-icon-button class="!text-gray-400 hover:!text-pink-600" title="Sign out everywhere" (click)="signOutMember(m)">
+                <mat-icon class="!text-base">logout</mat-icon>
+              </button>
               @if (m.role !== 'admin') {
                 <button mat-icon-button class="!text-gray-400 hover:!text-red-500" (click)="removeMember(m.id)">
                   <mat-icon class="!text-base">delete</mat-icon>
@@ -124,19 +166,25 @@ import { User } from '../../../core/models';
     .role-badge--admin {
       background: #fce7f3; color: #9d174d; border: 1px solid #fbcfe8;
     }
+    .locked-badge {
+      font-size: 10px; font-weight: 600; text-transform: uppercase;
+      padding: 2px 8px; border-radius: 4px; margin-left: 6px;
+      background: #fff1f2; color: #b91c1c; border: 1px solid #fecaca;
+    }
   `]
 })
 export class TeamComponent implements OnInit {
   members = signal<User[]>([]);
+  invites = signal<Invite[]>([]);
   loading = signal(true);
   inviteEmail = '';
   inviteRole = 'doctor';
-  inviteCode = signal('');
+  invitedEmail = signal('');
   displayedColumns = ['name', 'email', 'role', 'actions'];
 
   constructor(private api: ApiService, private snackBar: MatSnackBar) {}
 
-  ngOnInit() { this.loadMembers(); }
+  ngOnInit() { this.loadMembers(); this.loadInvites(); }
 
   loadMembers() {
     this.api.getMembers().subscribe(res => {
@@ -145,16 +193,70 @@ export class TeamComponent implements OnInit {
     });
   }
 
+  loadInvites() {
+    this.api.getInvites().subscribe(res => {
+      if (res.success && res.data) this.invites.set(res.data);
+    });
+  }
+
   onInvite() {
-    this.inviteCode.set('');
+    this.invitedEmail.set('');
     this.api.createInvite(this.inviteEmail, this.inviteRole).subscribe({
       next: (res) => {
-        if (res.success && res.data) { this.inviteCode.set(res.data.code); this.inviteEmail = ''; }
+        if (res.success && res.data) { this.invitedEmail.set(res.data.email); this.inviteEmail = ''; this.loadInvites(); }
       },
       error: (err) => { this.snackBar.open(err.error?.error || 'Failed to create invite', 'OK', { duration: 3000 }); }
     });
   }
 
+  resendInvite(inv: Invite) {
+    this.api.resendInvite(inv.id).subscribe({
+      next: (res) => {
+        if (res.success) { this.snackBar.open(`Invite re-sent to ${inv.email}`, 'OK', { duration: 2000 }); this.loadInvites(); }
+      },
+      error: (err) => { this.snackBar.open(err.error?.error || 'Failed to resend invite', 'OK', { duration: 3000 }); }
+    });
+  }
+
+  revokeInvite(inv: Invite) {
+    if (!confirm(`Revoke the invite for ${inv.email}?`)) return;
+    this.api.revokeInvite(inv.id).subscribe({
+      next: (res) => {
+        if (res.success) { this.snackBar.open('Invite revoked', 'OK', { duration: 2000 }); this.loadInvites(); }
+      },
+      error: (err) => { this.snackBar.open(err.error?.error || 'Failed', 'OK', { duration: 3000 }); }
+    });
+  }
+
+  signOutMember(m: User) {
+    if (!confirm(`Sign ${m.name} out of every device?`)) return;
+    this.api.revokeMemberSessions(m.id).subscribe({
+      next: (res) => {
+        if (res.success) this.snackBar.open(`Signed out ${res.data?.revoked ?? 0} session(s)`, 'OK', { duration: 2000 });
+      },
+      error: (err) => { this.snackBar.open(err.error?.error || 'Failed', 'OK', { duration: 3000 }); }
+    });
+  }
+
+  unlockMember(m: User) {
+    this.api.unlockMember(m.id).subscribe({
+      next: (res) => {
+        if (res.success) { this.snackBar.open(`${m.name} can sign in again`, 'OK', { duration: 2000 }); this.loadMembers(); }
+      },
+      error: (err) => { this.snackBar.open(err.error?.error || 'Failed', 'OK', { duration: 3000 }); }
+    });
+  }
+
+  resetMemberTOTP(m: User) {
+    if (!confirm(`Turn off two-factor authentication for ${m.name}? They will be signed out and can sign in with just their password.`)) return;
+    this.api.resetMemberTOTP(m.id).subscribe({
+      next: (res) => {
+        if (res.success) { this.snackBar.open('Two-factor authentication reset', 'OK', { duration: 2000 }); this.loadMembers(); }
+      },
+      error: (err) => { this.snackBar.open(err.error?.error || 'Failed', 'OK', { duration: 3000 }); }
+    });
+  }
+
   removeMember(id: string) {
     if (!confirm('Remove this team member?')) return;
     this.api.removeMember(id).subscribe({